@@ -3,17 +3,38 @@ package main
 import (
 	"context"
 	"flag"
+	"github.com/Jamshid90/go-clean-architecture/pkg/admin"
+	"github.com/Jamshid90/go-clean-architecture/pkg/apikey"
 	"github.com/Jamshid90/go-clean-architecture/pkg/auth"
 	"github.com/Jamshid90/go-clean-architecture/pkg/config"
+	"github.com/Jamshid90/go-clean-architecture/pkg/emailverification"
+	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
 	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/middleware"
 	"github.com/Jamshid90/go-clean-architecture/pkg/http/server"
+	"github.com/Jamshid90/go-clean-architecture/pkg/jwks"
 	zaplogger "github.com/Jamshid90/go-clean-architecture/pkg/logger"
+	"github.com/Jamshid90/go-clean-architecture/pkg/loginattempt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/magiclink"
+	"github.com/Jamshid90/go-clean-architecture/pkg/mailer"
+	"github.com/Jamshid90/go-clean-architecture/pkg/mfa"
+	"github.com/Jamshid90/go-clean-architecture/pkg/oauth"
+	"github.com/Jamshid90/go-clean-architecture/pkg/oidc"
+	"github.com/Jamshid90/go-clean-architecture/pkg/passwordpolicy"
+	"github.com/Jamshid90/go-clean-architecture/pkg/passwordreset"
+	"github.com/Jamshid90/go-clean-architecture/pkg/phoneotp"
+	"github.com/Jamshid90/go-clean-architecture/pkg/profile"
 	"github.com/Jamshid90/go-clean-architecture/pkg/refreshtoken"
+	"github.com/Jamshid90/go-clean-architecture/pkg/revocation"
+	"github.com/Jamshid90/go-clean-architecture/pkg/session"
+	"github.com/Jamshid90/go-clean-architecture/pkg/sms"
+	"github.com/Jamshid90/go-clean-architecture/pkg/token"
 	"github.com/Jamshid90/go-clean-architecture/pkg/user"
+	"github.com/Jamshid90/go-clean-architecture/pkg/usertoken"
 	"github.com/go-chi/chi"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"log"
 	"os"
+	"time"
 )
 
 var (
@@ -35,7 +56,7 @@ func main() {
 	// connect  pgxpool
 	dbpool, err := pgxpool.Connect(context.Background(), config.GetPsqlConnStr())
 	if err != nil {
-		log.Fatal(os.Stderr, "Unable to connect to database: %v\n", err)
+		log.Fatalf("Unable to connect to database: %v\n", err)
 		os.Exit(1)
 	}
 	defer dbpool.Close()
@@ -47,15 +68,184 @@ func main() {
 	}
 	defer logger.Sync()
 
+	// initialization jwt keys
+	keys, err := token.LoadKeySet(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// initialization mailer
+	appMailer, err := mailer.NewMailer(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// initialization sms sender
+	smsSender, err := sms.NewSMSSender(config, logger)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	phoneOTPTTL, err := time.ParseDuration(config.PhoneOTP.TTL)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	phoneOTPResendInterval, err := time.ParseDuration(config.PhoneOTP.ResendInterval)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if config.PhoneOTP.Secret == "" {
+		log.Fatal("phone_otp.secret is required")
+	}
+
+	emailVerificationTTL, err := time.ParseDuration(config.EmailVerification.TTL)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	passwordResetTTL, err := time.ParseDuration(config.PasswordReset.TTL)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	magicLinkTTL, err := time.ParseDuration(config.MagicLink.TTL)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	magicLinkWindow, err := time.ParseDuration(config.MagicLink.Window)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	oidcStateTTL, err := time.ParseDuration(config.OIDC.StateTTL)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	oidcProviders, err := oidc.NewProviders(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	oauthCodeTTL, err := time.ParseDuration(config.OAuth.CodeTTL)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	oauthAccessTTL, err := time.ParseDuration(config.OAuth.AccessTTL)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	oauthRefreshTTL, err := time.ParseDuration(config.OAuth.RefreshTTL)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	accessTTL, err := time.ParseDuration(config.Jwt.AccessTTL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	impersonationTTL, err := time.ParseDuration(config.Jwt.ImpersonationTTL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	refreshTTL, err := time.ParseDuration(config.Jwt.RefreshTTL)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// revoked users are kept until access tokens of oauth clients and impersonation tokens expire too
+	revocationTTL := accessTTL
+	if oauthAccessTTL > revocationTTL {
+		revocationTTL = oauthAccessTTL
+	}
+	if impersonationTTL > revocationTTL {
+		revocationTTL = impersonationTTL
+	}
+
+	// cursors are signed with their own secret, so the jwt secret is never used to sign client-visible data
+	if config.Pagination.CursorSecret == "" {
+		log.Fatal("pagination.cursor_secret is required")
+	}
+	cursorSecret := []byte(config.Pagination.CursorSecret)
+
+	passwordHasher, err := hash.NewPasswordHasher(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	passwordPolicy, err := passwordpolicy.NewPolicy(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	loginAttemptPolicy, err := loginattempt.NewPolicy(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	r := chi.NewRouter()
 
 	// initialization repositorys
 	userRepo := user.NewPgxUserRepository(dbpool)
 	refreshTokenRepo := refreshtoken.NewRefreshTokenRepositoryPgx(dbpool)
+	emailVerificationTokenRepo := usertoken.NewUserTokenRepositoryPgx(dbpool, "email_verification_token")
+	passwordResetTokenRepo := usertoken.NewUserTokenRepositoryPgx(dbpool, "password_reset_token")
+	magicLinkTokenRepo := usertoken.NewUserTokenRepositoryPgx(dbpool, "magic_link_token")
+	mfaRepo := mfa.NewMFARepositoryPgx(dbpool)
+	phoneOTPRepo := phoneotp.NewPhoneOTPRepositoryPgx(dbpool)
+	oidcAuthRequestRepo := oidc.NewOIDCAuthRequestRepositoryPgx(dbpool)
+	userIdentityRepo := oidc.NewUserIdentityRepositoryPgx(dbpool)
+	oauthClientRepo := oauth.NewOAuthClientRepositoryPgx(dbpool)
+	oauthCodeRepo := oauth.NewOAuthAuthorizationCodeRepositoryPgx(dbpool)
+	oauthConsentRepo := oauth.NewOAuthConsentRepositoryPgx(dbpool)
+	oauthRefreshTokenRepo := oauth.NewOAuthRefreshTokenRepositoryPgx(dbpool)
+	sessionRepo := session.NewSessionRepositoryPgx(dbpool)
+	apiKeyRepo := apikey.NewAPIKeyRepositoryPgx(dbpool)
+	revocationRepo, err := revocation.NewTokenRevocationRepository(config, dbpool)
+	if err != nil {
+		log.Fatal(err)
+	}
+	loginAttemptRepo, err := loginattempt.NewLoginAttemptRepository(config, dbpool)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// initialization usecase
-	userUsecase := user.NewUserUsecase(userRepo, config.Context.Timeout)
-	refreshTokenUsecase := refreshtoken.NewRefreshTokenUsecase(refreshTokenRepo, config.Context.Timeout)
+	emailVerificationUsecase := emailverification.NewEmailVerificationUsecase(emailVerificationTokenRepo, appMailer, emailVerificationTTL, config.EmailVerification.URL, config.Context.Timeout)
+	sessionUsecase := session.NewSessionUsecase(sessionRepo, refreshTokenRepo, refreshTTL, config.Context.Timeout)
+	revocationUsecase := revocation.NewTokenRevocationUsecase(revocationRepo, revocationTTL, config.Context.Timeout)
+	userUsecase := user.NewUserUsecase(userRepo, &sessionUsecase, &emailVerificationUsecase, &revocationUsecase, apiKeyRepo, oauthRefreshTokenRepo, passwordHasher, passwordPolicy, config.Context.Timeout)
+	refreshTokenUsecase := refreshtoken.NewRefreshTokenUsecase(refreshTokenRepo, sessionRepo, refreshTTL, config.Context.Timeout)
+	passwordResetUsecase := passwordreset.NewPasswordResetUsecase(passwordResetTokenRepo, appMailer, passwordResetTTL, config.PasswordReset.URL, config.Context.Timeout)
+	magicLinkUsecase := magiclink.NewMagicLinkUsecase(magicLinkTokenRepo, appMailer, magicLinkTTL, config.MagicLink.URL, config.MagicLink.MaxRequests, magicLinkWindow, config.Context.Timeout)
+	loginAttemptUsecase := loginattempt.NewLoginAttemptUsecase(loginAttemptRepo, loginAttemptPolicy, config.Context.Timeout)
+	phoneOTPUsecase := phoneotp.NewPhoneOTPUsecase(phoneOTPRepo, smsSender, &loginAttemptUsecase, []byte(config.PhoneOTP.Secret), phoneOTPTTL, config.PhoneOTP.MaxAttempts, config.PhoneOTP.MaxSends, phoneOTPResendInterval, config.Context.Timeout)
+	oidcUsecase := oidc.NewOIDCUsecase(oidcProviders, oidcAuthRequestRepo, userIdentityRepo, &userUsecase, oidcStateTTL, config.Context.Timeout)
+	oauthClientUsecase := oauth.NewOAuthClientUsecase(oauthClientRepo, oauthConsentRepo, oauthRefreshTokenRepo, config.Context.Timeout)
+	mfaUsecase := mfa.NewMFAUsecase(mfaRepo, config.MFA.Issuer, config.Context.Timeout)
+	oauthUsecase := oauth.NewOAuthUsecase(oauthClientRepo, oauthCodeRepo, oauthConsentRepo, oauthRefreshTokenRepo, &userUsecase, &sessionUsecase, &refreshTokenUsecase, &revocationUsecase, keys, config.OAuth.Issuer, oauthCodeTTL, oauthAccessTTL, oauthRefreshTTL, config.Context.Timeout)
+	apiKeyUsecase := apikey.NewAPIKeyUsecase(apiKeyRepo, config.Context.Timeout)
+
+	// initialization auth middleware
+	authMiddleware := middleware.Auth(keys, &revocationUsecase, &sessionUsecase, &userUsecase, &apiKeyUsecase)
+
+	// initialization jwks handler
+	jwks.NewJWKSHandler(r, keys)
+
+	// initialization oauth authorization server handlers
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequestID)
+		r.Use(middleware.Cors)
+		r.Use(middleware.ContentTypeJson)
+		r.Use(middleware.Logger(logger))
+
+		oauth.NewOAuthHandler(r, &oauthUsecase, keys, authMiddleware, config, logger)
+	})
 
 	r.Route("/api", func(r chi.Router) {
 
@@ -66,10 +256,34 @@ func main() {
 		r.Use(middleware.Logger(logger))
 
 		// initialization auth handlers
-		auth.NewAuthHandler(r, &userUsecase, &refreshTokenUsecase, config, logger)
+		auth.NewAuthHandler(r, &userUsecase, &refreshTokenUsecase, &sessionUsecase, &revocationUsecase, &emailVerificationUsecase, &mfaUsecase, &loginAttemptUsecase, &magicLinkUsecase, &phoneOTPUsecase, &oidcUsecase, keys, authMiddleware, config, logger)
+
+		// initialization session handlers
+		session.NewSessionHandler(r, &sessionUsecase, authMiddleware, logger)
+
+		// initialization mfa handlers
+		mfa.NewMFAHandler(r, &mfaUsecase, &userUsecase, &loginAttemptUsecase, authMiddleware, logger)
+
+		// initialization email verification handlers
+		emailverification.NewEmailVerificationHandler(r, &emailVerificationUsecase, &userUsecase, logger)
+
+		// initialization password reset handlers
+		passwordreset.NewPasswordResetHandler(r, &passwordResetUsecase, &userUsecase, &sessionUsecase, &revocationUsecase, &loginAttemptUsecase, config.PasswordReset.MaxRequests, config.PasswordReset.MaxIPRequests, logger)
+
+		// initialization profile handlers
+		profile.NewProfileHandler(r, &userUsecase, &refreshTokenUsecase, &sessionUsecase, &revocationUsecase, &phoneOTPUsecase, keys, authMiddleware, config, logger)
+
+		// initialization oauth client and consent handlers
+		oauth.NewOAuthClientHandler(r, &oauthClientUsecase, &oauthUsecase, authMiddleware, logger)
+
+		// initialization api key and service account handlers
+		apikey.NewAPIKeyHandler(r, &apiKeyUsecase, &userUsecase, authMiddleware, logger)
+
+		// initialization admin handlers
+		admin.NewAdminHandler(r, &userUsecase, keys, impersonationTTL, authMiddleware, logger)
 
 		// initialization user handlers
-		user.NewUserHandler(r, &userUsecase, logger)
+		user.NewUserHandler(r, &userUsecase, &loginAttemptUsecase, cursorSecret, authMiddleware, logger)
 
 	})
 
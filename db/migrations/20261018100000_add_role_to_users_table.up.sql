@@ -0,0 +1,3 @@
+ALTER TABLE "user"
+    ADD COLUMN IF NOT EXISTS "role" character varying(50) NOT NULL DEFAULT 'user',
+    ADD COLUMN IF NOT EXISTS "permissions" text[] NOT NULL DEFAULT '{}';
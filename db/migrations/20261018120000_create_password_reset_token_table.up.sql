@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS "password_reset_token" (
+    "user_id" character varying(20) NOT NULL,
+    "token" character varying(64) NOT NULL,
+    "expires_at" timestamp(0) without time zone NOT NULL,
+    "created_at" timestamp(0) without time zone,
+    CONSTRAINT password_reset_token_pkey PRIMARY KEY (token));
+
+CREATE INDEX IF NOT EXISTS password_reset_token_user_id_idx ON "password_reset_token" (user_id);
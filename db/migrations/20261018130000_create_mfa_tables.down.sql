@@ -0,0 +1,2 @@
+DROP TABLE "mfa_recovery_code";
+DROP TABLE "user_mfa";
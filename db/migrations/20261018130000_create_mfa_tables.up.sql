@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS "user_mfa" (
+    "user_id" character varying(20) NOT NULL,
+    "secret" character varying(64) NOT NULL,
+    "enabled" boolean NOT NULL DEFAULT false,
+    "last_used_step" bigint NOT NULL DEFAULT 0,
+    "created_at" timestamp(0) without time zone,
+    "updated_at" timestamp(0) without time zone,
+    CONSTRAINT user_mfa_pkey PRIMARY KEY (user_id));
+
+CREATE TABLE IF NOT EXISTS "mfa_recovery_code" (
+    "user_id" character varying(20) NOT NULL,
+    "code" character varying(64) NOT NULL,
+    "used_at" timestamp(0) without time zone,
+    CONSTRAINT mfa_recovery_code_pkey PRIMARY KEY (user_id, code));
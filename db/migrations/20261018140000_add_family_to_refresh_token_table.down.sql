@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS refresh_token_family_id_idx;
+
+ALTER TABLE "refresh_token"
+    DROP COLUMN IF EXISTS "family_id",
+    DROP COLUMN IF EXISTS "parent_token",
+    DROP COLUMN IF EXISTS "rotated_at";
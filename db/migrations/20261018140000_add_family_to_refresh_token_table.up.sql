@@ -0,0 +1,9 @@
+ALTER TABLE "refresh_token"
+    ADD COLUMN IF NOT EXISTS "family_id" character varying(32) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS "parent_token" character varying(500) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS "rotated_at" timestamp(0) without time zone;
+
+-- every existing token starts its own family
+UPDATE "refresh_token" SET family_id = md5(token) WHERE family_id = '';
+
+CREATE INDEX IF NOT EXISTS refresh_token_family_id_idx ON "refresh_token" (family_id);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS refresh_token_token_key;
+
+-- digests can not be turned back into tokens
+DELETE FROM "refresh_token";
+
+ALTER TABLE "refresh_token"
+    ALTER COLUMN "token" TYPE character varying(500),
+    ALTER COLUMN "parent_token" TYPE character varying(500);
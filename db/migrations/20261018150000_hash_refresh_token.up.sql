@@ -0,0 +1,15 @@
+-- tokens generated in the same second could be equal before jti claim, keep one of them
+DELETE FROM "refresh_token" a
+    USING "refresh_token" b
+    WHERE a.ctid < b.ctid AND a.token = b.token;
+
+-- keep sessions alive by replacing plain tokens with their digests (sha256 requires PostgreSQL 11)
+UPDATE "refresh_token"
+    SET token = encode(sha256(token::bytea), 'hex'),
+        parent_token = CASE WHEN parent_token = '' THEN '' ELSE encode(sha256(parent_token::bytea), 'hex') END;
+
+ALTER TABLE "refresh_token"
+    ALTER COLUMN "token" TYPE character varying(64),
+    ALTER COLUMN "parent_token" TYPE character varying(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS refresh_token_token_key ON "refresh_token" (token);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS "session" (
+    "id" character varying(32) NOT NULL,
+    "user_id" character varying(20) NOT NULL,
+    "user_agent" character varying(500) NOT NULL DEFAULT '',
+    "ip" character varying(64) NOT NULL DEFAULT '',
+    "device_name" character varying(100) NOT NULL DEFAULT '',
+    "created_at" timestamp(0) without time zone,
+    "last_used_at" timestamp(0) without time zone,
+    CONSTRAINT session_pkey PRIMARY KEY (id));
+
+CREATE INDEX IF NOT EXISTS session_user_id_idx ON "session" (user_id);
+
+-- existing token families become sessions of unknown devices
+INSERT INTO "session" (id, user_id, created_at, last_used_at)
+    SELECT family_id, user_id, min(created_at), max(created_at)
+    FROM "refresh_token"
+    GROUP BY family_id, user_id
+    ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,2 @@
+DROP TABLE "revoked_user";
+DROP TABLE "revoked_token";
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS "revoked_token" (
+    "id" character varying(64) NOT NULL,
+    "expires_at" timestamp(0) without time zone NOT NULL,
+    CONSTRAINT revoked_token_pkey PRIMARY KEY (id));
+
+CREATE TABLE IF NOT EXISTS "revoked_user" (
+    "user_id" character varying(20) NOT NULL,
+    "revoked_at" timestamp(0) without time zone NOT NULL,
+    "expires_at" timestamp(0) without time zone NOT NULL,
+    CONSTRAINT revoked_user_pkey PRIMARY KEY (user_id));
+
+CREATE INDEX IF NOT EXISTS revoked_token_expires_at_idx ON "revoked_token" (expires_at);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS "login_attempt" (
+    "key" character varying(300) NOT NULL,
+    "failures" integer NOT NULL DEFAULT 0,
+    "locked_until" timestamp(0) without time zone NOT NULL,
+    "updated_at" timestamp(0) without time zone NOT NULL,
+    CONSTRAINT login_attempt_pkey PRIMARY KEY (key));
+
+CREATE INDEX IF NOT EXISTS login_attempt_updated_at_idx ON "login_attempt" (updated_at);
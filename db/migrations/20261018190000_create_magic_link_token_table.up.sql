@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS "magic_link_token" (
+    "user_id" character varying(20) NOT NULL,
+    "token" character varying(64) NOT NULL,
+    "expires_at" timestamp(0) without time zone NOT NULL,
+    "created_at" timestamp(0) without time zone NOT NULL,
+    CONSTRAINT magic_link_token_pkey PRIMARY KEY (token));
+
+CREATE INDEX IF NOT EXISTS magic_link_token_user_id_created_at_idx ON "magic_link_token" (user_id, created_at);
@@ -0,0 +1,5 @@
+DROP TABLE "phone_otp";
+
+DROP INDEX IF EXISTS user_verified_phone_key;
+
+ALTER TABLE "user" DROP COLUMN IF EXISTS "phone_verified_at";
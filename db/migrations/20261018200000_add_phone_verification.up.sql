@@ -0,0 +1,17 @@
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS "phone_verified_at" timestamp(0) without time zone;
+
+-- numbers given in international format are normalized to E.164, the others are left for the users to fix
+UPDATE "user"
+SET phone = regexp_replace(regexp_replace(phone, '[\s\-\.\(\)]', '', 'g'), '^00', '+')
+WHERE regexp_replace(regexp_replace(phone, '[\s\-\.\(\)]', '', 'g'), '^00', '+') ~ '^\+[1-9][0-9]{6,14}$';
+
+CREATE UNIQUE INDEX IF NOT EXISTS user_verified_phone_key ON "user" (phone) WHERE phone_verified_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS "phone_otp" (
+    "phone" character varying(20) NOT NULL,
+    "purpose" character varying(20) NOT NULL,
+    "code" character varying(64) NOT NULL,
+    "attempts" integer NOT NULL DEFAULT 0,
+    "expires_at" timestamp(0) without time zone NOT NULL,
+    "created_at" timestamp(0) without time zone NOT NULL,
+    CONSTRAINT phone_otp_pkey PRIMARY KEY (phone, purpose));
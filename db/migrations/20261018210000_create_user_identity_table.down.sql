@@ -0,0 +1,3 @@
+DROP TABLE "oidc_auth_request";
+
+DROP TABLE "user_identity";
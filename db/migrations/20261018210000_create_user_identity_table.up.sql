@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS "user_identity" (
+    "user_id" character varying(20) NOT NULL,
+    "provider" character varying(50) NOT NULL,
+    "subject" character varying(255) NOT NULL,
+    "email" character varying(255) DEFAULT '',
+    "created_at" timestamp(0) without time zone NOT NULL,
+    CONSTRAINT user_identity_pkey PRIMARY KEY (provider, subject));
+
+CREATE INDEX IF NOT EXISTS user_identity_user_id_idx ON "user_identity" (user_id);
+
+CREATE TABLE IF NOT EXISTS "oidc_auth_request" (
+    "state" character varying(64) NOT NULL,
+    "provider" character varying(50) NOT NULL,
+    "nonce" character varying(64) NOT NULL,
+    "code_verifier" character varying(128) NOT NULL,
+    "expires_at" timestamp(0) without time zone NOT NULL,
+    "created_at" timestamp(0) without time zone NOT NULL,
+    CONSTRAINT oidc_auth_request_pkey PRIMARY KEY (state));
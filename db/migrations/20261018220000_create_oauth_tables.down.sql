@@ -0,0 +1,7 @@
+DROP TABLE "oauth_refresh_token";
+
+DROP TABLE "oauth_consent";
+
+DROP TABLE "oauth_authorization_code";
+
+DROP TABLE "oauth_client";
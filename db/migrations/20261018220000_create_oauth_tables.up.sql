@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS "oauth_client" (
+    "id" character varying(64) NOT NULL,
+    "secret" character varying(64) DEFAULT '',
+    "name" character varying(100) NOT NULL,
+    "redirect_uris" text[] NOT NULL DEFAULT '{}',
+    "grant_types" text[] NOT NULL DEFAULT '{}',
+    "scopes" text[] NOT NULL DEFAULT '{}',
+    "first_party" boolean NOT NULL DEFAULT false,
+    "created_at" timestamp(0) without time zone NOT NULL,
+    "updated_at" timestamp(0) without time zone NOT NULL,
+    CONSTRAINT oauth_client_pkey PRIMARY KEY (id));
+
+CREATE TABLE IF NOT EXISTS "oauth_authorization_code" (
+    "code" character varying(64) NOT NULL,
+    "client_id" character varying(64) NOT NULL,
+    "user_id" character varying(20) NOT NULL,
+    "redirect_uri" text NOT NULL,
+    "scopes" text[] NOT NULL DEFAULT '{}',
+    "code_challenge" character varying(128) NOT NULL,
+    "nonce" character varying(255) DEFAULT '',
+    "expires_at" timestamp(0) without time zone NOT NULL,
+    "created_at" timestamp(0) without time zone NOT NULL,
+    CONSTRAINT oauth_authorization_code_pkey PRIMARY KEY (code));
+
+CREATE TABLE IF NOT EXISTS "oauth_consent" (
+    "user_id" character varying(20) NOT NULL,
+    "client_id" character varying(64) NOT NULL,
+    "scopes" text[] NOT NULL DEFAULT '{}',
+    "created_at" timestamp(0) without time zone NOT NULL,
+    "updated_at" timestamp(0) without time zone NOT NULL,
+    CONSTRAINT oauth_consent_pkey PRIMARY KEY (user_id, client_id));
+
+CREATE INDEX IF NOT EXISTS oauth_consent_client_id_idx ON "oauth_consent" (client_id);
+
+CREATE TABLE IF NOT EXISTS "oauth_refresh_token" (
+    "token" character varying(64) NOT NULL,
+    "client_id" character varying(64) NOT NULL,
+    "user_id" character varying(64) NOT NULL,
+    "scopes" text[] NOT NULL DEFAULT '{}',
+    "expires_at" timestamp(0) without time zone NOT NULL,
+    "created_at" timestamp(0) without time zone NOT NULL,
+    CONSTRAINT oauth_refresh_token_pkey PRIMARY KEY (token));
+
+CREATE INDEX IF NOT EXISTS oauth_refresh_token_user_id_client_id_idx ON "oauth_refresh_token" (user_id, client_id);
+
+CREATE INDEX IF NOT EXISTS oauth_refresh_token_client_id_idx ON "oauth_refresh_token" (client_id);
@@ -0,0 +1,6 @@
+DROP TABLE "api_key";
+
+DROP INDEX IF EXISTS user_type_idx;
+
+ALTER TABLE "user"
+    DROP COLUMN IF EXISTS "type";
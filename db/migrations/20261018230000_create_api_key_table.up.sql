@@ -0,0 +1,20 @@
+ALTER TABLE "user"
+    ADD COLUMN IF NOT EXISTS "type" character varying(20) NOT NULL DEFAULT 'human';
+
+CREATE INDEX IF NOT EXISTS user_type_idx ON "user" (type);
+
+CREATE TABLE IF NOT EXISTS "api_key" (
+    "id" character varying(32) NOT NULL,
+    "user_id" character varying(20) NOT NULL,
+    "name" character varying(100) NOT NULL,
+    "prefix" character varying(20) NOT NULL,
+    "key" character varying(64) NOT NULL,
+    "scopes" text[] NOT NULL DEFAULT '{}',
+    "expires_at" timestamp(0) without time zone,
+    "last_used_at" timestamp(0) without time zone,
+    "created_at" timestamp(0) without time zone NOT NULL,
+    CONSTRAINT api_key_pkey PRIMARY KEY (id));
+
+CREATE UNIQUE INDEX IF NOT EXISTS api_key_key_idx ON "api_key" (key);
+
+CREATE INDEX IF NOT EXISTS api_key_user_id_idx ON "api_key" (user_id);
@@ -0,0 +1,2 @@
+ALTER TABLE "revoked_user"
+    ALTER COLUMN "revoked_at" TYPE timestamp(0) without time zone;
@@ -0,0 +1,3 @@
+-- "iat" claim of access tokens has microseconds, the revocation is compared with it
+ALTER TABLE "revoked_user"
+    ALTER COLUMN "revoked_at" TYPE timestamp(6) without time zone;
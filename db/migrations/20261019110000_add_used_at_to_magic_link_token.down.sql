@@ -0,0 +1 @@
+ALTER TABLE "magic_link_token" DROP COLUMN IF EXISTS "used_at";
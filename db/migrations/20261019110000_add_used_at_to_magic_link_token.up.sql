@@ -0,0 +1,2 @@
+-- magic link tokens are marked used instead of deleted, so used links are counted by the limit of the user
+ALTER TABLE "magic_link_token" ADD COLUMN IF NOT EXISTS "used_at" timestamp(0) without time zone;
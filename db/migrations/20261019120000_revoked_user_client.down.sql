@@ -0,0 +1,5 @@
+ALTER TABLE "oauth_authorization_code" DROP COLUMN IF EXISTS "used_at";
+
+DELETE FROM "revoked_user" WHERE user_id LIKE '%@%';
+ALTER TABLE "revoked_user"
+    ALTER COLUMN "user_id" TYPE character varying(20);
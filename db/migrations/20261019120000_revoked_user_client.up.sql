@@ -0,0 +1,6 @@
+-- tokens an oauth client got for the user are revoked with "user_id@client_id"
+ALTER TABLE "revoked_user"
+    ALTER COLUMN "user_id" TYPE character varying(100);
+
+-- used codes are kept until they expire, so tokens issued with a reused code can be revoked
+ALTER TABLE "oauth_authorization_code" ADD COLUMN IF NOT EXISTS "used_at" timestamp(0) without time zone;
@@ -0,0 +1 @@
+ALTER TABLE "oidc_auth_request" DROP COLUMN IF EXISTS "binding";
@@ -0,0 +1,2 @@
+-- hash of the binding kept in the cookie of the browser which started the authorization
+ALTER TABLE "oidc_auth_request" ADD COLUMN IF NOT EXISTS "binding" character varying(64) NOT NULL DEFAULT '';
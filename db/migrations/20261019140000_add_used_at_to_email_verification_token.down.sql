@@ -0,0 +1 @@
+ALTER TABLE "email_verification_token" DROP COLUMN IF EXISTS "used_at";
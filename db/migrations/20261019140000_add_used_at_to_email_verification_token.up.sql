@@ -0,0 +1,2 @@
+-- user tokens are looked up only while unused, email verification tokens share the columns of user tokens
+ALTER TABLE "email_verification_token" ADD COLUMN IF NOT EXISTS "used_at" timestamp(0) without time zone;
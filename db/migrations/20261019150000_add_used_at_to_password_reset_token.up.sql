@@ -0,0 +1,2 @@
+-- reset tokens are marked used while the password is changed and released when the reset fails
+ALTER TABLE "password_reset_token" ADD COLUMN IF NOT EXISTS "used_at" timestamp(0) without time zone;
@@ -0,0 +1,98 @@
+package admin
+
+import (
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/middleware"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/response"
+	"github.com/Jamshid90/go-clean-architecture/pkg/token"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+type AdminHandler struct {
+	logger           *zap.Logger
+	userUsecase      entity.UserUsecase
+	keys             *token.KeySet
+	impersonationTTL time.Duration
+}
+
+// New admin handler, impersonation is requested by admins themselves, neither with api keys nor under impersonation
+func NewAdminHandler(r chi.Router, userUsecase entity.UserUsecase, keys *token.KeySet, impersonationTTL time.Duration, auth func(http.Handler) http.Handler, logger *zap.Logger) {
+	handler := AdminHandler{
+		userUsecase:      userUsecase,
+		keys:             keys,
+		impersonationTTL: impersonationTTL,
+		logger:           logger,
+	}
+
+	r.Group(func(r chi.Router) {
+		r.Use(auth)
+		r.Use(middleware.NoAPIKey)
+		r.Use(middleware.NoImpersonation)
+		r.With(middleware.Permission(entity.PERMISSION_USER_IMPERSONATE)).Post("/admin/impersonate/{id}", handler.impersonate())
+	})
+}
+
+// impersonate issues short-lived access token of the user naming the admin as the actor,
+// the admin can not gain permissions the admin does not have
+func (a *AdminHandler) impersonate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		admin, ok := middleware.GetAuthUser(ctx)
+		if !ok {
+			response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		id := chi.URLParam(r, "id")
+		if id == admin.ID {
+			response.Error(w, r, &errors.ErrBadRequest{Message: "admin can not impersonate themselves"}, http.StatusBadRequest)
+			return
+		}
+
+		user, err := a.userUsecase.Find(ctx, id)
+		if err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if user.IsDisabled() {
+			response.Error(w, r, errors.ErrAccountDisabled, http.StatusUnprocessableEntity)
+			return
+		}
+
+		for _, permission := range user.AllPermissions() {
+			if !admin.HasPermission(permission) {
+				response.Error(w, r, errors.ErrForbidden, http.StatusForbidden)
+				return
+			}
+		}
+
+		accessToken, err := token.GenerateImpersonationToken(a.keys, a.impersonationTTL, user, admin.ID)
+		if err != nil {
+			a.logger.Error("admin impersonate generate token", zap.Error(err))
+			response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		expiresAt := time.Now().Add(a.impersonationTTL)
+		a.logger.Info("impersonation started",
+			zap.String("admin_id", admin.ID),
+			zap.String("user_id", user.ID),
+			zap.String("request_id", middleware.GetReqID(ctx)),
+			zap.Time("expires_at", expiresAt),
+		)
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+			"token": map[string]interface{}{
+				"type":       "Bearer",
+				"access":     accessToken,
+				"expires_in": int(a.impersonationTTL.Seconds()),
+			},
+		})
+	}
+}
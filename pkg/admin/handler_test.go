@@ -0,0 +1,150 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity/mocks"
+	apperrors "github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/token"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// test auth authenticates every request as the user, like Auth middleware does with the token or the api key
+func testAuth(user *entity.User, accessToken *token.AccessToken, apiKey *entity.APIKey) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), "user", user)
+			if accessToken != nil {
+				ctx = context.WithValue(ctx, "access_token", accessToken)
+			}
+			if apiKey != nil {
+				ctx = context.WithValue(ctx, "api_key", apiKey)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func impersonate(t *testing.T, userUsecase entity.UserUsecase, keys *token.KeySet, auth func(http.Handler) http.Handler, id string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r := chi.NewRouter()
+	NewAdminHandler(r, userUsecase, keys, time.Minute*15, auth, zap.NewNop())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/impersonate/"+id, nil))
+	return w
+}
+
+func TestImpersonate(t *testing.T) {
+	keys := token.TestKeySet(t)
+	admin := &entity.User{ID: "admin", Role: entity.USER_ROLE_ADMIN, Status: entity.USER_STATUS_ACTIVE}
+	user := &entity.User{ID: "user", Role: entity.USER_ROLE_USER, Status: entity.USER_STATUS_ACTIVE, Permissions: []string{entity.PERMISSION_USER_READ}}
+
+	t.Run("success", func(t *testing.T) {
+		mockUsecase := new(mocks.UserUsecase)
+		mockUsecase.On("Find", mock.Anything, user.ID).Return(user, nil).Once()
+
+		w := impersonate(t, mockUsecase, keys, testAuth(admin, nil, nil), user.ID)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		mockUsecase.AssertExpectations(t)
+
+		var body struct {
+			Token struct {
+				Type      string `json:"type"`
+				Access    string `json:"access"`
+				ExpiresIn int    `json:"expires_in"`
+			} `json:"token"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "Bearer", body.Token.Type)
+		assert.Equal(t, 900, body.Token.ExpiresIn)
+
+		// the token is the user's and names the admin as the actor
+		accessToken, err := token.ParseAccessTokenString(keys, body.Token.Access)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, accessToken.User.ID)
+		assert.Equal(t, admin.ID, accessToken.ActorID)
+		assert.NotEmpty(t, accessToken.ID)
+	})
+
+	t.Run("error-without-permission", func(t *testing.T) {
+		mockUsecase := new(mocks.UserUsecase)
+		manager := &entity.User{ID: "manager", Role: entity.USER_ROLE_USER, Permissions: []string{entity.PERMISSION_USER_READ, entity.PERMISSION_USER_WRITE}}
+
+		w := impersonate(t, mockUsecase, keys, testAuth(manager, nil, nil), user.ID)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockUsecase.AssertNotCalled(t, "Find", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error-gaining-permissions", func(t *testing.T) {
+		mockUsecase := new(mocks.UserUsecase)
+		support := &entity.User{ID: "support", Role: entity.USER_ROLE_USER, Permissions: []string{entity.PERMISSION_USER_IMPERSONATE}}
+		mockUsecase.On("Find", mock.Anything, admin.ID).Return(admin, nil).Once()
+
+		w := impersonate(t, mockUsecase, keys, testAuth(support, nil, nil), admin.ID)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("error-self", func(t *testing.T) {
+		mockUsecase := new(mocks.UserUsecase)
+
+		w := impersonate(t, mockUsecase, keys, testAuth(admin, nil, nil), admin.ID)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("error-disabled-user", func(t *testing.T) {
+		mockUsecase := new(mocks.UserUsecase)
+		suspended := &entity.User{ID: "suspended", Role: entity.USER_ROLE_USER, Status: entity.USER_STATUS_SUSPENDED}
+		mockUsecase.On("Find", mock.Anything, suspended.ID).Return(suspended, nil).Once()
+
+		w := impersonate(t, mockUsecase, keys, testAuth(admin, nil, nil), suspended.ID)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("error-not-found", func(t *testing.T) {
+		mockUsecase := new(mocks.UserUsecase)
+		mockUsecase.On("Find", mock.Anything, "unknown").Return(nil, apperrors.NewErrNotFound("user")).Once()
+
+		w := impersonate(t, mockUsecase, keys, testAuth(admin, nil, nil), "unknown")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("error-under-impersonation", func(t *testing.T) {
+		mockUsecase := new(mocks.UserUsecase)
+		impersonated := &token.AccessToken{ID: "jti", User: admin, ActorID: "other-admin"}
+
+		w := impersonate(t, mockUsecase, keys, testAuth(admin, impersonated, nil), user.ID)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockUsecase.AssertNotCalled(t, "Find", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error-api-key", func(t *testing.T) {
+		mockUsecase := new(mocks.UserUsecase)
+		apiKey := &entity.APIKey{ID: "key", UserID: admin.ID, Scopes: []string{entity.PERMISSION_USER_IMPERSONATE}}
+
+		w := impersonate(t, mockUsecase, keys, testAuth(admin, nil, apiKey), user.ID)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockUsecase.AssertNotCalled(t, "Find", mock.Anything, mock.Anything)
+	})
+}
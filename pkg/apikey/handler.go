@@ -0,0 +1,295 @@
+package apikey
+
+import (
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/middleware"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/request"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/response"
+	"github.com/Jamshid90/go-clean-architecture/pkg/validation"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type APIKeyHandler struct {
+	logger        *zap.Logger
+	apiKeyUsecase entity.APIKeyUsecase
+	userUsecase   entity.UserUsecase
+}
+
+// New api key handler, users manage their own keys and admins manage service accounts and their keys.
+// Keys can not be managed with api keys nor under impersonation.
+func NewAPIKeyHandler(r chi.Router, apiKeyUsecase entity.APIKeyUsecase, userUsecase entity.UserUsecase, auth func(http.Handler) http.Handler, logger *zap.Logger) {
+	handler := APIKeyHandler{
+		apiKeyUsecase: apiKeyUsecase,
+		userUsecase:   userUsecase,
+		logger:        logger,
+	}
+
+	r.Group(func(r chi.Router) {
+		r.Use(auth)
+		r.Use(middleware.NoAPIKey)
+		r.Use(middleware.NoImpersonation)
+		r.Get("/api-keys", handler.findAll())
+		r.Post("/api-keys", handler.store())
+		r.Delete("/api-keys/{key_id}", handler.delete())
+
+		r.With(middleware.Permission(entity.PERMISSION_SERVICE_ACCOUNT_READ)).Get("/service-accounts", handler.findServiceAccounts())
+		r.With(middleware.Permission(entity.PERMISSION_SERVICE_ACCOUNT_WRITE)).Post("/service-accounts", handler.storeServiceAccount())
+		r.With(middleware.Permission(entity.PERMISSION_SERVICE_ACCOUNT_WRITE)).Delete("/service-accounts/{id}", handler.deleteServiceAccount())
+		r.With(middleware.Permission(entity.PERMISSION_SERVICE_ACCOUNT_READ)).Get("/service-accounts/{id}/api-keys", handler.findAll())
+		r.With(middleware.Permission(entity.PERMISSION_SERVICE_ACCOUNT_WRITE)).Post("/service-accounts/{id}/api-keys", handler.store())
+		r.With(middleware.Permission(entity.PERMISSION_SERVICE_ACCOUNT_WRITE)).Delete("/service-accounts/{id}/api-keys/{key_id}", handler.delete())
+	})
+}
+
+// convert entity api key to api key model
+func (a *APIKeyHandler) convert(apiKey *entity.APIKey) *APIKey {
+	return &APIKey{
+		ID:         apiKey.ID,
+		Name:       apiKey.Name,
+		Prefix:     apiKey.Prefix,
+		Scopes:     apiKey.Scopes,
+		ExpiresAt:  apiKey.ExpiresAt,
+		LastUsedAt: apiKey.LastUsedAt,
+		CreatedAt:  apiKey.CreatedAt,
+	}
+}
+
+// convert entity user to service account model
+func (a *APIKeyHandler) convertServiceAccount(user *entity.User) *ServiceAccount {
+	return &ServiceAccount{
+		ID:          user.ID,
+		Name:        user.FirstName,
+		Status:      user.Status,
+		Role:        user.Role,
+		Permissions: user.Permissions,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+	}
+}
+
+// owner returns the service account of the url or the authenticated user
+func (a *APIKeyHandler) owner(r *http.Request) (*entity.User, error) {
+	ctx := r.Context()
+	if id := chi.URLParam(r, "id"); id != "" {
+		return a.findServiceAccount(r, id)
+	}
+
+	authUser, ok := middleware.GetAuthUser(ctx)
+	if !ok {
+		return nil, errors.ErrInternalServerError
+	}
+	return a.userUsecase.Find(ctx, authUser.ID)
+}
+
+// find service account, other users are not found
+func (a *APIKeyHandler) findServiceAccount(r *http.Request, id string) (*entity.User, error) {
+	user, err := a.userUsecase.Find(r.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsServiceAccount() {
+		return nil, errors.NewErrNotFound("service account")
+	}
+	return user, nil
+}
+
+// store creates the key, the key is in the response only once
+func (a *APIKeyHandler) store() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var apiKeyRequest CreateAPIKeyRequest
+		if err := request.DecodeJson(r, &apiKeyRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := validation.Validator(&apiKeyRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		owner, err := a.owner(r)
+		if err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		apiKey := entity.APIKey{
+			Name:      apiKeyRequest.Name,
+			Scopes:    apiKeyRequest.Scopes,
+			ExpiresAt: apiKeyRequest.ExpiresAt,
+		}
+		key, err := a.apiKeyUsecase.Store(r.Context(), owner, &apiKey)
+		if err != nil {
+			a.logger.Error("api key store", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		model := a.convert(&apiKey)
+		model.Key = key
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+			"data":   model,
+		})
+	}
+}
+
+// find all keys of the owner
+func (a *APIKeyHandler) findAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		owner, err := a.owner(r)
+		if err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		items, err := a.apiKeyUsecase.FindByUserId(r.Context(), owner.ID)
+		if err != nil {
+			a.logger.Error("api key find all", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		apiKeys := []*APIKey{}
+		for _, item := range items {
+			apiKeys = append(apiKeys, a.convert(item))
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+			"data":   apiKeys,
+		})
+	}
+}
+
+// delete revokes the key of the owner
+func (a *APIKeyHandler) delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		owner, err := a.owner(r)
+		if err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := a.apiKeyUsecase.Delete(r.Context(), owner.ID, chi.URLParam(r, "key_id")); err != nil {
+			a.logger.Error("api key delete", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+		})
+	}
+}
+
+// find service accounts
+func (a *APIKeyHandler) findServiceAccounts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter := entity.UserFilter{Limit: 10, Type: entity.USER_TYPE_SERVICE}
+
+		if _limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil {
+			filter.Limit = _limit
+		}
+
+		if _offset, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil {
+			filter.Offset = _offset
+		}
+
+		page, err := a.userUsecase.FindAll(r.Context(), &filter)
+		if err != nil {
+			a.logger.Error("service account find all", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		serviceAccounts := []*ServiceAccount{}
+		for _, item := range page.Items {
+			serviceAccounts = append(serviceAccounts, a.convertServiceAccount(item))
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+			"data":   serviceAccounts,
+		})
+	}
+}
+
+// store service account, the account has neither email nor password and authenticates with api keys only
+func (a *APIKeyHandler) storeServiceAccount() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var serviceAccountRequest CreateServiceAccountRequest
+		if err := request.DecodeJson(r, &serviceAccountRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := validation.Validator(&serviceAccountRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		authUser, ok := middleware.GetAuthUser(r.Context())
+		if !ok {
+			response.Error(w, r, errors.ErrUnauthorized, http.StatusUnauthorized)
+			return
+		}
+
+		// keys of the account get its permissions, so they are limited to the ones the auth user has
+		if !authUser.CanGrant(&entity.User{}, serviceAccountRequest.Role, serviceAccountRequest.Permissions) {
+			response.Error(w, r, errors.ErrForbidden, http.StatusForbidden)
+			return
+		}
+
+		user := entity.User{
+			Type:        entity.USER_TYPE_SERVICE,
+			Status:      entity.USER_STATUS_ACTIVE,
+			Role:        serviceAccountRequest.Role,
+			Permissions: serviceAccountRequest.Permissions,
+			FirstName:   serviceAccountRequest.Name,
+		}
+		if err := a.userUsecase.Store(r.Context(), &user); err != nil {
+			a.logger.Error("service account store", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+			"data":   a.convertServiceAccount(&user),
+		})
+	}
+}
+
+// delete service account together with its keys
+func (a *APIKeyHandler) deleteServiceAccount() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		user, err := a.findServiceAccount(r, chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := a.apiKeyUsecase.DeleteByUserId(ctx, user.ID); err != nil {
+			a.logger.Error("service account delete api keys", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := a.userUsecase.Delete(ctx, user.ID); err != nil {
+			a.logger.Error("service account delete", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+		})
+	}
+}
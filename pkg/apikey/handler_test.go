@@ -0,0 +1,79 @@
+package apikey
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity/mocks"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// test auth authenticates every request as the user, like Auth middleware does with the access token
+func testAuth(user *entity.User) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "user", user)))
+		})
+	}
+}
+
+func TestStoreServiceAccount(t *testing.T) {
+	writer := &entity.User{ID: "writer", Role: entity.USER_ROLE_USER, Permissions: []string{entity.PERMISSION_SERVICE_ACCOUNT_WRITE, entity.PERMISSION_USER_READ}}
+
+	serve := func(userUsecase entity.UserUsecase, body string) *httptest.ResponseRecorder {
+		apiKeyUsecase := NewAPIKeyUsecase(new(mocks.APIKeyRepository), time.Second*2)
+
+		r := chi.NewRouter()
+		NewAPIKeyHandler(r, &apiKeyUsecase, userUsecase, testAuth(writer), zap.NewNop())
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/service-accounts", strings.NewReader(body)))
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockUsecase := new(mocks.UserUsecase)
+		mockUsecase.On("Store", mock.Anything, mock.MatchedBy(func(m *entity.User) bool {
+			return m.Type == entity.USER_TYPE_SERVICE && m.Permissions[0] == entity.PERMISSION_USER_READ
+		})).Return(nil).Once()
+
+		w := serve(mockUsecase, `{"name":"Reporting","permissions":["user:read"]}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("error-admin-role", func(t *testing.T) {
+		mockUsecase := new(mocks.UserUsecase)
+
+		w := serve(mockUsecase, `{"name":"Reporting","role":"admin"}`)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockUsecase.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error-not-held-permission", func(t *testing.T) {
+		mockUsecase := new(mocks.UserUsecase)
+
+		w := serve(mockUsecase, `{"name":"Reporting","permissions":["user:delete"]}`)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockUsecase.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error-unknown-permission", func(t *testing.T) {
+		mockUsecase := new(mocks.UserUsecase)
+
+		w := serve(mockUsecase, `{"name":"Reporting","permissions":["everything"]}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockUsecase.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,126 @@
+package apikey
+
+import (
+	"context"
+	"fmt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+const apiKeyColumns = `id, user_id, name, prefix, key, scopes, expires_at, last_used_at, created_at`
+
+type pgxAPIKeyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAPIKeyRepositoryPgx(dbpool *pgxpool.Pool) entity.APIKeyRepository {
+	return &pgxAPIKeyRepository{db: dbpool}
+}
+
+func (p *pgxAPIKeyRepository) Store(ctx context.Context, m *entity.APIKey) error {
+	_, err := p.db.Exec(ctx, `INSERT INTO "api_key"(`+apiKeyColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		m.ID,
+		m.UserID,
+		m.Name,
+		m.Prefix,
+		m.Key,
+		m.Scopes,
+		m.ExpiresAt,
+		m.LastUsedAt,
+		m.CreatedAt,
+	)
+
+	if err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during store to api key repository: %w", err)}
+	}
+
+	return nil
+}
+
+func (p *pgxAPIKeyRepository) scan(row pgx.Row, key *entity.APIKey) error {
+	return row.Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&key.Key,
+		&key.Scopes,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.CreatedAt,
+	)
+}
+
+func (p *pgxAPIKeyRepository) Find(ctx context.Context, id string) (*entity.APIKey, error) {
+	key := entity.APIKey{}
+	err := p.scan(p.db.QueryRow(ctx, `SELECT `+apiKeyColumns+` FROM "api_key" WHERE id=$1`, id), &key)
+
+	if err == pgx.ErrNoRows {
+		return nil, errors.NewErrNotFound("api key")
+	}
+
+	if err != nil {
+		return nil, errors.ErrRepository{Err: fmt.Errorf("error during find to api key repository: %w", err)}
+	}
+
+	return &key, nil
+}
+
+// find by key, the key is the hash of the api key
+func (p *pgxAPIKeyRepository) FindByKey(ctx context.Context, hash string) (*entity.APIKey, error) {
+	key := entity.APIKey{}
+	err := p.scan(p.db.QueryRow(ctx, `SELECT `+apiKeyColumns+` FROM "api_key" WHERE key=$1`, hash), &key)
+
+	if err == pgx.ErrNoRows {
+		return nil, errors.NewErrNotFound("api key")
+	}
+
+	if err != nil {
+		return nil, errors.ErrRepository{Err: fmt.Errorf("error during find by key to api key repository: %w", err)}
+	}
+
+	return &key, nil
+}
+
+func (p *pgxAPIKeyRepository) FindByUserId(ctx context.Context, userID string) ([]*entity.APIKey, error) {
+	var items []*entity.APIKey
+	rows, err := p.db.Query(ctx, `SELECT `+apiKeyColumns+` FROM "api_key" WHERE user_id=$1 ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return items, errors.ErrRepository{Err: fmt.Errorf("error during find by user id to api key repository: %w", err)}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		key := entity.APIKey{}
+		if err := p.scan(rows, &key); err != nil {
+			return items, errors.ErrRepository{Err: fmt.Errorf("error during find by user id to api key repository: %w", err)}
+		}
+		items = append(items, &key)
+	}
+	return items, nil
+}
+
+func (p *pgxAPIKeyRepository) Touch(ctx context.Context, id string, lastUsedAt time.Time) error {
+	if _, err := p.db.Exec(ctx, `UPDATE "api_key" SET last_used_at=$1 WHERE id=$2`, lastUsedAt, id); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during touch to api key repository: %w", err)}
+	}
+	return nil
+}
+
+func (p *pgxAPIKeyRepository) Delete(ctx context.Context, id string) error {
+	if _, err := p.db.Exec(ctx, `DELETE FROM "api_key" WHERE id=$1`, id); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during delete to api key repository: %w", err)}
+	}
+	return nil
+}
+
+func (p *pgxAPIKeyRepository) DeleteByUserId(ctx context.Context, userID string) error {
+	if _, err := p.db.Exec(ctx, `DELETE FROM "api_key" WHERE user_id=$1`, userID); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during delete by user id to api key repository: %w", err)}
+	}
+	return nil
+}
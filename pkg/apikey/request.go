@@ -0,0 +1,15 @@
+package apikey
+
+import "time"
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"omitempty,dive,required"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type CreateServiceAccountRequest struct {
+	Name        string   `json:"name" validate:"required,min=2,max=50"`
+	Role        string   `json:"role" validate:"omitempty,oneof=admin user"`
+	Permissions []string `json:"permissions" validate:"omitempty,dive,permission"`
+}
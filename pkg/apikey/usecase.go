@@ -0,0 +1,155 @@
+package apikey
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
+	"github.com/Jamshid90/go-clean-architecture/pkg/rand"
+	"strings"
+	"time"
+)
+
+const (
+	// PREFIX_LENGTH is the length of the visible start of the key after entity.API_KEY_PREFIX
+	PREFIX_LENGTH = 8
+	// LAST_USED_PRECISION limits writes of last used time, a key used in a burst is touched once
+	LAST_USED_PRECISION = time.Minute
+)
+
+type apiKeyUsecase struct {
+	apiKeyRepo     entity.APIKeyRepository
+	contextTimeout time.Duration
+}
+
+// New api key usecase
+func NewAPIKeyUsecase(repo entity.APIKeyRepository, timeout time.Duration) apiKeyUsecase {
+	return apiKeyUsecase{
+		apiKeyRepo:     repo,
+		contextTimeout: timeout,
+	}
+}
+
+// validate, the owner can not grant the key scopes the owner does not have
+func (a *apiKeyUsecase) validate(owner *entity.User, m *entity.APIKey) error {
+	errValidation := errors.NewErrValidation()
+
+	for _, scope := range m.Scopes {
+		if !owner.HasPermission(scope) {
+			errValidation.Errors["scopes"] = "scope " + scope + " is not granted to the owner"
+			break
+		}
+	}
+
+	if m.ExpiresAt != nil && !m.ExpiresAt.After(time.Now().UTC()) {
+		errValidation.Errors["expires_at"] = "expires_at must be in the future"
+	}
+
+	if len(errValidation.Errors) > 0 {
+		return errValidation
+	}
+	return nil
+}
+
+// Store generates the key for the owner, the key is returned only once and only its hash is stored
+func (a *apiKeyUsecase) Store(ctx context.Context, owner *entity.User, m *entity.APIKey) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.contextTimeout)
+	defer cancel()
+
+	if m.Scopes == nil {
+		m.Scopes = []string{}
+	}
+
+	if err := a.validate(owner, m); err != nil {
+		return "", err
+	}
+
+	id, err := rand.Token(16)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := rand.Token(32)
+	if err != nil {
+		return "", err
+	}
+	key := entity.API_KEY_PREFIX + secret
+
+	m.ID = id
+	m.UserID = owner.ID
+	m.Prefix = key[:len(entity.API_KEY_PREFIX)+PREFIX_LENGTH]
+	m.Key = hash.HashToken(key)
+	m.LastUsedAt = nil
+	m.CreatedAt = time.Now().UTC()
+
+	if err := a.apiKeyRepo.Store(ctx, m); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// Authenticate returns the api key which is not expired and records its use
+func (a *apiKeyUsecase) Authenticate(ctx context.Context, key string) (*entity.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.contextTimeout)
+	defer cancel()
+
+	if !strings.HasPrefix(key, entity.API_KEY_PREFIX) {
+		return nil, errors.ErrUnauthorized
+	}
+
+	apiKey, err := a.apiKeyRepo.FindByKey(ctx, hash.HashToken(key))
+	if err != nil {
+		if _, ok := err.(*errors.ErrNotFound); ok {
+			return nil, errors.ErrUnauthorized
+		}
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if apiKey.IsExpired(now) {
+		return nil, errors.ErrUnauthorized
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= LAST_USED_PRECISION {
+		if err := a.apiKeyRepo.Touch(ctx, apiKey.ID, now); err != nil {
+			return nil, err
+		}
+		apiKey.LastUsedAt = &now
+	}
+
+	return apiKey, nil
+}
+
+// Find by user id
+func (a *apiKeyUsecase) FindByUserId(ctx context.Context, userID string) ([]*entity.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.contextTimeout)
+	defer cancel()
+
+	return a.apiKeyRepo.FindByUserId(ctx, userID)
+}
+
+// Delete revokes the key, keys of other users are not found
+func (a *apiKeyUsecase) Delete(ctx context.Context, userID, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, a.contextTimeout)
+	defer cancel()
+
+	apiKey, err := a.apiKeyRepo.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if apiKey.UserID != userID {
+		return errors.NewErrNotFound("api key")
+	}
+
+	return a.apiKeyRepo.Delete(ctx, id)
+}
+
+// Delete by user id
+func (a *apiKeyUsecase) DeleteByUserId(ctx context.Context, userID string) error {
+	ctx, cancel := context.WithTimeout(ctx, a.contextTimeout)
+	defer cancel()
+
+	return a.apiKeyRepo.DeleteByUserId(ctx, userID)
+}
@@ -0,0 +1,138 @@
+package apikey
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity/mocks"
+	apperrors "github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	owner := &entity.User{ID: "123456789", Role: entity.USER_ROLE_USER, Permissions: []string{entity.PERMISSION_USER_READ}}
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo := new(mocks.APIKeyRepository)
+		mockRepo.On("Store", mock.Anything, mock.AnythingOfType("*entity.APIKey")).Return(nil).Once()
+
+		apiKey := &entity.APIKey{Name: "deploy", Scopes: []string{entity.PERMISSION_USER_READ}}
+		usecase := NewAPIKeyUsecase(mockRepo, time.Second*2)
+		key, err := usecase.Store(context.TODO(), owner, apiKey)
+
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(key, entity.API_KEY_PREFIX))
+		assert.True(t, strings.HasPrefix(key, apiKey.Prefix))
+		assert.Len(t, apiKey.Prefix, len(entity.API_KEY_PREFIX)+PREFIX_LENGTH)
+		assert.Equal(t, hash.HashToken(key), apiKey.Key)
+		assert.Equal(t, owner.ID, apiKey.UserID)
+		assert.NotEmpty(t, apiKey.ID)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-validation", func(t *testing.T) {
+		expired := time.Now().Add(-time.Hour)
+
+		for name, tc := range map[string]struct {
+			apiKey *entity.APIKey
+			field  string
+		}{
+			"scope-not-granted": {&entity.APIKey{Name: "deploy", Scopes: []string{entity.PERMISSION_USER_DELETE}}, "scopes"},
+			"expired":           {&entity.APIKey{Name: "deploy", ExpiresAt: &expired}, "expires_at"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				mockRepo := new(mocks.APIKeyRepository)
+				usecase := NewAPIKeyUsecase(mockRepo, time.Second*2)
+				_, err := usecase.Store(context.TODO(), owner, tc.apiKey)
+
+				errValidation, ok := err.(*apperrors.ErrValidation)
+				if assert.True(t, ok) {
+					assert.Contains(t, errValidation.Errors, tc.field)
+				}
+				mockRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+			})
+		}
+	})
+}
+
+func TestAuthenticate(t *testing.T) {
+	const key = entity.API_KEY_PREFIX + "secret"
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo := new(mocks.APIKeyRepository)
+		mockRepo.On("FindByKey", mock.Anything, hash.HashToken(key)).Return(&entity.APIKey{ID: "key", UserID: "123456789"}, nil).Once()
+		mockRepo.On("Touch", mock.Anything, "key", mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+		usecase := NewAPIKeyUsecase(mockRepo, time.Second*2)
+		apiKey, err := usecase.Authenticate(context.TODO(), key)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "123456789", apiKey.UserID)
+		assert.NotNil(t, apiKey.LastUsedAt)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("success-recently-used", func(t *testing.T) {
+		lastUsedAt := time.Now().UTC().Add(-time.Second)
+		mockRepo := new(mocks.APIKeyRepository)
+		mockRepo.On("FindByKey", mock.Anything, hash.HashToken(key)).Return(&entity.APIKey{ID: "key", LastUsedAt: &lastUsedAt}, nil).Once()
+
+		usecase := NewAPIKeyUsecase(mockRepo, time.Second*2)
+		_, err := usecase.Authenticate(context.TODO(), key)
+
+		assert.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "Touch", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error-expired", func(t *testing.T) {
+		expiresAt := time.Now().UTC().Add(-time.Second)
+		mockRepo := new(mocks.APIKeyRepository)
+		mockRepo.On("FindByKey", mock.Anything, hash.HashToken(key)).Return(&entity.APIKey{ID: "key", ExpiresAt: &expiresAt}, nil).Once()
+
+		usecase := NewAPIKeyUsecase(mockRepo, time.Second*2)
+		_, err := usecase.Authenticate(context.TODO(), key)
+
+		assert.Equal(t, apperrors.ErrUnauthorized, err)
+	})
+
+	t.Run("error-unknown", func(t *testing.T) {
+		mockRepo := new(mocks.APIKeyRepository)
+		mockRepo.On("FindByKey", mock.Anything, hash.HashToken(key)).Return(nil, apperrors.NewErrNotFound("api key")).Once()
+
+		usecase := NewAPIKeyUsecase(mockRepo, time.Second*2)
+		_, err := usecase.Authenticate(context.TODO(), key)
+		assert.Equal(t, apperrors.ErrUnauthorized, err)
+
+		_, err = usecase.Authenticate(context.TODO(), "jwt")
+		assert.Equal(t, apperrors.ErrUnauthorized, err)
+	})
+}
+
+func TestDelete(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockRepo := new(mocks.APIKeyRepository)
+		mockRepo.On("Find", mock.Anything, "key").Return(&entity.APIKey{ID: "key", UserID: "123456789"}, nil).Once()
+		mockRepo.On("Delete", mock.Anything, "key").Return(nil).Once()
+
+		usecase := NewAPIKeyUsecase(mockRepo, time.Second*2)
+		err := usecase.Delete(context.TODO(), "123456789", "key")
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-other-user", func(t *testing.T) {
+		mockRepo := new(mocks.APIKeyRepository)
+		mockRepo.On("Find", mock.Anything, "key").Return(&entity.APIKey{ID: "key", UserID: "123456789"}, nil).Once()
+
+		usecase := NewAPIKeyUsecase(mockRepo, time.Second*2)
+		err := usecase.Delete(context.TODO(), "987654321", "key")
+
+		assert.IsType(t, &apperrors.ErrNotFound{}, err)
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
@@ -1,10 +1,10 @@
 package auth
 
 import (
+	stderrors "errors"
 	"github.com/Jamshid90/go-clean-architecture/pkg/config"
 	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
 	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
-	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
 	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/middleware"
 	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/request"
 	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/response"
@@ -12,33 +12,60 @@ import (
 	"github.com/Jamshid90/go-clean-architecture/pkg/validation"
 	"github.com/go-chi/chi"
 	"go.uber.org/zap"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 )
 
 type AuthHandler struct {
-	logger              *zap.Logger
-	config              *config.Config
-	userUsecase         entity.UserUsecase
-	refreshTokenUsecase entity.RefreshTokenUsecase
+	logger                   *zap.Logger
+	config                   *config.Config
+	keys                     *token.KeySet
+	userUsecase              entity.UserUsecase
+	refreshTokenUsecase      entity.RefreshTokenUsecase
+	sessionUsecase           entity.SessionUsecase
+	revocationUsecase        entity.TokenRevocationUsecase
+	emailVerificationUsecase entity.EmailVerificationUsecase
+	mfaUsecase               entity.MFAUsecase
+	loginAttemptUsecase      entity.LoginAttemptUsecase
+	magicLinkUsecase         entity.MagicLinkUsecase
+	phoneOTPUsecase          entity.PhoneOTPUsecase
+	oidcUsecase              entity.OIDCUsecase
 }
 
 // New user handler
-func NewAuthHandler(r chi.Router, userUsecase entity.UserUsecase, refreshTokenUsecase entity.RefreshTokenUsecase, config *config.Config, logger *zap.Logger) {
+func NewAuthHandler(r chi.Router, userUsecase entity.UserUsecase, refreshTokenUsecase entity.RefreshTokenUsecase, sessionUsecase entity.SessionUsecase, revocationUsecase entity.TokenRevocationUsecase, emailVerificationUsecase entity.EmailVerificationUsecase, mfaUsecase entity.MFAUsecase, loginAttemptUsecase entity.LoginAttemptUsecase, magicLinkUsecase entity.MagicLinkUsecase, phoneOTPUsecase entity.PhoneOTPUsecase, oidcUsecase entity.OIDCUsecase, keys *token.KeySet, auth func(http.Handler) http.Handler, config *config.Config, logger *zap.Logger) {
 	handler := AuthHandler{
-		logger:              logger,
-		config:              config,
-		userUsecase:         userUsecase,
-		refreshTokenUsecase: refreshTokenUsecase,
+		logger:                   logger,
+		config:                   config,
+		keys:                     keys,
+		userUsecase:              userUsecase,
+		refreshTokenUsecase:      refreshTokenUsecase,
+		sessionUsecase:           sessionUsecase,
+		revocationUsecase:        revocationUsecase,
+		emailVerificationUsecase: emailVerificationUsecase,
+		mfaUsecase:               mfaUsecase,
+		loginAttemptUsecase:      loginAttemptUsecase,
+		magicLinkUsecase:         magicLinkUsecase,
+		phoneOTPUsecase:          phoneOTPUsecase,
+		oidcUsecase:              oidcUsecase,
 	}
 
 	r.Post("/auth/login", handler.login())
+	r.Post("/auth/login/mfa", handler.loginMFA())
+	r.Post("/auth/login/phone", handler.loginPhone())
+	r.Post("/auth/login/phone/verify", handler.loginPhoneVerify())
+	r.Post("/auth/magic-link", handler.magicLink())
+	r.Post("/auth/magic-link/consume", handler.consumeMagicLink())
+	r.Get("/auth/oidc/{provider}", handler.oidcAuthorize())
+	r.Post("/auth/oidc/{provider}/callback", handler.oidcCallback())
 	r.Post("/auth/signup", handler.signup())
 	r.Post("/auth/refresh-token", handler.refreshToken())
 
 	r.Group(func(r chi.Router) {
-		r.Use(middleware.Auth(config.Jwt.Secret))
-		r.Get("/auth/logout", handler.logout())
+		r.Use(auth)
+		r.With(middleware.NoAPIKey).Get("/auth/logout", handler.logout())
 	})
 }
 
@@ -59,62 +86,479 @@ func (a *AuthHandler) login() http.HandlerFunc {
 		}
 
 		ctx := r.Context()
+		ip := request.ClientIP(r)
+
+		// failed attempts of the email or the ip address delay next ones, the attempt is counted
+		// before the password is checked so parallel logins can not pass together
+		retryAfter, err := a.loginAttemptUsecase.Reserve(ctx, loginRequest.Email, ip)
+		if err != nil {
+			a.logger.Error("auth login reserve attempt", zap.Error(err))
+			response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		if retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			response.Error(w, r, errors.ErrTooManyLoginAttempts, http.StatusTooManyRequests)
+			return
+		}
+
 		// find user by email
 		user, err := a.userUsecase.FindByEmail(ctx, loginRequest.Email)
 		if err != nil {
 			a.logger.Error("auth login find by email", zap.Error(err))
+			a.loginFailed(r, loginRequest.Email, ip)
 			response.Error(w, r, errors.ErrInvalidEmailOrPassword, http.StatusUnauthorized)
 			return
 		}
 
 		// check password
-		if hash.CheckPasswordHash(loginRequest.Password, user.Password) == false {
+		passwordValid, err := a.userUsecase.CheckPassword(ctx, user, loginRequest.Password)
+		if err != nil {
+			a.logger.Error("auth login rehash password", zap.Error(err))
+		}
+
+		if passwordValid == false {
+			a.loginFailed(r, loginRequest.Email, ip)
 			response.Error(w, r, errors.ErrInvalidEmailOrPassword, http.StatusUnauthorized)
 			return
 		}
 
-		// generate token
-		access_token, refresh_token, err := token.GenerateToken(a.config.Jwt.Secret, a.config.Jwt.AccessTTL, a.config.Jwt.RefreshTTL, user.ID)
+		if err := a.loginAttemptUsecase.Succeed(ctx, loginRequest.Email, ip); err != nil {
+			a.logger.Error("auth login reset attempts", zap.Error(err))
+		}
+
+		a.completeLogin(w, r, user, loginRequest.DeviceName)
+	}
+}
+
+// complete login of the authenticated user, with two-factor authentication the mfa challenge is responded instead of tokens
+func (a *AuthHandler) completeLogin(w http.ResponseWriter, r *http.Request, user *entity.User, deviceName string) {
+	if user.IsDisabled() {
+		response.Error(w, r, errors.ErrAccountDisabled, http.StatusForbidden)
+		return
+	}
+
+	// email must be verified before first login
+	if user.Status == entity.USER_STATUS_PENDING {
+		response.Error(w, r, errors.ErrEmailNotVerified, http.StatusForbidden)
+		return
+	}
+
+	// with two-factor authentication tokens are issued by login mfa
+	mfaEnabled, err := a.mfaUsecase.IsEnabled(r.Context(), user.ID)
+	if err != nil {
+		a.logger.Error("auth login mfa is enabled", zap.Error(err))
+		response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	if mfaEnabled {
+		challenge, err := token.GenerateMFAChallenge(a.keys, a.config.MFA.ChallengeTTL, user.ID)
 		if err != nil {
-			a.logger.Error("auth login generate token", zap.Error(err))
+			a.logger.Error("auth login generate mfa challenge", zap.Error(err))
 			response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
 			return
 		}
 
-		// create refresh token
-		if err = a.refreshTokenUsecase.Store(ctx, &entity.RefreshToken{
-			UserID: user.ID,
-			Token:  refresh_token,
-		}); err != nil {
-			a.logger.Error("auth login refresh token store", zap.Error(err))
-			response.Error(w, r, errors.ErrInternalServerError, response.GetStatusCodeErr(err))
+		response.Json(w, r, 200, map[string]interface{}{
+			"status":       "success",
+			"mfa_required": true,
+			"challenge":    challenge,
+		})
+		return
+	}
+
+	a.respondWithTokens(w, r, user, deviceName)
+}
+
+// login failed locks logins after the reserved failure, the login is answered anyway so errors are only logged
+func (a *AuthHandler) loginFailed(r *http.Request, email, ip string) {
+	lockedOut, err := a.loginAttemptUsecase.Fail(r.Context(), email, ip)
+	if err != nil {
+		a.logger.Error("auth login count failed attempt", zap.Error(err))
+		return
+	}
+
+	if lockedOut {
+		a.logger.Warn("security event: login locked out",
+			zap.String("email", email),
+			zap.String("remote_addr", ip),
+			zap.String("request_id", middleware.GetReqID(r.Context())),
+		)
+	}
+}
+
+// login phone sends a one-time code to the verified phone, the response does not tell whether the phone is registered
+func (a *AuthHandler) loginPhone() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var loginPhoneRequest LoginPhoneRequest
+		if err := request.DecodeJson(r, &loginPhoneRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
 			return
 		}
 
-		userInfo := User{
-			ID:        user.ID,
-			Email:     user.Email,
-			Phone:     user.Phone,
-			Gender:    user.Gender,
-			FirstName: user.FirstName,
-			LastName:  user.LastName,
-			BirthDate: user.BirthDate,
-			CreatedAt: user.CreatedAt,
-			UpdatedAt: user.UpdatedAt,
+		if err := validation.Validator(&loginPhoneRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		ctx := r.Context()
+		user, err := a.userUsecase.FindByPhone(ctx, loginPhoneRequest.Phone)
+		if err != nil {
+			if _, ok := err.(*errors.ErrValidation); ok {
+				response.Error(w, r, err, response.GetStatusCodeErr(err))
+				return
+			}
+		} else if !user.IsDisabled() {
+			// the resend interval is not reported either, otherwise it would reveal registered phones
+			if err := a.phoneOTPUsecase.Send(ctx, user.Phone, entity.PHONE_OTP_PURPOSE_LOGIN); err != nil {
+				if err == errors.ErrTooManyRequests {
+					a.logger.Warn("auth login phone rate limited", zap.String("user_id", user.ID))
+				} else {
+					a.logger.Error("auth login phone send code", zap.Error(err))
+					response.Error(w, r, err, response.GetStatusCodeErr(err))
+					return
+				}
+			}
 		}
 
 		response.Json(w, r, 200, map[string]interface{}{
 			"status": "success",
-			"data":   userInfo,
-			"token": map[string]string{
-				"type":    "Bearer",
-				"access":  access_token,
-				"refresh": refresh_token,
+		})
+	}
+}
+
+// login phone verify exchanges the phone and the one-time code for the login response
+func (a *AuthHandler) loginPhoneVerify() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var verifyRequest LoginPhoneVerifyRequest
+		if err := request.DecodeJson(r, &verifyRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := validation.Validator(&verifyRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		ctx := r.Context()
+		user, err := a.userUsecase.FindByPhone(ctx, verifyRequest.Phone)
+		if err != nil {
+			if _, ok := err.(*errors.ErrValidation); ok {
+				response.Error(w, r, err, response.GetStatusCodeErr(err))
+				return
+			}
+			a.logger.Error("auth login phone verify find user", zap.Error(err))
+			response.Error(w, r, errors.ErrInvalidOTPCode, http.StatusUnauthorized)
+			return
+		}
+
+		if err := a.phoneOTPUsecase.Verify(ctx, user.Phone, entity.PHONE_OTP_PURPOSE_LOGIN, verifyRequest.Code); err != nil {
+			if err == errors.ErrInvalidOTPCode {
+				response.Error(w, r, errors.ErrInvalidOTPCode, http.StatusUnauthorized)
+				return
+			}
+			if err == errors.ErrTooManyRequests {
+				response.Error(w, r, err, http.StatusTooManyRequests)
+				return
+			}
+			a.logger.Error("auth login phone verify", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		a.completeLogin(w, r, user, verifyRequest.DeviceName)
+	}
+}
+
+// magic link mails a login link, the response does not tell whether the email is registered
+func (a *AuthHandler) magicLink() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var magicLinkRequest MagicLinkRequest
+		if err := request.DecodeJson(r, &magicLinkRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := validation.Validator(&magicLinkRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		ctx := r.Context()
+		if user, err := a.userUsecase.FindByEmail(ctx, magicLinkRequest.Email); err == nil && !user.IsDisabled() {
+			// the limit is not reported either, otherwise it would reveal registered emails
+			if err := a.magicLinkUsecase.Send(ctx, user); err != nil {
+				if err == errors.ErrTooManyRequests {
+					a.logger.Warn("auth magic link rate limited", zap.String("user_id", user.ID))
+				} else {
+					a.logger.Error("auth magic link send", zap.Error(err))
+					response.Error(w, r, err, response.GetStatusCodeErr(err))
+					return
+				}
+			}
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+		})
+	}
+}
+
+// consume magic link exchanges the link token for the login response
+func (a *AuthHandler) consumeMagicLink() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var consumeRequest ConsumeMagicLinkRequest
+		if err := request.DecodeJson(r, &consumeRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := validation.Validator(&consumeRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		ctx := r.Context()
+		userID, err := a.magicLinkUsecase.Consume(ctx, consumeRequest.Token)
+		if err != nil {
+			a.logger.Error("auth magic link consume", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		user, err := a.userUsecase.Find(ctx, userID)
+		if err != nil {
+			a.logger.Error("auth magic link find user", zap.Error(err))
+			response.Error(w, r, errors.ErrUnauthorized, http.StatusUnauthorized)
+			return
+		}
+
+		a.completeLogin(w, r, user, consumeRequest.DeviceName)
+	}
+}
+
+// the browser which starts the oidc flow keeps its binding in the cookie, the callback is accepted from that browser only
+const oidcBindingCookie = "oidc_binding"
+
+// oidc authorize responds with the url of the provider the user has to be redirected to
+func (a *AuthHandler) oidcAuthorize() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authURL, binding, err := a.oidcUsecase.AuthorizationURL(r.Context(), chi.URLParam(r, "provider"))
+		if err != nil {
+			a.logger.Error("auth oidc authorization url", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     oidcBindingCookie,
+			Value:    binding,
+			Path:     "/auth/oidc",
+			HttpOnly: true,
+			Secure:   true,
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+			"data": map[string]string{
+				"authorization_url": authURL,
 			},
 		})
 	}
 }
 
+// oidc callback exchanges the code and the state the provider redirected with for the login response
+func (a *AuthHandler) oidcCallback() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var callbackRequest OIDCCallbackRequest
+		if err := request.DecodeJson(r, &callbackRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := validation.Validator(&callbackRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		binding, err := r.Cookie(oidcBindingCookie)
+		if err != nil {
+			response.Error(w, r, errors.ErrInvalidOrExpiredToken, http.StatusBadRequest)
+			return
+		}
+
+		// the binding is single use like the state
+		http.SetCookie(w, &http.Cookie{
+			Name:     oidcBindingCookie,
+			Path:     "/auth/oidc",
+			MaxAge:   -1,
+			HttpOnly: true,
+			Secure:   true,
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		user, err := a.oidcUsecase.Authenticate(r.Context(), chi.URLParam(r, "provider"), callbackRequest.Code, callbackRequest.State, binding.Value)
+		if err != nil {
+			a.logger.Error("auth oidc authenticate", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		a.completeLogin(w, r, user, callbackRequest.DeviceName)
+	}
+}
+
+// login mfa exchanges mfa challenge and code for tokens
+func (a *AuthHandler) loginMFA() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var loginMFARequest LoginMFARequest
+		if err := request.DecodeJson(r, &loginMFARequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := validation.Validator(&loginMFARequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		challenge, err := token.ParseMFAChallenge(loginMFARequest.Challenge, a.keys)
+		if err != nil {
+			response.Error(w, r, errors.ErrInvalidOrExpiredToken, http.StatusUnauthorized)
+			return
+		}
+
+		ctx := r.Context()
+		user, err := a.userUsecase.Find(ctx, challenge.UserID)
+		if err != nil {
+			a.logger.Error("auth login mfa find user", zap.Error(err))
+			response.Error(w, r, errors.ErrUnauthorized, http.StatusUnauthorized)
+			return
+		}
+
+		if user.IsDisabled() {
+			response.Error(w, r, errors.ErrAccountDisabled, http.StatusForbidden)
+			return
+		}
+
+		// wrong codes are counted like wrong passwords of the user and the ip address
+		ip := request.ClientIP(r)
+		retryAfter, err := a.loginAttemptUsecase.Reserve(ctx, user.Email, ip)
+		if err != nil {
+			a.logger.Error("auth login mfa reserve attempt", zap.Error(err))
+			response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		if retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			response.Error(w, r, errors.ErrTooManyLoginAttempts, http.StatusTooManyRequests)
+			return
+		}
+
+		// the challenge is single use and consumed before the code is checked, so a replayed or parallel login
+		// neither burns a recovery code nor the time step of the code
+		consumed, err := a.revocationUsecase.Consume(ctx, challenge.ID, challenge.ExpiresAt)
+		if err != nil {
+			a.logger.Error("auth login mfa consume challenge", zap.Error(err))
+			a.loginFailed(r, user.Email, ip)
+			response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		if !consumed {
+			a.loginFailed(r, user.Email, ip)
+			response.Error(w, r, errors.ErrInvalidOrExpiredToken, http.StatusUnauthorized)
+			return
+		}
+
+		if err := a.mfaUsecase.Verify(ctx, user.ID, loginMFARequest.Code); err != nil {
+			a.logger.Error("auth login mfa verify", zap.Error(err))
+			a.loginFailed(r, user.Email, ip)
+			if stderrors.Is(err, errors.ErrInvalidMFACode) {
+				response.Error(w, r, errors.ErrInvalidMFACode, http.StatusUnauthorized)
+				return
+			}
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := a.loginAttemptUsecase.Succeed(ctx, user.Email, ip); err != nil {
+			a.logger.Error("auth login mfa reset attempts", zap.Error(err))
+		}
+
+		a.respondWithTokens(w, r, user, loginMFARequest.DeviceName)
+	}
+}
+
+// respond with tokens starts a new session, generates access and refresh tokens for the user and writes login response
+func (a *AuthHandler) respondWithTokens(w http.ResponseWriter, r *http.Request, user *entity.User, deviceName string) {
+	session := entity.Session{
+		UserID:     user.ID,
+		UserAgent:  r.UserAgent(),
+		IP:         request.ClientIP(r),
+		DeviceName: deviceName,
+	}
+
+	if err := a.sessionUsecase.Store(r.Context(), &session); err != nil {
+		a.logger.Error("auth login session store", zap.Error(err))
+		response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	// generate token
+	access_token, refresh_token, err := token.GenerateToken(a.keys, a.config.Jwt.AccessTTL, a.config.Jwt.RefreshTTL, user, session.ID)
+	if err != nil {
+		a.logger.Error("auth login generate token", zap.Error(err))
+		response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	// create refresh token
+	if err = a.refreshTokenUsecase.Store(r.Context(), &entity.RefreshToken{
+		UserID:   user.ID,
+		Token:    refresh_token,
+		FamilyID: session.ID,
+	}); err != nil {
+		a.logger.Error("auth login refresh token store", zap.Error(err))
+		response.Error(w, r, errors.ErrInternalServerError, response.GetStatusCodeErr(err))
+		return
+	}
+
+	userInfo := User{
+		ID:        user.ID,
+		Email:     user.Email,
+		Phone:     user.Phone,
+		Gender:    user.Gender,
+		Role:      user.Role,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		BirthDate: user.BirthDate,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+
+	response.Json(w, r, 200, map[string]interface{}{
+		"status": "success",
+		"data":   userInfo,
+		"token": map[string]string{
+			"type":    "Bearer",
+			"access":  access_token,
+			"refresh": refresh_token,
+		},
+	})
+}
+
 // signup
 func (a *AuthHandler) signup() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -139,7 +583,7 @@ func (a *AuthHandler) signup() http.HandlerFunc {
 
 		ctx := r.Context()
 		user := entity.User{
-			Status:    entity.USER_STATUS_ACTIVE,
+			Status:    entity.USER_STATUS_PENDING,
 			Email:     signupRequest.Email,
 			Phone:     signupRequest.Phone,
 			Gender:    signupRequest.Gender,
@@ -155,11 +599,17 @@ func (a *AuthHandler) signup() http.HandlerFunc {
 			return
 		}
 
+		// the user is stored already, so a failed delivery is only logged, verification can be resent
+		if err := a.emailVerificationUsecase.Send(ctx, &user); err != nil {
+			a.logger.Error("auth signup send email verification", zap.Error(err))
+		}
+
 		userInfo := User{
 			ID:        user.ID,
 			Email:     user.Email,
 			Phone:     user.Phone,
 			Gender:    user.Gender,
+			Status:    user.Status,
 			BirthDate: user.BirthDate,
 			FirstName: user.FirstName,
 			LastName:  user.LastName,
@@ -174,22 +624,54 @@ func (a *AuthHandler) signup() http.HandlerFunc {
 	}
 }
 
-// logout
+// logout revokes the current session and access token, with "all=true" every session and access token of the user
 func (a *AuthHandler) logout() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		user, ok := r.Context().Value("user").(*entity.User)
+		user, ok := middleware.GetAuthUser(r.Context())
 		if !ok {
 			response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
 			return
 		}
 
 		ctx := r.Context()
-		if err := a.refreshTokenUsecase.DeleteByUserId(ctx, user.ID); err != nil {
-			a.logger.Error("auth logout delete refresh token", zap.Error(err))
-			response.Error(w, r, err, response.GetStatusCodeErr(err))
+		accessToken, ok := middleware.GetAccessToken(ctx)
+		if !ok {
+			response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
 			return
 		}
 
+		// impersonation ends with the token, sessions of the user are kept
+		if accessToken.ActorID != "" {
+			if err := a.revocationUsecase.Revoke(ctx, accessToken.ID, accessToken.ExpiresAt); err != nil {
+				a.logger.Error("auth logout revoke impersonation token", zap.Error(err))
+				response.Error(w, r, err, response.GetStatusCodeErr(err))
+				return
+			}
+		} else if accessToken.SessionID == "" || accessToken.ID == "" || r.URL.Query().Get("all") == "true" {
+			// tokens issued before sessions were introduced can not tell their session and can be revoked only with the user
+			if err := a.sessionUsecase.DeleteByUserId(ctx, user.ID); err != nil {
+				a.logger.Error("auth logout delete sessions", zap.Error(err))
+				response.Error(w, r, err, response.GetStatusCodeErr(err))
+				return
+			}
+			if err := a.revocationUsecase.RevokeUser(ctx, user.ID); err != nil {
+				a.logger.Error("auth logout revoke user tokens", zap.Error(err))
+				response.Error(w, r, err, response.GetStatusCodeErr(err))
+				return
+			}
+		} else {
+			if err := a.sessionUsecase.Delete(ctx, user.ID, accessToken.SessionID); err != nil {
+				a.logger.Error("auth logout delete session", zap.Error(err))
+				response.Error(w, r, err, response.GetStatusCodeErr(err))
+				return
+			}
+			if err := a.revocationUsecase.Revoke(ctx, accessToken.ID, accessToken.ExpiresAt); err != nil {
+				a.logger.Error("auth logout revoke token", zap.Error(err))
+				response.Error(w, r, err, response.GetStatusCodeErr(err))
+				return
+			}
+		}
+
 		response.Json(w, r, 200, map[string]interface{}{
 			"status": "success",
 		})
@@ -220,7 +702,7 @@ func (a *AuthHandler) refreshToken() http.HandlerFunc {
 			return
 		}
 
-		if _, err := token.ParseJwtToken(refreshToken.Token, a.config.Jwt.Secret); err != nil {
+		if _, err := token.ParseJwtToken(refreshToken.Token, a.keys); err != nil {
 			if err := a.refreshTokenUsecase.Delete(ctx, refreshToken.Token); err != nil {
 				a.logger.Error("auth refresh token delete", zap.Error(err))
 				response.Error(w, r, err, response.GetStatusCodeErr(err))
@@ -230,28 +712,49 @@ func (a *AuthHandler) refreshToken() http.HandlerFunc {
 			return
 		}
 
+		// find token owner for actual role and permissions
+		user, err := a.userUsecase.Find(ctx, refreshToken.UserID)
+		if err != nil {
+			a.logger.Error("auth refresh token find user", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if user.IsDisabled() {
+			response.Error(w, r, errors.ErrAccountDisabled, http.StatusForbidden)
+			return
+		}
+
 		// generate token
-		access_token, refresh_token, err := token.GenerateToken(a.config.Jwt.Secret, a.config.Jwt.AccessTTL, a.config.Jwt.RefreshTTL, refreshToken.UserID)
+		access_token, refresh_token, err := token.GenerateToken(a.keys, a.config.Jwt.AccessTTL, a.config.Jwt.RefreshTTL, user, refreshToken.FamilyID)
 		if err != nil {
 			a.logger.Error("auth refresh token generate", zap.Error(err))
 			response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
 			return
 		}
 
-		// create refresh token
-		if err = a.refreshTokenUsecase.Store(ctx, &entity.RefreshToken{
-			UserID: refreshToken.UserID,
-			Token:  refresh_token,
+		// rotate refresh token
+		if err = a.refreshTokenUsecase.Rotate(ctx, refreshToken, &entity.RefreshToken{
+			Token: refresh_token,
 		}); err != nil {
-			a.logger.Error("auth refresh token store", zap.Error(err))
+			if err == errors.ErrRefreshTokenReused {
+				a.logger.Warn("security event: refresh token reuse, token family revoked",
+					zap.String("user_id", refreshToken.UserID),
+					zap.String("family_id", refreshToken.FamilyID),
+					zap.String("remote_addr", request.ClientIP(r)),
+					zap.String("request_id", middleware.GetReqID(ctx)),
+				)
+				response.Error(w, r, err, http.StatusUnauthorized)
+				return
+			}
+			a.logger.Error("auth refresh token rotate", zap.Error(err))
 			response.Error(w, r, errors.ErrInternalServerError, response.GetStatusCodeErr(err))
 			return
 		}
 
-		if err := a.refreshTokenUsecase.Delete(ctx, refreshToken.Token); err != nil {
-			a.logger.Error("auth refresh token delete old token", zap.Error(err))
-			response.Error(w, r, err, response.GetStatusCodeErr(err))
-			return
+		// the tokens are issued already, failed last used time update is only logged
+		if err := a.sessionUsecase.Touch(ctx, refreshToken.FamilyID); err != nil {
+			a.logger.Error("auth refresh token session touch", zap.Error(err))
 		}
 
 		response.Json(w, r, 200, map[string]interface{}{
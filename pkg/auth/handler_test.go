@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/config"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity/mocks"
+	"github.com/Jamshid90/go-clean-architecture/pkg/loginattempt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/mfa"
+	"github.com/Jamshid90/go-clean-architecture/pkg/refreshtoken"
+	"github.com/Jamshid90/go-clean-architecture/pkg/revocation"
+	"github.com/Jamshid90/go-clean-architecture/pkg/token"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// test auth handler has the dependencies of the auth endpoints, revocations are kept in memory
+type testAuthHandler struct {
+	keys              *token.KeySet
+	userUsecase       *mocks.UserUsecase
+	sessionUsecase    *mocks.SessionUsecase
+	refreshTokenRepo  *mocks.RefreshTokenRepository
+	sessionRepo       *mocks.SessionRepository
+	mfaRepo           *mocks.MFARepository
+	revocationUsecase entity.TokenRevocationUsecase
+}
+
+func newTestAuthHandler(t *testing.T) *testAuthHandler {
+	t.Helper()
+
+	revocationUsecase := revocation.NewTokenRevocationUsecase(revocation.NewTokenRevocationRepositoryMemory(), time.Hour, time.Second*2)
+	return &testAuthHandler{
+		keys:              token.TestKeySet(t),
+		userUsecase:       new(mocks.UserUsecase),
+		sessionUsecase:    new(mocks.SessionUsecase),
+		refreshTokenRepo:  new(mocks.RefreshTokenRepository),
+		sessionRepo:       new(mocks.SessionRepository),
+		mfaRepo:           new(mocks.MFARepository),
+		revocationUsecase: &revocationUsecase,
+	}
+}
+
+// serve the request, with access token the request is authenticated like Auth middleware does
+func (h *testAuthHandler) serve(t *testing.T, accessToken *token.AccessToken, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.Jwt.AccessTTL = "1h"
+	cfg.Jwt.RefreshTTL = "24h"
+
+	refreshTokenUsecase := refreshtoken.NewRefreshTokenUsecase(h.refreshTokenRepo, h.sessionRepo, time.Hour*24, time.Second*2)
+	loginAttemptUsecase := loginattempt.NewLoginAttemptUsecase(loginattempt.NewLoginAttemptRepositoryMemory(), loginattempt.Policy{
+		MaxFailures:   5,
+		MaxIPFailures: 20,
+		BaseDelay:     time.Second,
+		Lockout:       time.Minute * 15,
+		Window:        time.Hour,
+	}, time.Second*2)
+
+	mfaUsecase := mfa.NewMFAUsecase(h.mfaRepo, "app", time.Second*2)
+
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), "user", &entity.User{ID: accessToken.User.ID})
+			ctx = context.WithValue(ctx, "access_token", accessToken)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+
+	r := chi.NewRouter()
+	NewAuthHandler(r, h.userUsecase, &refreshTokenUsecase, h.sessionUsecase, h.revocationUsecase, nil, &mfaUsecase, &loginAttemptUsecase, nil, nil, nil, h.keys, auth, cfg, zap.NewNop())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return w
+}
+
+func (h *testAuthHandler) assertExpectations(t *testing.T) {
+	h.userUsecase.AssertExpectations(t)
+	h.sessionUsecase.AssertExpectations(t)
+	h.refreshTokenRepo.AssertExpectations(t)
+	h.sessionRepo.AssertExpectations(t)
+}
+
+func (h *testAuthHandler) isRevoked(t *testing.T, accessToken *token.AccessToken) bool {
+	t.Helper()
+
+	revoked, err := h.revocationUsecase.IsRevoked(context.TODO(), accessToken.ID, accessToken.User.ID, accessToken.IssuedAt)
+	require.NoError(t, err)
+	return revoked
+}
+
+func testUser() *entity.User {
+	return &entity.User{
+		ID:     "123456789",
+		Email:  "user@info.com",
+		Status: entity.USER_STATUS_ACTIVE,
+		Role:   entity.USER_ROLE_USER,
+	}
+}
+
+func testAccessToken(user *entity.User) *token.AccessToken {
+	now := time.Now().UTC()
+	return &token.AccessToken{
+		ID:        "jti",
+		User:      &entity.User{ID: user.ID},
+		SessionID: "session",
+		IssuedAt:  now.Add(-time.Minute),
+		ExpiresAt: now.Add(time.Hour),
+	}
+}
+
+func TestLogout(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		h := newTestAuthHandler(t)
+		accessToken := testAccessToken(testUser())
+		h.sessionUsecase.On("Delete", mock.Anything, accessToken.User.ID, "session").Return(nil).Once()
+
+		w := h.serve(t, accessToken, http.MethodGet, "/auth/logout", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, h.isRevoked(t, accessToken))
+		// other sessions of the user are kept
+		assert.False(t, h.isRevoked(t, &token.AccessToken{ID: "other", User: accessToken.User, IssuedAt: accessToken.IssuedAt}))
+		h.sessionUsecase.AssertNotCalled(t, "DeleteByUserId", mock.Anything, mock.Anything)
+		h.assertExpectations(t)
+	})
+
+	t.Run("success-all", func(t *testing.T) {
+		h := newTestAuthHandler(t)
+		accessToken := testAccessToken(testUser())
+		h.sessionUsecase.On("DeleteByUserId", mock.Anything, accessToken.User.ID).Return(nil).Once()
+
+		w := h.serve(t, accessToken, http.MethodGet, "/auth/logout?all=true", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		// every token of the user issued before is revoked
+		assert.True(t, h.isRevoked(t, &token.AccessToken{ID: "other", User: accessToken.User, IssuedAt: accessToken.IssuedAt}))
+		h.sessionUsecase.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+		h.assertExpectations(t)
+	})
+
+	t.Run("success-impersonation", func(t *testing.T) {
+		h := newTestAuthHandler(t)
+		accessToken := testAccessToken(testUser())
+		accessToken.SessionID = ""
+		accessToken.ActorID = "admin"
+
+		w := h.serve(t, accessToken, http.MethodGet, "/auth/logout?all=true", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, h.isRevoked(t, accessToken))
+		// sessions and tokens of the user are kept
+		assert.False(t, h.isRevoked(t, &token.AccessToken{ID: "other", User: accessToken.User, IssuedAt: accessToken.IssuedAt}))
+		h.sessionUsecase.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+		h.sessionUsecase.AssertNotCalled(t, "DeleteByUserId", mock.Anything, mock.Anything)
+	})
+}
+
+func TestRefreshToken(t *testing.T) {
+	t.Run("error-reused", func(t *testing.T) {
+		h := newTestAuthHandler(t)
+		user := testUser()
+		_, refresh, err := token.GenerateToken(h.keys, "1h", "24h", user, "session")
+		require.NoError(t, err)
+
+		h.refreshTokenRepo.On("Find", mock.Anything, refresh).Return(&entity.RefreshToken{UserID: user.ID, Token: refresh, FamilyID: "session"}, nil).Once()
+		h.userUsecase.On("Find", mock.Anything, user.ID).Return(user, nil).Once()
+		h.refreshTokenRepo.On("Rotate", mock.Anything, refresh, mock.AnythingOfType("*entity.RefreshToken"), mock.AnythingOfType("time.Time")).Return(false, nil).Once()
+		h.refreshTokenRepo.On("DeleteByFamily", mock.Anything, "session").Return(nil).Once()
+		h.sessionRepo.On("Delete", mock.Anything, "session").Return(nil).Once()
+
+		w := h.serve(t, nil, http.MethodPost, "/auth/refresh-token", `{"token":"`+refresh+`"}`)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.NotContains(t, w.Body.String(), `"access"`)
+		h.refreshTokenRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+		h.sessionUsecase.AssertNotCalled(t, "Touch", mock.Anything, mock.Anything)
+		h.assertExpectations(t)
+	})
+}
+
+func TestLogin(t *testing.T) {
+	const body = `{"email":"user@info.com","password":"password"}`
+
+	for name, status := range map[string]string{
+		"error-disabled": entity.USER_STATUS_SUSPENDED,
+		"error-pending":  entity.USER_STATUS_PENDING,
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := newTestAuthHandler(t)
+			user := testUser()
+			user.Status = status
+			h.userUsecase.On("FindByEmail", mock.Anything, user.Email).Return(user, nil).Once()
+			h.userUsecase.On("CheckPassword", mock.Anything, user, "password").Return(true, nil).Once()
+
+			w := h.serve(t, nil, http.MethodPost, "/auth/login", body)
+
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			// no session is started and no tokens are issued
+			h.sessionUsecase.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+			h.refreshTokenRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+			assert.NotContains(t, w.Body.String(), `"access"`)
+			h.assertExpectations(t)
+		})
+	}
+}
+
+func TestLoginMFA(t *testing.T) {
+	t.Run("error-replayed-challenge", func(t *testing.T) {
+		h := newTestAuthHandler(t)
+		user := testUser()
+		challenge, err := token.GenerateMFAChallenge(h.keys, "5m", user.ID)
+		require.NoError(t, err)
+		parsed, err := token.ParseMFAChallenge(challenge, h.keys)
+		require.NoError(t, err)
+
+		// the challenge was used by a parallel login already
+		consumed, err := h.revocationUsecase.Consume(context.TODO(), parsed.ID, parsed.ExpiresAt)
+		require.NoError(t, err)
+		require.True(t, consumed)
+
+		h.userUsecase.On("Find", mock.Anything, user.ID).Return(user, nil).Once()
+
+		w := h.serve(t, nil, http.MethodPost, "/auth/login/mfa", `{"challenge":"`+challenge+`","code":"recovery-code"}`)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		// the code is not checked, so the recovery code is not used up
+		h.mfaRepo.AssertNotCalled(t, "Find", mock.Anything, mock.Anything)
+		h.mfaRepo.AssertNotCalled(t, "UseRecoveryCode", mock.Anything, mock.Anything, mock.Anything)
+		h.sessionUsecase.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+		h.assertExpectations(t)
+	})
+}
+
+func TestOIDCCallback(t *testing.T) {
+	t.Run("error-no-binding", func(t *testing.T) {
+		h := newTestAuthHandler(t)
+
+		// the callback is posted by a browser which did not start the flow
+		w := h.serve(t, nil, http.MethodPost, "/auth/oidc/fake/callback", `{"code":"code","state":"state"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		h.sessionUsecase.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+		assert.NotContains(t, w.Body.String(), `"access"`)
+	})
+}
@@ -1,8 +1,40 @@
 package auth
 
 type LoginRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=8"`
+	Email      string `json:"email" validate:"required,email"`
+	Password   string `json:"password" validate:"required"`
+	DeviceName string `json:"device_name" validate:"omitempty,max=100"`
+}
+
+type LoginMFARequest struct {
+	Challenge  string `json:"challenge" validate:"required"`
+	Code       string `json:"code" validate:"required"`
+	DeviceName string `json:"device_name" validate:"omitempty,max=100"`
+}
+
+type LoginPhoneRequest struct {
+	Phone string `json:"phone" validate:"required"`
+}
+
+type LoginPhoneVerifyRequest struct {
+	Phone      string `json:"phone" validate:"required"`
+	Code       string `json:"code" validate:"required,numeric,len=6"`
+	DeviceName string `json:"device_name" validate:"omitempty,max=100"`
+}
+
+type MagicLinkRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ConsumeMagicLinkRequest struct {
+	Token      string `json:"token" validate:"required"`
+	DeviceName string `json:"device_name" validate:"omitempty,max=100"`
+}
+
+type OIDCCallbackRequest struct {
+	Code       string `json:"code" validate:"required"`
+	State      string `json:"state" validate:"required"`
+	DeviceName string `json:"device_name" validate:"omitempty,max=100"`
 }
 
 type SignupRequest struct {
@@ -12,8 +44,8 @@ type SignupRequest struct {
 	FirstName       string `json:"first_name" validate:"required,min=2,max=50"`
 	LastName        string `json:"last_name" validate:"required,min=2,max=50"`
 	BirthDate       string `json:"birth_date" validate:"required,datetime=2006-01-02"`
-	Password        string `json:"password" validate:"required,min=8"`
-	ConfirmPassword string `json:"confirm_password" validate:"required,min=8,eqfield=Password"`
+	Password        string `json:"password" validate:"required"`
+	ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=Password"`
 }
 
 type RefreshTokenRequest struct {
@@ -7,6 +7,7 @@ type User struct {
 	Phone     string    `json:"phone,omitempty"`
 	Gender    string    `json:"gender,omitempty"`
 	Status    string    `json:"status,omitempty"`
+	Role      string    `json:"role,omitempty"`
 	Email     string    `json:"email,omitempty"`
 	FirstName string    `json:"first_name,omitempty"`
 	LastName  string    `json:"last_name,omitempty"`
@@ -29,7 +29,113 @@ type Config struct {
 		Secret     string `toml:"secret"`
 		AccessTTL  string `toml:"access_ttl"`
 		RefreshTTL string `toml:"refresh_ttl"`
+		// ImpersonationTTL is the lifetime of access tokens admins get to act as a user
+		ImpersonationTTL string `toml:"impersonation_ttl"`
+		SigningKey       string `toml:"signing_key"`
+		// LegacySecretUntil is the RFC 3339 time after which the secret verifies no tokens, it is required
+		// when the secret is kept with keys and should be at least refresh ttl after the keys were configured
+		LegacySecretUntil string `toml:"legacy_secret_until"`
+		Keys              []struct {
+			ID         string `toml:"id"`
+			Algorithm  string `toml:"algorithm"`
+			PrivateKey string `toml:"private_key"`
+			PublicKey  string `toml:"public_key"`
+		} `toml:"keys"`
 	} `toml:"jwt"`
+	Mailer struct {
+		Driver   string `toml:"driver"`
+		Host     string `toml:"host"`
+		Port     string `toml:"port"`
+		Username string `toml:"username"`
+		Password string `toml:"password"`
+		From     string `toml:"from"`
+		Dir      string `toml:"dir"`
+	} `toml:"mailer"`
+	SMS struct {
+		Driver        string `toml:"driver"`
+		Dir           string `toml:"dir"`
+		WebhookURL    string `toml:"webhook_url"`
+		WebhookSecret string `toml:"webhook_secret"`
+		Timeout       string `toml:"timeout"`
+	} `toml:"sms"`
+	PhoneOTP struct {
+		// Secret is the hmac key of stored codes, it is required
+		Secret         string `toml:"secret"`
+		TTL            string `toml:"ttl"`
+		MaxAttempts    int    `toml:"max_attempts"`
+		MaxSends       int    `toml:"max_sends"`
+		ResendInterval string `toml:"resend_interval"`
+	} `toml:"phone_otp"`
+	EmailVerification struct {
+		TTL string `toml:"ttl"`
+		URL string `toml:"url"`
+	} `toml:"email_verification"`
+	MFA struct {
+		Issuer       string `toml:"issuer"`
+		ChallengeTTL string `toml:"challenge_ttl"`
+	} `toml:"mfa"`
+	PasswordReset struct {
+		TTL           string `toml:"ttl"`
+		URL           string `toml:"url"`
+		MaxRequests   int    `toml:"max_requests"`
+		MaxIPRequests int    `toml:"max_ip_requests"`
+	} `toml:"password_reset"`
+	MagicLink struct {
+		TTL         string `toml:"ttl"`
+		URL         string `toml:"url"`
+		MaxRequests int    `toml:"max_requests"`
+		Window      string `toml:"window"`
+	} `toml:"magic_link"`
+	OIDC struct {
+		StateTTL  string `toml:"state_ttl"`
+		Providers []struct {
+			Name         string   `toml:"name"`
+			Issuer       string   `toml:"issuer"`
+			ClientID     string   `toml:"client_id"`
+			ClientSecret string   `toml:"client_secret"`
+			RedirectURL  string   `toml:"redirect_url"`
+			Scopes       []string `toml:"scopes"`
+		} `toml:"providers"`
+	} `toml:"oidc"`
+	OAuth struct {
+		Issuer     string `toml:"issuer"`
+		LoginURL   string `toml:"login_url"`
+		CodeTTL    string `toml:"code_ttl"`
+		AccessTTL  string `toml:"access_ttl"`
+		RefreshTTL string `toml:"refresh_ttl"`
+	} `toml:"oauth"`
+	Revocation struct {
+		Driver string `toml:"driver"`
+	} `toml:"revocation"`
+	Password struct {
+		Algorithm         string `toml:"algorithm"`
+		BcryptCost        int    `toml:"bcrypt_cost"`
+		Argon2Memory      uint32 `toml:"argon2_memory"`
+		Argon2Iterations  uint32 `toml:"argon2_iterations"`
+		Argon2Parallelism uint8  `toml:"argon2_parallelism"`
+	} `toml:"password"`
+	LoginAttempt struct {
+		Driver        string `toml:"driver"`
+		MaxFailures   int    `toml:"max_failures"`
+		MaxIPFailures int    `toml:"max_ip_failures"`
+		BaseDelay     string `toml:"base_delay"`
+		Lockout       string `toml:"lockout"`
+		Window        string `toml:"window"`
+	} `toml:"login_attempt"`
+	Pagination struct {
+		// CursorSecret signs pagination cursors, it is required
+		CursorSecret string `toml:"cursor_secret"`
+	} `toml:"pagination"`
+	PasswordPolicy struct {
+		MinLength      int    `toml:"min_length"`
+		MaxLength      int    `toml:"max_length"`
+		RequireUpper   bool   `toml:"require_upper"`
+		RequireLower   bool   `toml:"require_lower"`
+		RequireDigit   bool   `toml:"require_digit"`
+		RequireSymbol  bool   `toml:"require_symbol"`
+		ForbidPersonal bool   `toml:"forbid_personal"`
+		BreachedFile   string `toml:"breached_file"`
+	} `toml:"password_policy"`
 }
 
 func NewConfig(filePath string) (*Config, error) {
@@ -0,0 +1,51 @@
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Encode returns opaque cursor of the position, the json of the position is signed by hmac-sha256
+// so clients can not forge positions
+func Encode(secret []byte, position interface{}) (string, error) {
+	payload, err := json.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(sign(secret, payload)), nil
+}
+
+// Decode verifies the cursor and decodes its position, forged and malformed cursors are ErrInvalidCursor
+func Decode(secret []byte, cursor string, position interface{}) error {
+	parts := strings.Split(cursor, ".")
+	if len(parts) != 2 {
+		return ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return ErrInvalidCursor
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, sign(secret, payload)) {
+		return ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(payload, position); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+func sign(secret, payload []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
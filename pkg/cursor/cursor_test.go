@@ -0,0 +1,47 @@
+package cursor
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+type position struct {
+	ID string `json:"id"`
+}
+
+func TestCursor(t *testing.T) {
+	secret := []byte("secret")
+
+	t.Run("success", func(t *testing.T) {
+		cursor, err := Encode(secret, position{ID: "123"})
+		require.NoError(t, err)
+
+		var p position
+		assert.NoError(t, Decode(secret, cursor, &p))
+		assert.Equal(t, "123", p.ID)
+	})
+
+	t.Run("error-forged", func(t *testing.T) {
+		cursor, err := Encode([]byte("other"), position{ID: "123"})
+		require.NoError(t, err)
+
+		var p position
+		assert.Equal(t, ErrInvalidCursor, Decode(secret, cursor, &p))
+	})
+
+	t.Run("error-tampered", func(t *testing.T) {
+		cursor, err := Encode(secret, position{ID: "123"})
+		require.NoError(t, err)
+
+		forged, err := Encode(secret, position{ID: "456"})
+		require.NoError(t, err)
+
+		tampered := strings.Split(forged, ".")[0] + "." + strings.Split(cursor, ".")[1]
+
+		var p position
+		assert.Equal(t, ErrInvalidCursor, Decode(secret, tampered, &p))
+		assert.Equal(t, ErrInvalidCursor, Decode(secret, "cursor", &p))
+	})
+}
@@ -0,0 +1,106 @@
+package emailverification
+
+import (
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/request"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/response"
+	"github.com/Jamshid90/go-clean-architecture/pkg/validation"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type EmailVerificationHandler struct {
+	logger                   *zap.Logger
+	userUsecase              entity.UserUsecase
+	emailVerificationUsecase entity.EmailVerificationUsecase
+}
+
+// New email verification handler
+func NewEmailVerificationHandler(r chi.Router, emailVerificationUsecase entity.EmailVerificationUsecase, userUsecase entity.UserUsecase, logger *zap.Logger) {
+	handler := EmailVerificationHandler{
+		logger:                   logger,
+		userUsecase:              userUsecase,
+		emailVerificationUsecase: emailVerificationUsecase,
+	}
+
+	r.Post("/auth/verify-email", handler.verify())
+	r.Post("/auth/verify-email/resend", handler.resend())
+}
+
+// verify
+func (e *EmailVerificationHandler) verify() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var verifyRequest VerifyEmailRequest
+		if err := request.DecodeJson(r, &verifyRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := validation.Validator(&verifyRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		ctx := r.Context()
+		userID, err := e.emailVerificationUsecase.Verify(ctx, verifyRequest.Token)
+		if err != nil {
+			e.logger.Error("email verification verify", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		user, err := e.userUsecase.Find(ctx, userID)
+		if err != nil {
+			e.logger.Error("email verification find user", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		// verification must not reactivate disabled users
+		if user.IsDisabled() {
+			response.Error(w, r, errors.ErrAccountDisabled, http.StatusForbidden)
+			return
+		}
+
+		if err := e.userUsecase.UpdateStatus(ctx, userID, entity.USER_STATUS_ACTIVE); err != nil {
+			e.logger.Error("email verification update user status", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+		})
+	}
+}
+
+// resend, the response does not tell whether the email is registered
+func (e *EmailVerificationHandler) resend() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var resendRequest ResendRequest
+		if err := request.DecodeJson(r, &resendRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := validation.Validator(&resendRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		// failures are only logged, otherwise the response would reveal registered emails
+		ctx := r.Context()
+		user, err := e.userUsecase.FindByEmail(ctx, resendRequest.Email)
+		if err == nil && user.Status == entity.USER_STATUS_PENDING {
+			if err := e.emailVerificationUsecase.Send(ctx, user); err != nil {
+				e.logger.Error("email verification resend", zap.Error(err))
+			}
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package emailverification
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ResendRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
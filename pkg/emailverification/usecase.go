@@ -0,0 +1,55 @@
+package emailverification
+
+import (
+	"context"
+	"fmt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/mailer"
+	"github.com/Jamshid90/go-clean-architecture/pkg/usertoken"
+	"time"
+)
+
+type emailVerificationUsecase struct {
+	tokenStore     usertoken.TokenStore
+	mailer         mailer.Mailer
+	ttl            time.Duration
+	url            string
+	contextTimeout time.Duration
+}
+
+// New email verification usecase, url is the link prefix the token is appended to
+func NewEmailVerificationUsecase(repo entity.UserTokenRepository, mailer mailer.Mailer, ttl time.Duration, url string, timeout time.Duration) emailVerificationUsecase {
+	return emailVerificationUsecase{
+		tokenStore:     usertoken.NewTokenStore(repo, ttl),
+		mailer:         mailer,
+		ttl:            ttl,
+		url:            url,
+		contextTimeout: timeout,
+	}
+}
+
+// Send replaces previous tokens of the user with a new one and mails it
+func (e *emailVerificationUsecase) Send(ctx context.Context, user *entity.User) error {
+	ctx, cancel := context.WithTimeout(ctx, e.contextTimeout)
+	defer cancel()
+
+	token, err := e.tokenStore.Issue(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return e.mailer.Send(ctx, &mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Hello %s,\n\nplease confirm your email address by following the link:\n%s%s\n\nThe link expires in %s.\n",
+			user.FirstName, e.url, token, e.ttl),
+	})
+}
+
+// Verify consumes the token and returns id of the user it was issued for
+func (e *emailVerificationUsecase) Verify(ctx context.Context, token string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.contextTimeout)
+	defer cancel()
+
+	return e.tokenStore.Consume(ctx, token)
+}
@@ -0,0 +1,73 @@
+package emailverification
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity/mocks"
+	apperrors "github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
+	"github.com/Jamshid90/go-clean-architecture/pkg/mailer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSend(t *testing.T) {
+	mockRepo := new(mocks.UserTokenRepository)
+	memoryMailer := mailer.NewMemoryMailer()
+	user := &entity.User{ID: "123456789", Email: "user@info.com", FirstName: "User"}
+
+	var stored *entity.UserToken
+	mockRepo.On("DeleteByUserId", mock.Anything, user.ID).Return(nil).Once()
+	mockRepo.On("Store", mock.Anything, mock.AnythingOfType("*entity.UserToken")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*entity.UserToken) }).
+		Return(nil).Once()
+
+	usecase := NewEmailVerificationUsecase(mockRepo, memoryMailer, time.Hour, "http://localhost/verify?token=", time.Second*2)
+	err := usecase.Send(context.TODO(), user)
+
+	assert := assert.New(t)
+	assert.NoError(err)
+	assert.NotNil(memoryMailer.Last())
+	assert.Equal(user.Email, memoryMailer.Last().To)
+
+	// only the digest of the mailed token is stored
+	body := memoryMailer.Last().Body
+	start := strings.Index(body, "token=") + len("token=")
+	token := body[start : start+strings.IndexByte(body[start:], '\n')]
+	assert.Equal(hash.HashToken(token), stored.Token)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestVerify(t *testing.T) {
+	mockRepo := new(mocks.UserTokenRepository)
+	usecase := NewEmailVerificationUsecase(mockRepo, mailer.NewMemoryMailer(), time.Hour, "", time.Second*2)
+
+	t.Run("success", func(t *testing.T) {
+		verificationToken := &entity.UserToken{
+			UserID:    "123456789",
+			Token:     hash.HashToken("token"),
+			ExpiresAt: time.Now().UTC().Add(time.Hour),
+		}
+		mockRepo.On("Consume", mock.Anything, hash.HashToken("token")).Return(verificationToken, nil).Once()
+
+		userID, err := usecase.Verify(context.TODO(), "token")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "123456789", userID)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-not-found", func(t *testing.T) {
+		mockRepo.On("Consume", mock.Anything, hash.HashToken("unknown")).Return(nil, apperrors.NewErrNotFound("email verification token")).Once()
+
+		_, err := usecase.Verify(context.TODO(), "unknown")
+
+		assert.Error(t, err)
+		assert.Equal(t, apperrors.ErrInvalidOrExpiredToken.Error(), err.Error())
+		mockRepo.AssertExpectations(t)
+	})
+}
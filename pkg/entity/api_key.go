@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"context"
+	"time"
+)
+
+// API_KEY_PREFIX starts every api key, so keys are recognized in headers and by secret scanners
+const API_KEY_PREFIX = "gca_"
+
+// APIKey is a long-lived credential of a user or a service account, only the hash of the key is stored
+// and the prefix is the visible start of the key. Requests authenticated with the key get only the
+// scopes of the key the owner still has.
+type APIKey struct {
+	ID         string
+	UserID     string
+	Name       string
+	Prefix     string
+	Key        string
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// is expired, keys without expiry never expire
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+type APIKeyUsecase interface {
+	Store(ctx context.Context, owner *User, key *APIKey) (string, error)
+	Authenticate(ctx context.Context, key string) (*APIKey, error)
+	FindByUserId(ctx context.Context, userID string) ([]*APIKey, error)
+	Delete(ctx context.Context, userID, id string) error
+	DeleteByUserId(ctx context.Context, userID string) error
+}
+
+type APIKeyRepository interface {
+	Store(ctx context.Context, key *APIKey) error
+	Find(ctx context.Context, id string) (*APIKey, error)
+	FindByKey(ctx context.Context, key string) (*APIKey, error)
+	FindByUserId(ctx context.Context, userID string) ([]*APIKey, error)
+	Touch(ctx context.Context, id string, lastUsedAt time.Time) error
+	Delete(ctx context.Context, id string) error
+	DeleteByUserId(ctx context.Context, userID string) error
+}
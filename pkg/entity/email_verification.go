@@ -0,0 +1,8 @@
+package entity
+
+import "context"
+
+type EmailVerificationUsecase interface {
+	Send(ctx context.Context, user *User) error
+	Verify(ctx context.Context, token string) (string, error)
+}
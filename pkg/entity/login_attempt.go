@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"context"
+	"time"
+)
+
+// LoginAttempt counts failed logins of an account or a client ip address, Key is "email:..." or "ip:..."
+type LoginAttempt struct {
+	Key         string
+	Failures    int
+	LockedUntil time.Time
+	UpdatedAt   time.Time
+}
+
+type LoginAttemptUsecase interface {
+	Reserve(ctx context.Context, email, ip string) (time.Duration, error)
+	Fail(ctx context.Context, email, ip string) (bool, error)
+	CheckKey(ctx context.Context, key string) (time.Duration, error)
+	ReserveKey(ctx context.Context, key string) (time.Duration, error)
+	FailKey(ctx context.Context, key string) (bool, error)
+	SucceedKey(ctx context.Context, key string) error
+	CountKey(ctx context.Context, key string, max int) (bool, error)
+	Succeed(ctx context.Context, email, ip string) error
+	Unlock(ctx context.Context, email string) error
+}
+
+type LoginAttemptRepository interface {
+	Find(ctx context.Context, key string) (*LoginAttempt, error)
+	Reserve(ctx context.Context, key string, now, resetBefore time.Time, maxFailures int) (*LoginAttempt, bool, error)
+	Release(ctx context.Context, key string) error
+	Lock(ctx context.Context, key string, until time.Time) error
+	Lockout(ctx context.Context, key string, until time.Time) error
+	Delete(ctx context.Context, key string) error
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
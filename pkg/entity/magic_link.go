@@ -0,0 +1,10 @@
+package entity
+
+import (
+	"context"
+)
+
+type MagicLinkUsecase interface {
+	Send(ctx context.Context, user *User) error
+	Consume(ctx context.Context, token string) (string, error)
+}
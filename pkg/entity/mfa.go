@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"context"
+	"time"
+)
+
+type MFA struct {
+	UserID       string
+	Secret       string
+	Enabled      bool
+	LastUsedStep int64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type MFAEnrollment struct {
+	Secret string
+	URI    string
+	QRCode []byte
+}
+
+type MFAUsecase interface {
+	Enroll(ctx context.Context, user *User) (*MFAEnrollment, error)
+	Confirm(ctx context.Context, userID, code string) ([]string, error)
+	Disable(ctx context.Context, userID, code string) error
+	IsEnabled(ctx context.Context, userID string) (bool, error)
+	Verify(ctx context.Context, userID, code string) error
+}
+
+type MFARepository interface {
+	Store(ctx context.Context, mfa *MFA) error
+	Update(ctx context.Context, mfa *MFA) error
+	UseStep(ctx context.Context, userID string, step int64, now time.Time) (bool, error)
+	Delete(ctx context.Context, userID string) error
+	Find(ctx context.Context, userID string) (*MFA, error)
+	StoreRecoveryCodes(ctx context.Context, userID string, codes []string) error
+	UseRecoveryCode(ctx context.Context, userID, code string) (bool, error)
+	DeleteRecoveryCodes(ctx context.Context, userID string) error
+}
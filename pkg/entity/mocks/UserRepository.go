@@ -7,6 +7,8 @@ import (
 
 	entity "github.com/Jamshid90/go-clean-architecture/pkg/entity"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // UserRepository is an autogenerated mock type for the UserRepository type
@@ -14,6 +16,27 @@ type UserRepository struct {
 	mock.Mock
 }
 
+// Count provides a mock function with given fields: ctx, filter
+func (_m *UserRepository) Count(ctx context.Context, filter *entity.UserFilter) (int, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.UserFilter) int); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *entity.UserFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Delete provides a mock function with given fields: ctx, id
 func (_m *UserRepository) Delete(ctx context.Context, id string) error {
 	ret := _m.Called(ctx, id)
@@ -51,13 +74,13 @@ func (_m *UserRepository) Find(ctx context.Context, id string) (*entity.User, er
 	return r0, r1
 }
 
-// FindAll provides a mock function with given fields: ctx, limit, offset, params
-func (_m *UserRepository) FindAll(ctx context.Context, limit int, offset int, params map[string]interface{}) ([]*entity.User, error) {
-	ret := _m.Called(ctx, limit, offset, params)
+// FindAll provides a mock function with given fields: ctx, filter
+func (_m *UserRepository) FindAll(ctx context.Context, filter *entity.UserFilter) ([]*entity.User, error) {
+	ret := _m.Called(ctx, filter)
 
 	var r0 []*entity.User
-	if rf, ok := ret.Get(0).(func(context.Context, int, int, map[string]interface{}) []*entity.User); ok {
-		r0 = rf(ctx, limit, offset, params)
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.UserFilter) []*entity.User); ok {
+		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*entity.User)
@@ -65,8 +88,8 @@ func (_m *UserRepository) FindAll(ctx context.Context, limit int, offset int, pa
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, int, int, map[string]interface{}) error); ok {
-		r1 = rf(ctx, limit, offset, params)
+	if rf, ok := ret.Get(1).(func(context.Context, *entity.UserFilter) error); ok {
+		r1 = rf(ctx, filter)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -97,6 +120,43 @@ func (_m *UserRepository) FindByEmail(ctx context.Context, email string) (*entit
 	return r0, r1
 }
 
+// FindByPhone provides a mock function with given fields: ctx, phone
+func (_m *UserRepository) FindByPhone(ctx context.Context, phone string) (*entity.User, error) {
+	ret := _m.Called(ctx, phone)
+
+	var r0 *entity.User
+	if rf, ok := ret.Get(0).(func(context.Context, string) *entity.User); ok {
+		r0 = rf(ctx, phone)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, phone)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Patch provides a mock function with given fields: ctx, id, patch
+func (_m *UserRepository) Patch(ctx context.Context, id string, patch *entity.UserPatch) error {
+	ret := _m.Called(ctx, id, patch)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *entity.UserPatch) error); ok {
+		r0 = rf(ctx, id, patch)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Store provides a mock function with given fields: ctx, user
 func (_m *UserRepository) Store(ctx context.Context, user *entity.User) error {
 	ret := _m.Called(ctx, user)
@@ -124,3 +184,45 @@ func (_m *UserRepository) Update(ctx context.Context, user *entity.User) error {
 
 	return r0
 }
+
+// UpdatePassword provides a mock function with given fields: ctx, id, password
+func (_m *UserRepository) UpdatePassword(ctx context.Context, id string, password string) error {
+	ret := _m.Called(ctx, id, password)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, id, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateStatus provides a mock function with given fields: ctx, id, status
+func (_m *UserRepository) UpdateStatus(ctx context.Context, id string, status string) error {
+	ret := _m.Called(ctx, id, status)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, id, status)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// VerifyPhone provides a mock function with given fields: ctx, id, verifiedAt
+func (_m *UserRepository) VerifyPhone(ctx context.Context, id string, verifiedAt time.Time) error {
+	ret := _m.Called(ctx, id, verifiedAt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = rf(ctx, id, verifiedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
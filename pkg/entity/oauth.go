@@ -0,0 +1,205 @@
+package entity
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	OAUTH_GRANT_AUTHORIZATION_CODE = "authorization_code"
+	OAUTH_GRANT_REFRESH_TOKEN      = "refresh_token"
+	OAUTH_GRANT_CLIENT_CREDENTIALS = "client_credentials"
+)
+
+// token type hints of introspection and revocation requests
+const (
+	OAUTH_TOKEN_TYPE_ACCESS_TOKEN  = "access_token"
+	OAUTH_TOKEN_TYPE_REFRESH_TOKEN = "refresh_token"
+)
+
+var OAuthGrantTypes = []string{
+	OAUTH_GRANT_AUTHORIZATION_CODE,
+	OAUTH_GRANT_REFRESH_TOKEN,
+	OAUTH_GRANT_CLIENT_CREDENTIALS,
+}
+
+// OAuthClient is an application registered to get tokens from the authorization server,
+// secret is stored as hash and is empty for public clients
+type OAuthClient struct {
+	ID           string
+	Secret       string
+	Name         string
+	RedirectURIs []string
+	GrantTypes   []string
+	Scopes       []string
+	FirstParty   bool
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// is public, public clients can not keep a secret and authenticate with pkce only
+func (c *OAuthClient) IsPublic() bool {
+	return c.Secret == ""
+}
+
+func (c *OAuthClient) HasGrantType(grantType string) bool {
+	for _, g := range c.GrantTypes {
+		if g == grantType {
+			return true
+		}
+	}
+	return false
+}
+
+// has redirect uri, redirect uris are compared by exact match
+func (c *OAuthClient) HasRedirectURI(uri string) bool {
+	for _, u := range c.RedirectURIs {
+		if u == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// OAuthAuthorizationCode is issued to the client after the user authorized it, code is stored as hash
+type OAuthAuthorizationCode struct {
+	Code          string
+	ClientID      string
+	UserID        string
+	RedirectURI   string
+	Scopes        []string
+	CodeChallenge string
+	Nonce         string
+	ExpiresAt     time.Time
+	CreatedAt     time.Time
+}
+
+// OAuthConsent keeps scopes the user granted to the client
+type OAuthConsent struct {
+	UserID    string
+	ClientID  string
+	Scopes    []string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// OAuthRefreshToken is rotated on every use, token is stored as hash
+type OAuthRefreshToken struct {
+	Token     string
+	ClientID  string
+	UserID    string
+	Scopes    []string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// OAuthAuthorizeRequest holds parameters of the authorization endpoint
+type OAuthAuthorizeRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Nonce               string
+}
+
+// OAuthTokenRequest holds parameters of the token endpoint, client credentials come from basic auth or the form
+type OAuthTokenRequest struct {
+	GrantType    string
+	ClientID     string
+	ClientSecret string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+	Scope        string
+}
+
+// OAuthToken is the response of the token endpoint, refresh token is empty when the grant does not issue it
+// and id token is issued for openid scope only
+type OAuthToken struct {
+	AccessToken  string
+	TokenType    string
+	ExpiresIn    int64
+	RefreshToken string
+	IDToken      string
+	Scopes       []string
+}
+
+// OAuthTokenInspectRequest holds parameters of the introspection and revocation endpoints,
+// the hint tells which token type to look up first
+type OAuthTokenInspectRequest struct {
+	ClientID      string
+	ClientSecret  string
+	Token         string
+	TokenTypeHint string
+}
+
+// OAuthIntrospection is the state of a token, only Active is set for tokens which are not active.
+// Client id is empty for first-party tokens, their scopes are the permissions of the user and
+// session id is the session they belong to.
+type OAuthIntrospection struct {
+	Active    bool
+	ID        string
+	TokenType string
+	Subject   string
+	ClientID  string
+	Scopes    []string
+	SessionID string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+type OAuthClientUsecase interface {
+	Store(ctx context.Context, client *OAuthClient, public bool) (string, error)
+	Find(ctx context.Context, id string) (*OAuthClient, error)
+	FindAll(ctx context.Context) ([]*OAuthClient, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type OAuthUsecase interface {
+	FindClient(ctx context.Context, clientID, redirectURI string) (*OAuthClient, error)
+	ValidateAuthorize(client *OAuthClient, request *OAuthAuthorizeRequest) ([]string, error)
+	ConsentRequired(ctx context.Context, userID string, client *OAuthClient, scopes []string) (bool, error)
+	Consent(ctx context.Context, userID string, client *OAuthClient, scopes []string) error
+	Authorize(ctx context.Context, userID string, client *OAuthClient, request *OAuthAuthorizeRequest, scopes []string) (string, error)
+	Token(ctx context.Context, request *OAuthTokenRequest) (*OAuthToken, error)
+	UserInfo(ctx context.Context, accessToken string) (map[string]interface{}, error)
+	FindConsents(ctx context.Context, userID string) ([]*OAuthConsent, error)
+	RevokeConsent(ctx context.Context, userID, clientID string) error
+	Introspect(ctx context.Context, request *OAuthTokenInspectRequest) (*OAuthIntrospection, error)
+	Revoke(ctx context.Context, request *OAuthTokenInspectRequest) error
+}
+
+type OAuthClientRepository interface {
+	Store(ctx context.Context, client *OAuthClient) error
+	Find(ctx context.Context, id string) (*OAuthClient, error)
+	FindAll(ctx context.Context) ([]*OAuthClient, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type OAuthAuthorizationCodeRepository interface {
+	Store(ctx context.Context, code *OAuthAuthorizationCode) error
+	Find(ctx context.Context, code string) (*OAuthAuthorizationCode, error)
+	Use(ctx context.Context, code string, usedAt time.Time) error
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+type OAuthConsentRepository interface {
+	Store(ctx context.Context, consent *OAuthConsent) error
+	Find(ctx context.Context, userID, clientID string) (*OAuthConsent, error)
+	FindByUserId(ctx context.Context, id string) ([]*OAuthConsent, error)
+	Delete(ctx context.Context, userID, clientID string) error
+	DeleteByClientId(ctx context.Context, id string) error
+}
+
+type OAuthRefreshTokenRepository interface {
+	Store(ctx context.Context, token *OAuthRefreshToken) error
+	Find(ctx context.Context, token string) (*OAuthRefreshToken, error)
+	Delete(ctx context.Context, token string) error
+	DeleteByUserAndClient(ctx context.Context, userID, clientID string) error
+	DeleteByUserId(ctx context.Context, id string) error
+	DeleteByClientId(ctx context.Context, id string) error
+}
@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"context"
+	"time"
+)
+
+// OIDCAuthRequest keeps nonce and pkce verifier of the authorization started with the provider, state and
+// binding of the browser which started the authorization are stored as hash
+type OIDCAuthRequest struct {
+	State        string
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	Binding      string
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+type OIDCUsecase interface {
+	AuthorizationURL(ctx context.Context, provider string) (authURL string, binding string, err error)
+	Authenticate(ctx context.Context, provider, code, state, binding string) (*User, error)
+}
+
+type OIDCAuthRequestRepository interface {
+	Store(ctx context.Context, request *OIDCAuthRequest) error
+	Consume(ctx context.Context, state string) (*OIDCAuthRequest, error)
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
@@ -0,0 +1,6 @@
+package entity
+
+// PasswordPolicy validates new passwords of the user, violations are returned as validation error
+type PasswordPolicy interface {
+	Validate(password string, user *User) error
+}
@@ -0,0 +1,10 @@
+package entity
+
+import "context"
+
+type PasswordResetUsecase interface {
+	Send(ctx context.Context, user *User) error
+	Check(ctx context.Context, token string) (string, error)
+	Consume(ctx context.Context, token string) (string, error)
+	Release(ctx context.Context, token string) error
+}
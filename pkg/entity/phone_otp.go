@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	PHONE_OTP_PURPOSE_LOGIN  = "login"
+	PHONE_OTP_PURPOSE_VERIFY = "verify"
+)
+
+// PhoneOTP is the last one-time code sent to the phone for the purpose, the code is stored as hash
+type PhoneOTP struct {
+	Phone     string
+	Purpose   string
+	Code      string
+	Attempts  int
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type PhoneOTPUsecase interface {
+	Send(ctx context.Context, phone, purpose string) error
+	Verify(ctx context.Context, phone, purpose, code string) error
+}
+
+type PhoneOTPRepository interface {
+	Store(ctx context.Context, otp *PhoneOTP) error
+	Find(ctx context.Context, phone, purpose string) (*PhoneOTP, error)
+	IncrementAttempts(ctx context.Context, phone, purpose string, maxAttempts int, now time.Time) (string, error)
+	Consume(ctx context.Context, phone, purpose, code string) error
+}
@@ -5,22 +5,32 @@ import (
 	"time"
 )
 
+// RefreshToken belongs to a family started at login, every rotation adds a token
+// pointing to its parent and marks the parent rotated.
+// Only digests of tokens are stored, so ParentToken of a loaded token is a digest.
 type RefreshToken struct {
-	UserID    string
-	Token     string
-	CreatedAt time.Time
+	UserID      string
+	Token       string
+	FamilyID    string
+	ParentToken string
+	RotatedAt   *time.Time
+	CreatedAt   time.Time
 }
 
 type RefreshTokenUsecase interface {
 	Store(ctx context.Context, user *RefreshToken) error
+	Rotate(ctx context.Context, old *RefreshToken, new *RefreshToken) error
 	Delete(ctx context.Context, token string) error
 	DeleteByUserId(ctx context.Context, id string) error
+	DeleteByFamily(ctx context.Context, familyID string) error
 	Find(ctx context.Context, token string) (*RefreshToken, error)
 }
 
 type RefreshTokenRepository interface {
 	Store(ctx context.Context, user *RefreshToken) error
+	Rotate(ctx context.Context, old string, new *RefreshToken, expiredBefore time.Time) (bool, error)
 	Delete(ctx context.Context, token string) error
 	DeleteByUserId(ctx context.Context, id string) error
+	DeleteByFamily(ctx context.Context, familyID string) error
 	Find(ctx context.Context, token string) (*RefreshToken, error)
 }
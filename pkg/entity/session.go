@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"context"
+	"time"
+)
+
+// Session is a login on a device, it lives as long as its refresh token family,
+// so the id of a session is the family id of its refresh tokens.
+type Session struct {
+	ID         string
+	UserID     string
+	UserAgent  string
+	IP         string
+	DeviceName string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+}
+
+type SessionUsecase interface {
+	Store(ctx context.Context, m *Session) error
+	Touch(ctx context.Context, id string) error
+	Find(ctx context.Context, id string) (*Session, error)
+	FindByUserId(ctx context.Context, userID string) ([]*Session, error)
+	Delete(ctx context.Context, userID, id string) error
+	DeleteOthers(ctx context.Context, userID, currentID string) error
+	DeleteByUserId(ctx context.Context, userID string) error
+}
+
+type SessionRepository interface {
+	Store(ctx context.Context, m *Session) error
+	Touch(ctx context.Context, id string, lastUsedAt time.Time) error
+	Find(ctx context.Context, id string) (*Session, error)
+	FindByUserId(ctx context.Context, userID string, expiredBefore time.Time) ([]*Session, error)
+	Delete(ctx context.Context, id string) error
+	DeleteByUserId(ctx context.Context, userID string) error
+	DeleteExpired(ctx context.Context, userID string, expiredBefore time.Time) error
+}
@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"context"
+	"time"
+)
+
+// RevokedToken is an access token revoked before expiry, ID is the jti claim of the token
+type RevokedToken struct {
+	ID        string
+	ExpiresAt time.Time
+}
+
+// RevokedUser revokes every access token of the user issued up to RevokedAt,
+// it is kept until the last of those tokens expires. UserID is "user_id@client_id" when only
+// access tokens an oauth client got for the user are revoked.
+type RevokedUser struct {
+	UserID    string
+	RevokedAt time.Time
+	ExpiresAt time.Time
+}
+
+type TokenRevocationUsecase interface {
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	RevokeUser(ctx context.Context, userID string) error
+	RevokeUserClient(ctx context.Context, userID, clientID string) error
+	Consume(ctx context.Context, jti string, expiresAt time.Time) (bool, error)
+	IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error)
+	IsClientRevoked(ctx context.Context, jti, userID, clientID string, issuedAt time.Time) (bool, error)
+}
+
+type TokenRevocationRepository interface {
+	Store(ctx context.Context, m *RevokedToken) error
+	StoreNew(ctx context.Context, m *RevokedToken) (bool, error)
+	StoreUser(ctx context.Context, m *RevokedUser) error
+	Exists(ctx context.Context, jti string, now time.Time) (bool, error)
+	ExistsUser(ctx context.Context, userID string, issuedAt time.Time, now time.Time) (bool, error)
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
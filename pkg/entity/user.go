@@ -6,38 +6,215 @@ import (
 )
 
 const (
-	USER_STATUS_ACTIVE   = "active"
-	USER_STATUS_DEACTIVE = "deactive"
+	USER_STATUS_PENDING   = "pending"
+	USER_STATUS_ACTIVE    = "active"
+	USER_STATUS_SUSPENDED = "suspended"
+	USER_STATUS_DEACTIVE  = "deactive"
 )
 
+// statuses a user can be moved to from the status, active users become pending on email change
+var UserStatusTransitions = map[string][]string{
+	USER_STATUS_PENDING:   {USER_STATUS_ACTIVE, USER_STATUS_SUSPENDED, USER_STATUS_DEACTIVE},
+	USER_STATUS_ACTIVE:    {USER_STATUS_PENDING, USER_STATUS_SUSPENDED, USER_STATUS_DEACTIVE},
+	USER_STATUS_SUSPENDED: {USER_STATUS_ACTIVE, USER_STATUS_DEACTIVE},
+	USER_STATUS_DEACTIVE:  {USER_STATUS_ACTIVE},
+}
+
+// service accounts are non-human users, they have neither password nor email and authenticate with api keys only
+const (
+	USER_TYPE_HUMAN   = "human"
+	USER_TYPE_SERVICE = "service"
+)
+
+const (
+	USER_ROLE_ADMIN = "admin"
+	USER_ROLE_USER  = "user"
+)
+
+const (
+	PERMISSION_USER_READ   = "user:read"
+	PERMISSION_USER_WRITE  = "user:write"
+	PERMISSION_USER_DELETE = "user:delete"
+
+	PERMISSION_OAUTH_CLIENT_READ  = "oauth_client:read"
+	PERMISSION_OAUTH_CLIENT_WRITE = "oauth_client:write"
+
+	PERMISSION_SERVICE_ACCOUNT_READ  = "service_account:read"
+	PERMISSION_SERVICE_ACCOUNT_WRITE = "service_account:write"
+
+	PERMISSION_USER_IMPERSONATE = "user:impersonate"
+)
+
+// Permissions are all known permissions, only they can be granted
+var Permissions = []string{
+	PERMISSION_USER_READ,
+	PERMISSION_USER_WRITE,
+	PERMISSION_USER_DELETE,
+	PERMISSION_OAUTH_CLIENT_READ,
+	PERMISSION_OAUTH_CLIENT_WRITE,
+	PERMISSION_SERVICE_ACCOUNT_READ,
+	PERMISSION_SERVICE_ACCOUNT_WRITE,
+	PERMISSION_USER_IMPERSONATE,
+}
+
+// IsPermission tells whether the permission is known
+func IsPermission(permission string) bool {
+	for _, p := range Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// permissions granted by role, users can be granted extra permissions one by one
+var RolePermissions = map[string][]string{
+	USER_ROLE_ADMIN: {
+		PERMISSION_USER_READ,
+		PERMISSION_USER_WRITE,
+		PERMISSION_USER_DELETE,
+		PERMISSION_OAUTH_CLIENT_READ,
+		PERMISSION_OAUTH_CLIENT_WRITE,
+		PERMISSION_SERVICE_ACCOUNT_READ,
+		PERMISSION_SERVICE_ACCOUNT_WRITE,
+		PERMISSION_USER_IMPERSONATE,
+	},
+	USER_ROLE_USER: {},
+}
+
 type User struct {
-	ID        string
-	Email     string
-	Phone     string
-	Gender    string
-	Status    string
-	FirstName string
-	LastName  string
-	Password  string
-	BirthDate time.Time
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID              string
+	Type            string
+	Email           string
+	Phone           string
+	PhoneVerifiedAt *time.Time
+	Gender          string
+	Status          string
+	Role            string
+	Permissions     []string
+	FirstName       string
+	LastName        string
+	Password        string
+	BirthDate       time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// has permission checks permissions of the user role and permissions granted to the user
+func (u *User) HasPermission(permission string) bool {
+	if u.hasRolePermission(permission) {
+		return true
+	}
+	for _, p := range u.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// all permissions returns permissions of the user role and permissions granted to the user
+func (u *User) AllPermissions() []string {
+	permissions := append([]string{}, RolePermissions[u.Role]...)
+	for _, p := range u.Permissions {
+		if !u.hasRolePermission(p) {
+			permissions = append(permissions, p)
+		}
+	}
+	return permissions
+}
+
+// can grant checks the user holds every permission the role and permissions give to the target beyond
+// the permissions the target already has, users can not grant more than they have
+func (u *User) CanGrant(target *User, role string, permissions []string) bool {
+	granted := User{Role: role, Permissions: permissions}
+	for _, p := range granted.AllPermissions() {
+		if !target.HasPermission(p) && !u.HasPermission(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// can manage checks the user holds every permission of the target, only such users can change status, email,
+// role or permissions of the target
+func (u *User) CanManage(target *User) bool {
+	return u.CanGrant(&User{}, target.Role, target.Permissions)
+}
+
+func (u *User) hasRolePermission(permission string) bool {
+	for _, p := range RolePermissions[u.Role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// is service account, users stored before service accounts were introduced have no type
+func (u *User) IsServiceAccount() bool {
+	return u.Type == USER_TYPE_SERVICE
+}
+
+// can change status checks the transition from the current status, keeping the status is allowed
+func (u *User) CanChangeStatus(status string) bool {
+	if u.Status == status {
+		return true
+	}
+	for _, s := range UserStatusTransitions[u.Status] {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// is disabled, suspended and deactivated users can not authenticate
+func (u *User) IsDisabled() bool {
+	return IsDisabledStatus(u.Status)
+}
+
+func IsDisabledStatus(status string) bool {
+	return status == USER_STATUS_SUSPENDED || status == USER_STATUS_DEACTIVE
+}
+
+// is email verified, users stay pending until the email is verified
+func (u *User) IsEmailVerified() bool {
+	return u.Status != USER_STATUS_PENDING
+}
+
+// is phone verified, only verified phone can be used to log in
+func (u *User) IsPhoneVerified() bool {
+	return u.PhoneVerifiedAt != nil
 }
 
 type UserUsecase interface {
 	Store(ctx context.Context, user *User) error
 	Update(ctx context.Context, user *User) error
+	Patch(ctx context.Context, id string, patch *UserPatch) (*User, error)
+	UpdateStatus(ctx context.Context, id, status string) error
+	UpdatePassword(ctx context.Context, id, password string) error
+	ValidatePassword(ctx context.Context, id, password string) error
+	CheckPassword(ctx context.Context, user *User, password string) (bool, error)
 	Delete(ctx context.Context, id string) error
 	Find(ctx context.Context, id string) (*User, error)
-	FindAll(ctx context.Context, limit, offset int, params map[string]interface{}) ([]*User, error)
+	FindAll(ctx context.Context, filter *UserFilter) (*UserPage, error)
 	FindByEmail(ctx context.Context, email string) (*User, error)
+	FindByPhone(ctx context.Context, phone string) (*User, error)
+	VerifyPhone(ctx context.Context, id string) error
 }
 
 type UserRepository interface {
 	Store(ctx context.Context, user *User) error
 	Update(ctx context.Context, user *User) error
+	Patch(ctx context.Context, id string, patch *UserPatch) error
+	UpdateStatus(ctx context.Context, id, status string) error
+	UpdatePassword(ctx context.Context, id, password string) error
 	Delete(ctx context.Context, id string) error
 	Find(ctx context.Context, id string) (*User, error)
-	FindAll(ctx context.Context, limit, offset int, params map[string]interface{}) ([]*User, error)
+	FindAll(ctx context.Context, filter *UserFilter) ([]*User, error)
+	Count(ctx context.Context, filter *UserFilter) (int, error)
 	FindByEmail(ctx context.Context, email string) (*User, error)
+	FindByPhone(ctx context.Context, phone string) (*User, error)
+	VerifyPhone(ctx context.Context, id string, verifiedAt time.Time) error
 }
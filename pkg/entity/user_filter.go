@@ -0,0 +1,75 @@
+package entity
+
+import "time"
+
+// fields users can be sorted by
+const (
+	USER_SORT_CREATED_AT = "created_at"
+	USER_SORT_UPDATED_AT = "updated_at"
+	USER_SORT_FIRST_NAME = "first_name"
+	USER_SORT_LAST_NAME  = "last_name"
+	USER_SORT_EMAIL      = "email"
+	USER_SORT_BIRTH_DATE = "birth_date"
+)
+
+var UserSortFields = []string{
+	USER_SORT_CREATED_AT,
+	USER_SORT_UPDATED_AT,
+	USER_SORT_FIRST_NAME,
+	USER_SORT_LAST_NAME,
+	USER_SORT_EMAIL,
+	USER_SORT_BIRTH_DATE,
+}
+
+// UserSort orders users by the field, ascending unless Desc
+type UserSort struct {
+	Field string
+	Desc  bool
+}
+
+// UserCursor is the position of the user in the listing sorted by created_at,
+// the page of the cursor starts after the user or ends before it when Backward
+type UserCursor struct {
+	CreatedAt time.Time
+	ID        string
+	Backward  bool
+}
+
+// UserPage is a page of users, Next and Prev are set when there are adjacent pages
+// and Total is counted only on request
+type UserPage struct {
+	Items []*User
+	Next  *UserCursor
+	Prev  *UserCursor
+	Total *int
+}
+
+// UserFilter selects users, empty fields do not filter. EmailPrefix matches the start of the email,
+// Query matches names and email anywhere and ranges include their bounds.
+// Cursor replaces Offset when users are sorted by created_at only.
+type UserFilter struct {
+	Limit         int
+	Offset        int
+	Cursor        *UserCursor
+	WithTotal     bool
+	Status        []string
+	Type          string
+	Gender        string
+	EmailPrefix   string
+	Query         string
+	CreatedFrom   *time.Time
+	CreatedTo     *time.Time
+	BirthDateFrom *time.Time
+	BirthDateTo   *time.Time
+	Sort          []UserSort
+}
+
+// IsKeyset tells whether pages of the filter can be found with cursors, users must be sorted by created_at only
+func (f *UserFilter) IsKeyset() bool {
+	return len(f.Sort) == 0 || (len(f.Sort) == 1 && f.Sort[0].Field == USER_SORT_CREATED_AT)
+}
+
+// IsDesc tells whether keyset listing is sorted from the newest users, the default
+func (f *UserFilter) IsDesc() bool {
+	return len(f.Sort) == 0 || f.Sort[0].Desc
+}
@@ -0,0 +1,22 @@
+package entity
+
+import (
+	"context"
+	"time"
+)
+
+// UserIdentity links the user to the subject of an external identity provider
+type UserIdentity struct {
+	UserID    string
+	Provider  string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+}
+
+type UserIdentityRepository interface {
+	Store(ctx context.Context, identity *UserIdentity) error
+	Find(ctx context.Context, provider, subject string) (*UserIdentity, error)
+	FindByUserId(ctx context.Context, id string) ([]*UserIdentity, error)
+	Delete(ctx context.Context, provider, subject string) error
+}
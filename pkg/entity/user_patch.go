@@ -0,0 +1,56 @@
+package entity
+
+import "time"
+
+// UserPatch changes only the fields which are set, a changed phone has to be verified again
+type UserPatch struct {
+	Status      *string
+	Role        *string
+	Permissions *[]string
+	Email       *string
+	Phone       *string
+	Gender      *string
+	FirstName   *string
+	LastName    *string
+	BirthDate   *time.Time
+	UpdatedAt   time.Time
+}
+
+// is empty, the patch changes nothing
+func (p *UserPatch) IsEmpty() bool {
+	return p.Status == nil && p.Role == nil && p.Permissions == nil && p.Email == nil && p.Phone == nil &&
+		p.Gender == nil && p.FirstName == nil && p.LastName == nil && p.BirthDate == nil
+}
+
+// apply the patch to the user
+func (p *UserPatch) Apply(u *User) {
+	if p.Status != nil {
+		u.Status = *p.Status
+	}
+	if p.Role != nil {
+		u.Role = *p.Role
+	}
+	if p.Permissions != nil {
+		u.Permissions = *p.Permissions
+	}
+	if p.Email != nil {
+		u.Email = *p.Email
+	}
+	if p.Phone != nil {
+		u.Phone = *p.Phone
+		u.PhoneVerifiedAt = nil
+	}
+	if p.Gender != nil {
+		u.Gender = *p.Gender
+	}
+	if p.FirstName != nil {
+		u.FirstName = *p.FirstName
+	}
+	if p.LastName != nil {
+		u.LastName = *p.LastName
+	}
+	if p.BirthDate != nil {
+		u.BirthDate = *p.BirthDate
+	}
+	u.UpdatedAt = p.UpdatedAt
+}
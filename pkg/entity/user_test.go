@@ -0,0 +1,83 @@
+package entity
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHasPermission(t *testing.T) {
+	t.Run("admin", func(t *testing.T) {
+		user := User{Role: USER_ROLE_ADMIN}
+		assert.True(t, user.HasPermission(PERMISSION_USER_DELETE))
+	})
+
+	t.Run("user", func(t *testing.T) {
+		user := User{Role: USER_ROLE_USER}
+		assert.False(t, user.HasPermission(PERMISSION_USER_READ))
+	})
+
+	t.Run("granted", func(t *testing.T) {
+		user := User{Role: USER_ROLE_USER, Permissions: []string{PERMISSION_USER_READ}}
+		assert.True(t, user.HasPermission(PERMISSION_USER_READ))
+		assert.False(t, user.HasPermission(PERMISSION_USER_WRITE))
+	})
+}
+
+func TestAllPermissions(t *testing.T) {
+	user := User{Role: USER_ROLE_ADMIN, Permissions: []string{PERMISSION_USER_READ, "report:read"}}
+	permissions := user.AllPermissions()
+	assert.ElementsMatch(t, append(append([]string{}, RolePermissions[USER_ROLE_ADMIN]...), "report:read"), permissions)
+
+	user = User{Role: USER_ROLE_USER}
+	assert.Empty(t, user.AllPermissions())
+}
+
+func TestCanChangeStatus(t *testing.T) {
+	user := User{Status: USER_STATUS_SUSPENDED}
+	assert.True(t, user.CanChangeStatus(USER_STATUS_ACTIVE))
+	assert.True(t, user.CanChangeStatus(USER_STATUS_SUSPENDED))
+	assert.False(t, user.CanChangeStatus(USER_STATUS_PENDING))
+	assert.True(t, user.IsDisabled())
+
+	user = User{Status: USER_STATUS_DEACTIVE}
+	assert.False(t, user.CanChangeStatus(USER_STATUS_SUSPENDED))
+	assert.True(t, user.CanChangeStatus(USER_STATUS_ACTIVE))
+}
+
+func TestCanGrant(t *testing.T) {
+	manager := User{Role: USER_ROLE_USER, Permissions: []string{PERMISSION_USER_READ, PERMISSION_USER_WRITE}}
+	target := User{Role: USER_ROLE_USER}
+
+	t.Run("success", func(t *testing.T) {
+		assert.True(t, manager.CanGrant(&target, USER_ROLE_USER, []string{PERMISSION_USER_WRITE}))
+	})
+
+	t.Run("success-already-granted", func(t *testing.T) {
+		admin := User{Role: USER_ROLE_ADMIN}
+		assert.True(t, manager.CanGrant(&admin, USER_ROLE_ADMIN, nil))
+	})
+
+	t.Run("error-role", func(t *testing.T) {
+		assert.False(t, manager.CanGrant(&target, USER_ROLE_ADMIN, nil))
+	})
+
+	t.Run("error-permissions", func(t *testing.T) {
+		assert.False(t, manager.CanGrant(&target, USER_ROLE_USER, []string{PERMISSION_USER_IMPERSONATE}))
+	})
+}
+
+func TestCanManage(t *testing.T) {
+	manager := User{Role: USER_ROLE_USER, Permissions: []string{PERMISSION_USER_READ, PERMISSION_USER_WRITE}}
+
+	t.Run("success", func(t *testing.T) {
+		assert.True(t, manager.CanManage(&User{Role: USER_ROLE_USER, Permissions: []string{PERMISSION_USER_READ}}))
+	})
+
+	t.Run("error-admin", func(t *testing.T) {
+		assert.False(t, manager.CanManage(&User{Role: USER_ROLE_ADMIN}))
+	})
+
+	t.Run("error-permissions", func(t *testing.T) {
+		assert.False(t, manager.CanManage(&User{Role: USER_ROLE_USER, Permissions: []string{PERMISSION_USER_DELETE}}))
+	})
+}
@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"context"
+	"time"
+)
+
+// UserToken is a single use token mailed to the user, like email verification, password reset and magic link tokens.
+// Token is the digest of the mailed token.
+type UserToken struct {
+	UserID    string
+	Token     string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type UserTokenRepository interface {
+	Store(ctx context.Context, token *UserToken) error
+	Find(ctx context.Context, token string) (*UserToken, error)
+	Consume(ctx context.Context, token string) (*UserToken, error)
+	Use(ctx context.Context, token string, usedAt time.Time) (*UserToken, error)
+	Release(ctx context.Context, token string) error
+	DeleteByUserId(ctx context.Context, id string) error
+	DeleteExpired(ctx context.Context, before time.Time) error
+	CountByUserIdSince(ctx context.Context, id string, since time.Time) (int, error)
+}
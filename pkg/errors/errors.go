@@ -8,11 +8,21 @@ import (
 
 var (
 	ErrUnauthorized           = errors.New(GetHTTPStatusText(http.StatusUnauthorized))
+	ErrForbidden              = errors.New(GetHTTPStatusText(http.StatusForbidden))
 	ErrUnprocessableEntity    = errors.New(GetHTTPStatusText(http.StatusUnprocessableEntity))
 	ErrInternalServerError    = errors.New(GetHTTPStatusText(http.StatusInternalServerError))
 	BadRequest                = errors.New(GetHTTPStatusText(http.StatusBadRequest))
 	ErrBadParamInput          = errors.New("Given param is not valid")
 	ErrInvalidEmailOrPassword = errors.New("invalid email or password")
+	ErrEmailNotVerified       = errors.New("email not verified")
+	ErrInvalidOrExpiredToken  = errors.New("invalid or expired token")
+	ErrInvalidMFACode         = errors.New("invalid two-factor authentication code")
+	ErrRefreshTokenReused     = errors.New("refresh token reuse detected")
+	ErrTooManyLoginAttempts   = errors.New("too many login attempts, try again later")
+	ErrAccountDisabled        = errors.New("account disabled")
+	ErrTooManyRequests        = errors.New("too many requests, try again later")
+	ErrInvalidOTPCode         = errors.New("invalid or expired one-time code")
+	ErrExternalAuthentication = errors.New("authentication with the identity provider failed")
 )
 
 // Get http status text
@@ -82,3 +92,36 @@ type ErrBadRequest struct {
 func (e ErrBadRequest) Error() string {
 	return e.Message
 }
+
+func (e ErrBadRequest) Unwrap() error {
+	return e.Err
+}
+
+// error codes of oauth endpoints, RFC 6749 and RFC 6750
+const (
+	OAUTH_INVALID_REQUEST           = "invalid_request"
+	OAUTH_INVALID_CLIENT            = "invalid_client"
+	OAUTH_INVALID_GRANT             = "invalid_grant"
+	OAUTH_INVALID_SCOPE             = "invalid_scope"
+	OAUTH_UNAUTHORIZED_CLIENT       = "unauthorized_client"
+	OAUTH_UNSUPPORTED_GRANT_TYPE    = "unsupported_grant_type"
+	OAUTH_UNSUPPORTED_RESPONSE_TYPE = "unsupported_response_type"
+	OAUTH_ACCESS_DENIED             = "access_denied"
+	OAUTH_SERVER_ERROR              = "server_error"
+	OAUTH_INVALID_TOKEN             = "invalid_token"
+	OAUTH_INSUFFICIENT_SCOPE        = "insufficient_scope"
+)
+
+// Error oauth, code and description are returned to oauth clients
+func NewErrOAuth(code, description string) error {
+	return &ErrOAuth{Code: code, Description: description}
+}
+
+type ErrOAuth struct {
+	Code        string
+	Description string
+}
+
+func (e *ErrOAuth) Error() string {
+	return e.Code + ": " + e.Description
+}
@@ -0,0 +1,109 @@
+package hash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"strings"
+)
+
+// default parameters, memory is in KiB
+const (
+	ARGON2ID_DEFAULT_MEMORY      = 64 * 1024
+	ARGON2ID_DEFAULT_ITERATIONS  = 3
+	ARGON2ID_DEFAULT_PARALLELISM = 2
+)
+
+const (
+	argon2idSaltLength = 16
+	argon2idKeyLength  = 32
+)
+
+// Argon2idHasher makes hashes in PHC string format: $argon2id$v=19$m=65536,t=3,p=2$salt$key
+type Argon2idHasher struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+func NewArgon2idHasher(memory, iterations uint32, parallelism uint8) *Argon2idHasher {
+	if memory == 0 {
+		memory = ARGON2ID_DEFAULT_MEMORY
+	}
+	if iterations == 0 {
+		iterations = ARGON2ID_DEFAULT_ITERATIONS
+	}
+	if parallelism == 0 {
+		parallelism = ARGON2ID_DEFAULT_PARALLELISM
+	}
+	return &Argon2idHasher{Memory: memory, Iterations: iterations, Parallelism: parallelism}
+}
+
+func (a *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2idSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.Iterations, a.Memory, a.Parallelism, argon2idKeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		a.Memory,
+		a.Iterations,
+		a.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// check uses parameters of the hash, so hashes made with old parameters are still accepted
+func (a *Argon2idHasher) Check(password, hash string) bool {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1
+}
+
+func (a *Argon2idHasher) NeedsRehash(hash string) bool {
+	params, _, _, err := decodeArgon2id(hash)
+	return err != nil || *params != *a
+}
+
+// decode argon2id hash into its parameters, salt and key
+func decodeArgon2id(hash string) (*Argon2idHasher, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, nil, nil, fmt.Errorf("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return nil, nil, nil, err
+	}
+	if version != argon2.Version {
+		return nil, nil, nil, fmt.Errorf("unsupported argon2id version: %d", version)
+	}
+
+	params := Argon2idHasher{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return nil, nil, nil, err
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return &params, salt, key, nil
+}
@@ -0,0 +1,32 @@
+package hash
+
+import "golang.org/x/crypto/bcrypt"
+
+// cost of hashes made before the cost was configurable
+const BCRYPT_DEFAULT_COST = 14
+
+type BcryptHasher struct {
+	Cost int
+}
+
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost == 0 {
+		cost = BCRYPT_DEFAULT_COST
+	}
+	return &BcryptHasher{Cost: cost}
+}
+
+func (b *BcryptHasher) Hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	return string(bytes), err
+}
+
+func (b *BcryptHasher) Check(password, hash string) bool {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	return err == nil
+}
+
+func (b *BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != b.Cost
+}
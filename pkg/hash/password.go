@@ -1,13 +1,80 @@
 package hash
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"fmt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/config"
+	"strings"
+)
 
-func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-	return string(bytes), err
+const (
+	ALGORITHM_BCRYPT   = "bcrypt"
+	ALGORITHM_ARGON2ID = "argon2id"
+)
+
+// PasswordHasher hashes passwords with configured algorithm and parameters,
+// NeedsRehash reports hashes made with other algorithm or parameters
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Check(password, hash string) bool
+	NeedsRehash(hash string) bool
+}
+
+// passwordHasher hashes with preferred algorithm and checks hashes of every supported one
+type passwordHasher struct {
+	preferred PasswordHasher
+	bcrypt    *BcryptHasher
+	argon2id  *Argon2idHasher
+}
+
+// New password hasher by configured algorithm
+func NewPasswordHasher(config *config.Config) (PasswordHasher, error) {
+	hasher := &passwordHasher{
+		bcrypt: NewBcryptHasher(config.Password.BcryptCost),
+		argon2id: NewArgon2idHasher(
+			config.Password.Argon2Memory,
+			config.Password.Argon2Iterations,
+			config.Password.Argon2Parallelism,
+		),
+	}
+
+	switch config.Password.Algorithm {
+	case ALGORITHM_BCRYPT, "":
+		hasher.preferred = hasher.bcrypt
+	case ALGORITHM_ARGON2ID:
+		hasher.preferred = hasher.argon2id
+	default:
+		return nil, fmt.Errorf("unknown password hashing algorithm: %s", config.Password.Algorithm)
+	}
+
+	return hasher, nil
+}
+
+func (p *passwordHasher) Hash(password string) (string, error) {
+	return p.preferred.Hash(password)
+}
+
+func (p *passwordHasher) Check(password, hash string) bool {
+	hasher := p.of(hash)
+	if hasher == nil {
+		return false
+	}
+	return hasher.Check(password, hash)
+}
+
+func (p *passwordHasher) NeedsRehash(hash string) bool {
+	if p.of(hash) != p.preferred {
+		return true
+	}
+	return p.preferred.NeedsRehash(hash)
 }
 
-func CheckPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+// of returns hasher of the hash algorithm
+func (p *passwordHasher) of(hash string) PasswordHasher {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return p.argon2id
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return p.bcrypt
+	}
+	return nil
 }
@@ -0,0 +1,60 @@
+package hash
+
+import (
+	"github.com/Jamshid90/go-clean-architecture/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func testConfig(algorithm string) *config.Config {
+	c := &config.Config{}
+	c.Password.Algorithm = algorithm
+	c.Password.BcryptCost = 4
+	c.Password.Argon2Memory = 1024
+	c.Password.Argon2Iterations = 1
+	c.Password.Argon2Parallelism = 1
+	return c
+}
+
+func TestArgon2idHasher(t *testing.T) {
+	hasher := NewArgon2idHasher(1024, 1, 1)
+
+	hash, err := hasher.Hash("password")
+	require.NoError(t, err)
+
+	assert.True(t, hasher.Check("password", hash))
+	assert.False(t, hasher.Check("wrong-password", hash))
+	assert.False(t, hasher.NeedsRehash(hash))
+
+	// hash of old parameters is accepted but has to be upgraded
+	upgraded := NewArgon2idHasher(2048, 1, 1)
+	assert.True(t, upgraded.Check("password", hash))
+	assert.True(t, upgraded.NeedsRehash(hash))
+}
+
+func TestPasswordHasher(t *testing.T) {
+	bcryptHasher, err := NewPasswordHasher(testConfig(ALGORITHM_BCRYPT))
+	require.NoError(t, err)
+	argon2idHasher, err := NewPasswordHasher(testConfig(ALGORITHM_ARGON2ID))
+	require.NoError(t, err)
+
+	bcryptHash, err := bcryptHasher.Hash("password")
+	require.NoError(t, err)
+
+	t.Run("check-other-algorithm", func(t *testing.T) {
+		assert.True(t, argon2idHasher.Check("password", bcryptHash))
+		assert.True(t, argon2idHasher.NeedsRehash(bcryptHash))
+		assert.False(t, bcryptHasher.NeedsRehash(bcryptHash))
+	})
+
+	t.Run("check-unknown-hash", func(t *testing.T) {
+		assert.False(t, argon2idHasher.Check("password", "password"))
+		assert.True(t, argon2idHasher.NeedsRehash("password"))
+	})
+
+	t.Run("error-unknown-algorithm", func(t *testing.T) {
+		_, err := NewPasswordHasher(testConfig("md5"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,21 @@
+package hash
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// HashToken returns sha256 hex digest of the token, used to keep random tokens out of the database
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// HMACToken returns hmac-sha256 hex digest of the token with the secret, used for short tokens like one-time codes
+// which could be recovered from a plain digest by trying all of them
+func HMACToken(secret []byte, token string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
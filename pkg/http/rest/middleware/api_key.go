@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/response"
+	"net/http"
+	"strings"
+)
+
+// api key from request, the key is sent in X-API-Key header or as bearer token
+func apiKeyFromRequest(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+
+	authorization := r.Header.Get("Authorization")
+	if len(authorization) > 7 && strings.EqualFold(authorization[:7], "Bearer ") && strings.HasPrefix(authorization[7:], entity.API_KEY_PREFIX) {
+		return authorization[7:]
+	}
+	return ""
+}
+
+// api key permissions returns scopes of the key the owner still has, the role is not granted to the key
+func apiKeyPermissions(owner *entity.User, apiKey *entity.APIKey) []string {
+	permissions := []string{}
+	for _, scope := range apiKey.Scopes {
+		if owner.HasPermission(scope) {
+			permissions = append(permissions, scope)
+		}
+	}
+	return permissions
+}
+
+// GetAPIKey returns api key the request was authenticated with
+func GetAPIKey(ctx context.Context) (*entity.APIKey, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	apiKey, ok := ctx.Value("api_key").(*entity.APIKey)
+	return apiKey, ok
+}
+
+// NoAPIKey rejects requests authenticated with api key, credentials and sessions are managed
+// by the user only. It must be used after Auth middleware.
+func NoAPIKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := GetAPIKey(r.Context()); ok {
+			response.Error(w, r, errors.ErrForbidden, http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
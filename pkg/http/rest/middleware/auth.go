@@ -2,21 +2,158 @@ package middleware
 
 import (
 	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
 	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
 	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/response"
 	"github.com/Jamshid90/go-clean-architecture/pkg/token"
 	"net/http"
 )
 
-func Auth(jwtsecret string) func(next http.Handler) http.Handler {
+// Auth accepts valid access tokens which are not revoked and api keys which are not expired,
+// the user of the token or the owner of the key must not be disabled.
+// Access tokens of deleted sessions are rejected, so logging a device out takes effect immediately.
+// Requests authenticated with api key get only the scopes of the key the owner still has.
+// Impersonation ends as soon as the admin acting as the user is disabled, revoked or loses the permission.
+func Auth(keys *token.KeySet, revocationUsecase entity.TokenRevocationUsecase, sessionUsecase entity.SessionUsecase, userUsecase entity.UserUsecase, apiKeyUsecase entity.APIKeyUsecase) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			user, err := token.GetAuthUser(jwtsecret, r)
+			var (
+				ctx      = r.Context()
+				authUser *entity.User
+			)
+
+			if key := apiKeyFromRequest(r); key != "" {
+				apiKey, err := apiKeyUsecase.Authenticate(ctx, key)
+				if err != nil {
+					if err == errors.ErrUnauthorized {
+						response.Error(w, r, errors.ErrUnauthorized, http.StatusUnauthorized)
+						return
+					}
+					response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+					return
+				}
+				authUser = &entity.User{ID: apiKey.UserID}
+				ctx = context.WithValue(ctx, "api_key", apiKey)
+			} else {
+				accessToken, err := token.ParseAccessToken(keys, r)
+				if err != nil {
+					response.Error(w, r, errors.ErrUnauthorized, http.StatusUnauthorized)
+					return
+				}
+
+				revoked, err := revocationUsecase.IsRevoked(ctx, accessToken.ID, accessToken.User.ID, accessToken.IssuedAt)
+				if err != nil {
+					response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+					return
+				}
+				if revoked {
+					response.Error(w, r, errors.ErrUnauthorized, http.StatusUnauthorized)
+					return
+				}
+
+				// tokens issued before sessions were introduced have no session
+				if accessToken.SessionID != "" {
+					session, err := sessionUsecase.Find(ctx, accessToken.SessionID)
+					if err != nil {
+						if _, ok := err.(*errors.ErrNotFound); ok {
+							response.Error(w, r, errors.ErrUnauthorized, http.StatusUnauthorized)
+							return
+						}
+						response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+						return
+					}
+					if session.UserID != accessToken.User.ID {
+						response.Error(w, r, errors.ErrUnauthorized, http.StatusUnauthorized)
+						return
+					}
+				}
+
+				if accessToken.ActorID != "" {
+					allowed, err := actorAllowed(ctx, revocationUsecase, userUsecase, accessToken)
+					if err != nil {
+						response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+						return
+					}
+					if !allowed {
+						response.Error(w, r, errors.ErrUnauthorized, http.StatusUnauthorized)
+						return
+					}
+				}
+				authUser = accessToken.User
+				ctx = context.WithValue(ctx, "access_token", accessToken)
+			}
+
+			user, err := userUsecase.Find(ctx, authUser.ID)
 			if err != nil {
-				response.Error(w, r, errors.ErrUnauthorized, http.StatusUnauthorized)
+				if _, ok := err.(*errors.ErrNotFound); ok {
+					response.Error(w, r, errors.ErrUnauthorized, http.StatusUnauthorized)
+					return
+				}
+				response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
 				return
 			}
-			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "user", user)))
+			if user.IsDisabled() {
+				response.Error(w, r, errors.ErrAccountDisabled, http.StatusForbidden)
+				return
+			}
+			// role and permissions of the token may be stale, the current ones take effect immediately
+			authUser.Type = user.Type
+			authUser.Status = user.Status
+			authUser.Role = user.Role
+			authUser.Permissions = user.Permissions
+
+			if apiKey, ok := GetAPIKey(ctx); ok {
+				authUser.Role = ""
+				authUser.Permissions = apiKeyPermissions(user, apiKey)
+			}
+
+			setRequestIdentity(ctx, authUser.ID, GetActorID(ctx))
+			ctx = context.WithValue(ctx, "user", authUser)
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// actor allowed checks the admin of the impersonation token is still allowed to act as the user
+func actorAllowed(ctx context.Context, revocationUsecase entity.TokenRevocationUsecase, userUsecase entity.UserUsecase, accessToken *token.AccessToken) (bool, error) {
+	revoked, err := revocationUsecase.IsRevoked(ctx, accessToken.ID, accessToken.ActorID, accessToken.IssuedAt)
+	if err != nil || revoked {
+		return false, err
+	}
+
+	actor, err := userUsecase.Find(ctx, accessToken.ActorID)
+	if err != nil {
+		if _, ok := err.(*errors.ErrNotFound); ok {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return !actor.IsDisabled() && actor.HasPermission(entity.PERMISSION_USER_IMPERSONATE), nil
+}
+
+func GetAuthUser(ctx context.Context) (*entity.User, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	user, ok := ctx.Value("user").(*entity.User)
+	return user, ok
+}
+
+// GetAccessToken returns access token the request was authenticated with
+func GetAccessToken(ctx context.Context) (*token.AccessToken, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	accessToken, ok := ctx.Value("access_token").(*token.AccessToken)
+	return accessToken, ok
+}
+
+// GetSessionID returns session of the access token, empty for tokens issued without session
+func GetSessionID(ctx context.Context) string {
+	accessToken, ok := GetAccessToken(ctx)
+	if !ok {
+		return ""
+	}
+	return accessToken.SessionID
+}
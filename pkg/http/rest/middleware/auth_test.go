@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity/mocks"
+	apperrors "github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/revocation"
+	"github.com/Jamshid90/go-clean-architecture/pkg/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAuthSession(t *testing.T) {
+	keys := token.TestKeySet(t)
+	user := &entity.User{ID: "123456789", Status: entity.USER_STATUS_ACTIVE, Role: entity.USER_ROLE_USER}
+	revocationUsecase := revocation.NewTokenRevocationUsecase(revocation.NewTokenRevocationRepositoryMemory(), time.Minute, time.Second*2)
+
+	serve := func(sessionUsecase entity.SessionUsecase, sid string) *httptest.ResponseRecorder {
+		accessToken, _, err := token.GenerateToken(keys, "1m", "1h", user, sid)
+		require.NoError(t, err)
+
+		userUsecase := new(mocks.UserUsecase)
+		userUsecase.On("Find", mock.Anything, user.ID).Return(user, nil).Maybe()
+
+		handler := Auth(keys, &revocationUsecase, sessionUsecase, userUsecase, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("success", func(t *testing.T) {
+		sessionUsecase := new(mocks.SessionUsecase)
+		sessionUsecase.On("Find", mock.Anything, "session").Return(&entity.Session{ID: "session", UserID: user.ID}, nil).Once()
+
+		assert.Equal(t, http.StatusOK, serve(sessionUsecase, "session").Code)
+		sessionUsecase.AssertExpectations(t)
+	})
+
+	t.Run("success-without-session", func(t *testing.T) {
+		sessionUsecase := new(mocks.SessionUsecase)
+
+		assert.Equal(t, http.StatusOK, serve(sessionUsecase, "").Code)
+		sessionUsecase.AssertNotCalled(t, "Find", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error-deleted-session", func(t *testing.T) {
+		sessionUsecase := new(mocks.SessionUsecase)
+		sessionUsecase.On("Find", mock.Anything, "session").Return(nil, apperrors.NewErrNotFound("session")).Once()
+
+		assert.Equal(t, http.StatusUnauthorized, serve(sessionUsecase, "session").Code)
+		sessionUsecase.AssertExpectations(t)
+	})
+
+	t.Run("error-session-of-other-user", func(t *testing.T) {
+		sessionUsecase := new(mocks.SessionUsecase)
+		sessionUsecase.On("Find", mock.Anything, "session").Return(&entity.Session{ID: "session", UserID: "987654321"}, nil).Once()
+
+		assert.Equal(t, http.StatusUnauthorized, serve(sessionUsecase, "session").Code)
+		sessionUsecase.AssertExpectations(t)
+	})
+}
+
+func TestAuthImpersonation(t *testing.T) {
+	keys := token.TestKeySet(t)
+	user := &entity.User{ID: "123456789", Status: entity.USER_STATUS_ACTIVE, Role: entity.USER_ROLE_USER}
+	admin := &entity.User{ID: "admin", Status: entity.USER_STATUS_ACTIVE, Role: entity.USER_ROLE_ADMIN}
+
+	serve := func(revocationRepo entity.TokenRevocationRepository, actor *entity.User, actorErr error) *httptest.ResponseRecorder {
+		accessToken, err := token.GenerateImpersonationToken(keys, time.Minute, user, admin.ID)
+		require.NoError(t, err)
+
+		revocationUsecase := revocation.NewTokenRevocationUsecase(revocationRepo, time.Minute, time.Second*2)
+		userUsecase := new(mocks.UserUsecase)
+		userUsecase.On("Find", mock.Anything, user.ID).Return(user, nil).Maybe()
+		userUsecase.On("Find", mock.Anything, admin.ID).Return(actor, actorErr).Maybe()
+
+		handler := Auth(keys, &revocationUsecase, new(mocks.SessionUsecase), userUsecase, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, admin.ID, GetActorID(r.Context()))
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("success", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(revocation.NewTokenRevocationRepositoryMemory(), admin, nil).Code)
+	})
+
+	t.Run("success-granted-permission", func(t *testing.T) {
+		actor := &entity.User{ID: admin.ID, Status: entity.USER_STATUS_ACTIVE, Role: entity.USER_ROLE_USER, Permissions: []string{entity.PERMISSION_USER_IMPERSONATE}}
+
+		assert.Equal(t, http.StatusOK, serve(revocation.NewTokenRevocationRepositoryMemory(), actor, nil).Code)
+	})
+
+	t.Run("error-actor-disabled", func(t *testing.T) {
+		actor := &entity.User{ID: admin.ID, Status: entity.USER_STATUS_SUSPENDED, Role: entity.USER_ROLE_ADMIN}
+
+		assert.Equal(t, http.StatusUnauthorized, serve(revocation.NewTokenRevocationRepositoryMemory(), actor, nil).Code)
+	})
+
+	t.Run("error-actor-without-permission", func(t *testing.T) {
+		actor := &entity.User{ID: admin.ID, Status: entity.USER_STATUS_ACTIVE, Role: entity.USER_ROLE_USER}
+
+		assert.Equal(t, http.StatusUnauthorized, serve(revocation.NewTokenRevocationRepositoryMemory(), actor, nil).Code)
+	})
+
+	t.Run("error-actor-deleted", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, serve(revocation.NewTokenRevocationRepositoryMemory(), nil, apperrors.NewErrNotFound("user")).Code)
+	})
+
+	t.Run("error-actor-revoked", func(t *testing.T) {
+		// tokens of the admin issued before the revocation are rejected
+		revocationRepo := revocation.NewTokenRevocationRepositoryMemory()
+		require.NoError(t, revocationRepo.StoreUser(context.TODO(), &entity.RevokedUser{
+			UserID:    admin.ID,
+			RevokedAt: time.Now().UTC().Add(time.Second),
+			ExpiresAt: time.Now().UTC().Add(time.Minute),
+		}))
+
+		assert.Equal(t, http.StatusUnauthorized, serve(revocationRepo, admin, nil).Code)
+	})
+}
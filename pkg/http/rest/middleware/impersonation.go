@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/response"
+	"net/http"
+)
+
+// GetActorID returns the admin impersonating the user, empty for requests without impersonation
+func GetActorID(ctx context.Context) string {
+	accessToken, ok := GetAccessToken(ctx)
+	if !ok {
+		return ""
+	}
+	return accessToken.ActorID
+}
+
+// NoImpersonation rejects requests made under impersonation, passwords, 2fa and credentials
+// are changed by the user only. It must be used after Auth middleware.
+func NoImpersonation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if GetActorID(r.Context()) != "" {
+			response.Error(w, r, errors.ErrForbidden, http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -1,29 +1,54 @@
 package middleware
 
 import (
+	"context"
 	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/response"
 	"go.uber.org/zap"
 	"net/http"
 	"time"
 )
 
+// request identity is filled in by Auth, so Logger knows who made the request
+type requestIdentity struct {
+	userID  string
+	actorID string
+}
+
+// set identity of the request for Logger, requests not passing Logger are ignored
+func setRequestIdentity(ctx context.Context, userID, actorID string) {
+	if identity, ok := ctx.Value("identity").(*requestIdentity); ok {
+		identity.userID = userID
+		identity.actorID = actorID
+	}
+}
+
 func Logger(logger *zap.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
 			start := time.Now()
 			rw := response.NewResponseWriter(w, http.StatusOK)
-			next.ServeHTTP(rw, r)
+			identity := &requestIdentity{}
+			next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), "identity", identity)))
 			end := time.Now()
 
-			logger.Info("request",
+			fields := []zap.Field{
 				zap.String("method", r.Method),
 				zap.String("remote_addr", r.RemoteAddr),
 				zap.String("route", r.URL.Path),
 				zap.String("request_id", GetReqID(r.Context())),
 				zap.Int("code", rw.StatusCode()),
 				zap.Duration("time", end.Sub(start)),
-			)
+			}
+			if identity.userID != "" {
+				fields = append(fields, zap.String("user_id", identity.userID))
+			}
+			// requests under impersonation name the admin too
+			if identity.actorID != "" {
+				fields = append(fields, zap.String("actor_id", identity.actorID), zap.Bool("impersonated", true))
+			}
+
+			logger.Info("request", fields...)
 		})
 	}
 }
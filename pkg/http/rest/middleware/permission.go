@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/response"
+	"github.com/go-chi/chi"
+	"net/http"
+)
+
+// Permission allows the request only when the authenticated user has the permission.
+// It must be used after Auth middleware.
+func Permission(permission string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := GetAuthUser(r.Context())
+			if !ok {
+				response.Error(w, r, errors.ErrUnauthorized, http.StatusUnauthorized)
+				return
+			}
+			if !user.HasPermission(permission) {
+				response.Error(w, r, errors.ErrForbidden, http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// PermissionOrSelf allows the request when the authenticated user has the permission
+// or when the url param holds the id of the authenticated user.
+func PermissionOrSelf(permission, param string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := GetAuthUser(r.Context())
+			if !ok {
+				response.Error(w, r, errors.ErrUnauthorized, http.StatusUnauthorized)
+				return
+			}
+			if !user.HasPermission(permission) && user.ID != chi.URLParam(r, param) {
+				response.Error(w, r, errors.ErrForbidden, http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	apperrors "github.com/Jamshid90/go-clean-architecture/pkg/errors"
 	"io"
+	"net"
 	"net/http"
 )
 
@@ -28,3 +29,12 @@ func DecodeJson(r *http.Request, v interface{}) error {
 	}
 	return nil
 }
+
+// ClientIP returns ip address of the remote side of the request without port
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -59,8 +59,6 @@ func GetStatusCodeErr(err error) int {
 	default:
 		return http.StatusInternalServerError
 	}
-
-	return 0
 }
 
 func Json(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
@@ -0,0 +1,326 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	OP_ADD     = "add"
+	OP_REMOVE  = "remove"
+	OP_REPLACE = "replace"
+	OP_MOVE    = "move"
+	OP_COPY    = "copy"
+	OP_TEST    = "test"
+)
+
+var (
+	// ErrInvalidOperation is a malformed operation or pointer
+	ErrInvalidOperation = errors.New("invalid patch operation")
+	// ErrPathNotFound is a path missing in the document
+	ErrPathNotFound = errors.New("path not found")
+	// ErrTestFailed is a test operation whose value differs from the document
+	ErrTestFailed = errors.New("test operation failed")
+)
+
+// Operation of json patch, RFC 6902
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Document returns json document of the value, objects are map[string]interface{}, arrays are []interface{}
+// and numbers are float64 like in documents decoded from request bodies
+func Document(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// MergePatch applies json merge patch to the document, RFC 7396. Null members of the patch remove
+// members of the document and the document is not modified.
+func MergePatch(doc, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	result := map[string]interface{}{}
+	if docObject, ok := doc.(map[string]interface{}); ok {
+		for name, value := range docObject {
+			result[name] = value
+		}
+	}
+
+	for name, value := range patchObject {
+		if value == nil {
+			delete(result, name)
+			continue
+		}
+		result[name] = MergePatch(result[name], value)
+	}
+	return result
+}
+
+// Apply applies operations of json patch to the document in order, RFC 6902.
+// The document is not modified and the patch is applied entirely or not at all.
+func Apply(doc interface{}, operations []Operation) (interface{}, error) {
+	result := deepCopy(doc)
+	for i, operation := range operations {
+		var err error
+		if result, err = apply(result, operation); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	return result, nil
+}
+
+func apply(doc interface{}, operation Operation) (interface{}, error) {
+	path, err := parsePointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case OP_ADD:
+		value, err := operationValue(operation)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case OP_REMOVE:
+		return remove(doc, path)
+	case OP_REPLACE:
+		value, err := operationValue(operation)
+		if err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return value, nil
+		}
+		if doc, err = remove(doc, path); err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case OP_MOVE, OP_COPY:
+		from, err := parsePointer(operation.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if operation.Op == OP_MOVE {
+			// a value can not be moved into itself
+			if len(path) > len(from) && reflect.DeepEqual(path[:len(from)], from) {
+				return nil, ErrInvalidOperation
+			}
+			if doc, err = remove(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			value = deepCopy(value)
+		}
+		return add(doc, path, value)
+	case OP_TEST:
+		value, err := operationValue(operation)
+		if err != nil {
+			return nil, err
+		}
+		current, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+	default:
+		return nil, ErrInvalidOperation
+	}
+}
+
+// value of the operation, add, replace and test require the value
+func operationValue(operation Operation) (interface{}, error) {
+	if len(operation.Value) == 0 {
+		return nil, ErrInvalidOperation
+	}
+	var value interface{}
+	if err := json.Unmarshal(operation.Value, &value); err != nil {
+		return nil, ErrInvalidOperation
+	}
+	return value, nil
+}
+
+// parse json pointer to reference tokens, RFC 6901
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, ErrInvalidOperation
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// index of the array element, "-" is the end of the array when end is allowed
+func arrayIndex(token string, length int, end bool) (int, error) {
+	if token == "-" && end {
+		return length, nil
+	}
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, ErrPathNotFound
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > length || (i == length && !end) {
+		return 0, ErrPathNotFound
+	}
+	return i, nil
+}
+
+func get(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch container := doc.(type) {
+		case map[string]interface{}:
+			value, ok := container[token]
+			if !ok {
+				return nil, ErrPathNotFound
+			}
+			doc = value
+		case []interface{}:
+			i, err := arrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = container[i]
+		default:
+			return nil, ErrPathNotFound
+		}
+	}
+	return doc, nil
+}
+
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	switch container := doc.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			container[path[0]] = value
+			return container, nil
+		}
+		child, ok := container[path[0]]
+		if !ok {
+			return nil, ErrPathNotFound
+		}
+		child, err := add(child, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		container[path[0]] = child
+		return container, nil
+	case []interface{}:
+		if len(path) == 1 {
+			i, err := arrayIndex(path[0], len(container), true)
+			if err != nil {
+				return nil, err
+			}
+			container = append(container, nil)
+			copy(container[i+1:], container[i:])
+			container[i] = value
+			return container, nil
+		}
+		i, err := arrayIndex(path[0], len(container), false)
+		if err != nil {
+			return nil, err
+		}
+		child, err := add(container[i], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		container[i] = child
+		return container, nil
+	default:
+		return nil, ErrPathNotFound
+	}
+}
+
+func remove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, ErrInvalidOperation
+	}
+
+	switch container := doc.(type) {
+	case map[string]interface{}:
+		child, ok := container[path[0]]
+		if !ok {
+			return nil, ErrPathNotFound
+		}
+		if len(path) == 1 {
+			delete(container, path[0])
+			return container, nil
+		}
+		child, err := remove(child, path[1:])
+		if err != nil {
+			return nil, err
+		}
+		container[path[0]] = child
+		return container, nil
+	case []interface{}:
+		i, err := arrayIndex(path[0], len(container), false)
+		if err != nil {
+			return nil, err
+		}
+		if len(path) == 1 {
+			return append(container[:i], container[i+1:]...), nil
+		}
+		child, err := remove(container[i], path[1:])
+		if err != nil {
+			return nil, err
+		}
+		container[i] = child
+		return container, nil
+	default:
+		return nil, ErrPathNotFound
+	}
+}
+
+// deep copy of the document, documents are modified in place while operations are applied
+func deepCopy(doc interface{}) interface{} {
+	switch value := doc.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for name, v := range value {
+			result[name] = deepCopy(v)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, v := range value {
+			result[i] = deepCopy(v)
+		}
+		return result
+	default:
+		return value
+	}
+}
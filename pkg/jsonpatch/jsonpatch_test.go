@@ -0,0 +1,108 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func testDocument(t *testing.T, data string) interface{} {
+	t.Helper()
+	var doc interface{}
+	require.NoError(t, json.Unmarshal([]byte(data), &doc))
+	return doc
+}
+
+func testOperations(t *testing.T, data string) []Operation {
+	t.Helper()
+	var operations []Operation
+	require.NoError(t, json.Unmarshal([]byte(data), &operations))
+	return operations
+}
+
+func TestMergePatch(t *testing.T) {
+	// examples of RFC 7396 appendix A
+	for _, tc := range []struct {
+		doc, patch, result string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	} {
+		doc := testDocument(t, tc.doc)
+		assert.Equal(t, testDocument(t, tc.result), MergePatch(doc, testDocument(t, tc.patch)), tc.patch)
+		assert.Equal(t, testDocument(t, tc.doc), doc, "document is not modified")
+	}
+}
+
+func TestApply(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// examples of RFC 6902 appendix A
+		for _, tc := range []struct {
+			doc, patch, result string
+		}{
+			{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+			{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+			{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+			{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+			{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+			{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+				`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+			{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+			{`{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+				`{"baz":"qux","foo":["a",2,"c"]}`},
+			{`{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`},
+			{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+			{`{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`},
+			{`{"foo":"bar"}`, `[{"op":"copy","from":"/foo","path":"/baz"}]`, `{"foo":"bar","baz":"bar"}`},
+			{`{"foo":null}`, `[{"op":"replace","path":"/foo","value":null}]`, `{"foo":null}`},
+		} {
+			doc := testDocument(t, tc.doc)
+			result, err := Apply(doc, testOperations(t, tc.patch))
+			if assert.NoError(t, err, tc.patch) {
+				assert.Equal(t, testDocument(t, tc.result), result, tc.patch)
+			}
+			assert.Equal(t, testDocument(t, tc.doc), doc, "document is not modified")
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		for _, tc := range []struct {
+			doc, patch string
+			err        error
+		}{
+			{`{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, ErrPathNotFound},
+			{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/2","value":"qux"}]`, ErrPathNotFound},
+			{`{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, ErrPathNotFound},
+			{`{"foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"qux"}]`, ErrPathNotFound},
+			{`{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, ErrTestFailed},
+			{`{"foo":"bar"}`, `[{"op":"add","path":"/baz"}]`, ErrInvalidOperation},
+			{`{"foo":"bar"}`, `[{"op":"unknown","path":"/baz"}]`, ErrInvalidOperation},
+			{`{"foo":"bar"}`, `[{"op":"remove","path":"foo"}]`, ErrInvalidOperation},
+			{`{"foo":{"bar":1}}`, `[{"op":"move","from":"/foo","path":"/foo/bar/baz"}]`, ErrInvalidOperation},
+		} {
+			_, err := Apply(testDocument(t, tc.doc), testOperations(t, tc.patch))
+			assert.True(t, errors.Is(err, tc.err), tc.patch)
+		}
+	})
+
+	t.Run("error-atomic", func(t *testing.T) {
+		doc := testDocument(t, `{"foo":"bar"}`)
+		_, err := Apply(doc, testOperations(t, `[{"op":"replace","path":"/foo","value":"baz"},{"op":"test","path":"/foo","value":"bar"}]`))
+
+		assert.Error(t, err)
+		assert.Equal(t, testDocument(t, `{"foo":"bar"}`), doc)
+	})
+}
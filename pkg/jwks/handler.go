@@ -0,0 +1,32 @@
+package jwks
+
+import (
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/response"
+	"github.com/Jamshid90/go-clean-architecture/pkg/token"
+	"github.com/go-chi/chi"
+	"net/http"
+)
+
+type JWKSHandler struct {
+	keys *token.KeySet
+}
+
+// New jwks handler, it publishes public keys for services verifying our tokens
+func NewJWKSHandler(r chi.Router, keys *token.KeySet) {
+	handler := JWKSHandler{
+		keys: keys,
+	}
+
+	r.Get("/.well-known/jwks.json", handler.jwks())
+}
+
+// jwks
+func (j *JWKSHandler) jwks() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		response.Json(w, r, 200, map[string]interface{}{
+			"keys": j.keys.JWKS(),
+		})
+	}
+}
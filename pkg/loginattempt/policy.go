@@ -0,0 +1,68 @@
+package loginattempt
+
+import (
+	"fmt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/config"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+// Policy of failed logins, after MaxFailures of an account or MaxIPFailures of an ip address
+// logins are locked out for Lockout, before that every failure doubles the delay starting from BaseDelay
+type Policy struct {
+	MaxFailures   int
+	MaxIPFailures int
+	BaseDelay     time.Duration
+	Lockout       time.Duration
+	Window        time.Duration
+}
+
+// New policy from configuration
+func NewPolicy(config *config.Config) (Policy, error) {
+	policy := Policy{
+		MaxFailures:   config.LoginAttempt.MaxFailures,
+		MaxIPFailures: config.LoginAttempt.MaxIPFailures,
+	}
+
+	if policy.MaxFailures <= 0 || policy.MaxIPFailures <= 0 {
+		return policy, fmt.Errorf("login attempt max failures must be positive")
+	}
+
+	var err error
+	if policy.BaseDelay, err = time.ParseDuration(config.LoginAttempt.BaseDelay); err != nil {
+		return policy, err
+	}
+	if policy.Lockout, err = time.ParseDuration(config.LoginAttempt.Lockout); err != nil {
+		return policy, err
+	}
+	if policy.Window, err = time.ParseDuration(config.LoginAttempt.Window); err != nil {
+		return policy, err
+	}
+
+	return policy, nil
+}
+
+// delay after the failure, capped by lockout
+func (p Policy) delay(failures int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < failures && delay < p.Lockout; i++ {
+		delay *= 2
+	}
+	if delay > p.Lockout {
+		return p.Lockout
+	}
+	return delay
+}
+
+// New login attempt repository by configured driver
+func NewLoginAttemptRepository(config *config.Config, dbpool *pgxpool.Pool) (entity.LoginAttemptRepository, error) {
+	switch config.LoginAttempt.Driver {
+	case "postgres", "":
+		return NewLoginAttemptRepositoryPgx(dbpool), nil
+	case "memory":
+		return NewLoginAttemptRepositoryMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown login attempt driver: %s", config.LoginAttempt.Driver)
+	}
+}
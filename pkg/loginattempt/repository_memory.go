@@ -0,0 +1,109 @@
+package loginattempt
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"sync"
+	"time"
+)
+
+// memoryLoginAttemptRepository keeps attempts in memory of the process,
+// it is meant for tests and a single instance deployment
+type memoryLoginAttemptRepository struct {
+	mu       sync.Mutex
+	attempts map[string]entity.LoginAttempt
+}
+
+func NewLoginAttemptRepositoryMemory() entity.LoginAttemptRepository {
+	return &memoryLoginAttemptRepository{attempts: map[string]entity.LoginAttempt{}}
+}
+
+func (m *memoryLoginAttemptRepository) Find(ctx context.Context, key string) (*entity.LoginAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	attempt, ok := m.attempts[key]
+	if !ok {
+		return nil, errors.NewErrNotFound("login attempt")
+	}
+	return &attempt, nil
+}
+
+func (m *memoryLoginAttemptRepository) Reserve(ctx context.Context, key string, now, resetBefore time.Time, maxFailures int) (*entity.LoginAttempt, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	attempt, ok := m.attempts[key]
+	if !ok {
+		attempt = entity.LoginAttempt{Key: key, LockedUntil: now}
+	}
+	reset := attempt.UpdatedAt.Before(resetBefore)
+	if attempt.LockedUntil.After(now) || (!reset && attempt.Failures >= maxFailures) {
+		return &attempt, false, nil
+	}
+	if reset {
+		attempt.Failures = 0
+	}
+	attempt.Failures++
+	attempt.UpdatedAt = now
+
+	m.attempts[key] = attempt
+	return &attempt, true, nil
+}
+
+func (m *memoryLoginAttemptRepository) Release(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if attempt, ok := m.attempts[key]; ok && attempt.Failures > 0 {
+		attempt.Failures--
+		m.attempts[key] = attempt
+	}
+	return nil
+}
+
+func (m *memoryLoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if attempt, ok := m.attempts[key]; ok && until.After(attempt.LockedUntil) {
+		attempt.LockedUntil = until
+		m.attempts[key] = attempt
+	}
+	return nil
+}
+
+func (m *memoryLoginAttemptRepository) Lockout(ctx context.Context, key string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if attempt, ok := m.attempts[key]; ok {
+		attempt.Failures = 0
+		if until.After(attempt.LockedUntil) {
+			attempt.LockedUntil = until
+		}
+		m.attempts[key] = attempt
+	}
+	return nil
+}
+
+func (m *memoryLoginAttemptRepository) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.attempts, key)
+	return nil
+}
+
+func (m *memoryLoginAttemptRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, attempt := range m.attempts {
+		if attempt.UpdatedAt.Before(before) && attempt.LockedUntil.Before(before) {
+			delete(m.attempts, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package loginattempt
+
+import (
+	"context"
+	"fmt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+type pgxLoginAttemptRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLoginAttemptRepositoryPgx(dbpool *pgxpool.Pool) entity.LoginAttemptRepository {
+	return &pgxLoginAttemptRepository{db: dbpool}
+}
+
+func (p *pgxLoginAttemptRepository) Find(ctx context.Context, key string) (*entity.LoginAttempt, error) {
+	attempt := entity.LoginAttempt{}
+	row := p.db.QueryRow(ctx, `SELECT key, failures, locked_until, updated_at FROM "login_attempt" WHERE key=$1`, key)
+
+	err := row.Scan(
+		&attempt.Key,
+		&attempt.Failures,
+		&attempt.LockedUntil,
+		&attempt.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, errors.NewErrNotFound("login attempt")
+	}
+
+	if err != nil {
+		return nil, errors.ErrRepository{Err: fmt.Errorf("error during find to login attempt repository: %w", err)}
+	}
+
+	return &attempt, nil
+}
+
+// reserve counts an attempt of the key in one statement when the key is not locked at now and has less than
+// max failures, so parallel attempts can not pass together. Failures not updated since reset before start over.
+// The attempt is returned as well when it was not reserved.
+func (p *pgxLoginAttemptRepository) Reserve(ctx context.Context, key string, now, resetBefore time.Time, maxFailures int) (*entity.LoginAttempt, bool, error) {
+	attempt := entity.LoginAttempt{Key: key}
+	row := p.db.QueryRow(ctx, `INSERT INTO "login_attempt"(key, failures, locked_until, updated_at)
+		VALUES ($1, 1, $2, $2)
+		ON CONFLICT (key) DO UPDATE SET
+			failures=CASE WHEN "login_attempt".updated_at < $3 THEN 1 ELSE "login_attempt".failures+1 END,
+			updated_at=$2
+		WHERE "login_attempt".locked_until <= $2 AND ("login_attempt".updated_at < $3 OR "login_attempt".failures < $4)
+		RETURNING failures, locked_until, updated_at`,
+		key,
+		now,
+		resetBefore,
+		maxFailures,
+	)
+
+	err := row.Scan(&attempt.Failures, &attempt.LockedUntil, &attempt.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		// the key is locked or its attempts are reserved
+		found, err := p.Find(ctx, key)
+		if err != nil {
+			if _, ok := err.(*errors.ErrNotFound); ok {
+				return &attempt, false, nil
+			}
+			return nil, false, err
+		}
+		return found, false, nil
+	}
+
+	if err != nil {
+		return nil, false, errors.ErrRepository{Err: fmt.Errorf("error during reserve to login attempt repository: %w", err)}
+	}
+
+	return &attempt, true, nil
+}
+
+// release gives back a reserved attempt of the key
+func (p *pgxLoginAttemptRepository) Release(ctx context.Context, key string) error {
+	if _, err := p.db.Exec(ctx, `UPDATE "login_attempt" SET failures=GREATEST(failures-1, 0) WHERE key=$1`, key); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during release to login attempt repository: %w", err)}
+	}
+	return nil
+}
+
+// lock locks the key out until, a later lock is kept
+func (p *pgxLoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	if _, err := p.db.Exec(ctx, `UPDATE "login_attempt" SET locked_until=GREATEST(locked_until, $2) WHERE key=$1`, key, until); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during lock to login attempt repository: %w", err)}
+	}
+	return nil
+}
+
+// lockout locks the key out until and forgets its failures, attempts start over after the lockout
+func (p *pgxLoginAttemptRepository) Lockout(ctx context.Context, key string, until time.Time) error {
+	if _, err := p.db.Exec(ctx, `UPDATE "login_attempt" SET failures=0, locked_until=GREATEST(locked_until, $2) WHERE key=$1`, key, until); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during lockout to login attempt repository: %w", err)}
+	}
+	return nil
+}
+
+func (p *pgxLoginAttemptRepository) Delete(ctx context.Context, key string) error {
+	if _, err := p.db.Exec(ctx, `DELETE FROM "login_attempt" WHERE key=$1`, key); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during delete to login attempt repository: %w", err)}
+	}
+	return nil
+}
+
+// delete expired removes attempts which are not locked and were not updated since before
+func (p *pgxLoginAttemptRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	if _, err := p.db.Exec(ctx, `DELETE FROM "login_attempt" WHERE updated_at < $1 AND locked_until < $1`, before); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during delete expired to login attempt repository: %w", err)}
+	}
+	return nil
+}
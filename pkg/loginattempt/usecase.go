@@ -0,0 +1,238 @@
+package loginattempt
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"strings"
+	"time"
+)
+
+type loginAttemptUsecase struct {
+	loginAttemptRepo entity.LoginAttemptRepository
+	policy           Policy
+	contextTimeout   time.Duration
+}
+
+// New login attempt usecase
+func NewLoginAttemptUsecase(repo entity.LoginAttemptRepository, policy Policy, timeout time.Duration) loginAttemptUsecase {
+	return loginAttemptUsecase{
+		loginAttemptRepo: repo,
+		policy:           policy,
+		contextTimeout:   timeout,
+	}
+}
+
+// Reserve counts login of the email from the ip address as failed before the password is checked, so parallel logins
+// can not pass together before their failures are counted. It returns how long logins have to wait when the attempt
+// is not reserved, zero when reserved. Fail locks reserved attempts and Succeed undoes them.
+func (l *loginAttemptUsecase) Reserve(ctx context.Context, email, ip string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.contextTimeout)
+	defer cancel()
+
+	return l.reserve(ctx, keys(email, ip)...)
+}
+
+// ReserveKey counts an attempt of the key as failed before it is checked like Reserve does for logins,
+// FailKey locks the reserved attempt and SucceedKey forgets failures of the key
+func (l *loginAttemptUsecase) ReserveKey(ctx context.Context, key string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.contextTimeout)
+	defer cancel()
+
+	return l.reserve(ctx, key)
+}
+
+func (l *loginAttemptUsecase) reserve(ctx context.Context, keys ...string) (time.Duration, error) {
+	now := time.Now().UTC()
+	if err := l.loginAttemptRepo.DeleteExpired(ctx, now.Add(-l.policy.Window)); err != nil {
+		return 0, err
+	}
+
+	var reserved []string
+	for _, key := range keys {
+		// failures are forgotten after the window without new ones
+		attempt, ok, err := l.loginAttemptRepo.Reserve(ctx, key, now, now.Add(-l.policy.Window), l.maxFailures(key))
+		if err != nil {
+			return 0, err
+		}
+
+		if !ok {
+			// the rejected login is not counted
+			if err := l.release(ctx, reserved...); err != nil {
+				return 0, err
+			}
+
+			retryAfter := attempt.LockedUntil.Sub(now)
+			if retryAfter <= 0 {
+				// parallel logins reserved the remaining attempts, they lock out when they fail
+				retryAfter = l.policy.BaseDelay
+			}
+			return retryAfter, nil
+		}
+
+		reserved = append(reserved, key)
+	}
+
+	return 0, nil
+}
+
+// CheckKey returns how long attempts of the key have to wait, keys of other usecases like
+// "phone:..." must not clash with "email:..." and "ip:..."
+func (l *loginAttemptUsecase) CheckKey(ctx context.Context, key string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.contextTimeout)
+	defer cancel()
+
+	return l.check(ctx, key)
+}
+
+// Fail locks logins of the email from the ip address after the failure reserved by Reserve, it returns true when
+// the failure locked logins out
+func (l *loginAttemptUsecase) Fail(ctx context.Context, email, ip string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.contextTimeout)
+	defer cancel()
+
+	return l.fail(ctx, keys(email, ip)...)
+}
+
+// FailKey locks attempts of the key after the failure reserved by ReserveKey, it returns true when the failure
+// locked the key out
+func (l *loginAttemptUsecase) FailKey(ctx context.Context, key string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.contextTimeout)
+	defer cancel()
+
+	return l.fail(ctx, key)
+}
+
+func (l *loginAttemptUsecase) fail(ctx context.Context, keys ...string) (bool, error) {
+	now := time.Now().UTC()
+	lockedOut := false
+	for _, key := range keys {
+		attempt, err := l.find(ctx, key)
+		if err != nil {
+			return false, err
+		}
+
+		// the lockout forgets failures, so attempts start over after it
+		if attempt.Failures >= l.maxFailures(key) {
+			if err := l.loginAttemptRepo.Lockout(ctx, key, now.Add(l.policy.Lockout)); err != nil {
+				return false, err
+			}
+			lockedOut = true
+			continue
+		}
+
+		if err := l.loginAttemptRepo.Lock(ctx, key, now.Add(l.policy.delay(attempt.Failures))); err != nil {
+			return false, err
+		}
+	}
+
+	return lockedOut, nil
+}
+
+// CountKey counts an event of the key like sent codes within the window, it returns false without counting when
+// the key has max events in the window already. Unlike FailKey the key is never locked.
+func (l *loginAttemptUsecase) CountKey(ctx context.Context, key string, max int) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.contextTimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	if err := l.loginAttemptRepo.DeleteExpired(ctx, now.Add(-l.policy.Window)); err != nil {
+		return false, err
+	}
+
+	_, ok, err := l.loginAttemptRepo.Reserve(ctx, key, now, now.Add(-l.policy.Window), max)
+	return ok, err
+}
+
+func (l *loginAttemptUsecase) check(ctx context.Context, keys ...string) (time.Duration, error) {
+	now := time.Now().UTC()
+	var retryAfter time.Duration
+	for _, key := range keys {
+		attempt, err := l.find(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+
+		if wait := attempt.LockedUntil.Sub(now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	return retryAfter, nil
+}
+
+// Succeed forgets failures of the account and undoes the attempt reserved for the ip address,
+// other failures of the ip address are kept
+func (l *loginAttemptUsecase) Succeed(ctx context.Context, email, ip string) error {
+	ctx, cancel := context.WithTimeout(ctx, l.contextTimeout)
+	defer cancel()
+
+	if err := l.loginAttemptRepo.Delete(ctx, emailKey(email)); err != nil {
+		return err
+	}
+
+	if ip == "" {
+		return nil
+	}
+	return l.release(ctx, ipKey(ip))
+}
+
+// SucceedKey forgets failures of the key after the attempt reserved by ReserveKey passed
+func (l *loginAttemptUsecase) SucceedKey(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, l.contextTimeout)
+	defer cancel()
+
+	return l.loginAttemptRepo.Delete(ctx, key)
+}
+
+// Unlock removes lockout of the account
+func (l *loginAttemptUsecase) Unlock(ctx context.Context, email string) error {
+	ctx, cancel := context.WithTimeout(ctx, l.contextTimeout)
+	defer cancel()
+
+	return l.loginAttemptRepo.Delete(ctx, emailKey(email))
+}
+
+// find returns new attempt when the key has no failures
+func (l *loginAttemptUsecase) find(ctx context.Context, key string) (*entity.LoginAttempt, error) {
+	attempt, err := l.loginAttemptRepo.Find(ctx, key)
+	if err != nil {
+		if _, ok := err.(*errors.ErrNotFound); ok {
+			return &entity.LoginAttempt{Key: key}, nil
+		}
+		return nil, err
+	}
+	return attempt, nil
+}
+
+func (l *loginAttemptUsecase) release(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		if err := l.loginAttemptRepo.Release(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *loginAttemptUsecase) maxFailures(key string) int {
+	if strings.HasPrefix(key, "ip:") {
+		return l.policy.MaxIPFailures
+	}
+	return l.policy.MaxFailures
+}
+
+func emailKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// keys of attempts, requests without known ip address are counted only by email
+func keys(email, ip string) []string {
+	if ip == "" {
+		return []string{emailKey(email)}
+	}
+	return []string{emailKey(email), ipKey(ip)}
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
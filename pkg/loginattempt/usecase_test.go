@@ -0,0 +1,245 @@
+package loginattempt
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testPolicy() Policy {
+	return Policy{
+		MaxFailures:   3,
+		MaxIPFailures: 5,
+		BaseDelay:     time.Second,
+		Lockout:       time.Minute * 15,
+		Window:        time.Hour,
+	}
+}
+
+// seed counts failures of the key reserved at the time
+func seed(t *testing.T, repo entity.LoginAttemptRepository, key string, failures int, at time.Time) {
+	t.Helper()
+
+	for i := 0; i < failures; i++ {
+		_, ok, err := repo.Reserve(context.TODO(), key, at, time.Time{}, failures)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+}
+
+func TestFail(t *testing.T) {
+	repo := NewLoginAttemptRepositoryMemory()
+	usecase := NewLoginAttemptUsecase(repo, testPolicy(), time.Second*2)
+	ctx := context.TODO()
+
+	t.Run("backoff", func(t *testing.T) {
+		retryAfter, err := usecase.Reserve(ctx, "user@mail.com", "127.0.0.1")
+		require.NoError(t, err)
+		assert.Zero(t, retryAfter)
+
+		lockedOut, err := usecase.Fail(ctx, "user@mail.com", "127.0.0.1")
+		require.NoError(t, err)
+		assert.False(t, lockedOut)
+
+		retryAfter, err = usecase.Reserve(ctx, "User@Mail.com", "10.0.0.1")
+		require.NoError(t, err)
+		assert.True(t, retryAfter > 0 && retryAfter <= time.Second)
+
+		// the rejected login is not counted
+		attempt, err := repo.Find(ctx, "email:user@mail.com")
+		require.NoError(t, err)
+		assert.Equal(t, 1, attempt.Failures)
+	})
+
+	t.Run("lockout", func(t *testing.T) {
+		// the delay after the first failure has passed
+		for _, key := range []string{"email:user@mail.com", "ip:127.0.0.1"} {
+			require.NoError(t, repo.Delete(ctx, key))
+			seed(t, repo, key, 1, time.Now().UTC())
+		}
+
+		for i := 0; i < 2; i++ {
+			retryAfter, err := usecase.Reserve(ctx, "user@mail.com", "127.0.0.1")
+			require.NoError(t, err)
+			assert.Zero(t, retryAfter)
+		}
+
+		lockedOut, err := usecase.Fail(ctx, "user@mail.com", "127.0.0.1")
+		require.NoError(t, err)
+		assert.True(t, lockedOut)
+
+		retryAfter, err := usecase.Reserve(ctx, "user@mail.com", "127.0.0.1")
+		require.NoError(t, err)
+		assert.True(t, retryAfter > time.Minute*14)
+	})
+
+	t.Run("unlock", func(t *testing.T) {
+		require.NoError(t, usecase.Unlock(ctx, "user@mail.com"))
+
+		retryAfter, err := usecase.Reserve(ctx, "user@mail.com", "10.0.0.1")
+		require.NoError(t, err)
+		assert.Zero(t, retryAfter)
+
+		// failures of the ip address are kept
+		retryAfter, err = usecase.Reserve(ctx, "other@mail.com", "127.0.0.1")
+		require.NoError(t, err)
+		assert.True(t, retryAfter > 0)
+	})
+}
+
+func TestSucceed(t *testing.T) {
+	repo := NewLoginAttemptRepositoryMemory()
+	usecase := NewLoginAttemptUsecase(repo, testPolicy(), time.Second*2)
+	ctx := context.TODO()
+
+	seed(t, repo, "ip:127.0.0.1", 2, time.Now().UTC())
+
+	retryAfter, err := usecase.Reserve(ctx, "user@mail.com", "127.0.0.1")
+	require.NoError(t, err)
+	assert.Zero(t, retryAfter)
+
+	require.NoError(t, usecase.Succeed(ctx, "user@mail.com", "127.0.0.1"))
+
+	_, err = repo.Find(ctx, "email:user@mail.com")
+	assert.Error(t, err)
+
+	// the reserved attempt is undone, earlier failures of the ip address are kept
+	attempt, err := repo.Find(ctx, "ip:127.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempt.Failures)
+}
+
+func TestReserveWindow(t *testing.T) {
+	repo := NewLoginAttemptRepositoryMemory()
+	usecase := NewLoginAttemptUsecase(repo, testPolicy(), time.Second*2)
+	ctx := context.TODO()
+
+	seed(t, repo, "email:user@mail.com", 3, time.Now().UTC().Add(-time.Hour*2))
+
+	retryAfter, err := usecase.Reserve(ctx, "user@mail.com", "")
+	require.NoError(t, err)
+	assert.Zero(t, retryAfter)
+
+	attempt, err := repo.Find(ctx, "email:user@mail.com")
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempt.Failures)
+}
+
+func TestReserveKeyWindow(t *testing.T) {
+	repo := NewLoginAttemptRepositoryMemory()
+	usecase := NewLoginAttemptUsecase(repo, testPolicy(), time.Second*2)
+	ctx := context.TODO()
+
+	seed(t, repo, "phone:+998901234567", 2, time.Now().UTC().Add(-time.Hour*2))
+
+	retryAfter, err := usecase.ReserveKey(ctx, "phone:+998901234567")
+	require.NoError(t, err)
+	assert.Zero(t, retryAfter)
+
+	lockedOut, err := usecase.FailKey(ctx, "phone:+998901234567")
+	require.NoError(t, err)
+	assert.False(t, lockedOut)
+
+	attempt, err := repo.Find(ctx, "phone:+998901234567")
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempt.Failures)
+}
+
+func TestPolicyDelay(t *testing.T) {
+	policy := testPolicy()
+
+	assert.Equal(t, time.Second, policy.delay(1))
+	assert.Equal(t, time.Second*4, policy.delay(3))
+	assert.Equal(t, policy.Lockout, policy.delay(100))
+}
+
+func TestReserveConcurrent(t *testing.T) {
+	repo := NewLoginAttemptRepositoryMemory()
+	usecase := NewLoginAttemptUsecase(repo, testPolicy(), time.Second*2)
+	ctx := context.TODO()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		reserved int
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			retryAfter, err := usecase.Reserve(ctx, "user@mail.com", "127.0.0.1")
+			assert.NoError(t, err)
+			if retryAfter == 0 {
+				mu.Lock()
+				reserved++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// only max failures pass before any failure is locked
+	assert.Equal(t, 3, reserved)
+
+	attempt, err := repo.Find(ctx, "ip:127.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempt.Failures)
+}
+
+func TestReserveKeyConcurrent(t *testing.T) {
+	repo := NewLoginAttemptRepositoryMemory()
+	usecase := NewLoginAttemptUsecase(repo, testPolicy(), time.Second*2)
+	ctx := context.TODO()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		reserved int
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			retryAfter, err := usecase.ReserveKey(ctx, "phone:+998901234567")
+			assert.NoError(t, err)
+			if retryAfter == 0 {
+				mu.Lock()
+				reserved++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// only max failures pass before any failure is locked
+	assert.Equal(t, 3, reserved)
+
+	attempt, err := repo.Find(ctx, "phone:+998901234567")
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempt.Failures)
+}
+
+func TestCountKey(t *testing.T) {
+	repo := NewLoginAttemptRepositoryMemory()
+	usecase := NewLoginAttemptUsecase(repo, testPolicy(), time.Second*2)
+	ctx := context.TODO()
+
+	for i := 0; i < 2; i++ {
+		counted, err := usecase.CountKey(ctx, "phone_send:+998901234567", 2)
+		require.NoError(t, err)
+		assert.True(t, counted)
+	}
+
+	counted, err := usecase.CountKey(ctx, "phone_send:+998901234567", 2)
+	require.NoError(t, err)
+	assert.False(t, counted)
+
+	// counted events do not lock the key
+	retryAfter, err := usecase.CheckKey(ctx, "phone_send:+998901234567")
+	require.NoError(t, err)
+	assert.Zero(t, retryAfter)
+}
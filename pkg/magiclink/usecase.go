@@ -0,0 +1,100 @@
+package magiclink
+
+import (
+	"context"
+	"fmt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
+	"github.com/Jamshid90/go-clean-architecture/pkg/mailer"
+	"github.com/Jamshid90/go-clean-architecture/pkg/rand"
+	"time"
+)
+
+type magicLinkUsecase struct {
+	tokenRepo      entity.UserTokenRepository
+	mailer         mailer.Mailer
+	ttl            time.Duration
+	url            string
+	maxRequests    int
+	window         time.Duration
+	contextTimeout time.Duration
+}
+
+// New magic link usecase, url is the link prefix the token is appended to,
+// at most maxRequests links are sent to the user within the window
+func NewMagicLinkUsecase(repo entity.UserTokenRepository, mailer mailer.Mailer, ttl time.Duration, url string, maxRequests int, window time.Duration, timeout time.Duration) magicLinkUsecase {
+	return magicLinkUsecase{
+		tokenRepo:      repo,
+		mailer:         mailer,
+		ttl:            ttl,
+		url:            url,
+		maxRequests:    maxRequests,
+		window:         window,
+		contextTimeout: timeout,
+	}
+}
+
+// Send mails a new login link, ErrTooManyRequests is returned when the limit of the user is reached
+func (m *magicLinkUsecase) Send(ctx context.Context, user *entity.User) error {
+	ctx, cancel := context.WithTimeout(ctx, m.contextTimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+
+	// expired tokens are kept for the window, they are counted by the limit
+	if err := m.tokenRepo.DeleteExpired(ctx, now.Add(-m.window)); err != nil {
+		return err
+	}
+
+	count, err := m.tokenRepo.CountByUserIdSince(ctx, user.ID, now.Add(-m.window))
+	if err != nil {
+		return err
+	}
+
+	if count >= m.maxRequests {
+		return errors.ErrTooManyRequests
+	}
+
+	token, err := rand.Token(32)
+	if err != nil {
+		return err
+	}
+
+	if err := m.tokenRepo.Store(ctx, &entity.UserToken{
+		UserID:    user.ID,
+		Token:     hash.HashToken(token),
+		ExpiresAt: now.Add(m.ttl),
+		CreatedAt: now,
+	}); err != nil {
+		return err
+	}
+
+	return m.mailer.Send(ctx, &mailer.Message{
+		To:      user.Email,
+		Subject: "Your login link",
+		Body: fmt.Sprintf("Hello %s,\n\nfollow the link to log in to your account:\n%s%s\n\nThe link can be used once and expires in %s. If it was not you, ignore this email.\n",
+			user.FirstName, m.url, token, m.ttl),
+	})
+}
+
+// Consume invalidates the token and returns id of the user it was issued for
+func (m *magicLinkUsecase) Consume(ctx context.Context, token string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.contextTimeout)
+	defer cancel()
+
+	// token is single use, it is kept as used so it is counted by the limit of the user
+	magicLinkToken, err := m.tokenRepo.Use(ctx, hash.HashToken(token), time.Now().UTC())
+	if err != nil {
+		if _, ok := err.(*errors.ErrNotFound); ok {
+			return "", &errors.ErrBadRequest{Err: err, Message: errors.ErrInvalidOrExpiredToken.Error()}
+		}
+		return "", err
+	}
+
+	if time.Now().UTC().After(magicLinkToken.ExpiresAt) {
+		return "", &errors.ErrBadRequest{Err: errors.ErrInvalidOrExpiredToken, Message: errors.ErrInvalidOrExpiredToken.Error()}
+	}
+
+	return magicLinkToken.UserID, nil
+}
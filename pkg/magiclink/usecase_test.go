@@ -0,0 +1,102 @@
+package magiclink
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity/mocks"
+	apperrors "github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
+	"github.com/Jamshid90/go-clean-architecture/pkg/mailer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSend(t *testing.T) {
+	user := &entity.User{ID: "123456789", Email: "user@inifo.com", FirstName: "User"}
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo := new(mocks.UserTokenRepository)
+		memoryMailer := mailer.NewMemoryMailer()
+		usecase := NewMagicLinkUsecase(mockRepo, memoryMailer, time.Minute*15, "http://localhost/login?token=", 3, time.Hour, time.Second*2)
+
+		mockRepo.On("DeleteExpired", mock.Anything, mock.AnythingOfType("time.Time")).Return(nil).Once()
+		mockRepo.On("CountByUserIdSince", mock.Anything, user.ID, mock.AnythingOfType("time.Time")).Return(2, nil).Once()
+		mockRepo.On("Store", mock.Anything, mock.AnythingOfType("*entity.UserToken")).Return(nil).Once()
+
+		err := usecase.Send(context.TODO(), user)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+
+		// the mailed token is stored as hash only
+		message := memoryMailer.Last()
+		assert.Equal(t, user.Email, message.To)
+		start := strings.Index(message.Body, "token=") + len("token=")
+		token := message.Body[start : start+strings.IndexByte(message.Body[start:], '\n')]
+		stored := mockRepo.Calls[2].Arguments.Get(1).(*entity.UserToken)
+		assert.Equal(t, hash.HashToken(token), stored.Token)
+	})
+
+	t.Run("error-too-many-requests", func(t *testing.T) {
+		mockRepo := new(mocks.UserTokenRepository)
+		memoryMailer := mailer.NewMemoryMailer()
+		usecase := NewMagicLinkUsecase(mockRepo, memoryMailer, time.Minute*15, "", 3, time.Hour, time.Second*2)
+
+		mockRepo.On("DeleteExpired", mock.Anything, mock.AnythingOfType("time.Time")).Return(nil).Once()
+		mockRepo.On("CountByUserIdSince", mock.Anything, user.ID, mock.AnythingOfType("time.Time")).Return(3, nil).Once()
+
+		err := usecase.Send(context.TODO(), user)
+
+		assert.Equal(t, apperrors.ErrTooManyRequests, err)
+		assert.Empty(t, memoryMailer.Messages())
+		mockRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestConsume(t *testing.T) {
+	mockRepo := new(mocks.UserTokenRepository)
+	usecase := NewMagicLinkUsecase(mockRepo, mailer.NewMemoryMailer(), time.Minute*15, "", 3, time.Hour, time.Second*2)
+
+	t.Run("success", func(t *testing.T) {
+		magicLinkToken := &entity.UserToken{
+			UserID:    "123456789",
+			Token:     hash.HashToken("token"),
+			ExpiresAt: time.Now().UTC().Add(time.Minute),
+		}
+		mockRepo.On("Use", mock.Anything, hash.HashToken("token"), mock.AnythingOfType("time.Time")).Return(magicLinkToken, nil).Once()
+
+		userID, err := usecase.Consume(context.TODO(), "token")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "123456789", userID)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-expired", func(t *testing.T) {
+		magicLinkToken := &entity.UserToken{
+			UserID:    "123456789",
+			Token:     hash.HashToken("token"),
+			ExpiresAt: time.Now().UTC().Add(-time.Minute),
+		}
+		mockRepo.On("Use", mock.Anything, hash.HashToken("token"), mock.AnythingOfType("time.Time")).Return(magicLinkToken, nil).Once()
+
+		_, err := usecase.Consume(context.TODO(), "token")
+
+		assert.Equal(t, apperrors.ErrInvalidOrExpiredToken.Error(), err.Error())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-not-found", func(t *testing.T) {
+		// unknown and used tokens are not found
+		mockRepo.On("Use", mock.Anything, hash.HashToken("unknown"), mock.AnythingOfType("time.Time")).Return(nil, apperrors.NewErrNotFound("magic link token")).Once()
+
+		_, err := usecase.Consume(context.TODO(), "unknown")
+
+		assert.IsType(t, &apperrors.ErrBadRequest{}, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,34 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// fileMailer writes every message to its own file in the directory
+type fileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) (Mailer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error during create mail directory: %w", err)
+	}
+	return &fileMailer{dir: dir, from: from}, nil
+}
+
+func (m *fileMailer) Send(ctx context.Context, message *Message) error {
+	name := fmt.Sprintf("%d.eml", time.Now().UnixNano())
+	content := fmt.Sprintf("From: %s\nTo: %s\nSubject: %s\n\n%s\n", m.from, message.To, message.Subject, message.Body)
+
+	if err := ioutil.WriteFile(filepath.Join(m.dir, name), []byte(content), 0644); err != nil {
+		return fmt.Errorf("error during write mail file: %w", err)
+	}
+
+	return nil
+}
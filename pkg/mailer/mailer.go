@@ -0,0 +1,40 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/config"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, message *Message) error
+}
+
+// New mailer by configured driver, the driver is required since memory mailer drops the mail
+func NewMailer(config *config.Config) (Mailer, error) {
+	switch config.Mailer.Driver {
+	case "":
+		return nil, errors.New("mailer driver is required")
+	case "smtp":
+		return NewSMTPMailer(
+			config.Mailer.Host,
+			config.Mailer.Port,
+			config.Mailer.Username,
+			config.Mailer.Password,
+			config.Mailer.From,
+		), nil
+	case "file":
+		return NewFileMailer(config.Mailer.Dir, config.Mailer.From)
+	case "memory":
+		return NewMemoryMailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer driver: %s", config.Mailer.Driver)
+	}
+}
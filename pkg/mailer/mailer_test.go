@@ -0,0 +1,29 @@
+package mailer
+
+import (
+	"github.com/Jamshid90/go-clean-architecture/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewMailer(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		cfg := &config.Config{}
+		cfg.Mailer.Driver = "memory"
+
+		m, err := NewMailer(cfg)
+		assert.NoError(t, err)
+		assert.NotNil(t, m)
+	})
+	t.Run("error-without-driver", func(t *testing.T) {
+		_, err := NewMailer(&config.Config{})
+		assert.Error(t, err)
+	})
+	t.Run("error-unknown-driver", func(t *testing.T) {
+		cfg := &config.Config{}
+		cfg.Mailer.Driver = "carrier-pigeon"
+
+		_, err := NewMailer(cfg)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,51 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"strings"
+)
+
+type smtpMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// New smtp mailer, auth is skipped when username is empty
+func NewSMTPMailer(host, port, username, password, from string) Mailer {
+	m := &smtpMailer{
+		addr: host + ":" + port,
+		from: from,
+	}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+func (m *smtpMailer) Send(ctx context.Context, message *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := smtp.SendMail(m.addr, m.auth, m.from, []string{message.To}, m.build(message)); err != nil {
+		return fmt.Errorf("error during send mail: %w", err)
+	}
+
+	return nil
+}
+
+// build rfc 822 message
+func (m *smtpMailer) build(message *Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + m.from + "\r\n")
+	b.WriteString("To: " + message.To + "\r\n")
+	b.WriteString("Subject: " + message.Subject + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(message.Body)
+	return []byte(b.String())
+}
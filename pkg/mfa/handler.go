@@ -0,0 +1,213 @@
+package mfa
+
+import (
+	"encoding/base64"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/middleware"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/request"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/response"
+	"github.com/Jamshid90/go-clean-architecture/pkg/validation"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+type MFAHandler struct {
+	logger              *zap.Logger
+	userUsecase         entity.UserUsecase
+	mfaUsecase          entity.MFAUsecase
+	loginAttemptUsecase entity.LoginAttemptUsecase
+}
+
+// New mfa handler, codes are checked with the same attempt limits as logins
+func NewMFAHandler(r chi.Router, mfaUsecase entity.MFAUsecase, userUsecase entity.UserUsecase, loginAttemptUsecase entity.LoginAttemptUsecase, auth func(http.Handler) http.Handler, logger *zap.Logger) {
+	handler := MFAHandler{
+		logger:              logger,
+		userUsecase:         userUsecase,
+		mfaUsecase:          mfaUsecase,
+		loginAttemptUsecase: loginAttemptUsecase,
+	}
+
+	r.Group(func(r chi.Router) {
+		r.Use(auth)
+		r.Use(middleware.NoAPIKey)
+		r.Use(middleware.NoImpersonation)
+		r.Post("/auth/mfa/enroll", handler.enroll())
+		r.Post("/auth/mfa/confirm", handler.confirm())
+		r.Post("/auth/mfa/disable", handler.disable())
+	})
+}
+
+// enroll
+func (m *MFAHandler) enroll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authUser, ok := middleware.GetAuthUser(r.Context())
+		if !ok {
+			response.Error(w, r, errors.ErrUnauthorized, http.StatusUnauthorized)
+			return
+		}
+
+		ctx := r.Context()
+		user, err := m.userUsecase.Find(ctx, authUser.ID)
+		if err != nil {
+			m.logger.Error("mfa enroll find user", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		enrollment, err := m.mfaUsecase.Enroll(ctx, user)
+		if err != nil {
+			m.logger.Error("mfa enroll", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+			"data": map[string]string{
+				"secret":  enrollment.Secret,
+				"uri":     enrollment.URI,
+				"qr_code": "data:image/png;base64," + base64.StdEncoding.EncodeToString(enrollment.QRCode),
+			},
+		})
+	}
+}
+
+// confirm
+func (m *MFAHandler) confirm() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var codeRequest CodeRequest
+		if err := request.DecodeJson(r, &codeRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := validation.Validator(&codeRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		authUser, ok := middleware.GetAuthUser(r.Context())
+		if !ok {
+			response.Error(w, r, errors.ErrUnauthorized, http.StatusUnauthorized)
+			return
+		}
+
+		ctx := r.Context()
+		user, ok := m.reserve(w, r, authUser.ID)
+		if !ok {
+			return
+		}
+
+		codes, err := m.mfaUsecase.Confirm(ctx, user.ID, codeRequest.Code)
+		m.checked(r, user, err)
+		if err != nil {
+			m.logger.Error("mfa confirm", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+			"data": map[string]interface{}{
+				"recovery_codes": codes,
+			},
+		})
+	}
+}
+
+// disable
+func (m *MFAHandler) disable() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var codeRequest CodeRequest
+		if err := request.DecodeJson(r, &codeRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := validation.Validator(&codeRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		authUser, ok := middleware.GetAuthUser(r.Context())
+		if !ok {
+			response.Error(w, r, errors.ErrUnauthorized, http.StatusUnauthorized)
+			return
+		}
+
+		ctx := r.Context()
+		user, ok := m.reserve(w, r, authUser.ID)
+		if !ok {
+			return
+		}
+
+		err := m.mfaUsecase.Disable(ctx, user.ID, codeRequest.Code)
+		m.checked(r, user, err)
+		if err != nil {
+			m.logger.Error("mfa disable", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+		})
+	}
+}
+
+// reserve counts the code check like a login of the user before the code is checked, so codes can not be
+// guessed with a stolen access token. The response is written when the check can not be made.
+func (m *MFAHandler) reserve(w http.ResponseWriter, r *http.Request, userID string) (*entity.User, bool) {
+	ctx := r.Context()
+	user, err := m.userUsecase.Find(ctx, userID)
+	if err != nil {
+		m.logger.Error("mfa find user", zap.Error(err))
+		response.Error(w, r, err, response.GetStatusCodeErr(err))
+		return nil, false
+	}
+
+	retryAfter, err := m.loginAttemptUsecase.Reserve(ctx, user.Email, request.ClientIP(r))
+	if err != nil {
+		m.logger.Error("mfa reserve attempt", zap.Error(err))
+		response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		response.Error(w, r, errors.ErrTooManyLoginAttempts, http.StatusTooManyRequests)
+		return nil, false
+	}
+
+	return user, true
+}
+
+// checked undoes the reserved attempt after the code passed and locks next checks otherwise,
+// errors are only logged as the check is answered anyway
+func (m *MFAHandler) checked(r *http.Request, user *entity.User, err error) {
+	ctx, ip := r.Context(), request.ClientIP(r)
+	if err == nil {
+		if err := m.loginAttemptUsecase.Succeed(ctx, user.Email, ip); err != nil {
+			m.logger.Error("mfa reset attempts", zap.Error(err))
+		}
+		return
+	}
+
+	lockedOut, err := m.loginAttemptUsecase.Fail(ctx, user.Email, ip)
+	if err != nil {
+		m.logger.Error("mfa count failed attempt", zap.Error(err))
+		return
+	}
+
+	if lockedOut {
+		m.logger.Warn("security event: mfa code checks locked out",
+			zap.String("user_id", user.ID),
+			zap.String("remote_addr", ip),
+			zap.String("request_id", middleware.GetReqID(ctx)),
+		)
+	}
+}
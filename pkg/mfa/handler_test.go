@@ -0,0 +1,83 @@
+package mfa
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity/mocks"
+	"github.com/Jamshid90/go-clean-architecture/pkg/loginattempt"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDisableHandler(t *testing.T) {
+	user := &entity.User{ID: testUserID, Email: "user@info.com", Status: entity.USER_STATUS_ACTIVE}
+	mfa, code := testMFA(t, true)
+
+	newRouter := func(mockRepo *mocks.MFARepository, mockUserUsecase *mocks.UserUsecase) chi.Router {
+		usecase := NewMFAUsecase(mockRepo, "app", time.Second*2)
+		loginAttemptUsecase := loginattempt.NewLoginAttemptUsecase(loginattempt.NewLoginAttemptRepositoryMemory(), loginattempt.Policy{
+			MaxFailures:   5,
+			MaxIPFailures: 20,
+			BaseDelay:     time.Second,
+			Lockout:       time.Minute * 15,
+			Window:        time.Hour,
+		}, time.Second*2)
+
+		auth := func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "user", user)))
+			})
+		}
+
+		r := chi.NewRouter()
+		NewMFAHandler(r, &usecase, mockUserUsecase, &loginAttemptUsecase, auth, zap.NewNop())
+		return r
+	}
+
+	serve := func(r chi.Router, code string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/auth/mfa/disable", strings.NewReader(`{"code":"`+code+`"}`)))
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo := new(mocks.MFARepository)
+		mockRepo.On("Find", mock.Anything, testUserID).Return(mfa, nil).Once()
+		mockRepo.On("UseStep", mock.Anything, testUserID, mock.AnythingOfType("int64"), mock.AnythingOfType("time.Time")).Return(true, nil).Once()
+		mockRepo.On("DeleteRecoveryCodes", mock.Anything, testUserID).Return(nil).Once()
+		mockRepo.On("Delete", mock.Anything, testUserID).Return(nil).Once()
+		mockUserUsecase := new(mocks.UserUsecase)
+		mockUserUsecase.On("Find", mock.Anything, testUserID).Return(user, nil).Once()
+
+		w := serve(newRouter(mockRepo, mockUserUsecase), code)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-throttled", func(t *testing.T) {
+		mockRepo := new(mocks.MFARepository)
+		mockRepo.On("Find", mock.Anything, testUserID).Return(mfa, nil).Once()
+		mockRepo.On("UseRecoveryCode", mock.Anything, testUserID, mock.AnythingOfType("string")).Return(false, nil).Once()
+		mockUserUsecase := new(mocks.UserUsecase)
+		mockUserUsecase.On("Find", mock.Anything, testUserID).Return(user, nil)
+		r := newRouter(mockRepo, mockUserUsecase)
+
+		w := serve(r, "wrong-code")
+		assert.NotEqual(t, http.StatusOK, w.Code)
+
+		// the next guess has to wait, the code is not checked
+		w = serve(r, "other-code")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,147 @@
+package mfa
+
+import (
+	"context"
+	"fmt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+type pgxMFARepository struct {
+	db *pgxpool.Pool
+}
+
+func NewMFARepositoryPgx(dbpool *pgxpool.Pool) entity.MFARepository {
+	return &pgxMFARepository{db: dbpool}
+}
+
+// store replaces not confirmed enrollment of the user
+func (p *pgxMFARepository) Store(ctx context.Context, m *entity.MFA) error {
+	_, err := p.db.Exec(ctx, `INSERT INTO "user_mfa"(
+		user_id, secret, enabled, last_used_step, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret=EXCLUDED.secret, enabled=EXCLUDED.enabled, last_used_step=EXCLUDED.last_used_step, updated_at=EXCLUDED.updated_at`,
+		m.UserID,
+		m.Secret,
+		m.Enabled,
+		m.LastUsedStep,
+		m.CreatedAt,
+		m.UpdatedAt,
+	)
+
+	if err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during store to mfa repository: %w", err)}
+	}
+
+	return nil
+}
+
+func (p *pgxMFARepository) Update(ctx context.Context, m *entity.MFA) error {
+	_, err := p.db.Exec(ctx, `UPDATE "user_mfa"
+		SET secret=$1, enabled=$2, last_used_step=$3, updated_at=$4
+		WHERE user_id=$5`,
+		m.Secret,
+		m.Enabled,
+		m.LastUsedStep,
+		m.UpdatedAt,
+		m.UserID,
+	)
+
+	if err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during update to mfa repository: %w", err)}
+	}
+
+	return nil
+}
+
+// use step stores the time step of the used code, false is returned when the step or a later one
+// was used already, so a code can not be replayed even by parallel requests
+func (p *pgxMFARepository) UseStep(ctx context.Context, userID string, step int64, now time.Time) (bool, error) {
+	tag, err := p.db.Exec(ctx, `UPDATE "user_mfa"
+		SET last_used_step=$1, updated_at=$2
+		WHERE user_id=$3 AND last_used_step < $1`, step, now, userID)
+	if err != nil {
+		return false, errors.ErrRepository{Err: fmt.Errorf("error during use step to mfa repository: %w", err)}
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (p *pgxMFARepository) Delete(ctx context.Context, userID string) error {
+	if _, err := p.db.Exec(ctx, `DELETE FROM "user_mfa" WHERE user_id=$1`, userID); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during delete to mfa repository: %w", err)}
+	}
+	return nil
+}
+
+func (p *pgxMFARepository) Find(ctx context.Context, userID string) (*entity.MFA, error) {
+	mfa := entity.MFA{}
+	row := p.db.QueryRow(ctx, `SELECT user_id, secret, enabled, last_used_step, created_at, updated_at
+		FROM "user_mfa"
+		WHERE user_id=$1`, userID)
+
+	err := row.Scan(
+		&mfa.UserID,
+		&mfa.Secret,
+		&mfa.Enabled,
+		&mfa.LastUsedStep,
+		&mfa.CreatedAt,
+		&mfa.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, errors.NewErrNotFound("two-factor authentication")
+	}
+
+	if err != nil {
+		return nil, errors.ErrRepository{Err: fmt.Errorf("error during find to mfa repository: %w", err)}
+	}
+
+	return &mfa, nil
+}
+
+// store recovery codes replaces all previous codes of the user
+func (p *pgxMFARepository) StoreRecoveryCodes(ctx context.Context, userID string, codes []string) error {
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during store recovery codes to mfa repository: %w", err)}
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM "mfa_recovery_code" WHERE user_id=$1`, userID); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during store recovery codes to mfa repository: %w", err)}
+	}
+
+	for _, code := range codes {
+		if _, err := tx.Exec(ctx, `INSERT INTO "mfa_recovery_code"(user_id, code) VALUES ($1, $2)`, userID, code); err != nil {
+			return errors.ErrRepository{Err: fmt.Errorf("error during store recovery codes to mfa repository: %w", err)}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during store recovery codes to mfa repository: %w", err)}
+	}
+
+	return nil
+}
+
+// use recovery code marks the code as used, false is returned when there is no unused code
+func (p *pgxMFARepository) UseRecoveryCode(ctx context.Context, userID, code string) (bool, error) {
+	tag, err := p.db.Exec(ctx, `UPDATE "mfa_recovery_code"
+		SET used_at=$1
+		WHERE user_id=$2 AND code=$3 AND used_at IS NULL`, time.Now().UTC(), userID, code)
+	if err != nil {
+		return false, errors.ErrRepository{Err: fmt.Errorf("error during use recovery code to mfa repository: %w", err)}
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (p *pgxMFARepository) DeleteRecoveryCodes(ctx context.Context, userID string) error {
+	if _, err := p.db.Exec(ctx, `DELETE FROM "mfa_recovery_code" WHERE user_id=$1`, userID); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during delete recovery codes to mfa repository: %w", err)}
+	}
+	return nil
+}
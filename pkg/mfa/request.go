@@ -0,0 +1,5 @@
+package mfa
+
+type CodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
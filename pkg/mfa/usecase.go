@@ -0,0 +1,210 @@
+package mfa
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
+	"github.com/Jamshid90/go-clean-architecture/pkg/totp"
+	"github.com/skip2/go-qrcode"
+	"strings"
+	"time"
+)
+
+const (
+	recoveryCodesCount = 10
+	// accepted clock drift between server and authenticator in time steps
+	skew = 1
+)
+
+type mfaUsecase struct {
+	mfaRepo        entity.MFARepository
+	issuer         string
+	contextTimeout time.Duration
+}
+
+// New mfa usecase, issuer is the account name shown in authenticator apps
+func NewMFAUsecase(repo entity.MFARepository, issuer string, timeout time.Duration) mfaUsecase {
+	return mfaUsecase{
+		mfaRepo:        repo,
+		issuer:         issuer,
+		contextTimeout: timeout,
+	}
+}
+
+// Enroll creates a new not confirmed secret of the user
+func (m *mfaUsecase) Enroll(ctx context.Context, user *entity.User) (*entity.MFAEnrollment, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.contextTimeout)
+	defer cancel()
+
+	existed, err := m.mfaRepo.Find(ctx, user.ID)
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+	if existed != nil && existed.Enabled {
+		return nil, errors.NewErrConflict("two-factor authentication")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	uri := totp.URI(m.issuer, user.Email, secret)
+	qr, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if err := m.mfaRepo.Store(ctx, &entity.MFA{
+		UserID:    user.ID,
+		Secret:    secret,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &entity.MFAEnrollment{
+		Secret: secret,
+		URI:    uri,
+		QRCode: qr,
+	}, nil
+}
+
+// Confirm enables two-factor authentication and returns new recovery codes
+func (m *mfaUsecase) Confirm(ctx context.Context, userID, code string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.contextTimeout)
+	defer cancel()
+
+	mfa, err := m.mfaRepo.Find(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa.Enabled {
+		return nil, errors.NewErrConflict("two-factor authentication")
+	}
+
+	step, ok := totp.Validate(code, mfa.Secret, time.Now(), skew)
+	if !ok {
+		return nil, errInvalidCode()
+	}
+
+	codes, hashes, err := m.recoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.mfaRepo.StoreRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	mfa.Enabled = true
+	mfa.LastUsedStep = step
+	mfa.UpdatedAt = time.Now().UTC()
+	if err := m.mfaRepo.Update(ctx, mfa); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Disable removes secret and recovery codes after checking the code
+func (m *mfaUsecase) Disable(ctx context.Context, userID, code string) error {
+	if err := m.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.contextTimeout)
+	defer cancel()
+
+	if err := m.mfaRepo.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return err
+	}
+
+	return m.mfaRepo.Delete(ctx, userID)
+}
+
+// IsEnabled
+func (m *mfaUsecase) IsEnabled(ctx context.Context, userID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.contextTimeout)
+	defer cancel()
+
+	mfa, err := m.mfaRepo.Find(ctx, userID)
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return mfa.Enabled, nil
+}
+
+// Verify accepts a not used yet totp code or an unused recovery code
+func (m *mfaUsecase) Verify(ctx context.Context, userID, code string) error {
+	ctx, cancel := context.WithTimeout(ctx, m.contextTimeout)
+	defer cancel()
+
+	mfa, err := m.mfaRepo.Find(ctx, userID)
+	if err != nil {
+		if isNotFound(err) {
+			return errInvalidCode()
+		}
+		return err
+	}
+	if !mfa.Enabled {
+		return errInvalidCode()
+	}
+
+	code = strings.TrimSpace(code)
+	if step, ok := totp.Validate(code, mfa.Secret, time.Now(), skew); ok {
+		// a code can be used only once
+		used, err := m.mfaRepo.UseStep(ctx, userID, step, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		if !used {
+			return errInvalidCode()
+		}
+		return nil
+	}
+
+	used, err := m.mfaRepo.UseRecoveryCode(ctx, userID, hash.HashToken(strings.ToLower(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return errInvalidCode()
+	}
+
+	return nil
+}
+
+// recovery codes returns plain codes for the user and their hashes for storing
+func (m *mfaUsecase) recoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodesCount)
+	hashes := make([]string, recoveryCodesCount)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		code := hex.EncodeToString(b)
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = hash.HashToken(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+func isNotFound(err error) bool {
+	_, ok := err.(*errors.ErrNotFound)
+	return ok
+}
+
+func errInvalidCode() error {
+	return &errors.ErrBadRequest{Err: errors.ErrInvalidMFACode, Message: errors.ErrInvalidMFACode.Error()}
+}
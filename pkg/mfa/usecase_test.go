@@ -0,0 +1,189 @@
+package mfa
+
+import (
+	"context"
+	stderrors "errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity/mocks"
+	apperrors "github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
+	"github.com/Jamshid90/go-clean-architecture/pkg/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const testUserID = "123456789"
+
+func testMFA(t *testing.T, enabled bool) (*entity.MFA, string) {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+
+	return &entity.MFA{UserID: testUserID, Secret: secret, Enabled: enabled}, code
+}
+
+func isInvalidCode(err error) bool {
+	return stderrors.Is(err, apperrors.ErrInvalidMFACode)
+}
+
+func TestConfirm(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockRepo := new(mocks.MFARepository)
+		usecase := NewMFAUsecase(mockRepo, "app", time.Second*2)
+		mfa, code := testMFA(t, false)
+
+		mockRepo.On("Find", mock.Anything, testUserID).Return(mfa, nil).Once()
+		mockRepo.On("StoreRecoveryCodes", mock.Anything, testUserID, mock.AnythingOfType("[]string")).Return(nil).Once()
+		mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*entity.MFA")).Return(nil).Once()
+
+		codes, err := usecase.Confirm(context.TODO(), testUserID, code)
+
+		assert.NoError(t, err)
+		assert.Len(t, codes, recoveryCodesCount)
+		mockRepo.AssertExpectations(t)
+
+		// recovery codes are stored as hashes only and the code can not be used again
+		hashes := mockRepo.Calls[1].Arguments.Get(2).([]string)
+		assert.Equal(t, hash.HashToken(codes[0]), hashes[0])
+		updated := mockRepo.Calls[2].Arguments.Get(1).(*entity.MFA)
+		assert.True(t, updated.Enabled)
+		assert.NotZero(t, updated.LastUsedStep)
+	})
+
+	t.Run("error-invalid-code", func(t *testing.T) {
+		mockRepo := new(mocks.MFARepository)
+		usecase := NewMFAUsecase(mockRepo, "app", time.Second*2)
+		mfa, _ := testMFA(t, false)
+
+		mockRepo.On("Find", mock.Anything, testUserID).Return(mfa, nil).Once()
+
+		_, err := usecase.Confirm(context.TODO(), testUserID, "000000x")
+
+		assert.True(t, isInvalidCode(err))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-enabled", func(t *testing.T) {
+		mockRepo := new(mocks.MFARepository)
+		usecase := NewMFAUsecase(mockRepo, "app", time.Second*2)
+		mfa, code := testMFA(t, true)
+
+		mockRepo.On("Find", mock.Anything, testUserID).Return(mfa, nil).Once()
+
+		_, err := usecase.Confirm(context.TODO(), testUserID, code)
+
+		_, ok := err.(*apperrors.ErrConflict)
+		assert.True(t, ok)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestVerify(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockRepo := new(mocks.MFARepository)
+		usecase := NewMFAUsecase(mockRepo, "app", time.Second*2)
+		mfa, code := testMFA(t, true)
+
+		mockRepo.On("Find", mock.Anything, testUserID).Return(mfa, nil).Once()
+		mockRepo.On("UseStep", mock.Anything, testUserID, mock.AnythingOfType("int64"), mock.AnythingOfType("time.Time")).Return(true, nil).Once()
+
+		err := usecase.Verify(context.TODO(), testUserID, code)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-replay", func(t *testing.T) {
+		mockRepo := new(mocks.MFARepository)
+		usecase := NewMFAUsecase(mockRepo, "app", time.Second*2)
+		mfa, code := testMFA(t, true)
+
+		// the step was used already, by an earlier or a parallel request
+		mockRepo.On("Find", mock.Anything, testUserID).Return(mfa, nil).Once()
+		mockRepo.On("UseStep", mock.Anything, testUserID, mock.AnythingOfType("int64"), mock.AnythingOfType("time.Time")).Return(false, nil).Once()
+
+		err := usecase.Verify(context.TODO(), testUserID, code)
+
+		assert.True(t, isInvalidCode(err))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("success-recovery-code", func(t *testing.T) {
+		mockRepo := new(mocks.MFARepository)
+		usecase := NewMFAUsecase(mockRepo, "app", time.Second*2)
+		mfa, _ := testMFA(t, true)
+
+		mockRepo.On("Find", mock.Anything, testUserID).Return(mfa, nil).Once()
+		mockRepo.On("UseRecoveryCode", mock.Anything, testUserID, hash.HashToken("abcde-12345")).Return(true, nil).Once()
+
+		// recovery codes are case insensitive
+		err := usecase.Verify(context.TODO(), testUserID, " ABCDE-12345 ")
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-used-recovery-code", func(t *testing.T) {
+		mockRepo := new(mocks.MFARepository)
+		usecase := NewMFAUsecase(mockRepo, "app", time.Second*2)
+		mfa, _ := testMFA(t, true)
+
+		mockRepo.On("Find", mock.Anything, testUserID).Return(mfa, nil).Once()
+		mockRepo.On("UseRecoveryCode", mock.Anything, testUserID, hash.HashToken("abcde-12345")).Return(false, nil).Once()
+
+		err := usecase.Verify(context.TODO(), testUserID, "abcde-12345")
+
+		assert.True(t, isInvalidCode(err))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-not-enabled", func(t *testing.T) {
+		mockRepo := new(mocks.MFARepository)
+		usecase := NewMFAUsecase(mockRepo, "app", time.Second*2)
+		mfa, code := testMFA(t, false)
+
+		mockRepo.On("Find", mock.Anything, testUserID).Return(mfa, nil).Once()
+
+		err := usecase.Verify(context.TODO(), testUserID, code)
+
+		assert.True(t, isInvalidCode(err))
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestDisable(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockRepo := new(mocks.MFARepository)
+		usecase := NewMFAUsecase(mockRepo, "app", time.Second*2)
+		mfa, code := testMFA(t, true)
+
+		mockRepo.On("Find", mock.Anything, testUserID).Return(mfa, nil).Once()
+		mockRepo.On("UseStep", mock.Anything, testUserID, mock.AnythingOfType("int64"), mock.AnythingOfType("time.Time")).Return(true, nil).Once()
+		mockRepo.On("DeleteRecoveryCodes", mock.Anything, testUserID).Return(nil).Once()
+		mockRepo.On("Delete", mock.Anything, testUserID).Return(nil).Once()
+
+		err := usecase.Disable(context.TODO(), testUserID, code)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-invalid-code", func(t *testing.T) {
+		mockRepo := new(mocks.MFARepository)
+		usecase := NewMFAUsecase(mockRepo, "app", time.Second*2)
+		mfa, _ := testMFA(t, true)
+
+		mockRepo.On("Find", mock.Anything, testUserID).Return(mfa, nil).Once()
+		mockRepo.On("UseRecoveryCode", mock.Anything, testUserID, hash.HashToken("000000x")).Return(false, nil).Once()
+
+		err := usecase.Disable(context.TODO(), testUserID, "000000x")
+
+		assert.True(t, isInvalidCode(err))
+		mockRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,70 @@
+package oauth
+
+import (
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"strings"
+)
+
+// openid connect scopes, the other scopes release the standard claims of the user
+const (
+	SCOPE_OPENID  = "openid"
+	SCOPE_PROFILE = "profile"
+	SCOPE_EMAIL   = "email"
+	SCOPE_PHONE   = "phone"
+)
+
+var SupportedScopes = []string{SCOPE_OPENID, SCOPE_PROFILE, SCOPE_EMAIL, SCOPE_PHONE}
+
+var SupportedClaims = []string{
+	"sub",
+	"name",
+	"given_name",
+	"family_name",
+	"gender",
+	"birthdate",
+	"updated_at",
+	"email",
+	"email_verified",
+	"phone_number",
+	"phone_number_verified",
+}
+
+// user claims maps the user to standard claims released by the scopes, empty values are left out
+func userClaims(user *entity.User, scopes []string) map[string]interface{} {
+	claims := map[string]interface{}{
+		"sub": user.ID,
+	}
+
+	if containsScope(scopes, SCOPE_PROFILE) {
+		if name := strings.TrimSpace(user.FirstName + " " + user.LastName); name != "" {
+			claims["name"] = name
+		}
+		if user.FirstName != "" {
+			claims["given_name"] = user.FirstName
+		}
+		if user.LastName != "" {
+			claims["family_name"] = user.LastName
+		}
+		if user.Gender != "" {
+			claims["gender"] = user.Gender
+		}
+		if !user.BirthDate.IsZero() {
+			claims["birthdate"] = user.BirthDate.Format("2006-01-02")
+		}
+		if !user.UpdatedAt.IsZero() {
+			claims["updated_at"] = user.UpdatedAt.Unix()
+		}
+	}
+
+	if containsScope(scopes, SCOPE_EMAIL) && user.Email != "" {
+		claims["email"] = user.Email
+		claims["email_verified"] = user.IsEmailVerified()
+	}
+
+	if containsScope(scopes, SCOPE_PHONE) && user.Phone != "" {
+		claims["phone_number"] = user.Phone
+		claims["phone_number_verified"] = user.IsPhoneVerified()
+	}
+
+	return claims
+}
@@ -0,0 +1,210 @@
+package oauth
+
+import (
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/middleware"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/request"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/response"
+	"github.com/Jamshid90/go-clean-architecture/pkg/validation"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type OAuthClientHandler struct {
+	logger             *zap.Logger
+	oauthClientUsecase entity.OAuthClientUsecase
+	oauthUsecase       entity.OAuthUsecase
+}
+
+// New oauth client handler, clients are managed by admins and the users manage consents they gave
+func NewOAuthClientHandler(r chi.Router, oauthClientUsecase entity.OAuthClientUsecase, oauthUsecase entity.OAuthUsecase, auth func(http.Handler) http.Handler, logger *zap.Logger) {
+	handler := OAuthClientHandler{
+		oauthClientUsecase: oauthClientUsecase,
+		oauthUsecase:       oauthUsecase,
+		logger:             logger,
+	}
+
+	r.Group(func(r chi.Router) {
+		r.Use(auth)
+		r.With(middleware.Permission(entity.PERMISSION_OAUTH_CLIENT_READ)).Get("/oauth/clients", handler.findAll())
+		r.With(middleware.Permission(entity.PERMISSION_OAUTH_CLIENT_READ)).Get("/oauth/clients/{id}", handler.find())
+		r.With(middleware.Permission(entity.PERMISSION_OAUTH_CLIENT_WRITE)).Post("/oauth/clients", handler.store())
+		r.With(middleware.Permission(entity.PERMISSION_OAUTH_CLIENT_WRITE)).Delete("/oauth/clients/{id}", handler.delete())
+		r.Get("/oauth/consents", handler.findConsents())
+		r.Delete("/oauth/consents/{client_id}", handler.revokeConsent())
+	})
+}
+
+// convert entity oauth client to client model
+func (o *OAuthClientHandler) convert(client *entity.OAuthClient) *Client {
+	return &Client{
+		ID:           client.ID,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectURIs,
+		GrantTypes:   client.GrantTypes,
+		Scopes:       client.Scopes,
+		Public:       client.IsPublic(),
+		FirstParty:   client.FirstParty,
+		CreatedAt:    client.CreatedAt,
+		UpdatedAt:    client.UpdatedAt,
+	}
+}
+
+// store registers the client, the secret is in the response only once
+func (o *OAuthClientHandler) store() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var clientRequest CreateClientRequest
+		if err := request.DecodeJson(r, &clientRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := validation.Validator(&clientRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		client := entity.OAuthClient{
+			Name:         clientRequest.Name,
+			RedirectURIs: clientRequest.RedirectURIs,
+			GrantTypes:   clientRequest.GrantTypes,
+			Scopes:       clientRequest.Scopes,
+			FirstParty:   clientRequest.FirstParty,
+		}
+		if client.RedirectURIs == nil {
+			client.RedirectURIs = []string{}
+		}
+		if client.Scopes == nil {
+			client.Scopes = []string{}
+		}
+
+		secret, err := o.oauthClientUsecase.Store(r.Context(), &client, clientRequest.Public)
+		if err != nil {
+			o.logger.Error("oauth client store", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		model := o.convert(&client)
+		model.Secret = secret
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+			"data":   model,
+		})
+	}
+}
+
+// find
+func (o *OAuthClientHandler) find() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		client, err := o.oauthClientUsecase.Find(r.Context(), chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+			"data":   o.convert(client),
+		})
+	}
+}
+
+// find all
+func (o *OAuthClientHandler) findAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		items, err := o.oauthClientUsecase.FindAll(r.Context())
+		if err != nil {
+			o.logger.Error("oauth client find all", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		clients := []*Client{}
+		for _, item := range items {
+			clients = append(clients, o.convert(item))
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+			"data":   clients,
+		})
+	}
+}
+
+// delete removes the client, refresh tokens issued to it stop working
+func (o *OAuthClientHandler) delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := o.oauthClientUsecase.Delete(r.Context(), chi.URLParam(r, "id")); err != nil {
+			o.logger.Error("oauth client delete", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+		})
+	}
+}
+
+// find consents the authenticated user gave to clients
+func (o *OAuthClientHandler) findConsents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.GetAuthUser(r.Context())
+		if !ok {
+			response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		ctx := r.Context()
+		items, err := o.oauthUsecase.FindConsents(ctx, user.ID)
+		if err != nil {
+			o.logger.Error("oauth consent find all", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		consents := []*Consent{}
+		for _, item := range items {
+			consent := &Consent{
+				ClientID:  item.ClientID,
+				Scopes:    item.Scopes,
+				CreatedAt: item.CreatedAt,
+				UpdatedAt: item.UpdatedAt,
+			}
+			if client, err := o.oauthClientUsecase.Find(ctx, item.ClientID); err == nil {
+				consent.ClientName = client.Name
+			}
+			consents = append(consents, consent)
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+			"data":   consents,
+		})
+	}
+}
+
+// revoke consent of the authenticated user, the client loses its refresh tokens
+func (o *OAuthClientHandler) revokeConsent() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.GetAuthUser(r.Context())
+		if !ok {
+			response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		if err := o.oauthUsecase.RevokeConsent(r.Context(), user.ID, chi.URLParam(r, "client_id")); err != nil {
+			o.logger.Error("oauth consent revoke", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+		})
+	}
+}
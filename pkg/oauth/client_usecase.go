@@ -0,0 +1,146 @@
+package oauth
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
+	"github.com/Jamshid90/go-clean-architecture/pkg/rand"
+	"net/url"
+	"time"
+)
+
+type oauthClientUsecase struct {
+	clientRepo       entity.OAuthClientRepository
+	consentRepo      entity.OAuthConsentRepository
+	refreshTokenRepo entity.OAuthRefreshTokenRepository
+	contextTimeout   time.Duration
+}
+
+// New oauth client usecase
+func NewOAuthClientUsecase(clientRepo entity.OAuthClientRepository, consentRepo entity.OAuthConsentRepository, refreshTokenRepo entity.OAuthRefreshTokenRepository, timeout time.Duration) oauthClientUsecase {
+	return oauthClientUsecase{
+		clientRepo:       clientRepo,
+		consentRepo:      consentRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		contextTimeout:   timeout,
+	}
+}
+
+// validate checks grant types and redirect uris the client is registered with
+func (o *oauthClientUsecase) validate(m *entity.OAuthClient, public bool) error {
+	errValidation := errors.NewErrValidation()
+
+	for _, grantType := range m.GrantTypes {
+		supported := false
+		for _, g := range entity.OAuthGrantTypes {
+			if g == grantType {
+				supported = true
+			}
+		}
+		if !supported {
+			errValidation.Errors["grant_types"] = "unsupported grant type " + grantType
+		}
+	}
+
+	// public clients can not authenticate, so they can not get tokens for themselves
+	if public && m.HasGrantType(entity.OAUTH_GRANT_CLIENT_CREDENTIALS) {
+		errValidation.Errors["grant_types"] = "client_credentials grant is not allowed for public clients"
+	}
+
+	// refresh tokens are issued by authorization code grant only
+	if m.HasGrantType(entity.OAUTH_GRANT_REFRESH_TOKEN) && !m.HasGrantType(entity.OAUTH_GRANT_AUTHORIZATION_CODE) {
+		errValidation.Errors["grant_types"] = "refresh_token grant requires authorization_code grant"
+	}
+
+	if m.HasGrantType(entity.OAUTH_GRANT_AUTHORIZATION_CODE) && len(m.RedirectURIs) == 0 {
+		errValidation.Errors["redirect_uris"] = "authorization_code grant requires redirect uris"
+	}
+
+	for _, uri := range m.RedirectURIs {
+		parsed, err := url.Parse(uri)
+		if err != nil || !parsed.IsAbs() || parsed.Host == "" || parsed.Fragment != "" {
+			errValidation.Errors["redirect_uris"] = "redirect uri must be absolute url without fragment"
+		}
+	}
+
+	for _, scope := range m.Scopes {
+		if !scopeTokenRegexp.MatchString(scope) {
+			errValidation.Errors["scopes"] = "invalid scope " + scope
+		}
+	}
+
+	if len(errValidation.Errors) > 0 {
+		return errValidation
+	}
+	return nil
+}
+
+// store registers the client, secret of confidential client is returned once and kept as hash
+func (o *oauthClientUsecase) Store(ctx context.Context, m *entity.OAuthClient, public bool) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.contextTimeout)
+	defer cancel()
+
+	if err := o.validate(m, public); err != nil {
+		return "", err
+	}
+
+	id, err := rand.Token(16)
+	if err != nil {
+		return "", err
+	}
+	m.ID = id
+
+	var secret string
+	m.Secret = ""
+	if !public {
+		secret, err = rand.Token(32)
+		if err != nil {
+			return "", err
+		}
+		m.Secret = hash.HashToken(secret)
+	}
+
+	m.CreatedAt = time.Now().UTC()
+	m.UpdatedAt = m.CreatedAt
+
+	if err := o.clientRepo.Store(ctx, m); err != nil {
+		return "", err
+	}
+
+	return secret, nil
+}
+
+// find
+func (o *oauthClientUsecase) Find(ctx context.Context, id string) (*entity.OAuthClient, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.contextTimeout)
+	defer cancel()
+	return o.clientRepo.Find(ctx, id)
+}
+
+// find all
+func (o *oauthClientUsecase) FindAll(ctx context.Context) ([]*entity.OAuthClient, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.contextTimeout)
+	defer cancel()
+	return o.clientRepo.FindAll(ctx)
+}
+
+// delete removes the client with consents given to it and its refresh tokens
+func (o *oauthClientUsecase) Delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, o.contextTimeout)
+	defer cancel()
+
+	if _, err := o.clientRepo.Find(ctx, id); err != nil {
+		return err
+	}
+
+	if err := o.refreshTokenRepo.DeleteByClientId(ctx, id); err != nil {
+		return err
+	}
+
+	if err := o.consentRepo.DeleteByClientId(ctx, id); err != nil {
+		return err
+	}
+
+	return o.clientRepo.Delete(ctx, id)
+}
@@ -0,0 +1,123 @@
+package oauth
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity/mocks"
+	apperrors "github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestClientStore(t *testing.T) {
+	newClient := func() *entity.OAuthClient {
+		return &entity.OAuthClient{
+			Name:         "Client",
+			RedirectURIs: []string{testRedirectURI},
+			GrantTypes:   []string{entity.OAUTH_GRANT_AUTHORIZATION_CODE, entity.OAUTH_GRANT_REFRESH_TOKEN},
+			Scopes:       []string{"profile"},
+		}
+	}
+
+	t.Run("success-confidential", func(t *testing.T) {
+		mockClientRepo := new(mocks.OAuthClientRepository)
+		usecase := NewOAuthClientUsecase(mockClientRepo, new(mocks.OAuthConsentRepository), new(mocks.OAuthRefreshTokenRepository), time.Second*2)
+		mockClientRepo.On("Store", mock.Anything, mock.AnythingOfType("*entity.OAuthClient")).Return(nil).Once()
+
+		client := newClient()
+		secret, err := usecase.Store(context.TODO(), client, false)
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, client.ID)
+		assert.NotEmpty(t, secret)
+		assert.Equal(t, hash.HashToken(secret), client.Secret)
+		assert.False(t, client.IsPublic())
+		mockClientRepo.AssertExpectations(t)
+	})
+
+	t.Run("success-public", func(t *testing.T) {
+		mockClientRepo := new(mocks.OAuthClientRepository)
+		usecase := NewOAuthClientUsecase(mockClientRepo, new(mocks.OAuthConsentRepository), new(mocks.OAuthRefreshTokenRepository), time.Second*2)
+		mockClientRepo.On("Store", mock.Anything, mock.AnythingOfType("*entity.OAuthClient")).Return(nil).Once()
+
+		client := newClient()
+		secret, err := usecase.Store(context.TODO(), client, true)
+
+		assert.NoError(t, err)
+		assert.Empty(t, secret)
+		assert.True(t, client.IsPublic())
+		mockClientRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-validation", func(t *testing.T) {
+		mockClientRepo := new(mocks.OAuthClientRepository)
+		usecase := NewOAuthClientUsecase(mockClientRepo, new(mocks.OAuthConsentRepository), new(mocks.OAuthRefreshTokenRepository), time.Second*2)
+
+		publicCredentials := newClient()
+		publicCredentials.GrantTypes = []string{entity.OAUTH_GRANT_CLIENT_CREDENTIALS}
+
+		noRedirect := newClient()
+		noRedirect.RedirectURIs = nil
+
+		fragment := newClient()
+		fragment.RedirectURIs = []string{"https://app.example.com/callback#fragment"}
+
+		unsupported := newClient()
+		unsupported.GrantTypes = []string{"password"}
+
+		for name, tc := range map[string]struct {
+			client *entity.OAuthClient
+			public bool
+			field  string
+		}{
+			"public-client-credentials": {publicCredentials, true, "grant_types"},
+			"no-redirect-uri":           {noRedirect, false, "redirect_uris"},
+			"redirect-uri-fragment":     {fragment, false, "redirect_uris"},
+			"unsupported-grant-type":    {unsupported, false, "grant_types"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := usecase.Store(context.TODO(), tc.client, tc.public)
+				errValidation, ok := err.(*apperrors.ErrValidation)
+				if assert.True(t, ok) {
+					assert.Contains(t, errValidation.Errors, tc.field)
+				}
+			})
+		}
+		mockClientRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+	})
+}
+
+func TestClientDelete(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockClientRepo := new(mocks.OAuthClientRepository)
+		mockConsentRepo := new(mocks.OAuthConsentRepository)
+		mockRefreshTokenRepo := new(mocks.OAuthRefreshTokenRepository)
+		usecase := NewOAuthClientUsecase(mockClientRepo, mockConsentRepo, mockRefreshTokenRepo, time.Second*2)
+
+		mockClientRepo.On("Find", mock.Anything, "client").Return(testClient(t), nil).Once()
+		mockRefreshTokenRepo.On("DeleteByClientId", mock.Anything, "client").Return(nil).Once()
+		mockConsentRepo.On("DeleteByClientId", mock.Anything, "client").Return(nil).Once()
+		mockClientRepo.On("Delete", mock.Anything, "client").Return(nil).Once()
+
+		err := usecase.Delete(context.TODO(), "client")
+
+		assert.NoError(t, err)
+		mockClientRepo.AssertExpectations(t)
+		mockConsentRepo.AssertExpectations(t)
+		mockRefreshTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-not-found", func(t *testing.T) {
+		mockClientRepo := new(mocks.OAuthClientRepository)
+		usecase := NewOAuthClientUsecase(mockClientRepo, new(mocks.OAuthConsentRepository), new(mocks.OAuthRefreshTokenRepository), time.Second*2)
+		mockClientRepo.On("Find", mock.Anything, "unknown").Return(nil, apperrors.NewErrNotFound("oauth client")).Once()
+
+		err := usecase.Delete(context.TODO(), "unknown")
+
+		assert.Error(t, err)
+		mockClientRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
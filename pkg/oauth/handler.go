@@ -0,0 +1,447 @@
+package oauth
+
+import (
+	stderrors "errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/config"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/middleware"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/request"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/response"
+	"github.com/Jamshid90/go-clean-architecture/pkg/token"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type OAuthHandler struct {
+	logger       *zap.Logger
+	config       *config.Config
+	keys         *token.KeySet
+	oauthUsecase entity.OAuthUsecase
+}
+
+// New oauth handler, the authorization endpoint is called by the login frontend with the access token
+// of the user, it answers with the url the browser has to be redirected to or asks for the consent.
+// Browsers sent by oauth clients without the token are redirected to the login page.
+func NewOAuthHandler(r chi.Router, oauthUsecase entity.OAuthUsecase, keys *token.KeySet, auth func(http.Handler) http.Handler, config *config.Config, logger *zap.Logger) {
+	handler := OAuthHandler{
+		oauthUsecase: oauthUsecase,
+		keys:         keys,
+		config:       config,
+		logger:       logger,
+	}
+
+	r.Get("/.well-known/openid-configuration", handler.discovery())
+	r.Post("/oauth/token", handler.token())
+	r.Post("/oauth/introspect", handler.introspect())
+	r.Post("/oauth/revoke", handler.revoke())
+	r.Get("/userinfo", handler.userInfo())
+	r.Post("/userinfo", handler.userInfo())
+
+	// consent is given by the user only, neither with api keys nor under impersonation
+	r.With(handler.loginRedirect, auth, middleware.NoAPIKey, middleware.NoImpersonation).Get("/oauth/authorize", handler.authorize())
+	r.With(auth, middleware.NoAPIKey, middleware.NoImpersonation).Post("/oauth/authorize", handler.authorize())
+}
+
+// error writes oauth error response, errors other than oauth errors are hidden from the client
+func (o *OAuthHandler) error(w http.ResponseWriter, r *http.Request, err error) {
+	var errOAuth *errors.ErrOAuth
+	if !stderrors.As(err, &errOAuth) {
+		o.logger.Error("oauth", zap.Error(err))
+		response.Json(w, r, http.StatusInternalServerError, Error{Error: errors.OAUTH_SERVER_ERROR})
+		return
+	}
+
+	status := http.StatusBadRequest
+	if errOAuth.Code == errors.OAUTH_INVALID_CLIENT {
+		status = http.StatusUnauthorized
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	response.Json(w, r, status, Error{Error: errOAuth.Code, ErrorDescription: errOAuth.Description})
+}
+
+// login redirect sends the browser without access token to the login page keeping the authorization request
+func (o *OAuthHandler) loginRedirect(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" || o.config.OAuth.LoginURL == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		loginURL, err := url.Parse(o.config.OAuth.LoginURL)
+		if err != nil {
+			o.error(w, r, err)
+			return
+		}
+		query := loginURL.Query()
+		for key, values := range r.URL.Query() {
+			query[key] = values
+		}
+		loginURL.RawQuery = query.Encode()
+
+		http.Redirect(w, r, loginURL.String(), http.StatusFound)
+	})
+}
+
+// redirect responds with the redirect uri of the client extended with the params
+func (o *OAuthHandler) redirect(w http.ResponseWriter, r *http.Request, redirectURI string, params url.Values) {
+	redirectURL, err := url.Parse(redirectURI)
+	if err != nil {
+		o.error(w, r, err)
+		return
+	}
+
+	query := redirectURL.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	redirectURL.RawQuery = query.Encode()
+
+	response.Json(w, r, 200, map[string]interface{}{
+		"status": "success",
+		"data": map[string]string{
+			"redirect_to": redirectURL.String(),
+		},
+	})
+}
+
+// redirect error sends the error to the client through the redirect uri
+func (o *OAuthHandler) redirectError(w http.ResponseWriter, r *http.Request, redirectURI, state string, err error) {
+	var errOAuth *errors.ErrOAuth
+	if !stderrors.As(err, &errOAuth) {
+		o.logger.Error("oauth authorize", zap.Error(err))
+		errOAuth = &errors.ErrOAuth{Code: errors.OAUTH_SERVER_ERROR}
+	}
+
+	params := url.Values{"error": {errOAuth.Code}}
+	if errOAuth.Description != "" {
+		params.Set("error_description", errOAuth.Description)
+	}
+	if state != "" {
+		params.Set("state", state)
+	}
+	o.redirect(w, r, redirectURI, params)
+}
+
+// authorize handles the authorization request of the client for the authenticated user. GET takes
+// the parameters from the query and issues the code when no consent is needed, POST takes them
+// from the body together with the decision of the user on the consent
+func (o *OAuthHandler) authorize() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.GetAuthUser(r.Context())
+		if !ok {
+			response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		var authorizeRequest AuthorizeRequest
+		if r.Method == http.MethodPost {
+			if err := request.DecodeJson(r, &authorizeRequest); err != nil {
+				o.error(w, r, errors.NewErrOAuth(errors.OAUTH_INVALID_REQUEST, err.Error()))
+				return
+			}
+		} else {
+			query := r.URL.Query()
+			authorizeRequest = AuthorizeRequest{
+				ResponseType:        query.Get("response_type"),
+				ClientID:            query.Get("client_id"),
+				RedirectURI:         query.Get("redirect_uri"),
+				Scope:               query.Get("scope"),
+				State:               query.Get("state"),
+				CodeChallenge:       query.Get("code_challenge"),
+				CodeChallengeMethod: query.Get("code_challenge_method"),
+				Nonce:               query.Get("nonce"),
+			}
+		}
+
+		authRequest := &entity.OAuthAuthorizeRequest{
+			ResponseType:        authorizeRequest.ResponseType,
+			ClientID:            authorizeRequest.ClientID,
+			RedirectURI:         authorizeRequest.RedirectURI,
+			Scope:               authorizeRequest.Scope,
+			State:               authorizeRequest.State,
+			CodeChallenge:       authorizeRequest.CodeChallenge,
+			CodeChallengeMethod: authorizeRequest.CodeChallengeMethod,
+			Nonce:               authorizeRequest.Nonce,
+		}
+
+		ctx := r.Context()
+
+		// unknown client or redirect uri is not redirected
+		client, err := o.oauthUsecase.FindClient(ctx, authRequest.ClientID, authRequest.RedirectURI)
+		if err != nil {
+			o.error(w, r, err)
+			return
+		}
+
+		scopes, err := o.oauthUsecase.ValidateAuthorize(client, authRequest)
+		if err != nil {
+			o.redirectError(w, r, authRequest.RedirectURI, authRequest.State, err)
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			if !authorizeRequest.Approve {
+				o.redirectError(w, r, authRequest.RedirectURI, authRequest.State, errors.NewErrOAuth(errors.OAUTH_ACCESS_DENIED, "the user denied the request"))
+				return
+			}
+			if err := o.oauthUsecase.Consent(ctx, user.ID, client, scopes); err != nil {
+				o.redirectError(w, r, authRequest.RedirectURI, authRequest.State, err)
+				return
+			}
+		} else {
+			consentRequired, err := o.oauthUsecase.ConsentRequired(ctx, user.ID, client, scopes)
+			if err != nil {
+				o.redirectError(w, r, authRequest.RedirectURI, authRequest.State, err)
+				return
+			}
+			if consentRequired {
+				response.Json(w, r, 200, map[string]interface{}{
+					"status": "success",
+					"data": map[string]interface{}{
+						"consent_required": true,
+						"client": map[string]string{
+							"id":   client.ID,
+							"name": client.Name,
+						},
+						"scopes": scopes,
+					},
+				})
+				return
+			}
+		}
+
+		code, err := o.oauthUsecase.Authorize(ctx, user.ID, client, authRequest, scopes)
+		if err != nil {
+			o.redirectError(w, r, authRequest.RedirectURI, authRequest.State, err)
+			return
+		}
+
+		params := url.Values{"code": {code}}
+		if authRequest.State != "" {
+			params.Set("state", authRequest.State)
+		}
+		o.redirect(w, r, authRequest.RedirectURI, params)
+	}
+}
+
+// client credentials returns credentials the client authenticates with, basic auth or client_id
+// and client_secret params of the form encoded request
+func (o *OAuthHandler) clientCredentials(r *http.Request) (string, string, error) {
+	clientID := r.PostForm.Get("client_id")
+	clientSecret := r.PostForm.Get("client_secret")
+
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return clientID, clientSecret, nil
+	}
+
+	// credentials of basic auth are form encoded, RFC 6749 section 2.3.1
+	basicClientID, err := url.QueryUnescape(username)
+	if err != nil {
+		return "", "", errors.NewErrOAuth(errors.OAUTH_INVALID_CLIENT, "client authentication failed")
+	}
+	basicClientSecret, err := url.QueryUnescape(password)
+	if err != nil {
+		return "", "", errors.NewErrOAuth(errors.OAUTH_INVALID_CLIENT, "client authentication failed")
+	}
+	if clientSecret != "" || (clientID != "" && clientID != basicClientID) {
+		return "", "", errors.NewErrOAuth(errors.OAUTH_INVALID_REQUEST, "client must use only one authentication method")
+	}
+	return basicClientID, basicClientSecret, nil
+}
+
+// token is the token endpoint, the request is form encoded and the client authenticates
+// with basic auth or with client_id and client_secret params
+func (o *OAuthHandler) token() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Pragma", "no-cache")
+
+		if err := r.ParseForm(); err != nil {
+			o.error(w, r, errors.NewErrOAuth(errors.OAUTH_INVALID_REQUEST, "request body must be form encoded"))
+			return
+		}
+
+		tokenRequest := &entity.OAuthTokenRequest{
+			GrantType:    r.PostForm.Get("grant_type"),
+			Code:         r.PostForm.Get("code"),
+			RedirectURI:  r.PostForm.Get("redirect_uri"),
+			CodeVerifier: r.PostForm.Get("code_verifier"),
+			RefreshToken: r.PostForm.Get("refresh_token"),
+			Scope:        r.PostForm.Get("scope"),
+		}
+
+		clientID, clientSecret, err := o.clientCredentials(r)
+		if err != nil {
+			o.error(w, r, err)
+			return
+		}
+		tokenRequest.ClientID = clientID
+		tokenRequest.ClientSecret = clientSecret
+
+		oauthToken, err := o.oauthUsecase.Token(r.Context(), tokenRequest)
+		if err != nil {
+			o.error(w, r, err)
+			return
+		}
+
+		response.Json(w, r, 200, Token{
+			AccessToken:  oauthToken.AccessToken,
+			TokenType:    oauthToken.TokenType,
+			ExpiresIn:    oauthToken.ExpiresIn,
+			RefreshToken: oauthToken.RefreshToken,
+			IDToken:      oauthToken.IDToken,
+			Scope:        strings.Join(oauthToken.Scopes, " "),
+		})
+	}
+}
+
+// inspect request parses form encoded request of introspection and revocation endpoints
+func (o *OAuthHandler) inspectRequest(r *http.Request) (*entity.OAuthTokenInspectRequest, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, errors.NewErrOAuth(errors.OAUTH_INVALID_REQUEST, "request body must be form encoded")
+	}
+
+	clientID, clientSecret, err := o.clientCredentials(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.OAuthTokenInspectRequest{
+		ClientID:      clientID,
+		ClientSecret:  clientSecret,
+		Token:         r.PostForm.Get("token"),
+		TokenTypeHint: r.PostForm.Get("token_type_hint"),
+	}, nil
+}
+
+// introspect reports the state of the token to resource servers, so they need neither the signing
+// keys nor the revocation list, RFC 7662. Tokens which are not active are reported with "active" only
+func (o *OAuthHandler) introspect() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Pragma", "no-cache")
+
+		inspectRequest, err := o.inspectRequest(r)
+		if err != nil {
+			o.error(w, r, err)
+			return
+		}
+
+		introspection, err := o.oauthUsecase.Introspect(r.Context(), inspectRequest)
+		if err != nil {
+			o.error(w, r, err)
+			return
+		}
+
+		if !introspection.Active {
+			response.Json(w, r, 200, Introspection{})
+			return
+		}
+
+		response.Json(w, r, 200, Introspection{
+			Active:    true,
+			Scope:     strings.Join(introspection.Scopes, " "),
+			ClientID:  introspection.ClientID,
+			TokenType: introspection.TokenType,
+			Exp:       introspection.ExpiresAt.Unix(),
+			Iat:       introspection.IssuedAt.Unix(),
+			Sub:       introspection.Subject,
+			Iss:       strings.TrimRight(o.config.OAuth.Issuer, "/"),
+			Jti:       introspection.ID,
+			Sid:       introspection.SessionID,
+		})
+	}
+}
+
+// revoke revokes access and refresh tokens, unknown tokens are answered with success too, RFC 7009
+func (o *OAuthHandler) revoke() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Pragma", "no-cache")
+
+		inspectRequest, err := o.inspectRequest(r)
+		if err != nil {
+			o.error(w, r, err)
+			return
+		}
+
+		if err := o.oauthUsecase.Revoke(r.Context(), inspectRequest); err != nil {
+			o.error(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// discovery publishes the openid provider metadata, standard clients configure themselves from it
+func (o *OAuthHandler) discovery() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		issuer := strings.TrimRight(o.config.OAuth.Issuer, "/")
+
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		response.Json(w, r, 200, Discovery{
+			Issuer:                                    issuer,
+			AuthorizationEndpoint:                     issuer + "/oauth/authorize",
+			TokenEndpoint:                             issuer + "/oauth/token",
+			UserinfoEndpoint:                          issuer + "/userinfo",
+			IntrospectionEndpoint:                     issuer + "/oauth/introspect",
+			RevocationEndpoint:                        issuer + "/oauth/revoke",
+			JwksURI:                                   issuer + "/.well-known/jwks.json",
+			ScopesSupported:                           SupportedScopes,
+			ResponseTypesSupported:                    []string{RESPONSE_TYPE_CODE},
+			GrantTypesSupported:                       entity.OAuthGrantTypes,
+			SubjectTypesSupported:                     []string{"public"},
+			IDTokenSigningAlgValuesSupported:          []string{o.keys.SigningAlgorithm()},
+			TokenEndpointAuthMethodsSupported:         []string{"client_secret_basic", "client_secret_post", "none"},
+			IntrospectionEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post"},
+			RevocationEndpointAuthMethodsSupported:    []string{"client_secret_basic", "client_secret_post", "none"},
+			CodeChallengeMethodsSupported:             []string{CODE_CHALLENGE_METHOD_S256},
+			ClaimsSupported:                           SupportedClaims,
+		})
+	}
+}
+
+// user info returns claims of the user of the bearer access token, errors are sent
+// in WWW-Authenticate header as well, RFC 6750 section 3
+func (o *OAuthHandler) userInfo() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accessToken := r.Header.Get("Authorization")
+		if len(accessToken) > 7 && strings.EqualFold(accessToken[:7], "Bearer ") {
+			accessToken = accessToken[7:]
+		} else {
+			accessToken = ""
+		}
+
+		if accessToken == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="userinfo"`)
+			response.Json(w, r, http.StatusUnauthorized, Error{Error: errors.OAUTH_INVALID_REQUEST, ErrorDescription: "bearer access token is required"})
+			return
+		}
+
+		claims, err := o.oauthUsecase.UserInfo(r.Context(), accessToken)
+		if err != nil {
+			var errOAuth *errors.ErrOAuth
+			if !stderrors.As(err, &errOAuth) {
+				o.error(w, r, err)
+				return
+			}
+
+			status := http.StatusUnauthorized
+			if errOAuth.Code == errors.OAUTH_INSUFFICIENT_SCOPE {
+				status = http.StatusForbidden
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="userinfo", error="`+errOAuth.Code+`", error_description="`+errOAuth.Description+`"`)
+			response.Json(w, r, status, Error{Error: errOAuth.Code, ErrorDescription: errOAuth.Description})
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		response.Json(w, r, 200, claims)
+	}
+}
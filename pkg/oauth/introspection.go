@@ -0,0 +1,282 @@
+package oauth
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
+	"github.com/Jamshid90/go-clean-architecture/pkg/token"
+	"time"
+)
+
+// Introspect returns the state of the token, RFC 7662. Only confidential clients may introspect,
+// first-party clients see every token and other clients only tokens issued to them
+func (o *oauthUsecase) Introspect(ctx context.Context, request *entity.OAuthTokenInspectRequest) (*entity.OAuthIntrospection, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.contextTimeout)
+	defer cancel()
+
+	client, err := o.authenticateClient(ctx, request.ClientID, request.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if client.IsPublic() {
+		return nil, errors.NewErrOAuth(errors.OAUTH_INVALID_CLIENT, "public clients can not introspect tokens")
+	}
+
+	if request.Token == "" {
+		return nil, errors.NewErrOAuth(errors.OAUTH_INVALID_REQUEST, "token is required")
+	}
+
+	introspection, err := o.inspect(ctx, request.Token, request.TokenTypeHint)
+	if err != nil {
+		return nil, err
+	}
+	if introspection == nil || !visible(client, introspection) {
+		return &entity.OAuthIntrospection{}, nil
+	}
+	return introspection, nil
+}
+
+// Revoke revokes the token, RFC 7009. Refresh tokens are deleted with their session, access tokens are
+// added to the revocation list. Unknown tokens and tokens of other clients are ignored, so the caller
+// can not tell them apart from revoked ones
+func (o *oauthUsecase) Revoke(ctx context.Context, request *entity.OAuthTokenInspectRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, o.contextTimeout)
+	defer cancel()
+
+	client, err := o.authenticateClient(ctx, request.ClientID, request.ClientSecret)
+	if err != nil {
+		return err
+	}
+
+	if request.Token == "" {
+		return errors.NewErrOAuth(errors.OAUTH_INVALID_REQUEST, "token is required")
+	}
+
+	introspection, err := o.inspect(ctx, request.Token, request.TokenTypeHint)
+	if err != nil {
+		return err
+	}
+	if introspection == nil || !visible(client, introspection) {
+		return nil
+	}
+
+	if introspection.TokenType == entity.OAUTH_TOKEN_TYPE_ACCESS_TOKEN {
+		// tokens issued before revocation was introduced have no "jti" and can be revoked only with the user
+		if introspection.ID == "" {
+			return o.revocationUsecase.RevokeUser(ctx, introspection.Subject)
+		}
+		return o.revocationUsecase.Revoke(ctx, introspection.ID, introspection.ExpiresAt)
+	}
+
+	if introspection.ClientID != "" {
+		if err := o.refreshTokenRepo.Delete(ctx, hash.HashToken(request.Token)); err != nil {
+			if _, ok := err.(*errors.ErrNotFound); !ok {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// first-party refresh token ends its session, families started before sessions have no session
+	if err := o.refreshTokenUsecase.DeleteByFamily(ctx, introspection.SessionID); err != nil {
+		return err
+	}
+	if err := o.sessionUsecase.Delete(ctx, introspection.Subject, introspection.SessionID); err != nil {
+		if _, ok := err.(*errors.ErrNotFound); !ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// visible tells the client may see the token, first-party clients see every token
+func visible(client *entity.OAuthClient, introspection *entity.OAuthIntrospection) bool {
+	return client.FirstParty || (introspection.ClientID != "" && introspection.ClientID == client.ID)
+}
+
+// inspect returns the state of active token or nil, the hint only tells which token type is tried first
+func (o *oauthUsecase) inspect(ctx context.Context, tokenStr, hint string) (*entity.OAuthIntrospection, error) {
+	inspectors := []func(context.Context, string) (*entity.OAuthIntrospection, error){o.inspectAccessToken, o.inspectRefreshToken}
+	if hint == entity.OAUTH_TOKEN_TYPE_REFRESH_TOKEN {
+		inspectors[0], inspectors[1] = inspectors[1], inspectors[0]
+	}
+
+	for _, inspector := range inspectors {
+		introspection, err := inspector(ctx, tokenStr)
+		if err != nil || introspection != nil {
+			return introspection, err
+		}
+	}
+	return nil, nil
+}
+
+// inspect access token of oauth client or first-party access token, tokens must not be revoked and
+// their user and client must still exist. First-party tokens of deleted sessions are not active.
+func (o *oauthUsecase) inspectAccessToken(ctx context.Context, tokenStr string) (*entity.OAuthIntrospection, error) {
+	if accessToken, err := token.ParseOAuthAccessToken(o.keys, tokenStr); err == nil {
+		if active, err := o.activeClientToken(ctx, accessToken); !active || err != nil {
+			return nil, err
+		}
+		if active, err := o.activeClient(ctx, accessToken.ClientID); !active || err != nil {
+			return nil, err
+		}
+		// subject of client credentials tokens is the client itself
+		if accessToken.Subject != accessToken.ClientID {
+			if active, err := o.activeUser(ctx, accessToken.Subject); !active || err != nil {
+				return nil, err
+			}
+		}
+
+		return &entity.OAuthIntrospection{
+			Active:    true,
+			ID:        accessToken.ID,
+			TokenType: entity.OAUTH_TOKEN_TYPE_ACCESS_TOKEN,
+			Subject:   accessToken.Subject,
+			ClientID:  accessToken.ClientID,
+			Scopes:    accessToken.Scopes,
+			IssuedAt:  accessToken.IssuedAt,
+			ExpiresAt: accessToken.ExpiresAt,
+		}, nil
+	}
+
+	accessToken, err := token.ParseAccessTokenString(o.keys, tokenStr)
+	if err != nil {
+		return nil, nil
+	}
+
+	if active, err := o.activeToken(ctx, accessToken.ID, accessToken.User.ID, accessToken.IssuedAt); !active || err != nil {
+		return nil, err
+	}
+	if active, err := o.activeUser(ctx, accessToken.User.ID); !active || err != nil {
+		return nil, err
+	}
+	// tokens issued before sessions were introduced have no session
+	if accessToken.SessionID != "" {
+		if active, err := o.activeSession(ctx, accessToken.User.ID, accessToken.SessionID); !active || err != nil {
+			return nil, err
+		}
+	}
+
+	return &entity.OAuthIntrospection{
+		Active:    true,
+		ID:        accessToken.ID,
+		TokenType: entity.OAUTH_TOKEN_TYPE_ACCESS_TOKEN,
+		Subject:   accessToken.User.ID,
+		Scopes:    accessToken.User.Permissions,
+		SessionID: accessToken.SessionID,
+		IssuedAt:  accessToken.IssuedAt,
+		ExpiresAt: accessToken.ExpiresAt,
+	}, nil
+}
+
+// inspect refresh token, first-party refresh tokens are jwt and refresh tokens of oauth clients are opaque
+func (o *oauthUsecase) inspectRefreshToken(ctx context.Context, tokenStr string) (*entity.OAuthIntrospection, error) {
+	if claims, err := token.ParseJwtToken(tokenStr, o.keys); err == nil {
+		if claims["typ"] != token.TYPE_REFRESH {
+			return nil, nil
+		}
+
+		refreshToken, err := o.refreshTokenUsecase.Find(ctx, tokenStr)
+		if err != nil {
+			if _, ok := err.(*errors.ErrNotFound); ok {
+				return nil, nil
+			}
+			return nil, err
+		}
+		// rotated token is not active anymore, presenting it again revokes the family
+		if refreshToken.RotatedAt != nil {
+			return nil, nil
+		}
+		if active, err := o.activeUser(ctx, refreshToken.UserID); !active || err != nil {
+			return nil, err
+		}
+
+		introspection := entity.OAuthIntrospection{
+			Active:    true,
+			TokenType: entity.OAUTH_TOKEN_TYPE_REFRESH_TOKEN,
+			Subject:   refreshToken.UserID,
+			SessionID: refreshToken.FamilyID,
+			IssuedAt:  refreshToken.CreatedAt,
+		}
+		introspection.ID, _ = claims["jti"].(string)
+		if exp, ok := claims["exp"].(float64); ok {
+			introspection.ExpiresAt = time.Unix(int64(exp), 0)
+		}
+		return &introspection, nil
+	}
+
+	refreshToken, err := o.refreshTokenRepo.Find(ctx, hash.HashToken(tokenStr))
+	if err != nil {
+		if _, ok := err.(*errors.ErrNotFound); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if time.Now().UTC().After(refreshToken.ExpiresAt) {
+		return nil, nil
+	}
+	if active, err := o.activeClient(ctx, refreshToken.ClientID); !active || err != nil {
+		return nil, err
+	}
+	if active, err := o.activeUser(ctx, refreshToken.UserID); !active || err != nil {
+		return nil, err
+	}
+
+	return &entity.OAuthIntrospection{
+		Active:    true,
+		TokenType: entity.OAUTH_TOKEN_TYPE_REFRESH_TOKEN,
+		Subject:   refreshToken.UserID,
+		ClientID:  refreshToken.ClientID,
+		Scopes:    refreshToken.Scopes,
+		IssuedAt:  refreshToken.CreatedAt,
+		ExpiresAt: refreshToken.ExpiresAt,
+	}, nil
+}
+
+// active token, the token and tokens of the user issued before must not be revoked
+func (o *oauthUsecase) activeToken(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error) {
+	revoked, err := o.revocationUsecase.IsRevoked(ctx, jti, userID, issuedAt)
+	return !revoked, err
+}
+
+// active client token, access tokens of oauth clients are revoked with the user or the user and the client too
+func (o *oauthUsecase) activeClientToken(ctx context.Context, accessToken *token.OAuthAccessToken) (bool, error) {
+	revoked, err := o.revocationUsecase.IsClientRevoked(ctx, accessToken.ID, accessToken.Subject, accessToken.ClientID, accessToken.IssuedAt)
+	return !revoked, err
+}
+
+// active client, tokens of deleted clients are not active
+func (o *oauthUsecase) activeClient(ctx context.Context, clientID string) (bool, error) {
+	if _, err := o.clientRepo.Find(ctx, clientID); err != nil {
+		if _, ok := err.(*errors.ErrNotFound); ok {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// active user, tokens of removed and disabled users are not active
+func (o *oauthUsecase) activeUser(ctx context.Context, userID string) (bool, error) {
+	user, err := o.userUsecase.Find(ctx, userID)
+	if err != nil {
+		if _, ok := err.(*errors.ErrNotFound); ok {
+			return false, nil
+		}
+		return false, err
+	}
+	return !user.IsDisabled(), nil
+}
+
+// active session, the session must exist and belong to the user
+func (o *oauthUsecase) activeSession(ctx context.Context, userID, sessionID string) (bool, error) {
+	session, err := o.sessionUsecase.Find(ctx, sessionID)
+	if err != nil {
+		if _, ok := err.(*errors.ErrNotFound); ok {
+			return false, nil
+		}
+		return false, err
+	}
+	return session.UserID == userID, nil
+}
@@ -0,0 +1,193 @@
+package oauth
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	apperrors "github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// login issues first-party tokens in the session as the login endpoint would
+func (env *testEnv) login(t *testing.T, sessionID string) (string, string) {
+	t.Helper()
+
+	accessToken, refreshToken, err := token.GenerateToken(env.keys, "15m", "720h", env.user, sessionID)
+	require.NoError(t, err)
+
+	env.sessions[sessionID] = &entity.Session{ID: sessionID, UserID: env.user.ID}
+	env.userRefreshTokens[refreshToken] = &entity.RefreshToken{
+		UserID:    env.user.ID,
+		Token:     refreshToken,
+		FamilyID:  sessionID,
+		CreatedAt: time.Now().UTC(),
+	}
+	return accessToken, refreshToken
+}
+
+func (env *testEnv) introspect(t *testing.T, client *entity.OAuthClient, tokenStr, hint string) *entity.OAuthIntrospection {
+	t.Helper()
+
+	introspection, err := env.usecase.Introspect(context.TODO(), &entity.OAuthTokenInspectRequest{
+		ClientID:      client.ID,
+		ClientSecret:  testSecret,
+		Token:         tokenStr,
+		TokenTypeHint: hint,
+	})
+	require.NoError(t, err)
+	return introspection
+}
+
+func (env *testEnv) revoke(t *testing.T, client *entity.OAuthClient, tokenStr, hint string) {
+	t.Helper()
+
+	err := env.usecase.Revoke(context.TODO(), &entity.OAuthTokenInspectRequest{
+		ClientID:      client.ID,
+		ClientSecret:  testSecret,
+		Token:         tokenStr,
+		TokenTypeHint: hint,
+	})
+	require.NoError(t, err)
+}
+
+func TestIntrospect(t *testing.T) {
+	t.Run("success-oauth-access-token", func(t *testing.T) {
+		env := newTestEnv(t)
+		oauthToken, err := env.exchange(env.authorize(t), testCodeVerifier)
+		require.NoError(t, err)
+
+		introspection := env.introspect(t, env.client, oauthToken.AccessToken, "")
+
+		assert.True(t, introspection.Active)
+		assert.Equal(t, entity.OAUTH_TOKEN_TYPE_ACCESS_TOKEN, introspection.TokenType)
+		assert.Equal(t, env.user.ID, introspection.Subject)
+		assert.Equal(t, env.client.ID, introspection.ClientID)
+		assert.Equal(t, []string{"profile"}, introspection.Scopes)
+		assert.NotEmpty(t, introspection.ID)
+		assert.True(t, introspection.ExpiresAt.After(time.Now()))
+	})
+
+	t.Run("success-oauth-refresh-token", func(t *testing.T) {
+		env := newTestEnv(t)
+		oauthToken, err := env.exchange(env.authorize(t), testCodeVerifier)
+		require.NoError(t, err)
+
+		for _, hint := range []string{"", entity.OAUTH_TOKEN_TYPE_REFRESH_TOKEN, entity.OAUTH_TOKEN_TYPE_ACCESS_TOKEN} {
+			introspection := env.introspect(t, env.client, oauthToken.RefreshToken, hint)
+
+			assert.True(t, introspection.Active)
+			assert.Equal(t, entity.OAUTH_TOKEN_TYPE_REFRESH_TOKEN, introspection.TokenType)
+			assert.Equal(t, env.user.ID, introspection.Subject)
+			assert.Equal(t, env.client.ID, introspection.ClientID)
+		}
+	})
+
+	t.Run("success-first-party-tokens", func(t *testing.T) {
+		env := newTestEnv(t)
+		accessToken, refreshToken := env.login(t, "session")
+
+		introspection := env.introspect(t, env.firstPartyClient, accessToken, "")
+		assert.True(t, introspection.Active)
+		assert.Equal(t, entity.OAUTH_TOKEN_TYPE_ACCESS_TOKEN, introspection.TokenType)
+		assert.Equal(t, env.user.ID, introspection.Subject)
+		assert.Equal(t, "session", introspection.SessionID)
+		assert.Empty(t, introspection.ClientID)
+
+		introspection = env.introspect(t, env.firstPartyClient, refreshToken, entity.OAUTH_TOKEN_TYPE_REFRESH_TOKEN)
+		assert.True(t, introspection.Active)
+		assert.Equal(t, entity.OAUTH_TOKEN_TYPE_REFRESH_TOKEN, introspection.TokenType)
+		assert.Equal(t, "session", introspection.SessionID)
+	})
+
+	t.Run("inactive", func(t *testing.T) {
+		env := newTestEnv(t)
+		accessToken, _ := env.login(t, "session")
+		deletedSessionToken, _ := env.login(t, "deleted")
+		delete(env.sessions, "deleted")
+		oauthToken, err := env.exchange(env.authorize(t), testCodeVerifier)
+		require.NoError(t, err)
+		require.NoError(t, env.revocationUsecase.Revoke(context.TODO(), env.introspect(t, env.client, oauthToken.AccessToken, "").ID, time.Now().Add(time.Hour)))
+
+		for name, tc := range map[string]struct {
+			client *entity.OAuthClient
+			token  string
+		}{
+			"unknown-token":               {env.firstPartyClient, "unknown"},
+			"revoked-token":               {env.client, oauthToken.AccessToken},
+			"deleted-session":             {env.firstPartyClient, deletedSessionToken},
+			"first-party-token-to-client": {env.client, accessToken},
+		} {
+			t.Run(name, func(t *testing.T) {
+				introspection := env.introspect(t, tc.client, tc.token, "")
+				assert.Equal(t, &entity.OAuthIntrospection{}, introspection)
+			})
+		}
+	})
+
+	t.Run("error-client-authentication", func(t *testing.T) {
+		env := newTestEnv(t)
+		accessToken, _ := env.login(t, "session")
+
+		_, err := env.usecase.Introspect(context.TODO(), &entity.OAuthTokenInspectRequest{ClientID: env.firstPartyClient.ID, ClientSecret: "wrong", Token: accessToken})
+		assertOAuthError(t, err, apperrors.OAUTH_INVALID_CLIENT)
+
+		_, err = env.usecase.Introspect(context.TODO(), &entity.OAuthTokenInspectRequest{ClientID: env.publicClient.ID, Token: accessToken})
+		assertOAuthError(t, err, apperrors.OAUTH_INVALID_CLIENT)
+	})
+}
+
+func TestRevoke(t *testing.T) {
+	t.Run("success-access-token", func(t *testing.T) {
+		env := newTestEnv(t)
+		oauthToken, err := env.exchange(env.authorize(t), testCodeVerifier)
+		require.NoError(t, err)
+
+		env.revoke(t, env.client, oauthToken.AccessToken, "")
+
+		assert.False(t, env.introspect(t, env.client, oauthToken.AccessToken, "").Active)
+		assert.True(t, env.introspect(t, env.client, oauthToken.RefreshToken, "").Active)
+	})
+
+	t.Run("success-refresh-token", func(t *testing.T) {
+		env := newTestEnv(t)
+		oauthToken, err := env.exchange(env.authorize(t), testCodeVerifier)
+		require.NoError(t, err)
+
+		env.revoke(t, env.client, oauthToken.RefreshToken, entity.OAUTH_TOKEN_TYPE_REFRESH_TOKEN)
+
+		assert.Empty(t, env.refreshTokens)
+		_, err = env.usecase.Token(context.TODO(), &entity.OAuthTokenRequest{
+			GrantType:    entity.OAUTH_GRANT_REFRESH_TOKEN,
+			ClientID:     env.client.ID,
+			ClientSecret: testSecret,
+			RefreshToken: oauthToken.RefreshToken,
+		})
+		assertOAuthError(t, err, apperrors.OAUTH_INVALID_GRANT)
+	})
+
+	t.Run("success-first-party-refresh-token", func(t *testing.T) {
+		env := newTestEnv(t)
+		accessToken, refreshToken := env.login(t, "session")
+
+		env.revoke(t, env.firstPartyClient, refreshToken, "")
+
+		assert.Empty(t, env.userRefreshTokens)
+		assert.Empty(t, env.sessions)
+		assert.False(t, env.introspect(t, env.firstPartyClient, accessToken, "").Active)
+	})
+
+	t.Run("ignored-token-of-other-client", func(t *testing.T) {
+		env := newTestEnv(t)
+		accessToken, refreshToken := env.login(t, "session")
+
+		env.revoke(t, env.client, accessToken, "")
+		env.revoke(t, env.client, refreshToken, "")
+		env.revoke(t, env.client, "unknown", "")
+
+		assert.True(t, env.introspect(t, env.firstPartyClient, accessToken, "").Active)
+		assert.Len(t, env.userRefreshTokens, 1)
+	})
+}
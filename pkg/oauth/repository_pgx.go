@@ -0,0 +1,343 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+type pgxOAuthClientRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOAuthClientRepositoryPgx(dbpool *pgxpool.Pool) entity.OAuthClientRepository {
+	return &pgxOAuthClientRepository{db: dbpool}
+}
+
+const oauthClientColumns = `id, secret, name, redirect_uris, grant_types, scopes, first_party, created_at, updated_at`
+
+func (p *pgxOAuthClientRepository) Store(ctx context.Context, m *entity.OAuthClient) error {
+	_, err := p.db.Exec(ctx, `INSERT INTO "oauth_client"(
+		`+oauthClientColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);`,
+		m.ID,
+		m.Secret,
+		m.Name,
+		m.RedirectURIs,
+		m.GrantTypes,
+		m.Scopes,
+		m.FirstParty,
+		m.CreatedAt,
+		m.UpdatedAt,
+	)
+
+	if err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during store to oauth client repository: %w", err)}
+	}
+
+	return nil
+}
+
+func (p *pgxOAuthClientRepository) scan(row pgx.Row, client *entity.OAuthClient) error {
+	return row.Scan(
+		&client.ID,
+		&client.Secret,
+		&client.Name,
+		&client.RedirectURIs,
+		&client.GrantTypes,
+		&client.Scopes,
+		&client.FirstParty,
+		&client.CreatedAt,
+		&client.UpdatedAt,
+	)
+}
+
+func (p *pgxOAuthClientRepository) Find(ctx context.Context, id string) (*entity.OAuthClient, error) {
+	client := entity.OAuthClient{}
+	err := p.scan(p.db.QueryRow(ctx, `SELECT `+oauthClientColumns+` FROM "oauth_client" WHERE id=$1`, id), &client)
+
+	if err == pgx.ErrNoRows {
+		return nil, errors.NewErrNotFound("oauth client")
+	}
+
+	if err != nil {
+		return nil, errors.ErrRepository{Err: fmt.Errorf("error during find to oauth client repository: %w", err)}
+	}
+
+	return &client, nil
+}
+
+func (p *pgxOAuthClientRepository) FindAll(ctx context.Context) ([]*entity.OAuthClient, error) {
+	var items []*entity.OAuthClient
+	rows, err := p.db.Query(ctx, `SELECT `+oauthClientColumns+` FROM "oauth_client" ORDER BY created_at`)
+	if err != nil {
+		return items, errors.ErrRepository{Err: fmt.Errorf("error during find all to oauth client repository: %w", err)}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		client := entity.OAuthClient{}
+		if err := p.scan(rows, &client); err != nil {
+			return items, errors.ErrRepository{Err: fmt.Errorf("error during find all to oauth client repository: %w", err)}
+		}
+		items = append(items, &client)
+	}
+	return items, nil
+}
+
+func (p *pgxOAuthClientRepository) Delete(ctx context.Context, id string) error {
+	if _, err := p.db.Exec(ctx, `DELETE FROM "oauth_client" WHERE id=$1`, id); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during delete to oauth client repository: %w", err)}
+	}
+	return nil
+}
+
+type pgxOAuthAuthorizationCodeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOAuthAuthorizationCodeRepositoryPgx(dbpool *pgxpool.Pool) entity.OAuthAuthorizationCodeRepository {
+	return &pgxOAuthAuthorizationCodeRepository{db: dbpool}
+}
+
+func (p *pgxOAuthAuthorizationCodeRepository) Store(ctx context.Context, m *entity.OAuthAuthorizationCode) error {
+	_, err := p.db.Exec(ctx, `INSERT INTO "oauth_authorization_code"(
+		code, client_id, user_id, redirect_uri, scopes, code_challenge, nonce, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);`,
+		m.Code,
+		m.ClientID,
+		m.UserID,
+		m.RedirectURI,
+		m.Scopes,
+		m.CodeChallenge,
+		m.Nonce,
+		m.ExpiresAt,
+		m.CreatedAt,
+	)
+
+	if err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during store to oauth authorization code repository: %w", err)}
+	}
+
+	return nil
+}
+
+func (p *pgxOAuthAuthorizationCodeRepository) Find(ctx context.Context, code string) (*entity.OAuthAuthorizationCode, error) {
+	authorizationCode := entity.OAuthAuthorizationCode{}
+	row := p.db.QueryRow(ctx, `SELECT code, client_id, user_id, redirect_uri, scopes, code_challenge, nonce, expires_at, created_at
+		FROM "oauth_authorization_code" WHERE code=$1`, code)
+
+	err := row.Scan(
+		&authorizationCode.Code,
+		&authorizationCode.ClientID,
+		&authorizationCode.UserID,
+		&authorizationCode.RedirectURI,
+		&authorizationCode.Scopes,
+		&authorizationCode.CodeChallenge,
+		&authorizationCode.Nonce,
+		&authorizationCode.ExpiresAt,
+		&authorizationCode.CreatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, errors.NewErrNotFound("oauth authorization code")
+	}
+
+	if err != nil {
+		return nil, errors.ErrRepository{Err: fmt.Errorf("error during find to oauth authorization code repository: %w", err)}
+	}
+
+	return &authorizationCode, nil
+}
+
+// use fails with not found when the code was already used, it makes the code single use.
+// Used codes are kept until they expire, so their reuse is detected.
+func (p *pgxOAuthAuthorizationCodeRepository) Use(ctx context.Context, code string, usedAt time.Time) error {
+	tag, err := p.db.Exec(ctx, `UPDATE "oauth_authorization_code" SET used_at=$2 WHERE code=$1 AND used_at IS NULL`, code, usedAt)
+	if err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during use to oauth authorization code repository: %w", err)}
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.NewErrNotFound("oauth authorization code")
+	}
+	return nil
+}
+
+func (p *pgxOAuthAuthorizationCodeRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	if _, err := p.db.Exec(ctx, `DELETE FROM "oauth_authorization_code" WHERE expires_at<$1`, before); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during delete expired to oauth authorization code repository: %w", err)}
+	}
+	return nil
+}
+
+type pgxOAuthConsentRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOAuthConsentRepositoryPgx(dbpool *pgxpool.Pool) entity.OAuthConsentRepository {
+	return &pgxOAuthConsentRepository{db: dbpool}
+}
+
+// store replaces scopes of the previous consent of the user to the client
+func (p *pgxOAuthConsentRepository) Store(ctx context.Context, m *entity.OAuthConsent) error {
+	_, err := p.db.Exec(ctx, `INSERT INTO "oauth_consent"(
+		user_id, client_id, scopes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, client_id) DO UPDATE
+		SET scopes=EXCLUDED.scopes, updated_at=EXCLUDED.updated_at`,
+		m.UserID,
+		m.ClientID,
+		m.Scopes,
+		m.CreatedAt,
+		m.UpdatedAt,
+	)
+
+	if err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during store to oauth consent repository: %w", err)}
+	}
+
+	return nil
+}
+
+func (p *pgxOAuthConsentRepository) Find(ctx context.Context, userID, clientID string) (*entity.OAuthConsent, error) {
+	consent := entity.OAuthConsent{}
+	row := p.db.QueryRow(ctx, `SELECT user_id, client_id, scopes, created_at, updated_at FROM "oauth_consent" WHERE user_id=$1 AND client_id=$2`, userID, clientID)
+
+	err := row.Scan(
+		&consent.UserID,
+		&consent.ClientID,
+		&consent.Scopes,
+		&consent.CreatedAt,
+		&consent.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, errors.NewErrNotFound("oauth consent")
+	}
+
+	if err != nil {
+		return nil, errors.ErrRepository{Err: fmt.Errorf("error during find to oauth consent repository: %w", err)}
+	}
+
+	return &consent, nil
+}
+
+func (p *pgxOAuthConsentRepository) FindByUserId(ctx context.Context, id string) ([]*entity.OAuthConsent, error) {
+	var items []*entity.OAuthConsent
+	rows, err := p.db.Query(ctx, `SELECT user_id, client_id, scopes, created_at, updated_at FROM "oauth_consent" WHERE user_id=$1 ORDER BY created_at`, id)
+	if err != nil {
+		return items, errors.ErrRepository{Err: fmt.Errorf("error during find by user id to oauth consent repository: %w", err)}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		consent := entity.OAuthConsent{}
+		if err := rows.Scan(&consent.UserID, &consent.ClientID, &consent.Scopes, &consent.CreatedAt, &consent.UpdatedAt); err != nil {
+			return items, errors.ErrRepository{Err: fmt.Errorf("error during find by user id to oauth consent repository: %w", err)}
+		}
+		items = append(items, &consent)
+	}
+	return items, nil
+}
+
+func (p *pgxOAuthConsentRepository) Delete(ctx context.Context, userID, clientID string) error {
+	if _, err := p.db.Exec(ctx, `DELETE FROM "oauth_consent" WHERE user_id=$1 AND client_id=$2`, userID, clientID); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during delete to oauth consent repository: %w", err)}
+	}
+	return nil
+}
+
+func (p *pgxOAuthConsentRepository) DeleteByClientId(ctx context.Context, id string) error {
+	if _, err := p.db.Exec(ctx, `DELETE FROM "oauth_consent" WHERE client_id=$1`, id); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during delete by client id to oauth consent repository: %w", err)}
+	}
+	return nil
+}
+
+type pgxOAuthRefreshTokenRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOAuthRefreshTokenRepositoryPgx(dbpool *pgxpool.Pool) entity.OAuthRefreshTokenRepository {
+	return &pgxOAuthRefreshTokenRepository{db: dbpool}
+}
+
+func (p *pgxOAuthRefreshTokenRepository) Store(ctx context.Context, m *entity.OAuthRefreshToken) error {
+	_, err := p.db.Exec(ctx, `INSERT INTO "oauth_refresh_token"(
+		token, client_id, user_id, scopes, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6);`,
+		m.Token,
+		m.ClientID,
+		m.UserID,
+		m.Scopes,
+		m.ExpiresAt,
+		m.CreatedAt,
+	)
+
+	if err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during store to oauth refresh token repository: %w", err)}
+	}
+
+	return nil
+}
+
+func (p *pgxOAuthRefreshTokenRepository) Find(ctx context.Context, token string) (*entity.OAuthRefreshToken, error) {
+	refreshToken := entity.OAuthRefreshToken{}
+	row := p.db.QueryRow(ctx, `SELECT token, client_id, user_id, scopes, expires_at, created_at FROM "oauth_refresh_token" WHERE token=$1`, token)
+
+	err := row.Scan(
+		&refreshToken.Token,
+		&refreshToken.ClientID,
+		&refreshToken.UserID,
+		&refreshToken.Scopes,
+		&refreshToken.ExpiresAt,
+		&refreshToken.CreatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, errors.NewErrNotFound("oauth refresh token")
+	}
+
+	if err != nil {
+		return nil, errors.ErrRepository{Err: fmt.Errorf("error during find to oauth refresh token repository: %w", err)}
+	}
+
+	return &refreshToken, nil
+}
+
+// delete fails with not found when the token was already used, it makes the token single use
+func (p *pgxOAuthRefreshTokenRepository) Delete(ctx context.Context, token string) error {
+	tag, err := p.db.Exec(ctx, `DELETE FROM "oauth_refresh_token" WHERE token=$1`, token)
+	if err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during delete to oauth refresh token repository: %w", err)}
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.NewErrNotFound("oauth refresh token")
+	}
+	return nil
+}
+
+func (p *pgxOAuthRefreshTokenRepository) DeleteByUserAndClient(ctx context.Context, userID, clientID string) error {
+	if _, err := p.db.Exec(ctx, `DELETE FROM "oauth_refresh_token" WHERE user_id=$1 AND client_id=$2`, userID, clientID); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during delete by user and client to oauth refresh token repository: %w", err)}
+	}
+	return nil
+}
+
+func (p *pgxOAuthRefreshTokenRepository) DeleteByUserId(ctx context.Context, id string) error {
+	if _, err := p.db.Exec(ctx, `DELETE FROM "oauth_refresh_token" WHERE user_id=$1`, id); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during delete by user id to oauth refresh token repository: %w", err)}
+	}
+	return nil
+}
+
+func (p *pgxOAuthRefreshTokenRepository) DeleteByClientId(ctx context.Context, id string) error {
+	if _, err := p.db.Exec(ctx, `DELETE FROM "oauth_refresh_token" WHERE client_id=$1`, id); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during delete by client id to oauth refresh token repository: %w", err)}
+	}
+	return nil
+}
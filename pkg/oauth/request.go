@@ -0,0 +1,22 @@
+package oauth
+
+type AuthorizeRequest struct {
+	ResponseType        string `json:"response_type"`
+	ClientID            string `json:"client_id"`
+	RedirectURI         string `json:"redirect_uri"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+	Nonce               string `json:"nonce"`
+	Approve             bool   `json:"approve"`
+}
+
+type CreateClientRequest struct {
+	Name         string   `json:"name" validate:"required,max=100"`
+	RedirectURIs []string `json:"redirect_uris" validate:"omitempty,dive,required,url"`
+	GrantTypes   []string `json:"grant_types" validate:"required,min=1"`
+	Scopes       []string `json:"scopes" validate:"omitempty,dive,required"`
+	Public       bool     `json:"public"`
+	FirstParty   bool     `json:"first_party"`
+}
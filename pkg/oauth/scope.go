@@ -0,0 +1,50 @@
+package oauth
+
+import (
+	"regexp"
+	"strings"
+)
+
+// scope token characters, RFC 6749 section 3.3
+var scopeTokenRegexp = regexp.MustCompile(`^[\x21\x23-\x5B\x5D-\x7E]+$`)
+
+// parse scope splits space delimited scope parameter, duplicates are dropped
+func parseScope(scope string) []string {
+	var scopes []string
+	for _, s := range strings.Fields(scope) {
+		if !containsScope(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// contains all checks every scope of want is in have
+func containsAllScopes(have, want []string) bool {
+	for _, s := range want {
+		if !containsScope(have, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// merge scopes returns scopes of a followed by scopes of b missing in a
+func mergeScopes(a, b []string) []string {
+	scopes := append([]string{}, a...)
+	for _, s := range b {
+		if !containsScope(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
@@ -0,0 +1,500 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
+	"github.com/Jamshid90/go-clean-architecture/pkg/rand"
+	"github.com/Jamshid90/go-clean-architecture/pkg/token"
+	"time"
+)
+
+const (
+	RESPONSE_TYPE_CODE         = "code"
+	CODE_CHALLENGE_METHOD_S256 = "S256"
+	TOKEN_TYPE_BEARER          = "Bearer"
+)
+
+type oauthUsecase struct {
+	clientRepo          entity.OAuthClientRepository
+	codeRepo            entity.OAuthAuthorizationCodeRepository
+	consentRepo         entity.OAuthConsentRepository
+	refreshTokenRepo    entity.OAuthRefreshTokenRepository
+	userUsecase         entity.UserUsecase
+	sessionUsecase      entity.SessionUsecase
+	refreshTokenUsecase entity.RefreshTokenUsecase
+	revocationUsecase   entity.TokenRevocationUsecase
+	keys                *token.KeySet
+	issuer              string
+	codeTTL             time.Duration
+	accessTTL           time.Duration
+	refreshTTL          time.Duration
+	contextTimeout      time.Duration
+}
+
+// New oauth usecase, access and id tokens are signed with the keys of the first-party tokens,
+// issuer is the "iss" claim of them, id tokens live as long as access tokens.
+// Session, refresh token and revocation usecases of the first-party tokens are used to introspect and revoke them.
+func NewOAuthUsecase(clientRepo entity.OAuthClientRepository, codeRepo entity.OAuthAuthorizationCodeRepository, consentRepo entity.OAuthConsentRepository, refreshTokenRepo entity.OAuthRefreshTokenRepository, userUsecase entity.UserUsecase, sessionUsecase entity.SessionUsecase, refreshTokenUsecase entity.RefreshTokenUsecase, revocationUsecase entity.TokenRevocationUsecase, keys *token.KeySet, issuer string, codeTTL, accessTTL, refreshTTL time.Duration, timeout time.Duration) oauthUsecase {
+	return oauthUsecase{
+		clientRepo:          clientRepo,
+		codeRepo:            codeRepo,
+		consentRepo:         consentRepo,
+		refreshTokenRepo:    refreshTokenRepo,
+		userUsecase:         userUsecase,
+		sessionUsecase:      sessionUsecase,
+		refreshTokenUsecase: refreshTokenUsecase,
+		revocationUsecase:   revocationUsecase,
+		keys:                keys,
+		issuer:              issuer,
+		codeTTL:             codeTTL,
+		accessTTL:           accessTTL,
+		refreshTTL:          refreshTTL,
+		contextTimeout:      timeout,
+	}
+}
+
+// FindClient returns the client of the authorization request, errors of it must not be redirected
+// to the redirect uri since the uri is not known to belong to the client
+func (o *oauthUsecase) FindClient(ctx context.Context, clientID, redirectURI string) (*entity.OAuthClient, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.contextTimeout)
+	defer cancel()
+
+	if clientID == "" {
+		return nil, errors.NewErrOAuth(errors.OAUTH_INVALID_REQUEST, "client_id is required")
+	}
+
+	client, err := o.clientRepo.Find(ctx, clientID)
+	if err != nil {
+		if _, ok := err.(*errors.ErrNotFound); ok {
+			return nil, errors.NewErrOAuth(errors.OAUTH_INVALID_CLIENT, "unknown client")
+		}
+		return nil, err
+	}
+
+	if redirectURI == "" || !client.HasRedirectURI(redirectURI) {
+		return nil, errors.NewErrOAuth(errors.OAUTH_INVALID_REQUEST, "redirect_uri is not registered for the client")
+	}
+
+	return client, nil
+}
+
+// ValidateAuthorize checks the authorization request of the client and returns the requested scopes,
+// errors of it are sent to the redirect uri of the client
+func (o *oauthUsecase) ValidateAuthorize(client *entity.OAuthClient, request *entity.OAuthAuthorizeRequest) ([]string, error) {
+	if request.ResponseType != RESPONSE_TYPE_CODE {
+		return nil, errors.NewErrOAuth(errors.OAUTH_UNSUPPORTED_RESPONSE_TYPE, "only code response type is supported")
+	}
+
+	if !client.HasGrantType(entity.OAUTH_GRANT_AUTHORIZATION_CODE) {
+		return nil, errors.NewErrOAuth(errors.OAUTH_UNAUTHORIZED_CLIENT, "client is not allowed to use authorization_code grant")
+	}
+
+	// pkce is required for public and confidential clients
+	if request.CodeChallengeMethod != CODE_CHALLENGE_METHOD_S256 {
+		return nil, errors.NewErrOAuth(errors.OAUTH_INVALID_REQUEST, "code_challenge_method must be S256")
+	}
+	// base64url encoded sha256 digest is 43 characters, verifiers are 43-128 characters, RFC 7636
+	if len(request.CodeChallenge) < 43 || len(request.CodeChallenge) > 128 {
+		return nil, errors.NewErrOAuth(errors.OAUTH_INVALID_REQUEST, "code_challenge is required")
+	}
+
+	return o.scopes(client, request.Scope, client.Scopes)
+}
+
+// scopes returns requested scopes allowed by the client, allowed scopes are granted when none is requested
+func (o *oauthUsecase) scopes(client *entity.OAuthClient, scope string, allowed []string) ([]string, error) {
+	scopes := parseScope(scope)
+	if len(scopes) == 0 {
+		return allowed, nil
+	}
+
+	for _, s := range scopes {
+		if !containsScope(allowed, s) || !containsScope(client.Scopes, s) {
+			return nil, errors.NewErrOAuth(errors.OAUTH_INVALID_SCOPE, "scope "+s+" is not allowed")
+		}
+	}
+	return scopes, nil
+}
+
+// ConsentRequired checks the user has already granted the scopes to the client, first-party clients need no consent
+func (o *oauthUsecase) ConsentRequired(ctx context.Context, userID string, client *entity.OAuthClient, scopes []string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.contextTimeout)
+	defer cancel()
+
+	if client.FirstParty {
+		return false, nil
+	}
+
+	consent, err := o.consentRepo.Find(ctx, userID, client.ID)
+	if err != nil {
+		if _, ok := err.(*errors.ErrNotFound); ok {
+			return true, nil
+		}
+		return false, err
+	}
+
+	return !containsAllScopes(consent.Scopes, scopes), nil
+}
+
+// Consent records the scopes granted by the user to the client, scopes granted before are kept
+func (o *oauthUsecase) Consent(ctx context.Context, userID string, client *entity.OAuthClient, scopes []string) error {
+	ctx, cancel := context.WithTimeout(ctx, o.contextTimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	consent, err := o.consentRepo.Find(ctx, userID, client.ID)
+	if err != nil {
+		if _, ok := err.(*errors.ErrNotFound); !ok {
+			return err
+		}
+		consent = &entity.OAuthConsent{UserID: userID, ClientID: client.ID, CreatedAt: now}
+	}
+
+	consent.Scopes = mergeScopes(consent.Scopes, scopes)
+	consent.UpdatedAt = now
+
+	return o.consentRepo.Store(ctx, consent)
+}
+
+// Authorize issues authorization code the client exchanges for tokens at the token endpoint
+func (o *oauthUsecase) Authorize(ctx context.Context, userID string, client *entity.OAuthClient, request *entity.OAuthAuthorizeRequest, scopes []string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.contextTimeout)
+	defer cancel()
+
+	code, err := rand.Token(32)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	if err := o.codeRepo.DeleteExpired(ctx, now); err != nil {
+		return "", err
+	}
+
+	if err := o.codeRepo.Store(ctx, &entity.OAuthAuthorizationCode{
+		Code:          hash.HashToken(code),
+		ClientID:      client.ID,
+		UserID:        userID,
+		RedirectURI:   request.RedirectURI,
+		Scopes:        scopes,
+		CodeChallenge: request.CodeChallenge,
+		Nonce:         request.Nonce,
+		ExpiresAt:     now.Add(o.codeTTL),
+		CreatedAt:     now,
+	}); err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
+
+// Token authenticates the client and issues tokens for the grant of the request
+func (o *oauthUsecase) Token(ctx context.Context, request *entity.OAuthTokenRequest) (*entity.OAuthToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.contextTimeout)
+	defer cancel()
+
+	supported := false
+	for _, g := range entity.OAuthGrantTypes {
+		if g == request.GrantType {
+			supported = true
+		}
+	}
+	if !supported {
+		return nil, errors.NewErrOAuth(errors.OAUTH_UNSUPPORTED_GRANT_TYPE, "unsupported grant type")
+	}
+
+	client, err := o.authenticateClient(ctx, request.ClientID, request.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	if !client.HasGrantType(request.GrantType) {
+		return nil, errors.NewErrOAuth(errors.OAUTH_UNAUTHORIZED_CLIENT, "client is not allowed to use "+request.GrantType+" grant")
+	}
+
+	switch request.GrantType {
+	case entity.OAUTH_GRANT_AUTHORIZATION_CODE:
+		return o.exchangeCode(ctx, client, request)
+	case entity.OAUTH_GRANT_REFRESH_TOKEN:
+		return o.refresh(ctx, client, request)
+	default:
+		return o.clientCredentials(ctx, client, request)
+	}
+}
+
+// authenticate client, public clients must not send a secret
+func (o *oauthUsecase) authenticateClient(ctx context.Context, clientID, secret string) (*entity.OAuthClient, error) {
+	errInvalidClient := errors.NewErrOAuth(errors.OAUTH_INVALID_CLIENT, "client authentication failed")
+	if clientID == "" {
+		return nil, errInvalidClient
+	}
+
+	client, err := o.clientRepo.Find(ctx, clientID)
+	if err != nil {
+		if _, ok := err.(*errors.ErrNotFound); ok {
+			return nil, errInvalidClient
+		}
+		return nil, err
+	}
+
+	if client.IsPublic() {
+		if secret != "" {
+			return nil, errInvalidClient
+		}
+		return client, nil
+	}
+
+	if secret == "" || subtle.ConstantTimeCompare([]byte(hash.HashToken(secret)), []byte(client.Secret)) != 1 {
+		return nil, errInvalidClient
+	}
+	return client, nil
+}
+
+// exchange code checks the code was issued to the client for the redirect uri and the pkce verifier matches the challenge
+func (o *oauthUsecase) exchangeCode(ctx context.Context, client *entity.OAuthClient, request *entity.OAuthTokenRequest) (*entity.OAuthToken, error) {
+	if request.Code == "" || request.CodeVerifier == "" {
+		return nil, errors.NewErrOAuth(errors.OAUTH_INVALID_REQUEST, "code and code_verifier are required")
+	}
+
+	errInvalidGrant := errors.NewErrOAuth(errors.OAUTH_INVALID_GRANT, "invalid or expired authorization code")
+
+	code, err := o.codeRepo.Find(ctx, hash.HashToken(request.Code))
+	if err != nil {
+		if _, ok := err.(*errors.ErrNotFound); ok {
+			return nil, errInvalidGrant
+		}
+		return nil, err
+	}
+
+	// code is single use, concurrent exchanges of the same code fail on use. The reused code may have leaked,
+	// so tokens the client got for the user are revoked
+	if err := o.codeRepo.Use(ctx, code.Code, time.Now().UTC()); err != nil {
+		if _, ok := err.(*errors.ErrNotFound); ok {
+			if err := o.revokeUserClient(ctx, code.UserID, code.ClientID); err != nil {
+				return nil, err
+			}
+			return nil, errInvalidGrant
+		}
+		return nil, err
+	}
+
+	if code.ClientID != client.ID || code.RedirectURI != request.RedirectURI || time.Now().UTC().After(code.ExpiresAt) {
+		return nil, errInvalidGrant
+	}
+
+	if !verifyCodeChallenge(request.CodeVerifier, code.CodeChallenge) {
+		return nil, errors.NewErrOAuth(errors.OAUTH_INVALID_GRANT, "code_verifier does not match code_challenge")
+	}
+
+	user, err := o.checkUser(ctx, code.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.issue(ctx, client, user, code.Scopes, client.HasGrantType(entity.OAUTH_GRANT_REFRESH_TOKEN), code.Nonce)
+}
+
+// refresh rotates the refresh token, narrower scopes than the granted ones can be requested
+func (o *oauthUsecase) refresh(ctx context.Context, client *entity.OAuthClient, request *entity.OAuthTokenRequest) (*entity.OAuthToken, error) {
+	if request.RefreshToken == "" {
+		return nil, errors.NewErrOAuth(errors.OAUTH_INVALID_REQUEST, "refresh_token is required")
+	}
+
+	errInvalidGrant := errors.NewErrOAuth(errors.OAUTH_INVALID_GRANT, "invalid or expired refresh token")
+
+	refreshToken, err := o.refreshTokenRepo.Find(ctx, hash.HashToken(request.RefreshToken))
+	if err != nil {
+		if _, ok := err.(*errors.ErrNotFound); ok {
+			return nil, errInvalidGrant
+		}
+		return nil, err
+	}
+
+	if refreshToken.ClientID != client.ID {
+		return nil, errInvalidGrant
+	}
+
+	if err := o.refreshTokenRepo.Delete(ctx, refreshToken.Token); err != nil {
+		if _, ok := err.(*errors.ErrNotFound); ok {
+			return nil, errInvalidGrant
+		}
+		return nil, err
+	}
+
+	if time.Now().UTC().After(refreshToken.ExpiresAt) {
+		return nil, errInvalidGrant
+	}
+
+	scopes, err := o.scopes(client, request.Scope, refreshToken.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := o.checkUser(ctx, refreshToken.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.issue(ctx, client, user, scopes, true, "")
+}
+
+// client credentials issues access token to the client itself
+func (o *oauthUsecase) clientCredentials(ctx context.Context, client *entity.OAuthClient, request *entity.OAuthTokenRequest) (*entity.OAuthToken, error) {
+	if client.IsPublic() {
+		return nil, errors.NewErrOAuth(errors.OAUTH_UNAUTHORIZED_CLIENT, "public clients can not use client_credentials grant")
+	}
+
+	scopes, err := o.scopes(client, request.Scope, client.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.issue(ctx, client, nil, scopes, false, "")
+}
+
+// check user, tokens are not issued for removed and disabled users
+func (o *oauthUsecase) checkUser(ctx context.Context, id string) (*entity.User, error) {
+	user, err := o.userUsecase.Find(ctx, id)
+	if err != nil {
+		if _, ok := err.(*errors.ErrNotFound); ok {
+			return nil, errors.NewErrOAuth(errors.OAUTH_INVALID_GRANT, "user not found")
+		}
+		return nil, err
+	}
+	if user.IsDisabled() {
+		return nil, errors.NewErrOAuth(errors.OAUTH_INVALID_GRANT, errors.ErrAccountDisabled.Error())
+	}
+	return user, nil
+}
+
+// issue returns access token of the user, or of the client itself when user is nil, a new refresh token
+// when withRefresh is set and id token when openid scope is granted
+func (o *oauthUsecase) issue(ctx context.Context, client *entity.OAuthClient, user *entity.User, scopes []string, withRefresh bool, nonce string) (*entity.OAuthToken, error) {
+	sub := client.ID
+	if user != nil {
+		sub = user.ID
+	}
+
+	accessToken, err := token.GenerateOAuthAccessToken(o.keys, o.issuer, o.accessTTL, sub, client.ID, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	oauthToken := entity.OAuthToken{
+		AccessToken: accessToken,
+		TokenType:   TOKEN_TYPE_BEARER,
+		ExpiresIn:   int64(o.accessTTL.Seconds()),
+		Scopes:      scopes,
+	}
+
+	if withRefresh {
+		refreshToken, err := rand.Token(32)
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now().UTC()
+		if err := o.refreshTokenRepo.Store(ctx, &entity.OAuthRefreshToken{
+			Token:     hash.HashToken(refreshToken),
+			ClientID:  client.ID,
+			UserID:    sub,
+			Scopes:    scopes,
+			ExpiresAt: now.Add(o.refreshTTL),
+			CreatedAt: now,
+		}); err != nil {
+			return nil, err
+		}
+		oauthToken.RefreshToken = refreshToken
+	}
+
+	if user != nil && containsScope(scopes, SCOPE_OPENID) {
+		idToken, err := token.GenerateIDToken(o.keys, o.issuer, o.accessTTL, client.ID, nonce, userClaims(user, scopes))
+		if err != nil {
+			return nil, err
+		}
+		oauthToken.IDToken = idToken
+	}
+
+	return &oauthToken, nil
+}
+
+// UserInfo returns claims of the user the access token was issued for, the token must be granted openid scope
+func (o *oauthUsecase) UserInfo(ctx context.Context, accessToken string) (map[string]interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.contextTimeout)
+	defer cancel()
+
+	errInvalidToken := errors.NewErrOAuth(errors.OAUTH_INVALID_TOKEN, "invalid or expired access token")
+
+	parsed, err := token.ParseOAuthAccessToken(o.keys, accessToken)
+	if err != nil {
+		return nil, errInvalidToken
+	}
+
+	active, err := o.activeClientToken(ctx, parsed)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, errInvalidToken
+	}
+
+	if !containsScope(parsed.Scopes, SCOPE_OPENID) {
+		return nil, errors.NewErrOAuth(errors.OAUTH_INSUFFICIENT_SCOPE, "openid scope is required")
+	}
+
+	user, err := o.userUsecase.Find(ctx, parsed.Subject)
+	if err != nil {
+		if _, ok := err.(*errors.ErrNotFound); ok {
+			return nil, errInvalidToken
+		}
+		return nil, err
+	}
+	if user.IsDisabled() {
+		return nil, errInvalidToken
+	}
+
+	return userClaims(user, parsed.Scopes), nil
+}
+
+// FindConsents returns clients the user granted access to
+func (o *oauthUsecase) FindConsents(ctx context.Context, userID string) ([]*entity.OAuthConsent, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.contextTimeout)
+	defer cancel()
+	return o.consentRepo.FindByUserId(ctx, userID)
+}
+
+// RevokeConsent removes the consent and revokes tokens the client got from the user
+func (o *oauthUsecase) RevokeConsent(ctx context.Context, userID, clientID string) error {
+	ctx, cancel := context.WithTimeout(ctx, o.contextTimeout)
+	defer cancel()
+
+	if _, err := o.consentRepo.Find(ctx, userID, clientID); err != nil {
+		return err
+	}
+
+	if err := o.revokeUserClient(ctx, userID, clientID); err != nil {
+		return err
+	}
+
+	return o.consentRepo.Delete(ctx, userID, clientID)
+}
+
+// revoke user client removes refresh tokens and revokes access tokens the client got for the user
+func (o *oauthUsecase) revokeUserClient(ctx context.Context, userID, clientID string) error {
+	if err := o.refreshTokenRepo.DeleteByUserAndClient(ctx, userID, clientID); err != nil {
+		return err
+	}
+	return o.revocationUsecase.RevokeUserClient(ctx, userID, clientID)
+}
+
+// verify code challenge, S256 method only: BASE64URL(SHA256(verifier)) == challenge
+func verifyCodeChallenge(verifier, challenge string) bool {
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
@@ -0,0 +1,638 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	stderrors "errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity/mocks"
+	apperrors "github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
+	"github.com/Jamshid90/go-clean-architecture/pkg/refreshtoken"
+	"github.com/Jamshid90/go-clean-architecture/pkg/revocation"
+	"github.com/Jamshid90/go-clean-architecture/pkg/session"
+	"github.com/Jamshid90/go-clean-architecture/pkg/token"
+	"github.com/Jamshid90/go-clean-architecture/pkg/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testSecret       = "secret"
+	testRedirectURI  = "https://app.example.com/callback"
+	testCodeVerifier = "dBjftJeZ4CVP-mJ92K9EvlhhQz4pL2Q6wB3ZsYD7Jqc-verifier"
+)
+
+func testCodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func testClient(t *testing.T) *entity.OAuthClient {
+	t.Helper()
+	return &entity.OAuthClient{
+		ID:           "client",
+		Secret:       hash.HashToken(testSecret),
+		Name:         "Client",
+		RedirectURIs: []string{testRedirectURI},
+		GrantTypes:   []string{entity.OAUTH_GRANT_AUTHORIZATION_CODE, entity.OAUTH_GRANT_REFRESH_TOKEN, entity.OAUTH_GRANT_CLIENT_CREDENTIALS},
+		Scopes:       []string{"profile", "email"},
+	}
+}
+
+func assertOAuthError(t *testing.T, err error, code string) {
+	t.Helper()
+	var errOAuth *apperrors.ErrOAuth
+	require.True(t, stderrors.As(err, &errOAuth), "expected oauth error, got %v", err)
+	assert.Equal(t, code, errOAuth.Code)
+}
+
+// testEnv keeps authorization codes and refresh tokens in the mocks as the repositories would,
+// first-party client and first-party refresh tokens and sessions are used by introspection
+type testEnv struct {
+	usecase              oauthUsecase
+	client               *entity.OAuthClient
+	firstPartyClient     *entity.OAuthClient
+	publicClient         *entity.OAuthClient
+	user                 *entity.User
+	keys                 *token.KeySet
+	clientRepo           *mocks.OAuthClientRepository
+	codeRepo             *mocks.OAuthAuthorizationCodeRepository
+	consentRepo          *mocks.OAuthConsentRepository
+	refreshTokenRepo     *mocks.OAuthRefreshTokenRepository
+	userRepo             *mocks.UserRepository
+	sessionRepo          *mocks.SessionRepository
+	userRefreshTokenRepo *mocks.RefreshTokenRepository
+	revocationUsecase    entity.TokenRevocationUsecase
+
+	codes             map[string]*entity.OAuthAuthorizationCode
+	usedCodes         map[string]bool
+	refreshTokens     map[string]*entity.OAuthRefreshToken
+	userRefreshTokens map[string]*entity.RefreshToken
+	sessions          map[string]*entity.Session
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	env := &testEnv{
+		client:               testClient(t),
+		firstPartyClient:     testClient(t),
+		publicClient:         testClient(t),
+		user:                 user.TestUser(t),
+		keys:                 token.TestKeySet(t),
+		clientRepo:           new(mocks.OAuthClientRepository),
+		codeRepo:             new(mocks.OAuthAuthorizationCodeRepository),
+		consentRepo:          new(mocks.OAuthConsentRepository),
+		refreshTokenRepo:     new(mocks.OAuthRefreshTokenRepository),
+		userRepo:             new(mocks.UserRepository),
+		sessionRepo:          new(mocks.SessionRepository),
+		userRefreshTokenRepo: new(mocks.RefreshTokenRepository),
+		codes:                make(map[string]*entity.OAuthAuthorizationCode),
+		usedCodes:            make(map[string]bool),
+		refreshTokens:        make(map[string]*entity.OAuthRefreshToken),
+		userRefreshTokens:    make(map[string]*entity.RefreshToken),
+		sessions:             make(map[string]*entity.Session),
+	}
+	env.firstPartyClient.ID = "first-party"
+	env.firstPartyClient.FirstParty = true
+	env.publicClient.ID = "public"
+	env.publicClient.Secret = ""
+
+	env.clientRepo.On("Find", mock.Anything, env.client.ID).Return(env.client, nil)
+	env.clientRepo.On("Find", mock.Anything, env.firstPartyClient.ID).Return(env.firstPartyClient, nil)
+	env.clientRepo.On("Find", mock.Anything, env.publicClient.ID).Return(env.publicClient, nil)
+	env.clientRepo.On("Find", mock.Anything, mock.AnythingOfType("string")).Return(nil, apperrors.NewErrNotFound("oauth client"))
+	env.userRepo.On("Find", mock.Anything, env.user.ID).Return(env.user, nil)
+
+	env.codeRepo.On("DeleteExpired", mock.Anything, mock.AnythingOfType("time.Time")).Return(nil)
+	env.codeRepo.On("Store", mock.Anything, mock.AnythingOfType("*entity.OAuthAuthorizationCode")).Run(func(args mock.Arguments) {
+		code := args.Get(1).(*entity.OAuthAuthorizationCode)
+		env.codes[code.Code] = code
+	}).Return(nil)
+	env.codeRepo.On("Find", mock.Anything, mock.AnythingOfType("string")).Return(
+		func(ctx context.Context, code string) *entity.OAuthAuthorizationCode {
+			return env.codes[code]
+		},
+		func(ctx context.Context, code string) error {
+			if _, ok := env.codes[code]; !ok {
+				return apperrors.NewErrNotFound("oauth authorization code")
+			}
+			return nil
+		},
+	)
+	env.codeRepo.On("Use", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(func(ctx context.Context, code string, usedAt time.Time) error {
+		if env.usedCodes[code] {
+			return apperrors.NewErrNotFound("oauth authorization code")
+		}
+		env.usedCodes[code] = true
+		return nil
+	})
+
+	env.refreshTokenRepo.On("Store", mock.Anything, mock.AnythingOfType("*entity.OAuthRefreshToken")).Run(func(args mock.Arguments) {
+		refreshToken := args.Get(1).(*entity.OAuthRefreshToken)
+		env.refreshTokens[refreshToken.Token] = refreshToken
+	}).Return(nil)
+	env.refreshTokenRepo.On("Find", mock.Anything, mock.AnythingOfType("string")).Return(
+		func(ctx context.Context, token string) *entity.OAuthRefreshToken {
+			return env.refreshTokens[token]
+		},
+		func(ctx context.Context, token string) error {
+			if _, ok := env.refreshTokens[token]; !ok {
+				return apperrors.NewErrNotFound("oauth refresh token")
+			}
+			return nil
+		},
+	)
+	env.refreshTokenRepo.On("Delete", mock.Anything, mock.AnythingOfType("string")).Return(func(ctx context.Context, token string) error {
+		if _, ok := env.refreshTokens[token]; !ok {
+			return apperrors.NewErrNotFound("oauth refresh token")
+		}
+		delete(env.refreshTokens, token)
+		return nil
+	})
+
+	env.userRefreshTokenRepo.On("Find", mock.Anything, mock.AnythingOfType("string")).Return(
+		func(ctx context.Context, token string) *entity.RefreshToken {
+			return env.userRefreshTokens[token]
+		},
+		func(ctx context.Context, token string) error {
+			if _, ok := env.userRefreshTokens[token]; !ok {
+				return apperrors.NewErrNotFound("refresh token")
+			}
+			return nil
+		},
+	)
+	env.userRefreshTokenRepo.On("DeleteByFamily", mock.Anything, mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		for token, refreshToken := range env.userRefreshTokens {
+			if refreshToken.FamilyID == args.String(1) {
+				delete(env.userRefreshTokens, token)
+			}
+		}
+	}).Return(nil)
+
+	env.sessionRepo.On("Find", mock.Anything, mock.AnythingOfType("string")).Return(
+		func(ctx context.Context, id string) *entity.Session {
+			return env.sessions[id]
+		},
+		func(ctx context.Context, id string) error {
+			if _, ok := env.sessions[id]; !ok {
+				return apperrors.NewErrNotFound("session")
+			}
+			return nil
+		},
+	)
+	env.sessionRepo.On("Delete", mock.Anything, mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		delete(env.sessions, args.String(1))
+	}).Return(nil)
+
+	userUsecase := user.NewUserUsecase(env.userRepo, new(mocks.SessionUsecase), new(mocks.EmailVerificationUsecase), user.TestRevocationUsecase(t), new(mocks.APIKeyRepository), new(mocks.OAuthRefreshTokenRepository), user.TestPasswordHasher(t), user.TestPasswordPolicy(t), time.Second*2)
+	sessionUsecase := session.NewSessionUsecase(env.sessionRepo, env.userRefreshTokenRepo, time.Hour*24, time.Second*2)
+	refreshTokenUsecase := refreshtoken.NewRefreshTokenUsecase(env.userRefreshTokenRepo, env.sessionRepo, time.Hour*24, time.Second*2)
+	revocationUsecase := revocation.NewTokenRevocationUsecase(revocation.NewTokenRevocationRepositoryMemory(), time.Minute*15, time.Second*2)
+	env.revocationUsecase = &revocationUsecase
+	env.usecase = NewOAuthUsecase(env.clientRepo, env.codeRepo, env.consentRepo, env.refreshTokenRepo, &userUsecase, &sessionUsecase, &refreshTokenUsecase, &revocationUsecase, env.keys, "http://localhost", time.Minute, time.Minute*15, time.Hour, time.Second*2)
+
+	return env
+}
+
+func (env *testEnv) authorizeRequest() *entity.OAuthAuthorizeRequest {
+	return &entity.OAuthAuthorizeRequest{
+		ResponseType:        RESPONSE_TYPE_CODE,
+		ClientID:            env.client.ID,
+		RedirectURI:         testRedirectURI,
+		Scope:               "profile",
+		State:               "state",
+		CodeChallenge:       testCodeChallenge(testCodeVerifier),
+		CodeChallengeMethod: CODE_CHALLENGE_METHOD_S256,
+	}
+}
+
+// authorize issues the code for the user as the authorization endpoint would
+func (env *testEnv) authorize(t *testing.T) string {
+	t.Helper()
+
+	request := env.authorizeRequest()
+	client, err := env.usecase.FindClient(context.TODO(), request.ClientID, request.RedirectURI)
+	require.NoError(t, err)
+	scopes, err := env.usecase.ValidateAuthorize(client, request)
+	require.NoError(t, err)
+	code, err := env.usecase.Authorize(context.TODO(), env.user.ID, client, request, scopes)
+	require.NoError(t, err)
+	return code
+}
+
+func (env *testEnv) exchange(code, verifier string) (*entity.OAuthToken, error) {
+	return env.usecase.Token(context.TODO(), &entity.OAuthTokenRequest{
+		GrantType:    entity.OAUTH_GRANT_AUTHORIZATION_CODE,
+		ClientID:     env.client.ID,
+		ClientSecret: testSecret,
+		Code:         code,
+		RedirectURI:  testRedirectURI,
+		CodeVerifier: verifier,
+	})
+}
+
+func TestFindClient(t *testing.T) {
+	env := newTestEnv(t)
+
+	t.Run("success", func(t *testing.T) {
+		client, err := env.usecase.FindClient(context.TODO(), env.client.ID, testRedirectURI)
+		assert.NoError(t, err)
+		assert.Equal(t, env.client, client)
+	})
+
+	t.Run("error-unknown-client", func(t *testing.T) {
+		_, err := env.usecase.FindClient(context.TODO(), "unknown", testRedirectURI)
+		assertOAuthError(t, err, apperrors.OAUTH_INVALID_CLIENT)
+	})
+
+	t.Run("error-redirect-uri", func(t *testing.T) {
+		_, err := env.usecase.FindClient(context.TODO(), env.client.ID, "https://evil.example.com/callback")
+		assertOAuthError(t, err, apperrors.OAUTH_INVALID_REQUEST)
+	})
+}
+
+func TestValidateAuthorize(t *testing.T) {
+	env := newTestEnv(t)
+
+	t.Run("success", func(t *testing.T) {
+		scopes, err := env.usecase.ValidateAuthorize(env.client, env.authorizeRequest())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"profile"}, scopes)
+	})
+
+	t.Run("success-default-scopes", func(t *testing.T) {
+		request := env.authorizeRequest()
+		request.Scope = ""
+		scopes, err := env.usecase.ValidateAuthorize(env.client, request)
+		assert.NoError(t, err)
+		assert.Equal(t, env.client.Scopes, scopes)
+	})
+
+	t.Run("error-response-type", func(t *testing.T) {
+		request := env.authorizeRequest()
+		request.ResponseType = "token"
+		_, err := env.usecase.ValidateAuthorize(env.client, request)
+		assertOAuthError(t, err, apperrors.OAUTH_UNSUPPORTED_RESPONSE_TYPE)
+	})
+
+	t.Run("error-pkce-required", func(t *testing.T) {
+		request := env.authorizeRequest()
+		request.CodeChallenge = ""
+		_, err := env.usecase.ValidateAuthorize(env.client, request)
+		assertOAuthError(t, err, apperrors.OAUTH_INVALID_REQUEST)
+
+		request = env.authorizeRequest()
+		request.CodeChallengeMethod = "plain"
+		_, err = env.usecase.ValidateAuthorize(env.client, request)
+		assertOAuthError(t, err, apperrors.OAUTH_INVALID_REQUEST)
+	})
+
+	t.Run("error-scope", func(t *testing.T) {
+		request := env.authorizeRequest()
+		request.Scope = "profile admin"
+		_, err := env.usecase.ValidateAuthorize(env.client, request)
+		assertOAuthError(t, err, apperrors.OAUTH_INVALID_SCOPE)
+	})
+}
+
+func TestConsentRequired(t *testing.T) {
+	env := newTestEnv(t)
+	env.consentRepo.On("Find", mock.Anything, "granted", env.client.ID).Return(&entity.OAuthConsent{Scopes: []string{"profile"}}, nil)
+	env.consentRepo.On("Find", mock.Anything, "new", env.client.ID).Return(nil, apperrors.NewErrNotFound("oauth consent"))
+
+	required, err := env.usecase.ConsentRequired(context.TODO(), "granted", env.client, []string{"profile"})
+	assert.NoError(t, err)
+	assert.False(t, required)
+
+	required, err = env.usecase.ConsentRequired(context.TODO(), "granted", env.client, []string{"profile", "email"})
+	assert.NoError(t, err)
+	assert.True(t, required)
+
+	required, err = env.usecase.ConsentRequired(context.TODO(), "new", env.client, []string{"profile"})
+	assert.NoError(t, err)
+	assert.True(t, required)
+
+	firstParty := *env.client
+	firstParty.FirstParty = true
+	required, err = env.usecase.ConsentRequired(context.TODO(), "new", &firstParty, []string{"profile"})
+	assert.NoError(t, err)
+	assert.False(t, required)
+}
+
+func TestConsent(t *testing.T) {
+	env := newTestEnv(t)
+	env.consentRepo.On("Find", mock.Anything, env.user.ID, env.client.ID).Return(&entity.OAuthConsent{UserID: env.user.ID, ClientID: env.client.ID, Scopes: []string{"profile"}}, nil).Once()
+	env.consentRepo.On("Store", mock.Anything, mock.MatchedBy(func(consent *entity.OAuthConsent) bool {
+		return assert.ObjectsAreEqual([]string{"profile", "email"}, consent.Scopes)
+	})).Return(nil).Once()
+
+	err := env.usecase.Consent(context.TODO(), env.user.ID, env.client, []string{"email"})
+
+	assert.NoError(t, err)
+	env.consentRepo.AssertExpectations(t)
+}
+
+func TestTokenAuthorizationCode(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		env := newTestEnv(t)
+		code := env.authorize(t)
+
+		oauthToken, err := env.exchange(code, testCodeVerifier)
+
+		require.NoError(t, err)
+		assert.Equal(t, TOKEN_TYPE_BEARER, oauthToken.TokenType)
+		assert.Equal(t, int64(900), oauthToken.ExpiresIn)
+		assert.Equal(t, []string{"profile"}, oauthToken.Scopes)
+		assert.NotEmpty(t, oauthToken.RefreshToken)
+		assert.Contains(t, env.refreshTokens, hash.HashToken(oauthToken.RefreshToken))
+
+		claims, err := token.ParseJwtToken(oauthToken.AccessToken, token.TestKeySet(t))
+		require.NoError(t, err)
+		assert.Equal(t, token.TYPE_OAUTH_ACCESS, claims["typ"])
+		assert.Equal(t, env.user.ID, claims["sub"])
+		assert.Equal(t, env.client.ID, claims["client_id"])
+		assert.Equal(t, "profile", claims["scope"])
+	})
+
+	t.Run("error-code-reused", func(t *testing.T) {
+		env := newTestEnv(t)
+		code := env.authorize(t)
+
+		oauthToken, err := env.exchange(code, testCodeVerifier)
+		require.NoError(t, err)
+		env.refreshTokenRepo.On("DeleteByUserAndClient", mock.Anything, env.user.ID, env.client.ID).Return(nil).Once()
+
+		_, err = env.exchange(code, testCodeVerifier)
+		assertOAuthError(t, err, apperrors.OAUTH_INVALID_GRANT)
+
+		// tokens issued with the code are revoked, the code may have leaked
+		env.refreshTokenRepo.AssertCalled(t, "DeleteByUserAndClient", mock.Anything, env.user.ID, env.client.ID)
+		_, err = env.usecase.UserInfo(context.TODO(), oauthToken.AccessToken)
+		assertOAuthError(t, err, apperrors.OAUTH_INVALID_TOKEN)
+	})
+
+	t.Run("error-code-verifier", func(t *testing.T) {
+		env := newTestEnv(t)
+		code := env.authorize(t)
+
+		_, err := env.exchange(code, strings.Repeat("a", 43))
+		assertOAuthError(t, err, apperrors.OAUTH_INVALID_GRANT)
+
+		// the code is burned by the failed attempt
+		assert.True(t, env.usedCodes[hash.HashToken(code)])
+	})
+
+	t.Run("error-redirect-uri", func(t *testing.T) {
+		env := newTestEnv(t)
+		code := env.authorize(t)
+
+		_, err := env.usecase.Token(context.TODO(), &entity.OAuthTokenRequest{
+			GrantType:    entity.OAUTH_GRANT_AUTHORIZATION_CODE,
+			ClientID:     env.client.ID,
+			ClientSecret: testSecret,
+			Code:         code,
+			RedirectURI:  "https://app.example.com/other",
+			CodeVerifier: testCodeVerifier,
+		})
+		assertOAuthError(t, err, apperrors.OAUTH_INVALID_GRANT)
+	})
+
+	t.Run("error-client-secret", func(t *testing.T) {
+		env := newTestEnv(t)
+		code := env.authorize(t)
+
+		_, err := env.usecase.Token(context.TODO(), &entity.OAuthTokenRequest{
+			GrantType:    entity.OAUTH_GRANT_AUTHORIZATION_CODE,
+			ClientID:     env.client.ID,
+			ClientSecret: "wrong",
+			Code:         code,
+			RedirectURI:  testRedirectURI,
+			CodeVerifier: testCodeVerifier,
+		})
+		assertOAuthError(t, err, apperrors.OAUTH_INVALID_CLIENT)
+	})
+
+	t.Run("error-disabled-user", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.user.Status = entity.USER_STATUS_SUSPENDED
+		code := env.authorize(t)
+
+		_, err := env.exchange(code, testCodeVerifier)
+		assertOAuthError(t, err, apperrors.OAUTH_INVALID_GRANT)
+	})
+
+	t.Run("success-public-client", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.client.Secret = ""
+		env.client.GrantTypes = []string{entity.OAUTH_GRANT_AUTHORIZATION_CODE}
+		code := env.authorize(t)
+
+		oauthToken, err := env.usecase.Token(context.TODO(), &entity.OAuthTokenRequest{
+			GrantType:    entity.OAUTH_GRANT_AUTHORIZATION_CODE,
+			ClientID:     env.client.ID,
+			Code:         code,
+			RedirectURI:  testRedirectURI,
+			CodeVerifier: testCodeVerifier,
+		})
+		require.NoError(t, err)
+		assert.Empty(t, oauthToken.RefreshToken)
+	})
+}
+
+func TestTokenRefreshToken(t *testing.T) {
+	refresh := func(env *testEnv, refreshToken, scope string) (*entity.OAuthToken, error) {
+		return env.usecase.Token(context.TODO(), &entity.OAuthTokenRequest{
+			GrantType:    entity.OAUTH_GRANT_REFRESH_TOKEN,
+			ClientID:     env.client.ID,
+			ClientSecret: testSecret,
+			RefreshToken: refreshToken,
+			Scope:        scope,
+		})
+	}
+
+	t.Run("success-rotation", func(t *testing.T) {
+		env := newTestEnv(t)
+		issued, err := env.exchange(env.authorize(t), testCodeVerifier)
+		require.NoError(t, err)
+
+		refreshed, err := refresh(env, issued.RefreshToken, "")
+		require.NoError(t, err)
+		assert.NotEqual(t, issued.RefreshToken, refreshed.RefreshToken)
+		assert.Equal(t, issued.Scopes, refreshed.Scopes)
+
+		// the used refresh token is rotated out
+		_, err = refresh(env, issued.RefreshToken, "")
+		assertOAuthError(t, err, apperrors.OAUTH_INVALID_GRANT)
+	})
+
+	t.Run("error-scope-widened", func(t *testing.T) {
+		env := newTestEnv(t)
+		issued, err := env.exchange(env.authorize(t), testCodeVerifier)
+		require.NoError(t, err)
+
+		_, err = refresh(env, issued.RefreshToken, "profile email")
+		assertOAuthError(t, err, apperrors.OAUTH_INVALID_SCOPE)
+	})
+
+	t.Run("error-expired", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.refreshTokens[hash.HashToken("expired")] = &entity.OAuthRefreshToken{
+			Token:     hash.HashToken("expired"),
+			ClientID:  env.client.ID,
+			UserID:    env.user.ID,
+			ExpiresAt: time.Now().UTC().Add(-time.Minute),
+		}
+
+		_, err := refresh(env, "expired", "")
+		assertOAuthError(t, err, apperrors.OAUTH_INVALID_GRANT)
+	})
+}
+
+func TestTokenClientCredentials(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		oauthToken, err := env.usecase.Token(context.TODO(), &entity.OAuthTokenRequest{
+			GrantType:    entity.OAUTH_GRANT_CLIENT_CREDENTIALS,
+			ClientID:     env.client.ID,
+			ClientSecret: testSecret,
+			Scope:        "email",
+		})
+
+		require.NoError(t, err)
+		assert.Empty(t, oauthToken.RefreshToken)
+		assert.Equal(t, []string{"email"}, oauthToken.Scopes)
+
+		claims, err := token.ParseJwtToken(oauthToken.AccessToken, token.TestKeySet(t))
+		require.NoError(t, err)
+		assert.Equal(t, env.client.ID, claims["sub"])
+	})
+
+	t.Run("error-unauthorized-client", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.client.GrantTypes = []string{entity.OAUTH_GRANT_AUTHORIZATION_CODE}
+
+		_, err := env.usecase.Token(context.TODO(), &entity.OAuthTokenRequest{
+			GrantType:    entity.OAUTH_GRANT_CLIENT_CREDENTIALS,
+			ClientID:     env.client.ID,
+			ClientSecret: testSecret,
+		})
+		assertOAuthError(t, err, apperrors.OAUTH_UNAUTHORIZED_CLIENT)
+	})
+
+	t.Run("error-unsupported-grant-type", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		_, err := env.usecase.Token(context.TODO(), &entity.OAuthTokenRequest{
+			GrantType:    "password",
+			ClientID:     env.client.ID,
+			ClientSecret: testSecret,
+		})
+		assertOAuthError(t, err, apperrors.OAUTH_UNSUPPORTED_GRANT_TYPE)
+	})
+}
+
+func TestRevokeConsent(t *testing.T) {
+	env := newTestEnv(t)
+	keys := token.TestKeySet(t)
+	accessToken, err := token.GenerateOAuthAccessToken(keys, "http://localhost", time.Minute, env.user.ID, env.client.ID, []string{SCOPE_OPENID})
+	require.NoError(t, err)
+	otherAccessToken, err := token.GenerateOAuthAccessToken(keys, "http://localhost", time.Minute, env.user.ID, env.firstPartyClient.ID, []string{SCOPE_OPENID})
+	require.NoError(t, err)
+
+	env.consentRepo.On("Find", mock.Anything, env.user.ID, env.client.ID).Return(&entity.OAuthConsent{}, nil).Once()
+	env.refreshTokenRepo.On("DeleteByUserAndClient", mock.Anything, env.user.ID, env.client.ID).Return(nil).Once()
+	env.consentRepo.On("Delete", mock.Anything, env.user.ID, env.client.ID).Return(nil).Once()
+
+	err = env.usecase.RevokeConsent(context.TODO(), env.user.ID, env.client.ID)
+
+	assert.NoError(t, err)
+	env.consentRepo.AssertExpectations(t)
+	env.refreshTokenRepo.AssertCalled(t, "DeleteByUserAndClient", mock.Anything, env.user.ID, env.client.ID)
+
+	// access tokens the client got are revoked, tokens of other clients are kept
+	_, err = env.usecase.UserInfo(context.TODO(), accessToken)
+	assertOAuthError(t, err, apperrors.OAUTH_INVALID_TOKEN)
+	_, err = env.usecase.UserInfo(context.TODO(), otherAccessToken)
+	assert.NoError(t, err)
+}
+
+func TestTokenIDToken(t *testing.T) {
+	env := newTestEnv(t)
+	env.client.Scopes = []string{SCOPE_OPENID, SCOPE_PROFILE, SCOPE_EMAIL}
+	env.user.Gender = "male"
+	env.user.BirthDate = time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC)
+
+	request := env.authorizeRequest()
+	request.Scope = "openid profile email"
+	request.Nonce = "nonce"
+	scopes, err := env.usecase.ValidateAuthorize(env.client, request)
+	require.NoError(t, err)
+	code, err := env.usecase.Authorize(context.TODO(), env.user.ID, env.client, request, scopes)
+	require.NoError(t, err)
+
+	oauthToken, err := env.exchange(code, testCodeVerifier)
+	require.NoError(t, err)
+	require.NotEmpty(t, oauthToken.IDToken)
+
+	claims, err := token.ParseJwtToken(oauthToken.IDToken, token.TestKeySet(t))
+	require.NoError(t, err)
+	assert.Equal(t, token.TYPE_ID, claims["typ"])
+	assert.Equal(t, "http://localhost", claims["iss"])
+	assert.Equal(t, env.client.ID, claims["aud"])
+	assert.Equal(t, "nonce", claims["nonce"])
+	assert.Equal(t, env.user.ID, claims["sub"])
+	assert.Equal(t, env.user.Email, claims["email"])
+	assert.Equal(t, true, claims["email_verified"])
+	assert.Equal(t, env.user.FirstName, claims["given_name"])
+	assert.Equal(t, env.user.LastName, claims["family_name"])
+	assert.Equal(t, "male", claims["gender"])
+	assert.Equal(t, "1990-05-17", claims["birthdate"])
+	assert.NotContains(t, claims, "phone_number")
+
+	// no id token without openid scope
+	oauthToken, err = env.exchange(env.authorize(t), testCodeVerifier)
+	require.NoError(t, err)
+	assert.Empty(t, oauthToken.IDToken)
+}
+
+func TestUserInfo(t *testing.T) {
+	env := newTestEnv(t)
+	keys := token.TestKeySet(t)
+
+	t.Run("success", func(t *testing.T) {
+		accessToken, err := token.GenerateOAuthAccessToken(keys, "http://localhost", time.Minute, env.user.ID, env.client.ID, []string{SCOPE_OPENID, SCOPE_PHONE})
+		require.NoError(t, err)
+
+		claims, err := env.usecase.UserInfo(context.TODO(), accessToken)
+
+		require.NoError(t, err)
+		assert.Equal(t, env.user.ID, claims["sub"])
+		assert.Equal(t, env.user.Phone, claims["phone_number"])
+		assert.Equal(t, false, claims["phone_number_verified"])
+		assert.NotContains(t, claims, "email")
+	})
+
+	t.Run("error-insufficient-scope", func(t *testing.T) {
+		accessToken, err := token.GenerateOAuthAccessToken(keys, "http://localhost", time.Minute, env.user.ID, env.client.ID, []string{SCOPE_PROFILE})
+		require.NoError(t, err)
+
+		_, err = env.usecase.UserInfo(context.TODO(), accessToken)
+		assertOAuthError(t, err, apperrors.OAUTH_INSUFFICIENT_SCOPE)
+	})
+
+	t.Run("error-first-party-token", func(t *testing.T) {
+		accessToken, _, err := token.GenerateToken(keys, "1m", "1h", env.user, "session")
+		require.NoError(t, err)
+
+		_, err = env.usecase.UserInfo(context.TODO(), accessToken)
+		assertOAuthError(t, err, apperrors.OAUTH_INVALID_TOKEN)
+	})
+}
@@ -0,0 +1,348 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/config"
+	"github.com/Jamshid90/go-clean-architecture/pkg/token"
+	"github.com/dgrijalva/jwt-go"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Claims of the verified id token the user is identified by
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+}
+
+// provider metadata published at the discovery document
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider is an external OpenID Connect provider, metadata and keys are discovered on first use
+type Provider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	client   *http.Client
+	mu       sync.Mutex
+	metadata *metadata
+	keys     *token.KeySet
+}
+
+func NewProvider(name, issuer, clientID, clientSecret, redirectURL string, scopes []string, client *http.Client) *Provider {
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+	return &Provider{
+		Name:         name,
+		Issuer:       strings.TrimSuffix(issuer, "/"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       scopes,
+		client:       client,
+	}
+}
+
+// New providers listed in config by name
+func NewProviders(config *config.Config) (map[string]*Provider, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	providers := make(map[string]*Provider)
+	for _, providerConfig := range config.OIDC.Providers {
+		if providerConfig.Name == "" || providerConfig.Issuer == "" || providerConfig.ClientID == "" {
+			return nil, fmt.Errorf("oidc provider requires name, issuer and client id")
+		}
+		if _, ok := providers[providerConfig.Name]; ok {
+			return nil, fmt.Errorf("duplicate oidc provider: %s", providerConfig.Name)
+		}
+		providers[providerConfig.Name] = NewProvider(
+			providerConfig.Name,
+			providerConfig.Issuer,
+			providerConfig.ClientID,
+			providerConfig.ClientSecret,
+			providerConfig.RedirectURL,
+			providerConfig.Scopes,
+			client,
+		)
+	}
+	return providers, nil
+}
+
+// AuthCodeURL of the authorization code flow with S256 pkce challenge of the verifier
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(codeVerifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {p.RedirectURL},
+		"scope":                 {strings.Join(p.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return metadata.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange the authorization code for the id token
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (string, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.RedirectURL},
+		"client_id":     {p.ClientID},
+		"code_verifier": {codeVerifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.ClientID), url.QueryEscape(p.ClientSecret))
+	}
+
+	var tokenResponse struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.do(req, &tokenResponse)
+	if err != nil {
+		return "", err
+	}
+
+	if status != http.StatusOK || tokenResponse.Error != "" {
+		return "", fmt.Errorf("oidc provider %s token endpoint: status %d: %s %s", p.Name, status, tokenResponse.Error, tokenResponse.ErrorDescription)
+	}
+
+	if tokenResponse.IDToken == "" {
+		return "", fmt.Errorf("oidc provider %s token endpoint: no id token", p.Name)
+	}
+
+	return tokenResponse.IDToken, nil
+}
+
+// VerifyIDToken checks signature, issuer, audience, expiration and nonce of the id token
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*Claims, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := p.keySet(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// keys are refreshed once when the token is signed by an unknown one, providers rotate keys
+	keyfunc := func(t *jwt.Token) (interface{}, error) {
+		key, err := keys.Keyfunc(t)
+		if err == nil {
+			return key, nil
+		}
+		if keys, err = p.keySet(ctx, true); err != nil {
+			return nil, err
+		}
+		return keys.Keyfunc(t)
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(rawIDToken, claims, keyfunc); err != nil {
+		return nil, fmt.Errorf("oidc provider %s: invalid id token: %w", p.Name, err)
+	}
+
+	if iss, _ := claims["iss"].(string); iss != metadata.Issuer {
+		return nil, fmt.Errorf("oidc provider %s: unexpected id token issuer %q", p.Name, iss)
+	}
+
+	if !hasAudience(claims["aud"], p.ClientID) {
+		return nil, fmt.Errorf("oidc provider %s: id token is not issued for the client", p.Name)
+	}
+
+	if azp, ok := claims["azp"].(string); ok && azp != p.ClientID {
+		return nil, fmt.Errorf("oidc provider %s: id token is authorized for other party %q", p.Name, azp)
+	}
+
+	if _, ok := claims["exp"]; !ok {
+		return nil, fmt.Errorf("oidc provider %s: id token has no expiration", p.Name)
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce == "" || tokenNonce != nonce {
+		return nil, fmt.Errorf("oidc provider %s: id token nonce mismatch", p.Name)
+	}
+
+	result := &Claims{}
+	result.Subject, _ = claims["sub"].(string)
+	result.Email, _ = claims["email"].(string)
+	result.GivenName, _ = claims["given_name"].(string)
+	result.FamilyName, _ = claims["family_name"].(string)
+
+	// some providers send the flag as string
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		result.EmailVerified = verified
+	case string:
+		result.EmailVerified = verified == "true"
+	}
+
+	if result.Subject == "" {
+		return nil, fmt.Errorf("oidc provider %s: id token has no subject", p.Name)
+	}
+
+	return result, nil
+}
+
+// discover loads provider metadata once, the issuer of the document must match the configured one
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var discovered metadata
+	status, err := p.do(req, &discovered)
+	if err != nil {
+		return nil, err
+	}
+
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("oidc provider %s discovery: status %d", p.Name, status)
+	}
+
+	if strings.TrimSuffix(discovered.Issuer, "/") != p.Issuer {
+		return nil, fmt.Errorf("oidc provider %s discovery: issuer %q does not match", p.Name, discovered.Issuer)
+	}
+
+	if discovered.AuthorizationEndpoint == "" || discovered.TokenEndpoint == "" || discovered.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc provider %s discovery: endpoints are missing", p.Name)
+	}
+
+	p.metadata = &discovered
+	return p.metadata, nil
+}
+
+// key set of the provider, loaded on first use or when refresh is requested
+func (p *Provider) keySet(ctx context.Context, refresh bool) (*token.KeySet, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.keys != nil && !refresh {
+		return p.keys, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadata.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var jwks struct {
+		Keys []token.JWK `json:"keys"`
+	}
+	status, err := p.do(req, &jwks)
+	if err != nil {
+		return nil, err
+	}
+
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("oidc provider %s jwks: status %d", p.Name, status)
+	}
+
+	// keys of unsupported types or for encryption are skipped
+	var keys []*token.Key
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, err := token.ParseJWK(jwk); err == nil {
+			keys = append(keys, key)
+		}
+	}
+
+	p.keys = token.NewVerificationKeySet(keys...)
+	return p.keys, nil
+}
+
+// do the request and decode json response body into v
+func (p *Provider) do(req *http.Request, v interface{}) (int, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("oidc provider %s: %w", p.Name, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, fmt.Errorf("oidc provider %s: %w", p.Name, err)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil && resp.StatusCode == http.StatusOK {
+		return 0, fmt.Errorf("oidc provider %s: invalid response: %w", p.Name, err)
+	}
+
+	return resp.StatusCode, nil
+}
+
+func hasAudience(aud interface{}, clientID string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == clientID
+	case []interface{}:
+		for _, a := range aud {
+			if a == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
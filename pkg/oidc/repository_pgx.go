@@ -0,0 +1,147 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+type pgxOIDCAuthRequestRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOIDCAuthRequestRepositoryPgx(dbpool *pgxpool.Pool) entity.OIDCAuthRequestRepository {
+	return &pgxOIDCAuthRequestRepository{db: dbpool}
+}
+
+func (p *pgxOIDCAuthRequestRepository) Store(ctx context.Context, m *entity.OIDCAuthRequest) error {
+	_, err := p.db.Exec(ctx, `INSERT INTO "oidc_auth_request"(
+		state, provider, nonce, code_verifier, binding, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7);`,
+		m.State,
+		m.Provider,
+		m.Nonce,
+		m.CodeVerifier,
+		m.Binding,
+		m.ExpiresAt,
+		m.CreatedAt,
+	)
+
+	if err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during store to oidc auth request repository: %w", err)}
+	}
+
+	return nil
+}
+
+// consume deletes the auth request and returns it in one statement, so a state can be used once only
+func (p *pgxOIDCAuthRequestRepository) Consume(ctx context.Context, state string) (*entity.OIDCAuthRequest, error) {
+	authRequest := entity.OIDCAuthRequest{}
+	row := p.db.QueryRow(ctx, `DELETE FROM "oidc_auth_request" WHERE state=$1
+		RETURNING state, provider, nonce, code_verifier, binding, expires_at, created_at`, state)
+
+	err := row.Scan(
+		&authRequest.State,
+		&authRequest.Provider,
+		&authRequest.Nonce,
+		&authRequest.CodeVerifier,
+		&authRequest.Binding,
+		&authRequest.ExpiresAt,
+		&authRequest.CreatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, errors.NewErrNotFound("oidc auth request")
+	}
+
+	if err != nil {
+		return nil, errors.ErrRepository{Err: fmt.Errorf("error during consume to oidc auth request repository: %w", err)}
+	}
+
+	return &authRequest, nil
+}
+
+func (p *pgxOIDCAuthRequestRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	if _, err := p.db.Exec(ctx, `DELETE FROM "oidc_auth_request" WHERE expires_at<$1`, before); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during delete expired to oidc auth request repository: %w", err)}
+	}
+	return nil
+}
+
+type pgxUserIdentityRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewUserIdentityRepositoryPgx(dbpool *pgxpool.Pool) entity.UserIdentityRepository {
+	return &pgxUserIdentityRepository{db: dbpool}
+}
+
+func (p *pgxUserIdentityRepository) Store(ctx context.Context, m *entity.UserIdentity) error {
+	_, err := p.db.Exec(ctx, `INSERT INTO "user_identity"(
+		user_id, provider, subject, email, created_at)
+		VALUES ($1, $2, $3, $4, $5);`,
+		m.UserID,
+		m.Provider,
+		m.Subject,
+		m.Email,
+		m.CreatedAt,
+	)
+
+	if err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during store to user identity repository: %w", err)}
+	}
+
+	return nil
+}
+
+func (p *pgxUserIdentityRepository) Find(ctx context.Context, provider, subject string) (*entity.UserIdentity, error) {
+	identity := entity.UserIdentity{}
+	row := p.db.QueryRow(ctx, `SELECT user_id, provider, subject, email, created_at FROM "user_identity" WHERE provider=$1 AND subject=$2`, provider, subject)
+
+	err := row.Scan(
+		&identity.UserID,
+		&identity.Provider,
+		&identity.Subject,
+		&identity.Email,
+		&identity.CreatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, errors.NewErrNotFound("user identity")
+	}
+
+	if err != nil {
+		return nil, errors.ErrRepository{Err: fmt.Errorf("error during find to user identity repository: %w", err)}
+	}
+
+	return &identity, nil
+}
+
+func (p *pgxUserIdentityRepository) FindByUserId(ctx context.Context, id string) ([]*entity.UserIdentity, error) {
+	var items []*entity.UserIdentity
+	rows, err := p.db.Query(ctx, `SELECT user_id, provider, subject, email, created_at FROM "user_identity" WHERE user_id=$1 ORDER BY created_at`, id)
+	if err != nil {
+		return items, errors.ErrRepository{Err: fmt.Errorf("error during find by user id to user identity repository: %w", err)}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		identity := entity.UserIdentity{}
+		if err := rows.Scan(&identity.UserID, &identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt); err != nil {
+			return items, errors.ErrRepository{Err: fmt.Errorf("error during find by user id to user identity repository: %w", err)}
+		}
+		items = append(items, &identity)
+	}
+	return items, nil
+}
+
+func (p *pgxUserIdentityRepository) Delete(ctx context.Context, provider, subject string) error {
+	if _, err := p.db.Exec(ctx, `DELETE FROM "user_identity" WHERE provider=$1 AND subject=$2`, provider, subject); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during delete to user identity repository: %w", err)}
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package oidc
+
+import (
+	"context"
+	"crypto/subtle"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
+	"github.com/Jamshid90/go-clean-architecture/pkg/rand"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// lengths of the user columns, longer names of the provider are truncated, longer emails can not be stored
+const (
+	MAX_EMAIL_LENGTH = 50
+	MAX_NAME_LENGTH  = 50
+)
+
+type oidcUsecase struct {
+	providers       map[string]*Provider
+	authRequestRepo entity.OIDCAuthRequestRepository
+	identityRepo    entity.UserIdentityRepository
+	userUsecase     entity.UserUsecase
+	stateTTL        time.Duration
+	contextTimeout  time.Duration
+}
+
+// New oidc usecase, state ttl is the time the user has to complete the login at the provider
+func NewOIDCUsecase(providers map[string]*Provider, authRequestRepo entity.OIDCAuthRequestRepository, identityRepo entity.UserIdentityRepository, userUsecase entity.UserUsecase, stateTTL time.Duration, timeout time.Duration) oidcUsecase {
+	return oidcUsecase{
+		providers:       providers,
+		authRequestRepo: authRequestRepo,
+		identityRepo:    identityRepo,
+		userUsecase:     userUsecase,
+		stateTTL:        stateTTL,
+		contextTimeout:  timeout,
+	}
+}
+
+// AuthorizationURL starts the authorization code flow, state, nonce and pkce verifier are kept until the callback.
+// The binding is kept by the browser which starts the flow, only that browser can complete it.
+func (o *oidcUsecase) AuthorizationURL(ctx context.Context, providerName string) (string, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.contextTimeout)
+	defer cancel()
+
+	provider, ok := o.providers[providerName]
+	if !ok {
+		return "", "", errors.NewErrNotFound("oidc provider")
+	}
+
+	state, err := rand.Token(32)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := rand.Token(32)
+	if err != nil {
+		return "", "", err
+	}
+	codeVerifier, err := rand.Token(32)
+	if err != nil {
+		return "", "", err
+	}
+	binding, err := rand.Token(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now().UTC()
+	if err := o.authRequestRepo.DeleteExpired(ctx, now); err != nil {
+		return "", "", err
+	}
+
+	if err := o.authRequestRepo.Store(ctx, &entity.OIDCAuthRequest{
+		State:        hash.HashToken(state),
+		Provider:     provider.Name,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		Binding:      hash.HashToken(binding),
+		ExpiresAt:    now.Add(o.stateTTL),
+		CreatedAt:    now,
+	}); err != nil {
+		return "", "", err
+	}
+
+	return authURL, binding, nil
+}
+
+// Authenticate completes the authorization code flow and returns the user of the external identity,
+// the identity is linked to the account with the same verified email or a new account is created.
+// The binding must be the one of the browser which started the flow, so nobody can make a victim log in to
+// the account of the attacker with a code and state the attacker got.
+func (o *oidcUsecase) Authenticate(ctx context.Context, providerName, code, state, binding string) (*entity.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.contextTimeout)
+	defer cancel()
+
+	provider, ok := o.providers[providerName]
+	if !ok {
+		return nil, errors.NewErrNotFound("oidc provider")
+	}
+
+	// state is single use, parallel callbacks with the same state get it once
+	authRequest, err := o.authRequestRepo.Consume(ctx, hash.HashToken(state))
+	if err != nil {
+		if _, ok := err.(*errors.ErrNotFound); ok {
+			return nil, &errors.ErrBadRequest{Err: err, Message: errors.ErrInvalidOrExpiredToken.Error()}
+		}
+		return nil, err
+	}
+
+	if authRequest.Provider != provider.Name || time.Now().UTC().After(authRequest.ExpiresAt) ||
+		subtle.ConstantTimeCompare([]byte(hash.HashToken(binding)), []byte(authRequest.Binding)) != 1 {
+		return nil, &errors.ErrBadRequest{Err: errors.ErrInvalidOrExpiredToken, Message: errors.ErrInvalidOrExpiredToken.Error()}
+	}
+
+	rawIDToken, err := provider.Exchange(ctx, code, authRequest.CodeVerifier)
+	if err != nil {
+		return nil, &errors.ErrBadRequest{Err: err, Message: errors.ErrExternalAuthentication.Error()}
+	}
+
+	claims, err := provider.VerifyIDToken(ctx, rawIDToken, authRequest.Nonce)
+	if err != nil {
+		return nil, &errors.ErrBadRequest{Err: err, Message: errors.ErrExternalAuthentication.Error()}
+	}
+
+	return o.resolveUser(ctx, provider.Name, claims)
+}
+
+// resolve user of the identity, linking and creating accounts require email verified by the provider,
+// existing accounts are linked only when their email was verified as well
+func (o *oidcUsecase) resolveUser(ctx context.Context, providerName string, claims *Claims) (*entity.User, error) {
+	identity, err := o.identityRepo.Find(ctx, providerName, claims.Subject)
+	if err != nil {
+		if _, ok := err.(*errors.ErrNotFound); !ok {
+			return nil, err
+		}
+	}
+
+	if identity != nil {
+		user, err := o.userUsecase.Find(ctx, identity.UserID)
+		if err == nil {
+			return user, nil
+		}
+		if _, ok := err.(*errors.ErrNotFound); !ok {
+			return nil, err
+		}
+		// the user was deleted, the identity is linked again
+		if err := o.identityRepo.Delete(ctx, providerName, claims.Subject); err != nil {
+			return nil, err
+		}
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, &errors.ErrBadRequest{Err: errors.ErrExternalAuthentication, Message: "the identity provider did not share a verified email"}
+	}
+
+	user, err := o.userUsecase.FindByEmail(ctx, claims.Email)
+	if err != nil {
+		if _, ok := err.(*errors.ErrNotFound); !ok {
+			return nil, err
+		}
+		if user, err = o.provision(ctx, claims); err != nil {
+			return nil, err
+		}
+	} else if !user.IsEmailVerified() {
+		// anyone could have registered the email with a password before its owner,
+		// only accounts whose email was verified are linked
+		return nil, &errors.ErrBadRequest{Err: errors.ErrExternalAuthentication, Message: "verify the email of the existing account before signing in with the identity provider"}
+	}
+
+	if err := o.identityRepo.Store(ctx, &entity.UserIdentity{
+		UserID:    user.ID,
+		Provider:  providerName,
+		Subject:   claims.Subject,
+		Email:     claims.Email,
+		CreatedAt: time.Now().UTC(),
+	}); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// provision a new active user without password, the names default to the email local part
+func (o *oidcUsecase) provision(ctx context.Context, claims *Claims) (*entity.User, error) {
+	if utf8.RuneCountInString(claims.Email) > MAX_EMAIL_LENGTH {
+		return nil, &errors.ErrBadRequest{Err: errors.ErrExternalAuthentication, Message: "the email shared by the identity provider is too long"}
+	}
+
+	user := &entity.User{
+		Status:    entity.USER_STATUS_ACTIVE,
+		Email:     claims.Email,
+		FirstName: claims.GivenName,
+		LastName:  claims.FamilyName,
+	}
+
+	if user.FirstName == "" {
+		user.FirstName = strings.SplitN(claims.Email, "@", 2)[0]
+	}
+
+	user.FirstName = truncate(user.FirstName, MAX_NAME_LENGTH)
+	user.LastName = truncate(user.LastName, MAX_NAME_LENGTH)
+
+	if err := o.userUsecase.Store(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,375 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity/mocks"
+	apperrors "github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/token"
+	"github.com/Jamshid90/go-clean-architecture/pkg/user"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testClientID     = "client"
+	testClientSecret = "secret"
+	testRedirectURL  = "http://localhost/callback"
+)
+
+// fakeProvider is a minimal OpenID Connect provider issuing id tokens for the authorizations made by the test
+type fakeProvider struct {
+	server *httptest.Server
+	keys   *token.KeySet
+
+	mu     sync.Mutex
+	grants map[string]fakeGrant
+}
+
+type fakeGrant struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keys, err := token.NewKeySet("fake", &token.Key{ID: "fake", Method: jwt.SigningMethodRS256, Private: rsaKey, Public: &rsaKey.PublicKey})
+	require.NoError(t, err)
+
+	f := &fakeProvider{keys: keys, grants: make(map[string]fakeGrant)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 f.server.URL,
+			"authorization_endpoint": f.server.URL + "/authorize",
+			"token_endpoint":         f.server.URL + "/token",
+			"jwks_uri":               f.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": f.keys.JWKS()})
+	})
+	mux.HandleFunc("/token", f.token)
+
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// authorize plays the user consenting at the authorization endpoint, the code and the state the client is redirected with are returned
+func (f *fakeProvider) authorize(t *testing.T, authURL string, claims jwt.MapClaims) (string, string) {
+	t.Helper()
+
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	query := parsed.Query()
+
+	require.Equal(t, f.server.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	require.Equal(t, testClientID, query.Get("client_id"))
+	require.Equal(t, testRedirectURL, query.Get("redirect_uri"))
+	require.Equal(t, "S256", query.Get("code_challenge_method"))
+
+	idClaims := jwt.MapClaims{
+		"iss":   f.server.URL,
+		"aud":   testClientID,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"nonce": query.Get("nonce"),
+	}
+	for k, v := range claims {
+		idClaims[k] = v
+	}
+
+	code := "code-" + query.Get("state")
+	f.mu.Lock()
+	f.grants[code] = fakeGrant{challenge: query.Get("code_challenge"), claims: idClaims}
+	f.mu.Unlock()
+
+	return code, query.Get("state")
+}
+
+func (f *fakeProvider) token(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok || clientID != testClientID || clientSecret != testClientSecret {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	f.mu.Lock()
+	grant, ok := f.grants[r.PostFormValue("code")]
+	delete(f.grants, r.PostFormValue("code"))
+	f.mu.Unlock()
+
+	verifier := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || r.PostFormValue("grant_type") != "authorization_code" || r.PostFormValue("redirect_uri") != testRedirectURL ||
+		base64.RawURLEncoding.EncodeToString(verifier[:]) != grant.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	idToken, _ := f.keys.Sign(grant.claims)
+	json.NewEncoder(w).Encode(map[string]string{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"id_token":     idToken,
+	})
+}
+
+type testEnv struct {
+	usecase         oidcUsecase
+	provider        *fakeProvider
+	userRepo        *mocks.UserRepository
+	identityRepo    *mocks.UserIdentityRepository
+	authRequestRepo *mocks.OIDCAuthRequestRepository
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	fake := newFakeProvider(t)
+	env := &testEnv{
+		provider:        fake,
+		userRepo:        new(mocks.UserRepository),
+		identityRepo:    new(mocks.UserIdentityRepository),
+		authRequestRepo: new(mocks.OIDCAuthRequestRepository),
+	}
+
+	// auth requests are kept by the mock as the repository would
+	var stored []*entity.OIDCAuthRequest
+	env.authRequestRepo.On("DeleteExpired", mock.Anything, mock.AnythingOfType("time.Time")).Return(nil)
+	env.authRequestRepo.On("Store", mock.Anything, mock.AnythingOfType("*entity.OIDCAuthRequest")).Run(func(args mock.Arguments) {
+		stored = append(stored, args.Get(1).(*entity.OIDCAuthRequest))
+	}).Return(nil)
+	// a consumed auth request is removed, so it is not found by the next callback
+	var consumed *entity.OIDCAuthRequest
+	env.authRequestRepo.On("Consume", mock.Anything, mock.AnythingOfType("string")).Return(
+		func(ctx context.Context, state string) *entity.OIDCAuthRequest {
+			consumed = nil
+			for i, authRequest := range stored {
+				if authRequest.State == state {
+					stored = append(stored[:i], stored[i+1:]...)
+					consumed = authRequest
+				}
+			}
+			return consumed
+		},
+		func(ctx context.Context, state string) error {
+			if consumed == nil {
+				return apperrors.NewErrNotFound("oidc auth request")
+			}
+			return nil
+		},
+	)
+
+	userUsecase := user.NewUserUsecase(env.userRepo, new(mocks.SessionUsecase), new(mocks.EmailVerificationUsecase), user.TestRevocationUsecase(t), new(mocks.APIKeyRepository), new(mocks.OAuthRefreshTokenRepository), user.TestPasswordHasher(t), user.TestPasswordPolicy(t), time.Second*2)
+	providers := map[string]*Provider{
+		"fake": NewProvider("fake", fake.server.URL, testClientID, testClientSecret, testRedirectURL, nil, fake.server.Client()),
+	}
+	env.usecase = NewOIDCUsecase(providers, env.authRequestRepo, env.identityRepo, &userUsecase, time.Minute, time.Second*5)
+
+	return env
+}
+
+// login runs the whole flow with the claims the provider asserts
+func (env *testEnv) login(t *testing.T, claims jwt.MapClaims) (*entity.User, error) {
+	t.Helper()
+
+	authURL, binding, err := env.usecase.AuthorizationURL(context.TODO(), "fake")
+	require.NoError(t, err)
+
+	code, state := env.provider.authorize(t, authURL, claims)
+	return env.usecase.Authenticate(context.TODO(), "fake", code, state, binding)
+}
+
+func TestAuthenticate(t *testing.T) {
+	claims := jwt.MapClaims{
+		"sub":            "subject",
+		"email":          "user@inifo.com",
+		"email_verified": true,
+		"given_name":     "User",
+		"family_name":    "Qwerty",
+	}
+
+	t.Run("success-provision", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.identityRepo.On("Find", mock.Anything, "fake", "subject").Return(nil, apperrors.NewErrNotFound("user identity")).Once()
+		env.userRepo.On("FindByEmail", mock.Anything, "user@inifo.com").Return(nil, apperrors.NewErrNotFound("user")).Twice()
+		env.userRepo.On("Find", mock.Anything, mock.AnythingOfType("string")).Return(nil, apperrors.NewErrNotFound("user")).Once()
+		env.userRepo.On("Store", mock.Anything, mock.AnythingOfType("*entity.User")).Return(nil).Once()
+		env.identityRepo.On("Store", mock.Anything, mock.MatchedBy(func(identity *entity.UserIdentity) bool {
+			return identity.Provider == "fake" && identity.Subject == "subject"
+		})).Return(nil).Once()
+
+		u, err := env.login(t, claims)
+
+		require.NoError(t, err)
+		assert.Equal(t, "user@inifo.com", u.Email)
+		assert.Equal(t, "User", u.FirstName)
+		assert.Equal(t, entity.USER_STATUS_ACTIVE, u.Status)
+		assert.Empty(t, u.Password)
+		env.userRepo.AssertExpectations(t)
+		env.identityRepo.AssertExpectations(t)
+	})
+
+	t.Run("success-provision-long-names", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.identityRepo.On("Find", mock.Anything, "fake", "subject").Return(nil, apperrors.NewErrNotFound("user identity")).Once()
+		env.userRepo.On("FindByEmail", mock.Anything, "user@inifo.com").Return(nil, apperrors.NewErrNotFound("user")).Twice()
+		env.userRepo.On("Find", mock.Anything, mock.AnythingOfType("string")).Return(nil, apperrors.NewErrNotFound("user")).Once()
+		env.userRepo.On("Store", mock.Anything, mock.AnythingOfType("*entity.User")).Return(nil).Once()
+		env.identityRepo.On("Store", mock.Anything, mock.AnythingOfType("*entity.UserIdentity")).Return(nil).Once()
+
+		long := jwt.MapClaims{"sub": "subject", "email": "user@inifo.com", "email_verified": true,
+			"given_name": strings.Repeat("ü", 60), "family_name": strings.Repeat("q", 60)}
+		u, err := env.login(t, long)
+
+		require.NoError(t, err)
+		assert.Equal(t, strings.Repeat("ü", MAX_NAME_LENGTH), u.FirstName)
+		assert.Equal(t, strings.Repeat("q", MAX_NAME_LENGTH), u.LastName)
+		env.userRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-provision-long-email", func(t *testing.T) {
+		env := newTestEnv(t)
+		email := strings.Repeat("u", 60) + "@inifo.com"
+		env.identityRepo.On("Find", mock.Anything, "fake", "subject").Return(nil, apperrors.NewErrNotFound("user identity")).Once()
+		env.userRepo.On("FindByEmail", mock.Anything, email).Return(nil, apperrors.NewErrNotFound("user")).Once()
+
+		_, err := env.login(t, jwt.MapClaims{"sub": "subject", "email": email, "email_verified": true})
+
+		assert.IsType(t, &apperrors.ErrBadRequest{}, err)
+		env.userRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+		env.identityRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+	})
+
+	t.Run("success-link-by-verified-email", func(t *testing.T) {
+		env := newTestEnv(t)
+		existing := user.TestUser(t)
+		env.identityRepo.On("Find", mock.Anything, "fake", "subject").Return(nil, apperrors.NewErrNotFound("user identity")).Once()
+		env.userRepo.On("FindByEmail", mock.Anything, "user@inifo.com").Return(existing, nil).Once()
+		env.identityRepo.On("Store", mock.Anything, mock.MatchedBy(func(identity *entity.UserIdentity) bool {
+			return identity.UserID == existing.ID
+		})).Return(nil).Once()
+
+		u, err := env.login(t, claims)
+
+		require.NoError(t, err)
+		assert.Equal(t, existing.ID, u.ID)
+		env.userRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+		env.identityRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-link-to-pending-account", func(t *testing.T) {
+		env := newTestEnv(t)
+		existing := user.TestUser(t)
+		existing.Status = entity.USER_STATUS_PENDING
+		env.identityRepo.On("Find", mock.Anything, "fake", "subject").Return(nil, apperrors.NewErrNotFound("user identity")).Once()
+		env.userRepo.On("FindByEmail", mock.Anything, "user@inifo.com").Return(existing, nil).Once()
+
+		_, err := env.login(t, claims)
+
+		assert.IsType(t, &apperrors.ErrBadRequest{}, err)
+		env.userRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+		env.identityRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+	})
+
+	t.Run("success-linked-identity", func(t *testing.T) {
+		env := newTestEnv(t)
+		existing := user.TestUser(t)
+		env.identityRepo.On("Find", mock.Anything, "fake", "subject").Return(&entity.UserIdentity{UserID: existing.ID, Provider: "fake", Subject: "subject"}, nil).Once()
+		env.userRepo.On("Find", mock.Anything, existing.ID).Return(existing, nil).Once()
+
+		u, err := env.login(t, claims)
+
+		require.NoError(t, err)
+		assert.Equal(t, existing.ID, u.ID)
+		env.identityRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error-unverified-email", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.identityRepo.On("Find", mock.Anything, "fake", "subject").Return(nil, apperrors.NewErrNotFound("user identity")).Once()
+
+		unverified := jwt.MapClaims{"sub": "subject", "email": "user@inifo.com", "email_verified": false}
+		_, err := env.login(t, unverified)
+
+		assert.IsType(t, &apperrors.ErrBadRequest{}, err)
+		env.userRepo.AssertNotCalled(t, "FindByEmail", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error-unknown-state", func(t *testing.T) {
+		env := newTestEnv(t)
+		_, err := env.usecase.Authenticate(context.TODO(), "fake", "code", "unknown-state", "binding")
+
+		assert.IsType(t, &apperrors.ErrBadRequest{}, err)
+	})
+
+	t.Run("error-replayed-state", func(t *testing.T) {
+		env := newTestEnv(t)
+		existing := user.TestUser(t)
+		env.identityRepo.On("Find", mock.Anything, "fake", "subject").Return(&entity.UserIdentity{UserID: existing.ID, Provider: "fake", Subject: "subject"}, nil).Once()
+		env.userRepo.On("Find", mock.Anything, existing.ID).Return(existing, nil).Once()
+
+		authURL, binding, err := env.usecase.AuthorizationURL(context.TODO(), "fake")
+		require.NoError(t, err)
+		code, state := env.provider.authorize(t, authURL, claims)
+
+		_, err = env.usecase.Authenticate(context.TODO(), "fake", code, state, binding)
+		require.NoError(t, err)
+
+		_, err = env.usecase.Authenticate(context.TODO(), "fake", code, state, binding)
+		assert.IsType(t, &apperrors.ErrBadRequest{}, err)
+		env.userRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-other-browser", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		// the attacker started the flow and got the code, the victim browser has a binding of its own
+		authURL, _, err := env.usecase.AuthorizationURL(context.TODO(), "fake")
+		require.NoError(t, err)
+		_, victimBinding, err := env.usecase.AuthorizationURL(context.TODO(), "fake")
+		require.NoError(t, err)
+		code, state := env.provider.authorize(t, authURL, claims)
+
+		_, err = env.usecase.Authenticate(context.TODO(), "fake", code, state, victimBinding)
+
+		assert.IsType(t, &apperrors.ErrBadRequest{}, err)
+		env.identityRepo.AssertNotCalled(t, "Find", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error-nonce-mismatch", func(t *testing.T) {
+		env := newTestEnv(t)
+
+		_, err := env.login(t, jwt.MapClaims{"sub": "subject", "nonce": "replayed"})
+
+		assert.IsType(t, &apperrors.ErrBadRequest{}, err)
+	})
+
+	t.Run("error-unknown-provider", func(t *testing.T) {
+		env := newTestEnv(t)
+		_, _, err := env.usecase.AuthorizationURL(context.TODO(), "unknown")
+
+		assert.IsType(t, &apperrors.ErrNotFound{}, err)
+	})
+}
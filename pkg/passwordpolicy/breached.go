@@ -0,0 +1,87 @@
+package passwordpolicy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"sort"
+	"strings"
+)
+
+// length of sha1 prefix the list is indexed by, the same as in k-anonymity range queries
+const prefixLength = 5
+
+// BreachedList is a set of breached passwords kept as sha1 suffixes grouped by prefix
+type BreachedList struct {
+	index map[string][]string
+}
+
+// LoadBreachedList reads breached passwords from the file, one per line.
+// A line is either sha1 hex of a password, optionally followed by ":count" as in downloaded
+// range files, or the password itself. Empty lines and lines starting with "#" are skipped.
+func LoadBreachedList(path string) (*BreachedList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	list := &BreachedList{index: map[string][]string{}}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		list.add(line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for prefix := range list.index {
+		sort.Strings(list.index[prefix])
+	}
+
+	return list, nil
+}
+
+// Contains checks the password in the list
+func (b *BreachedList) Contains(password string) bool {
+	digest := sha1Hex(password)
+	suffixes := b.index[digest[:prefixLength]]
+	suffix := digest[prefixLength:]
+
+	i := sort.SearchStrings(suffixes, suffix)
+	return i < len(suffixes) && suffixes[i] == suffix
+}
+
+func (b *BreachedList) add(line string) {
+	digest := line
+	if i := strings.IndexByte(line, ':'); i == 40 {
+		digest = line[:i]
+	}
+
+	digest = strings.ToUpper(digest)
+	if !isSha1Hex(digest) {
+		digest = sha1Hex(line)
+	}
+
+	prefix := digest[:prefixLength]
+	b.index[prefix] = append(b.index[prefix], digest[prefixLength:])
+}
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func isSha1Hex(s string) bool {
+	if len(s) != 40 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,138 @@
+package passwordpolicy
+
+import (
+	"fmt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/config"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// defaults used when the length is not configured
+const (
+	DEFAULT_MIN_LENGTH = 8
+	DEFAULT_MAX_LENGTH = 128
+)
+
+// length of email local part or name which is looked up in the password
+const minPersonalLength = 3
+
+type Policy struct {
+	MinLength      int
+	MaxLength      int
+	RequireUpper   bool
+	RequireLower   bool
+	RequireDigit   bool
+	RequireSymbol  bool
+	ForbidPersonal bool
+	Breached       *BreachedList
+}
+
+// New policy from configuration, the breached password list is loaded when the file is given
+func NewPolicy(config *config.Config) (*Policy, error) {
+	policy := &Policy{
+		MinLength:      config.PasswordPolicy.MinLength,
+		MaxLength:      config.PasswordPolicy.MaxLength,
+		RequireUpper:   config.PasswordPolicy.RequireUpper,
+		RequireLower:   config.PasswordPolicy.RequireLower,
+		RequireDigit:   config.PasswordPolicy.RequireDigit,
+		RequireSymbol:  config.PasswordPolicy.RequireSymbol,
+		ForbidPersonal: config.PasswordPolicy.ForbidPersonal,
+	}
+
+	if policy.MinLength == 0 {
+		policy.MinLength = DEFAULT_MIN_LENGTH
+	}
+	if policy.MaxLength == 0 {
+		policy.MaxLength = DEFAULT_MAX_LENGTH
+	}
+	if policy.MinLength > policy.MaxLength {
+		return nil, fmt.Errorf("password policy min length is greater than max length")
+	}
+
+	if config.PasswordPolicy.BreachedFile != "" {
+		breached, err := LoadBreachedList(config.PasswordPolicy.BreachedFile)
+		if err != nil {
+			return nil, err
+		}
+		policy.Breached = breached
+	}
+
+	return policy, nil
+}
+
+// Validate returns validation error with every violation of the password
+func (p *Policy) Validate(password string, user *entity.User) error {
+	var violations []string
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violations = append(violations, fmt.Sprintf("password must be at least %d characters in length", p.MinLength))
+	}
+	if length > p.MaxLength {
+		violations = append(violations, fmt.Sprintf("password must be at most %d characters in length", p.MaxLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsLetter(r):
+			symbol = true
+		}
+	}
+
+	if p.RequireUpper && !upper {
+		violations = append(violations, "password must contain an uppercase letter")
+	}
+	if p.RequireLower && !lower {
+		violations = append(violations, "password must contain a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		violations = append(violations, "password must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		violations = append(violations, "password must contain a symbol")
+	}
+
+	if p.ForbidPersonal && user != nil && containsPersonal(password, user) {
+		violations = append(violations, "password must not contain your email or name")
+	}
+
+	if p.Breached != nil && p.Breached.Contains(password) {
+		violations = append(violations, "password is known from data breaches, choose another one")
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	errValidation := errors.NewErrValidation()
+	errValidation.Errors["password"] = strings.Join(violations, "; ")
+	return errValidation
+}
+
+// contains personal checks email, its local part and names of the user in the password
+func containsPersonal(password string, user *entity.User) bool {
+	password = strings.ToLower(password)
+
+	personal := []string{user.Email, user.FirstName, user.LastName}
+	if i := strings.IndexByte(user.Email, '@'); i > 0 {
+		personal = append(personal, user.Email[:i])
+	}
+
+	for _, p := range personal {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if utf8.RuneCountInString(p) >= minPersonalLength && strings.Contains(password, p) {
+			return true
+		}
+	}
+	return false
+}
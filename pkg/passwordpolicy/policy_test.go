@@ -0,0 +1,70 @@
+package passwordpolicy
+
+import (
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	apperrors "github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	policy := &Policy{
+		MinLength:      10,
+		MaxLength:      20,
+		RequireUpper:   true,
+		RequireDigit:   true,
+		ForbidPersonal: true,
+	}
+	user := &entity.User{Email: "jamshid@mail.com", FirstName: "Jamshid", LastName: "Qwerty"}
+
+	t.Run("success", func(t *testing.T) {
+		assert.NoError(t, policy.Validate("Correct-Horse-7", user))
+	})
+
+	t.Run("error-violations", func(t *testing.T) {
+		err := policy.Validate("short", user)
+
+		errValidation, ok := err.(*apperrors.ErrValidation)
+		require.True(t, ok)
+		assert.Contains(t, errValidation.Errors["password"], "at least 10 characters")
+		assert.Contains(t, errValidation.Errors["password"], "uppercase letter")
+		assert.Contains(t, errValidation.Errors["password"], "digit")
+	})
+
+	t.Run("error-personal", func(t *testing.T) {
+		err := policy.Validate("MyNameIsJamshid1", user)
+		assert.IsType(t, &apperrors.ErrValidation{}, err)
+	})
+
+	t.Run("error-too-long", func(t *testing.T) {
+		err := policy.Validate("Correct-Horse-Battery-Staple-7", user)
+		assert.IsType(t, &apperrors.ErrValidation{}, err)
+	})
+}
+
+func TestBreachedList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "breached")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "breached.txt")
+	content := "# breached passwords\n" +
+		// sha1 of "password1" with count as in range files
+		"E38AD214943DAAD1D64C102FAEC29DE4AFE9DA3D:2413945\n" +
+		"Summer2024!\n"
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+
+	list, err := LoadBreachedList(path)
+	require.NoError(t, err)
+
+	assert.True(t, list.Contains("password1"))
+	assert.True(t, list.Contains("Summer2024!"))
+	assert.False(t, list.Contains("Correct-Horse-7"))
+
+	policy := &Policy{MinLength: 8, MaxLength: 128, Breached: list}
+	assert.IsType(t, &apperrors.ErrValidation{}, policy.Validate("Summer2024!", nil))
+}
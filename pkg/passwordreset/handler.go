@@ -0,0 +1,165 @@
+package passwordreset
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/request"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/response"
+	"github.com/Jamshid90/go-clean-architecture/pkg/validation"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+)
+
+type PasswordResetHandler struct {
+	logger               *zap.Logger
+	userUsecase          entity.UserUsecase
+	sessionUsecase       entity.SessionUsecase
+	revocationUsecase    entity.TokenRevocationUsecase
+	passwordResetUsecase entity.PasswordResetUsecase
+	loginAttemptUsecase  entity.LoginAttemptUsecase
+	maxRequests          int
+	maxIPRequests        int
+}
+
+// New password reset handler, at most maxRequests resets of an email and maxIPRequests resets from a client
+// ip address are requested within the login attempt window
+func NewPasswordResetHandler(r chi.Router, passwordResetUsecase entity.PasswordResetUsecase, userUsecase entity.UserUsecase, sessionUsecase entity.SessionUsecase, revocationUsecase entity.TokenRevocationUsecase, loginAttemptUsecase entity.LoginAttemptUsecase, maxRequests, maxIPRequests int, logger *zap.Logger) {
+	handler := PasswordResetHandler{
+		logger:               logger,
+		userUsecase:          userUsecase,
+		sessionUsecase:       sessionUsecase,
+		revocationUsecase:    revocationUsecase,
+		passwordResetUsecase: passwordResetUsecase,
+		loginAttemptUsecase:  loginAttemptUsecase,
+		maxRequests:          maxRequests,
+		maxIPRequests:        maxIPRequests,
+	}
+
+	r.Post("/auth/password/forgot", handler.forgot())
+	r.Post("/auth/password/reset", handler.reset())
+}
+
+// forgot, the response does not tell whether the email is registered
+func (p *PasswordResetHandler) forgot() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var forgotRequest ForgotPasswordRequest
+		if err := request.DecodeJson(r, &forgotRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := validation.Validator(&forgotRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		// requests are counted for unknown emails too, otherwise the limit would reveal registered emails
+		ctx := r.Context()
+		allowed, err := p.count(ctx, forgotRequest.Email, request.ClientIP(r))
+		if err != nil {
+			p.logger.Error("password reset count request", zap.Error(err))
+			response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		if !allowed {
+			response.Error(w, r, errors.ErrTooManyRequests, http.StatusTooManyRequests)
+			return
+		}
+
+		// failures are only logged, otherwise the response would reveal registered emails
+		if user, err := p.userUsecase.FindByEmail(ctx, forgotRequest.Email); err == nil {
+			if err := p.passwordResetUsecase.Send(ctx, user); err != nil {
+				p.logger.Error("password reset send", zap.Error(err))
+			}
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+		})
+	}
+}
+
+// reset, the token is consumed only after the new password is accepted by the policy and before
+// the password is changed, so concurrent requests with the same token change the password once.
+// The token is released when the reset fails after it was consumed, so the request can be retried.
+func (p *PasswordResetHandler) reset() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var resetRequest ResetPasswordRequest
+		if err := request.DecodeJson(r, &resetRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := validation.Validator(&resetRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		ctx := r.Context()
+		userID, err := p.passwordResetUsecase.Check(ctx, resetRequest.Token)
+		if err != nil {
+			p.logger.Error("password reset check", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := p.userUsecase.ValidatePassword(ctx, userID, resetRequest.Password); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if _, err := p.passwordResetUsecase.Consume(ctx, resetRequest.Token); err != nil {
+			p.logger.Error("password reset consume", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := p.userUsecase.UpdatePassword(ctx, userID, resetRequest.Password); err != nil {
+			p.logger.Error("password reset update password", zap.Error(err))
+			p.failed(w, r, resetRequest.Token, err)
+			return
+		}
+
+		// sessions opened with the old password are revoked
+		if err := p.sessionUsecase.DeleteByUserId(ctx, userID); err != nil {
+			p.logger.Error("password reset delete sessions", zap.Error(err))
+			p.failed(w, r, resetRequest.Token, err)
+			return
+		}
+
+		if err := p.revocationUsecase.RevokeUser(ctx, userID); err != nil {
+			p.logger.Error("password reset revoke tokens", zap.Error(err))
+			p.failed(w, r, resetRequest.Token, err)
+			return
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+		})
+	}
+}
+
+// failed releases the consumed token and answers with the error, the token stays consumed when it can not be released
+func (p *PasswordResetHandler) failed(w http.ResponseWriter, r *http.Request, token string, err error) {
+	if releaseErr := p.passwordResetUsecase.Release(r.Context(), token); releaseErr != nil {
+		p.logger.Error("password reset release token", zap.Error(releaseErr))
+	}
+	response.Error(w, r, err, response.GetStatusCodeErr(err))
+}
+
+// count counts the request of the email from the ip address, it returns false when either of them reached the limit.
+// Requests without known ip address are counted only by email.
+func (p *PasswordResetHandler) count(ctx context.Context, email, ip string) (bool, error) {
+	if ip != "" {
+		allowed, err := p.loginAttemptUsecase.CountKey(ctx, "password_reset_ip:"+ip, p.maxIPRequests)
+		if err != nil || !allowed {
+			return false, err
+		}
+	}
+
+	return p.loginAttemptUsecase.CountKey(ctx, "password_reset:"+strings.ToLower(strings.TrimSpace(email)), p.maxRequests)
+}
@@ -0,0 +1,177 @@
+package passwordreset
+
+import (
+	"errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity/mocks"
+	apperrors "github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
+	"github.com/Jamshid90/go-clean-architecture/pkg/loginattempt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/mailer"
+	"github.com/Jamshid90/go-clean-architecture/pkg/revocation"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newAttemptUsecase() entity.LoginAttemptUsecase {
+	usecase := loginattempt.NewLoginAttemptUsecase(loginattempt.NewLoginAttemptRepositoryMemory(), loginattempt.Policy{
+		MaxFailures:   5,
+		MaxIPFailures: 20,
+		BaseDelay:     time.Second,
+		Lockout:       time.Minute * 15,
+		Window:        time.Hour,
+	}, time.Second*2)
+	return &usecase
+}
+
+// new test router serves password reset endpoints, 2 resets of an email and 3 from an ip address are allowed
+func newTestRouter(tokenRepo entity.UserTokenRepository, userUsecase entity.UserUsecase, sessionUsecase entity.SessionUsecase, attemptUsecase entity.LoginAttemptUsecase) chi.Router {
+	passwordResetUsecase := NewPasswordResetUsecase(tokenRepo, mailer.NewMemoryMailer(), time.Hour, "http://localhost/reset?token=", time.Second*2)
+	revocationUsecase := revocation.NewTokenRevocationUsecase(revocation.NewTokenRevocationRepositoryMemory(), time.Hour, time.Second*2)
+
+	r := chi.NewRouter()
+	NewPasswordResetHandler(r, &passwordResetUsecase, userUsecase, sessionUsecase, &revocationUsecase, attemptUsecase, 2, 3, zap.NewNop())
+	return r
+}
+
+func TestForgot(t *testing.T) {
+	serve := func(r chi.Router, email, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/auth/password/forgot", strings.NewReader(`{"email":"`+email+`"}`))
+		req.RemoteAddr = ip + ":1234"
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("error-too-many-email-requests", func(t *testing.T) {
+		userUsecase := new(mocks.UserUsecase)
+		userUsecase.On("FindByEmail", mock.Anything, "unknown@info.com").Return(nil, apperrors.NewErrNotFound("user")).Times(2)
+		r := newTestRouter(new(mocks.UserTokenRepository), userUsecase, new(mocks.SessionUsecase), newAttemptUsecase())
+
+		for _, ip := range []string{"127.0.0.1", "127.0.0.2"} {
+			assert.Equal(t, http.StatusOK, serve(r, "unknown@info.com", ip).Code)
+		}
+
+		// unknown emails are limited too, so the limit does not reveal registered emails
+		w := serve(r, "Unknown@info.com", "127.0.0.3")
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		userUsecase.AssertExpectations(t)
+	})
+
+	t.Run("error-too-many-ip-requests", func(t *testing.T) {
+		userUsecase := new(mocks.UserUsecase)
+		userUsecase.On("FindByEmail", mock.Anything, mock.Anything).Return(nil, apperrors.NewErrNotFound("user")).Times(3)
+		r := newTestRouter(new(mocks.UserTokenRepository), userUsecase, new(mocks.SessionUsecase), newAttemptUsecase())
+
+		for _, email := range []string{"first@info.com", "second@info.com", "third@info.com"} {
+			assert.Equal(t, http.StatusOK, serve(r, email, "127.0.0.1").Code)
+		}
+
+		w := serve(r, "fourth@info.com", "127.0.0.1")
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		userUsecase.AssertExpectations(t)
+	})
+}
+
+func TestReset(t *testing.T) {
+	const (
+		userID = "123456789"
+		body   = `{"token":"token","password":"new-password","confirm_password":"new-password"}`
+	)
+	userToken := &entity.UserToken{UserID: userID, Token: hash.HashToken("token"), ExpiresAt: time.Now().UTC().Add(time.Hour)}
+
+	serve := func(tokenRepo entity.UserTokenRepository, userUsecase entity.UserUsecase, sessionUsecase entity.SessionUsecase) *httptest.ResponseRecorder {
+		r := newTestRouter(tokenRepo, userUsecase, sessionUsecase, newAttemptUsecase())
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/auth/password/reset", strings.NewReader(body)))
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		var calls []string
+		record := func(name string) func(mock.Arguments) {
+			return func(mock.Arguments) { calls = append(calls, name) }
+		}
+
+		tokenRepo := new(mocks.UserTokenRepository)
+		tokenRepo.On("Find", mock.Anything, userToken.Token).Return(userToken, nil).Once()
+		tokenRepo.On("Use", mock.Anything, userToken.Token, mock.AnythingOfType("time.Time")).Run(record("Use")).Return(userToken, nil).Once()
+		userUsecase := new(mocks.UserUsecase)
+		userUsecase.On("ValidatePassword", mock.Anything, userID, "new-password").Run(record("ValidatePassword")).Return(nil).Once()
+		userUsecase.On("UpdatePassword", mock.Anything, userID, "new-password").Run(record("UpdatePassword")).Return(nil).Once()
+		sessionUsecase := new(mocks.SessionUsecase)
+		sessionUsecase.On("DeleteByUserId", mock.Anything, userID).Return(nil).Once()
+
+		w := serve(tokenRepo, userUsecase, sessionUsecase)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		// the token is consumed before the password is changed
+		assert.Equal(t, []string{"ValidatePassword", "Use", "UpdatePassword"}, calls)
+		tokenRepo.AssertExpectations(t)
+		userUsecase.AssertExpectations(t)
+		sessionUsecase.AssertExpectations(t)
+	})
+
+	t.Run("error-policy-violation", func(t *testing.T) {
+		errValidation := apperrors.NewErrValidation()
+		errValidation.Errors["password"] = "password must be at least 12 characters"
+
+		tokenRepo := new(mocks.UserTokenRepository)
+		tokenRepo.On("Find", mock.Anything, userToken.Token).Return(userToken, nil).Once()
+		userUsecase := new(mocks.UserUsecase)
+		userUsecase.On("ValidatePassword", mock.Anything, userID, "new-password").Return(errValidation).Once()
+		sessionUsecase := new(mocks.SessionUsecase)
+
+		w := serve(tokenRepo, userUsecase, sessionUsecase)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		// the token stays valid for another try
+		tokenRepo.AssertNotCalled(t, "Use", mock.Anything, mock.Anything, mock.Anything)
+		userUsecase.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error-consumed-concurrently", func(t *testing.T) {
+		tokenRepo := new(mocks.UserTokenRepository)
+		tokenRepo.On("Find", mock.Anything, userToken.Token).Return(userToken, nil).Once()
+		tokenRepo.On("Use", mock.Anything, userToken.Token, mock.AnythingOfType("time.Time")).Return(nil, apperrors.NewErrNotFound("password reset token")).Once()
+		userUsecase := new(mocks.UserUsecase)
+		userUsecase.On("ValidatePassword", mock.Anything, userID, "new-password").Return(nil).Once()
+		sessionUsecase := new(mocks.SessionUsecase)
+
+		w := serve(tokenRepo, userUsecase, sessionUsecase)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		userUsecase.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+		sessionUsecase.AssertNotCalled(t, "DeleteByUserId", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error-update-password", func(t *testing.T) {
+		tokenRepo := new(mocks.UserTokenRepository)
+		tokenRepo.On("Find", mock.Anything, userToken.Token).Return(userToken, nil).Once()
+		tokenRepo.On("Use", mock.Anything, userToken.Token, mock.AnythingOfType("time.Time")).Return(userToken, nil).Once()
+		tokenRepo.On("Release", mock.Anything, userToken.Token).Return(nil).Once()
+		userUsecase := new(mocks.UserUsecase)
+		userUsecase.On("ValidatePassword", mock.Anything, userID, "new-password").Return(nil).Once()
+		userUsecase.On("UpdatePassword", mock.Anything, userID, "new-password").Return(apperrors.ErrRepository{Err: errors.New("connection reset")}).Once()
+		sessionUsecase := new(mocks.SessionUsecase)
+
+		w := serve(tokenRepo, userUsecase, sessionUsecase)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		// the token is released, so the reset can be retried with it
+		tokenRepo.AssertExpectations(t)
+		userUsecase.AssertExpectations(t)
+		sessionUsecase.AssertNotCalled(t, "DeleteByUserId", mock.Anything, mock.Anything)
+	})
+}
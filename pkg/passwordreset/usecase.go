@@ -0,0 +1,72 @@
+package passwordreset
+
+import (
+	"context"
+	"fmt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/mailer"
+	"github.com/Jamshid90/go-clean-architecture/pkg/usertoken"
+	"time"
+)
+
+type passwordResetUsecase struct {
+	tokenStore     usertoken.TokenStore
+	mailer         mailer.Mailer
+	ttl            time.Duration
+	url            string
+	contextTimeout time.Duration
+}
+
+// New password reset usecase, url is the link prefix the token is appended to
+func NewPasswordResetUsecase(repo entity.UserTokenRepository, mailer mailer.Mailer, ttl time.Duration, url string, timeout time.Duration) passwordResetUsecase {
+	return passwordResetUsecase{
+		tokenStore:     usertoken.NewTokenStore(repo, ttl),
+		mailer:         mailer,
+		ttl:            ttl,
+		url:            url,
+		contextTimeout: timeout,
+	}
+}
+
+// Send replaces previous reset tokens of the user with a new one and mails it
+func (p *passwordResetUsecase) Send(ctx context.Context, user *entity.User) error {
+	ctx, cancel := context.WithTimeout(ctx, p.contextTimeout)
+	defer cancel()
+
+	token, err := p.tokenStore.Issue(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return p.mailer.Send(ctx, &mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello %s,\n\nsomeone requested a password reset for your account. Follow the link to choose a new password:\n%s%s\n\nThe link expires in %s. If it was not you, ignore this email.\n",
+			user.FirstName, p.url, token, p.ttl),
+	})
+}
+
+// Check returns id of the user the token was issued for without invalidating it
+func (p *passwordResetUsecase) Check(ctx context.Context, token string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.contextTimeout)
+	defer cancel()
+
+	return p.tokenStore.Check(ctx, token)
+}
+
+// Consume invalidates the token and returns id of the user it was issued for,
+// Release makes the token valid again when the password could not be changed
+func (p *passwordResetUsecase) Consume(ctx context.Context, token string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.contextTimeout)
+	defer cancel()
+
+	return p.tokenStore.Use(ctx, token)
+}
+
+// Release makes the consumed token valid again
+func (p *passwordResetUsecase) Release(ctx context.Context, token string) error {
+	ctx, cancel := context.WithTimeout(ctx, p.contextTimeout)
+	defer cancel()
+
+	return p.tokenStore.Release(ctx, token)
+}
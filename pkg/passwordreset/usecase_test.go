@@ -0,0 +1,64 @@
+package passwordreset
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity/mocks"
+	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
+	"github.com/Jamshid90/go-clean-architecture/pkg/mailer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSend(t *testing.T) {
+	mockRepo := new(mocks.UserTokenRepository)
+	memoryMailer := mailer.NewMemoryMailer()
+	user := &entity.User{ID: "123456789", Email: "user@info.com", FirstName: "User"}
+
+	var stored *entity.UserToken
+	mockRepo.On("DeleteByUserId", mock.Anything, user.ID).Return(nil).Once()
+	mockRepo.On("Store", mock.Anything, mock.AnythingOfType("*entity.UserToken")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*entity.UserToken) }).
+		Return(nil).Once()
+
+	usecase := NewPasswordResetUsecase(mockRepo, memoryMailer, time.Hour, "http://localhost/reset?token=", time.Second*2)
+	err := usecase.Send(context.TODO(), user)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Reset your password", memoryMailer.Last().Subject)
+
+	// only the digest of the mailed token is stored
+	body := memoryMailer.Last().Body
+	start := strings.Index(body, "token=") + len("token=")
+	token := body[start : start+strings.IndexByte(body[start:], '\n')]
+	assert.Equal(t, hash.HashToken(token), stored.Token)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCheckAndConsume(t *testing.T) {
+	mockRepo := new(mocks.UserTokenRepository)
+	usecase := NewPasswordResetUsecase(mockRepo, mailer.NewMemoryMailer(), time.Hour, "", time.Second*2)
+	resetToken := &entity.UserToken{
+		UserID:    "123456789",
+		Token:     hash.HashToken("token"),
+		ExpiresAt: time.Now().UTC().Add(time.Hour),
+	}
+
+	// the token is checked before the new password is accepted and consumed after
+	mockRepo.On("Find", mock.Anything, hash.HashToken("token")).Return(resetToken, nil).Once()
+	mockRepo.On("Use", mock.Anything, hash.HashToken("token"), mock.AnythingOfType("time.Time")).Return(resetToken, nil).Once()
+
+	userID, err := usecase.Check(context.TODO(), "token")
+	assert.NoError(t, err)
+	assert.Equal(t, "123456789", userID)
+
+	userID, err = usecase.Consume(context.TODO(), "token")
+	assert.NoError(t, err)
+	assert.Equal(t, "123456789", userID)
+
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,41 @@
+package phone
+
+import (
+	"errors"
+	"strings"
+)
+
+// limits of digits in E.164 number including the country code
+const (
+	MIN_DIGITS = 7
+	MAX_DIGITS = 15
+)
+
+var ErrInvalidPhone = errors.New("phone must be an international number, e.g. +998901234567")
+
+// Normalize converts number in international format to E.164, spaces, dashes, dots and
+// parentheses are dropped and "00" international prefix is replaced by "+"
+func Normalize(number string) (string, error) {
+	number = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "").Replace(strings.TrimSpace(number))
+
+	if strings.HasPrefix(number, "00") {
+		number = "+" + number[2:]
+	}
+
+	if !strings.HasPrefix(number, "+") {
+		return "", ErrInvalidPhone
+	}
+
+	digits := number[1:]
+	if len(digits) < MIN_DIGITS || len(digits) > MAX_DIGITS || digits[0] == '0' {
+		return "", ErrInvalidPhone
+	}
+
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return "", ErrInvalidPhone
+		}
+	}
+
+	return number, nil
+}
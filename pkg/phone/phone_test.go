@@ -0,0 +1,34 @@
+package phone
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	valid := map[string]string{
+		"+998901234567":        "+998901234567",
+		" +998 (90) 123-45-67": "+998901234567",
+		"00998901234567":       "+998901234567",
+		"+1.555.010.9999":      "+15550109999",
+	}
+	for number, expected := range valid {
+		normalized, err := Normalize(number)
+		assert.NoError(t, err, number)
+		assert.Equal(t, expected, normalized, number)
+	}
+
+	invalid := []string{
+		"",
+		"901234567",
+		"+0998901234567",
+		"+99890",
+		"+9989012345678901",
+		"+99890123456a",
+		"++998901234567",
+	}
+	for _, number := range invalid {
+		_, err := Normalize(number)
+		assert.Equal(t, ErrInvalidPhone, err, number)
+	}
+}
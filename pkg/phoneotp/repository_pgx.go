@@ -0,0 +1,101 @@
+package phoneotp
+
+import (
+	"context"
+	"fmt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+type pgxPhoneOTPRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPhoneOTPRepositoryPgx(dbpool *pgxpool.Pool) entity.PhoneOTPRepository {
+	return &pgxPhoneOTPRepository{db: dbpool}
+}
+
+// store replaces the previous code of the phone for the purpose
+func (p *pgxPhoneOTPRepository) Store(ctx context.Context, m *entity.PhoneOTP) error {
+	_, err := p.db.Exec(ctx, `INSERT INTO "phone_otp"(
+		phone, purpose, code, attempts, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (phone, purpose) DO UPDATE
+		SET code=EXCLUDED.code, attempts=EXCLUDED.attempts, expires_at=EXCLUDED.expires_at, created_at=EXCLUDED.created_at`,
+		m.Phone,
+		m.Purpose,
+		m.Code,
+		m.Attempts,
+		m.ExpiresAt,
+		m.CreatedAt,
+	)
+
+	if err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during store to phone otp repository: %w", err)}
+	}
+
+	return nil
+}
+
+func (p *pgxPhoneOTPRepository) Find(ctx context.Context, phone, purpose string) (*entity.PhoneOTP, error) {
+	otp := entity.PhoneOTP{}
+	row := p.db.QueryRow(ctx, `SELECT phone, purpose, code, attempts, expires_at, created_at FROM "phone_otp" WHERE phone=$1 AND purpose=$2`, phone, purpose)
+
+	err := row.Scan(
+		&otp.Phone,
+		&otp.Purpose,
+		&otp.Code,
+		&otp.Attempts,
+		&otp.ExpiresAt,
+		&otp.CreatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, errors.NewErrNotFound("phone otp")
+	}
+
+	if err != nil {
+		return nil, errors.ErrRepository{Err: fmt.Errorf("error during find to phone otp repository: %w", err)}
+	}
+
+	return &otp, nil
+}
+
+// increment attempts counts an attempt of the code and returns the code in one statement,
+// expired codes and codes without attempts left are not found
+func (p *pgxPhoneOTPRepository) IncrementAttempts(ctx context.Context, phone, purpose string, maxAttempts int, now time.Time) (string, error) {
+	var code string
+	row := p.db.QueryRow(ctx, `UPDATE "phone_otp" SET attempts=attempts+1
+		WHERE phone=$1 AND purpose=$2 AND attempts<$3 AND expires_at>$4
+		RETURNING code`, phone, purpose, maxAttempts, now)
+
+	err := row.Scan(&code)
+	if err == pgx.ErrNoRows {
+		return "", errors.NewErrNotFound("phone otp")
+	}
+
+	if err != nil {
+		return "", errors.ErrRepository{Err: fmt.Errorf("error during increment attempts to phone otp repository: %w", err)}
+	}
+
+	return code, nil
+}
+
+// consume deletes the code, the code is not found when it was consumed or replaced already
+func (p *pgxPhoneOTPRepository) Consume(ctx context.Context, phone, purpose, code string) error {
+	row := p.db.QueryRow(ctx, `DELETE FROM "phone_otp" WHERE phone=$1 AND purpose=$2 AND code=$3 RETURNING code`, phone, purpose, code)
+
+	err := row.Scan(&code)
+	if err == pgx.ErrNoRows {
+		return errors.NewErrNotFound("phone otp")
+	}
+
+	if err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during consume to phone otp repository: %w", err)}
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package phoneotp
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
+	"github.com/Jamshid90/go-clean-architecture/pkg/rand"
+	"github.com/Jamshid90/go-clean-architecture/pkg/sms"
+	"time"
+)
+
+// digits of the one-time code
+const CODE_LENGTH = 6
+
+type phoneOTPUsecase struct {
+	otpRepo        entity.PhoneOTPRepository
+	smsSender      sms.SMSSender
+	attemptUsecase entity.LoginAttemptUsecase
+	secret         []byte
+	ttl            time.Duration
+	maxAttempts    int
+	maxSends       int
+	resendInterval time.Duration
+	contextTimeout time.Duration
+}
+
+// New phone otp usecase, codes are stored as hmac with the secret. The code is invalidated after maxAttempts
+// wrong codes and a new one is not sent before resendInterval passes. Wrong codes of the phone are counted by
+// the attempt usecase, so new codes do not give unlimited guesses, and no more than maxSends codes are sent
+// to the phone within the attempt window.
+func NewPhoneOTPUsecase(repo entity.PhoneOTPRepository, smsSender sms.SMSSender, attemptUsecase entity.LoginAttemptUsecase, secret []byte, ttl time.Duration, maxAttempts, maxSends int, resendInterval time.Duration, timeout time.Duration) phoneOTPUsecase {
+	return phoneOTPUsecase{
+		otpRepo:        repo,
+		smsSender:      smsSender,
+		attemptUsecase: attemptUsecase,
+		secret:         secret,
+		ttl:            ttl,
+		maxAttempts:    maxAttempts,
+		maxSends:       maxSends,
+		resendInterval: resendInterval,
+		contextTimeout: timeout,
+	}
+}
+
+// Send replaces the previous code of the phone for the purpose with a new one and sends it,
+// ErrTooManyRequests is returned when the previous one was sent recently or the phone is locked out
+func (p *phoneOTPUsecase) Send(ctx context.Context, phone, purpose string) error {
+	ctx, cancel := context.WithTimeout(ctx, p.contextTimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+
+	if err := p.checkAttempts(ctx, phone); err != nil {
+		return err
+	}
+
+	otp, err := p.otpRepo.Find(ctx, phone, purpose)
+	if err != nil {
+		if _, ok := err.(*errors.ErrNotFound); !ok {
+			return err
+		}
+	}
+
+	if otp != nil && now.Before(otp.CreatedAt.Add(p.resendInterval)) {
+		return errors.ErrTooManyRequests
+	}
+
+	code, err := rand.Digits(CODE_LENGTH)
+	if err != nil {
+		return err
+	}
+
+	// every send is counted, no more codes are sent after max sends in the window
+	sent, err := p.attemptUsecase.CountKey(ctx, sendKey(phone), p.maxSends)
+	if err != nil {
+		return err
+	}
+	if !sent {
+		return errors.ErrTooManyRequests
+	}
+
+	if err := p.otpRepo.Store(ctx, &entity.PhoneOTP{
+		Phone:     phone,
+		Purpose:   purpose,
+		Code:      hash.HMACToken(p.secret, code),
+		ExpiresAt: now.Add(p.ttl),
+		CreatedAt: now,
+	}); err != nil {
+		return err
+	}
+
+	return p.smsSender.Send(ctx, &sms.Message{
+		To:   phone,
+		Body: fmt.Sprintf("Your code is %s. It expires in %s, do not share it with anyone.", code, p.ttl),
+	})
+}
+
+// Verify checks the code, the code is single use and is invalidated after too many wrong ones,
+// ErrTooManyRequests is returned when the phone is locked out after too many wrong codes
+func (p *phoneOTPUsecase) Verify(ctx context.Context, phone, purpose, code string) error {
+	ctx, cancel := context.WithTimeout(ctx, p.contextTimeout)
+	defer cancel()
+
+	// the failure of the phone is counted before the code is compared, so parallel guesses of all codes
+	// of the phone can not pass together
+	retryAfter, err := p.attemptUsecase.ReserveKey(ctx, failureKey(phone))
+	if err != nil {
+		return err
+	}
+	if retryAfter > 0 {
+		return errors.ErrTooManyRequests
+	}
+
+	// the attempt of the code is counted too, so the code is invalidated after max attempts
+	otpCode, err := p.otpRepo.IncrementAttempts(ctx, phone, purpose, p.maxAttempts, time.Now().UTC())
+	if err != nil {
+		if _, ok := err.(*errors.ErrNotFound); ok {
+			return p.fail(ctx, phone, errors.ErrInvalidOTPCode)
+		}
+		return p.fail(ctx, phone, err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(otpCode), []byte(hash.HMACToken(p.secret, code))) != 1 {
+		return p.fail(ctx, phone, errors.ErrInvalidOTPCode)
+	}
+
+	// code is single use, only one of parallel verifications consumes it
+	if err := p.otpRepo.Consume(ctx, phone, purpose, otpCode); err != nil {
+		if _, ok := err.(*errors.ErrNotFound); ok {
+			return p.fail(ctx, phone, errors.ErrInvalidOTPCode)
+		}
+		return p.fail(ctx, phone, err)
+	}
+
+	return p.attemptUsecase.SucceedKey(ctx, failureKey(phone))
+}
+
+// check attempts returns ErrTooManyRequests when the phone is locked out
+func (p *phoneOTPUsecase) checkAttempts(ctx context.Context, phone string) error {
+	retryAfter, err := p.attemptUsecase.CheckKey(ctx, failureKey(phone))
+	if err != nil {
+		return err
+	}
+	if retryAfter > 0 {
+		return errors.ErrTooManyRequests
+	}
+	return nil
+}
+
+// fail locks the failure of the phone reserved before the code was checked and returns the error
+func (p *phoneOTPUsecase) fail(ctx context.Context, phone string, err error) error {
+	if _, failErr := p.attemptUsecase.FailKey(ctx, failureKey(phone)); failErr != nil {
+		return failErr
+	}
+	return err
+}
+
+// attempt keys of the phone, sends and wrong codes are counted for all purposes
+func sendKey(phone string) string {
+	return "phone_send:" + phone
+}
+
+func failureKey(phone string) string {
+	return "phone:" + phone
+}
@@ -0,0 +1,169 @@
+package phoneotp
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity/mocks"
+	apperrors "github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
+	"github.com/Jamshid90/go-clean-architecture/pkg/loginattempt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/sms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"testing"
+	"time"
+)
+
+const testPhone = "+998901234567"
+
+var testSecret = []byte("secret")
+
+func newAttemptUsecase() entity.LoginAttemptUsecase {
+	return newAttemptUsecaseDelay(time.Nanosecond)
+}
+
+func newAttemptUsecaseDelay(baseDelay time.Duration) entity.LoginAttemptUsecase {
+	usecase := loginattempt.NewLoginAttemptUsecase(loginattempt.NewLoginAttemptRepositoryMemory(), loginattempt.Policy{
+		MaxFailures:   3,
+		MaxIPFailures: 3,
+		BaseDelay:     baseDelay,
+		Lockout:       time.Minute * 15,
+		Window:        time.Hour,
+	}, time.Second*2)
+	return &usecase
+}
+
+func TestSend(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockRepo := new(mocks.PhoneOTPRepository)
+		sender := sms.NewMemorySender()
+		usecase := NewPhoneOTPUsecase(mockRepo, sender, newAttemptUsecase(), testSecret, time.Minute*5, 5, 3, time.Minute, time.Second*2)
+
+		mockRepo.On("Find", mock.Anything, testPhone, entity.PHONE_OTP_PURPOSE_LOGIN).Return(nil, apperrors.NewErrNotFound("phone otp")).Once()
+		mockRepo.On("Store", mock.Anything, mock.AnythingOfType("*entity.PhoneOTP")).Return(nil).Once()
+
+		err := usecase.Send(context.TODO(), testPhone, entity.PHONE_OTP_PURPOSE_LOGIN)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+
+		// the sent code is stored as hmac only
+		code := regexp.MustCompile(`\d{6}`).FindString(sender.Last().Body)
+		stored := mockRepo.Calls[1].Arguments.Get(1).(*entity.PhoneOTP)
+		assert.Equal(t, testPhone, sender.Last().To)
+		assert.Equal(t, hash.HMACToken(testSecret, code), stored.Code)
+		assert.NotEqual(t, hash.HashToken(code), stored.Code)
+		assert.Equal(t, 0, stored.Attempts)
+	})
+
+	t.Run("error-resend-interval", func(t *testing.T) {
+		mockRepo := new(mocks.PhoneOTPRepository)
+		sender := sms.NewMemorySender()
+		usecase := NewPhoneOTPUsecase(mockRepo, sender, newAttemptUsecase(), testSecret, time.Minute*5, 5, 3, time.Minute, time.Second*2)
+
+		mockRepo.On("Find", mock.Anything, testPhone, entity.PHONE_OTP_PURPOSE_LOGIN).Return(&entity.PhoneOTP{
+			CreatedAt: time.Now().UTC().Add(-time.Second * 10),
+		}, nil).Once()
+
+		err := usecase.Send(context.TODO(), testPhone, entity.PHONE_OTP_PURPOSE_LOGIN)
+
+		assert.Equal(t, apperrors.ErrTooManyRequests, err)
+		assert.Empty(t, sender.Messages())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-too-many-sends", func(t *testing.T) {
+		mockRepo := new(mocks.PhoneOTPRepository)
+		sender := sms.NewMemorySender()
+		usecase := NewPhoneOTPUsecase(mockRepo, sender, newAttemptUsecaseDelay(time.Hour), testSecret, time.Minute*5, 5, 3, 0, time.Second*2)
+
+		mockRepo.On("Find", mock.Anything, testPhone, mock.Anything).Return(nil, apperrors.NewErrNotFound("phone otp"))
+		mockRepo.On("Store", mock.Anything, mock.AnythingOfType("*entity.PhoneOTP")).Return(nil)
+
+		// sends of all purposes are counted for the phone, they are not delayed like wrong codes
+		for _, purpose := range []string{entity.PHONE_OTP_PURPOSE_LOGIN, entity.PHONE_OTP_PURPOSE_VERIFY, entity.PHONE_OTP_PURPOSE_LOGIN} {
+			require.NoError(t, usecase.Send(context.TODO(), testPhone, purpose))
+		}
+
+		err := usecase.Send(context.TODO(), testPhone, entity.PHONE_OTP_PURPOSE_VERIFY)
+
+		assert.Equal(t, apperrors.ErrTooManyRequests, err)
+		assert.Len(t, sender.Messages(), 3)
+	})
+}
+
+func TestVerify(t *testing.T) {
+	code := hash.HMACToken(testSecret, "123456")
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo := new(mocks.PhoneOTPRepository)
+		usecase := NewPhoneOTPUsecase(mockRepo, sms.NewMemorySender(), newAttemptUsecase(), testSecret, time.Minute*5, 5, 3, time.Minute, time.Second*2)
+
+		mockRepo.On("IncrementAttempts", mock.Anything, testPhone, entity.PHONE_OTP_PURPOSE_LOGIN, 5, mock.AnythingOfType("time.Time")).Return(code, nil).Once()
+		mockRepo.On("Consume", mock.Anything, testPhone, entity.PHONE_OTP_PURPOSE_LOGIN, code).Return(nil).Once()
+
+		err := usecase.Verify(context.TODO(), testPhone, entity.PHONE_OTP_PURPOSE_LOGIN, "123456")
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-wrong-code", func(t *testing.T) {
+		mockRepo := new(mocks.PhoneOTPRepository)
+		usecase := NewPhoneOTPUsecase(mockRepo, sms.NewMemorySender(), newAttemptUsecase(), testSecret, time.Minute*5, 5, 3, time.Minute, time.Second*2)
+
+		mockRepo.On("IncrementAttempts", mock.Anything, testPhone, entity.PHONE_OTP_PURPOSE_LOGIN, 5, mock.AnythingOfType("time.Time")).Return(code, nil).Once()
+
+		err := usecase.Verify(context.TODO(), testPhone, entity.PHONE_OTP_PURPOSE_LOGIN, "654321")
+
+		assert.Equal(t, apperrors.ErrInvalidOTPCode, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-attempts-exceeded-or-expired", func(t *testing.T) {
+		mockRepo := new(mocks.PhoneOTPRepository)
+		usecase := NewPhoneOTPUsecase(mockRepo, sms.NewMemorySender(), newAttemptUsecase(), testSecret, time.Minute*5, 5, 3, time.Minute, time.Second*2)
+
+		mockRepo.On("IncrementAttempts", mock.Anything, testPhone, entity.PHONE_OTP_PURPOSE_LOGIN, 5, mock.AnythingOfType("time.Time")).Return("", apperrors.NewErrNotFound("phone otp")).Once()
+
+		// even the right code is rejected
+		err := usecase.Verify(context.TODO(), testPhone, entity.PHONE_OTP_PURPOSE_LOGIN, "123456")
+
+		assert.Equal(t, apperrors.ErrInvalidOTPCode, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-consumed", func(t *testing.T) {
+		mockRepo := new(mocks.PhoneOTPRepository)
+		usecase := NewPhoneOTPUsecase(mockRepo, sms.NewMemorySender(), newAttemptUsecase(), testSecret, time.Minute*5, 5, 3, time.Minute, time.Second*2)
+
+		mockRepo.On("IncrementAttempts", mock.Anything, testPhone, entity.PHONE_OTP_PURPOSE_LOGIN, 5, mock.AnythingOfType("time.Time")).Return(code, nil).Once()
+		mockRepo.On("Consume", mock.Anything, testPhone, entity.PHONE_OTP_PURPOSE_LOGIN, code).Return(apperrors.NewErrNotFound("phone otp")).Once()
+
+		// a parallel verification consumed the code first
+		err := usecase.Verify(context.TODO(), testPhone, entity.PHONE_OTP_PURPOSE_LOGIN, "123456")
+
+		assert.Equal(t, apperrors.ErrInvalidOTPCode, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-too-many-failures", func(t *testing.T) {
+		mockRepo := new(mocks.PhoneOTPRepository)
+		usecase := NewPhoneOTPUsecase(mockRepo, sms.NewMemorySender(), newAttemptUsecase(), testSecret, time.Minute*5, 5, 3, time.Minute, time.Second*2)
+
+		mockRepo.On("IncrementAttempts", mock.Anything, testPhone, mock.Anything, 5, mock.AnythingOfType("time.Time")).Return(code, nil).Times(3)
+
+		for i := 0; i < 3; i++ {
+			time.Sleep(time.Millisecond)
+			assert.Equal(t, apperrors.ErrInvalidOTPCode, usecase.Verify(context.TODO(), testPhone, entity.PHONE_OTP_PURPOSE_LOGIN, "654321"))
+		}
+
+		// the phone is locked out even for the right code of a new one
+		err := usecase.Verify(context.TODO(), testPhone, entity.PHONE_OTP_PURPOSE_VERIFY, "123456")
+
+		assert.Equal(t, apperrors.ErrTooManyRequests, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
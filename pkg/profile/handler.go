@@ -0,0 +1,341 @@
+package profile
+
+import (
+	"github.com/Jamshid90/go-clean-architecture/pkg/config"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/middleware"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/request"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/response"
+	"github.com/Jamshid90/go-clean-architecture/pkg/token"
+	"github.com/Jamshid90/go-clean-architecture/pkg/validation"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+type ProfileHandler struct {
+	logger              *zap.Logger
+	config              *config.Config
+	keys                *token.KeySet
+	userUsecase         entity.UserUsecase
+	refreshTokenUsecase entity.RefreshTokenUsecase
+	sessionUsecase      entity.SessionUsecase
+	revocationUsecase   entity.TokenRevocationUsecase
+	phoneOTPUsecase     entity.PhoneOTPUsecase
+}
+
+// New profile handler
+func NewProfileHandler(r chi.Router, userUsecase entity.UserUsecase, refreshTokenUsecase entity.RefreshTokenUsecase, sessionUsecase entity.SessionUsecase, revocationUsecase entity.TokenRevocationUsecase, phoneOTPUsecase entity.PhoneOTPUsecase, keys *token.KeySet, auth func(http.Handler) http.Handler, config *config.Config, logger *zap.Logger) {
+	handler := ProfileHandler{
+		logger:              logger,
+		config:              config,
+		keys:                keys,
+		userUsecase:         userUsecase,
+		refreshTokenUsecase: refreshTokenUsecase,
+		sessionUsecase:      sessionUsecase,
+		revocationUsecase:   revocationUsecase,
+		phoneOTPUsecase:     phoneOTPUsecase,
+	}
+
+	r.Group(func(r chi.Router) {
+		r.Use(auth)
+		r.Get("/me", handler.find())
+
+		// the profile is changed by the user only, neither with api keys nor under impersonation
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.NoAPIKey)
+			r.Use(middleware.NoImpersonation)
+			r.Patch("/me", handler.update())
+			r.Post("/me/password", handler.changePassword())
+			r.Post("/me/phone/verification", handler.sendPhoneVerification())
+			r.Post("/me/phone/verify", handler.verifyPhone())
+		})
+	})
+}
+
+// convert entity user to profile user
+func (p *ProfileHandler) convert(user *entity.User) *User {
+	return &User{
+		ID:            user.ID,
+		Email:         user.Email,
+		Phone:         user.Phone,
+		PhoneVerified: user.IsPhoneVerified(),
+		Gender:        user.Gender,
+		Status:        user.Status,
+		Role:          user.Role,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		BirthDate:     user.BirthDate,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+	}
+}
+
+// current loads the authenticated user from the repository
+func (p *ProfileHandler) current(r *http.Request) (*entity.User, error) {
+	authUser, ok := middleware.GetAuthUser(r.Context())
+	if !ok {
+		return nil, errors.ErrUnauthorized
+	}
+	return p.userUsecase.Find(r.Context(), authUser.ID)
+}
+
+// find
+func (p *ProfileHandler) find() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := p.current(r)
+		if err != nil {
+			p.logger.Error("profile find", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+			"data":   p.convert(user),
+		})
+	}
+}
+
+// update
+func (p *ProfileHandler) update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var updateRequest UpdateProfileRequest
+		if err := request.DecodeJson(r, &updateRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := validation.Validator(&updateRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		user, err := p.current(r)
+		if err != nil {
+			p.logger.Error("profile update find", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		// a new email is verified again, the usecase makes the user pending and sends the verification
+		if updateRequest.Email != nil {
+			user.Email = *updateRequest.Email
+		}
+		if updateRequest.Phone != nil {
+			user.Phone = *updateRequest.Phone
+		}
+		if updateRequest.Gender != nil {
+			user.Gender = *updateRequest.Gender
+		}
+		if updateRequest.FirstName != nil {
+			user.FirstName = *updateRequest.FirstName
+		}
+		if updateRequest.LastName != nil {
+			user.LastName = *updateRequest.LastName
+		}
+		if updateRequest.BirthDate != nil {
+			birthDate, err := time.Parse("2006-01-02", *updateRequest.BirthDate)
+			if err != nil {
+				p.logger.Error("profile update parse birth date", zap.Error(err))
+				response.Error(w, r, err, response.GetStatusCodeErr(err))
+				return
+			}
+			user.BirthDate = birthDate
+		}
+
+		if err := p.userUsecase.Update(r.Context(), user); err != nil {
+			p.logger.Error("profile update", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+			"data":   p.convert(user),
+		})
+	}
+}
+
+// change password, other sessions are logged out and the current one gets a new token pair
+func (p *ProfileHandler) changePassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var changeRequest ChangePasswordRequest
+		if err := request.DecodeJson(r, &changeRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := validation.Validator(&changeRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		user, err := p.current(r)
+		if err != nil {
+			p.logger.Error("profile change password find", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		ctx := r.Context()
+		passwordValid, err := p.userUsecase.CheckPassword(ctx, user, changeRequest.CurrentPassword)
+		if err != nil {
+			p.logger.Error("profile change password rehash", zap.Error(err))
+		}
+
+		if !passwordValid {
+			errValidation := errors.NewErrValidation()
+			errValidation.Errors["current_password"] = "current_password is incorrect"
+			response.Error(w, r, errValidation, response.GetStatusCodeErr(errValidation))
+			return
+		}
+
+		if err := p.userUsecase.UpdatePassword(ctx, user.ID, changeRequest.Password); err != nil {
+			p.logger.Error("profile change password update", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := p.sessionUsecase.DeleteByUserId(ctx, user.ID); err != nil {
+			p.logger.Error("profile change password delete sessions", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := p.revocationUsecase.RevokeUser(ctx, user.ID); err != nil {
+			p.logger.Error("profile change password revoke tokens", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		// the device changing password continues in a new session
+		session := entity.Session{
+			UserID:    user.ID,
+			UserAgent: r.UserAgent(),
+			IP:        request.ClientIP(r),
+		}
+		if err := p.sessionUsecase.Store(ctx, &session); err != nil {
+			p.logger.Error("profile change password session store", zap.Error(err))
+			response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		// generate token
+		access_token, refresh_token, err := token.GenerateToken(p.keys, p.config.Jwt.AccessTTL, p.config.Jwt.RefreshTTL, user, session.ID)
+		if err != nil {
+			p.logger.Error("profile change password generate token", zap.Error(err))
+			response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		// create refresh token
+		if err = p.refreshTokenUsecase.Store(ctx, &entity.RefreshToken{
+			UserID:   user.ID,
+			Token:    refresh_token,
+			FamilyID: session.ID,
+		}); err != nil {
+			p.logger.Error("profile change password refresh token store", zap.Error(err))
+			response.Error(w, r, errors.ErrInternalServerError, response.GetStatusCodeErr(err))
+			return
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+			"token": map[string]string{
+				"type":    "Bearer",
+				"access":  access_token,
+				"refresh": refresh_token,
+			},
+		})
+	}
+}
+
+// send phone verification sends a one-time code to the phone of the profile
+func (p *ProfileHandler) sendPhoneVerification() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := p.current(r)
+		if err != nil {
+			p.logger.Error("profile send phone verification find", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if user.IsPhoneVerified() {
+			response.Error(w, r, &errors.ErrBadRequest{Err: errors.BadRequest, Message: "phone is verified already"}, http.StatusBadRequest)
+			return
+		}
+
+		if err := p.phoneOTPUsecase.Send(r.Context(), user.Phone, entity.PHONE_OTP_PURPOSE_VERIFY); err != nil {
+			if err == errors.ErrTooManyRequests {
+				response.Error(w, r, err, http.StatusTooManyRequests)
+				return
+			}
+			p.logger.Error("profile send phone verification", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+		})
+	}
+}
+
+// verify phone checks the one-time code sent to the phone of the profile
+func (p *ProfileHandler) verifyPhone() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var verifyRequest VerifyPhoneRequest
+		if err := request.DecodeJson(r, &verifyRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := validation.Validator(&verifyRequest); err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		user, err := p.current(r)
+		if err != nil {
+			p.logger.Error("profile verify phone find", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		ctx := r.Context()
+		if err := p.phoneOTPUsecase.Verify(ctx, user.Phone, entity.PHONE_OTP_PURPOSE_VERIFY, verifyRequest.Code); err != nil {
+			if err == errors.ErrInvalidOTPCode {
+				response.Error(w, r, err, http.StatusBadRequest)
+				return
+			}
+			if err == errors.ErrTooManyRequests {
+				response.Error(w, r, err, http.StatusTooManyRequests)
+				return
+			}
+			p.logger.Error("profile verify phone", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := p.userUsecase.VerifyPhone(ctx, user.ID); err != nil {
+			p.logger.Error("profile verify phone update user", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		user, err = p.userUsecase.Find(ctx, user.ID)
+		if err != nil {
+			p.logger.Error("profile verify phone find", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+			"data":   p.convert(user),
+		})
+	}
+}
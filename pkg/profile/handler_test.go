@@ -0,0 +1,260 @@
+package profile
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Jamshid90/go-clean-architecture/pkg/config"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity/mocks"
+	apperrors "github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/refreshtoken"
+	"github.com/Jamshid90/go-clean-architecture/pkg/revocation"
+	"github.com/Jamshid90/go-clean-architecture/pkg/token"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// test profile handler has the dependencies of the profile endpoints, the user is authenticated with access token
+type testProfileHandler struct {
+	keys             *token.KeySet
+	userUsecase      *mocks.UserUsecase
+	sessionUsecase   *mocks.SessionUsecase
+	refreshTokenRepo *mocks.RefreshTokenRepository
+}
+
+func newTestProfileHandler(t *testing.T) *testProfileHandler {
+	t.Helper()
+	return &testProfileHandler{
+		keys:             token.TestKeySet(t),
+		userUsecase:      new(mocks.UserUsecase),
+		sessionUsecase:   new(mocks.SessionUsecase),
+		refreshTokenRepo: new(mocks.RefreshTokenRepository),
+	}
+}
+
+func (h *testProfileHandler) serve(t *testing.T, user *entity.User, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.Jwt.AccessTTL = "1h"
+	cfg.Jwt.RefreshTTL = "24h"
+
+	refreshTokenUsecase := refreshtoken.NewRefreshTokenUsecase(h.refreshTokenRepo, new(mocks.SessionRepository), time.Hour*24, time.Second*2)
+	revocationUsecase := revocation.NewTokenRevocationUsecase(revocation.NewTokenRevocationRepositoryMemory(), time.Hour, time.Second*2)
+
+	// like Auth middleware with the access token of the user
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), "user", &entity.User{ID: user.ID, Role: user.Role})
+			ctx = context.WithValue(ctx, "access_token", &token.AccessToken{ID: "jti", SessionID: "session", User: &entity.User{ID: user.ID}})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+
+	r := chi.NewRouter()
+	NewProfileHandler(r, h.userUsecase, &refreshTokenUsecase, h.sessionUsecase, &revocationUsecase, nil, h.keys, auth, cfg, zap.NewNop())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return w
+}
+
+func (h *testProfileHandler) assertExpectations(t *testing.T) {
+	h.userUsecase.AssertExpectations(t)
+	h.sessionUsecase.AssertExpectations(t)
+	h.refreshTokenRepo.AssertExpectations(t)
+}
+
+func testUser() *entity.User {
+	return &entity.User{
+		ID:        "123456789",
+		Email:     "user@info.com",
+		Status:    entity.USER_STATUS_ACTIVE,
+		Role:      entity.USER_ROLE_USER,
+		FirstName: "User",
+		LastName:  "Admin",
+		Password:  "hash",
+	}
+}
+
+func validationErrors(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	errs, _ := body["errors"].(map[string]interface{})
+	return errs
+}
+
+func TestFind(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		h := newTestProfileHandler(t)
+		user := testUser()
+		h.userUsecase.On("Find", mock.Anything, user.ID).Return(user, nil).Once()
+
+		w := h.serve(t, user, http.MethodGet, "/me", "")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var body struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, user.ID, body.Data["id"])
+		assert.Equal(t, user.Email, body.Data["email"])
+		assert.NotContains(t, body.Data, "password")
+		h.assertExpectations(t)
+	})
+
+	t.Run("error-not-found", func(t *testing.T) {
+		h := newTestProfileHandler(t)
+		user := testUser()
+		h.userUsecase.On("Find", mock.Anything, user.ID).Return(nil, apperrors.NewErrNotFound("user")).Once()
+
+		w := h.serve(t, user, http.MethodGet, "/me", "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		h.assertExpectations(t)
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		h := newTestProfileHandler(t)
+		user := testUser()
+		h.userUsecase.On("Find", mock.Anything, user.ID).Return(user, nil).Once()
+		h.userUsecase.On("Update", mock.Anything, mock.MatchedBy(func(m *entity.User) bool {
+			return m.FirstName == "Name" && m.LastName == "Admin" && m.Status == entity.USER_STATUS_ACTIVE
+		})).Return(nil).Once()
+
+		w := h.serve(t, user, http.MethodPatch, "/me", `{"first_name":"Name"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		h.assertExpectations(t)
+	})
+
+	t.Run("success-email-changed", func(t *testing.T) {
+		h := newTestProfileHandler(t)
+		user := testUser()
+		h.userUsecase.On("Find", mock.Anything, user.ID).Return(user, nil).Once()
+		h.userUsecase.On("Update", mock.Anything, mock.MatchedBy(func(m *entity.User) bool {
+			return m.Email == "new@info.com"
+		})).Return(nil).Once()
+
+		w := h.serve(t, user, http.MethodPatch, "/me", `{"email":"new@info.com"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		h.assertExpectations(t)
+	})
+
+	t.Run("error-validation", func(t *testing.T) {
+		h := newTestProfileHandler(t)
+		user := testUser()
+
+		w := h.serve(t, user, http.MethodPatch, "/me", `{"email":"user","gender":"other"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		errs := validationErrors(t, w)
+		assert.Contains(t, errs, "email")
+		assert.Contains(t, errs, "gender")
+		h.userUsecase.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error-conflict", func(t *testing.T) {
+		h := newTestProfileHandler(t)
+		user := testUser()
+		h.userUsecase.On("Find", mock.Anything, user.ID).Return(user, nil).Once()
+		h.userUsecase.On("Update", mock.Anything, mock.AnythingOfType("*entity.User")).Return(apperrors.NewErrConflict("email")).Once()
+
+		w := h.serve(t, user, http.MethodPatch, "/me", `{"email":"taken@info.com"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		h.assertExpectations(t)
+	})
+}
+
+func TestChangePassword(t *testing.T) {
+	const body = `{"current_password":"current","password":"new-password","confirm_password":"new-password"}`
+
+	t.Run("success", func(t *testing.T) {
+		h := newTestProfileHandler(t)
+		user := testUser()
+		h.userUsecase.On("Find", mock.Anything, user.ID).Return(user, nil).Once()
+		h.userUsecase.On("CheckPassword", mock.Anything, user, "current").Return(true, nil).Once()
+		h.userUsecase.On("UpdatePassword", mock.Anything, user.ID, "new-password").Return(nil).Once()
+		h.sessionUsecase.On("DeleteByUserId", mock.Anything, user.ID).Return(nil).Once()
+		h.sessionUsecase.On("Store", mock.Anything, mock.AnythingOfType("*entity.Session")).Run(func(args mock.Arguments) {
+			args.Get(1).(*entity.Session).ID = "new-session"
+		}).Return(nil).Once()
+		h.refreshTokenRepo.On("Store", mock.Anything, mock.MatchedBy(func(m *entity.RefreshToken) bool {
+			return m.UserID == user.ID && m.FamilyID == "new-session"
+		})).Return(nil).Once()
+
+		w := h.serve(t, user, http.MethodPost, "/me/password", body)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		h.assertExpectations(t)
+
+		// the current device continues in the new session
+		var response struct {
+			Token struct {
+				Access string `json:"access"`
+			} `json:"token"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		accessToken, err := token.ParseAccessTokenString(h.keys, response.Token.Access)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, accessToken.User.ID)
+		assert.Equal(t, "new-session", accessToken.SessionID)
+	})
+
+	t.Run("error-wrong-current-password", func(t *testing.T) {
+		h := newTestProfileHandler(t)
+		user := testUser()
+		h.userUsecase.On("Find", mock.Anything, user.ID).Return(user, nil).Once()
+		h.userUsecase.On("CheckPassword", mock.Anything, user, "current").Return(false, nil).Once()
+
+		w := h.serve(t, user, http.MethodPost, "/me/password", body)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, validationErrors(t, w), "current_password")
+		h.userUsecase.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+		h.sessionUsecase.AssertNotCalled(t, "DeleteByUserId", mock.Anything, mock.Anything)
+		h.assertExpectations(t)
+	})
+
+	t.Run("error-policy-violation", func(t *testing.T) {
+		h := newTestProfileHandler(t)
+		user := testUser()
+		errValidation := apperrors.NewErrValidation()
+		errValidation.Errors["password"] = "password must be at least 12 characters"
+		h.userUsecase.On("Find", mock.Anything, user.ID).Return(user, nil).Once()
+		h.userUsecase.On("CheckPassword", mock.Anything, user, "current").Return(true, nil).Once()
+		h.userUsecase.On("UpdatePassword", mock.Anything, user.ID, "new-password").Return(errValidation).Once()
+
+		w := h.serve(t, user, http.MethodPost, "/me/password", body)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "password must be at least 12 characters", validationErrors(t, w)["password"])
+		h.sessionUsecase.AssertNotCalled(t, "DeleteByUserId", mock.Anything, mock.Anything)
+		h.assertExpectations(t)
+	})
+
+	t.Run("error-confirm-password", func(t *testing.T) {
+		h := newTestProfileHandler(t)
+		user := testUser()
+
+		w := h.serve(t, user, http.MethodPost, "/me/password", `{"current_password":"current","password":"new-password","confirm_password":"other"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, validationErrors(t, w), "confirm_password")
+		h.userUsecase.AssertNotCalled(t, "CheckPassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
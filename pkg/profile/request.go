@@ -0,0 +1,21 @@
+package profile
+
+// only the fields present in the request are updated
+type UpdateProfileRequest struct {
+	Email     *string `json:"email" validate:"omitempty,email"`
+	Phone     *string `json:"phone" validate:"omitempty,min=1"`
+	Gender    *string `json:"gender" validate:"omitempty,eq=male|eq=female"`
+	FirstName *string `json:"first_name" validate:"omitempty,min=2,max=50"`
+	LastName  *string `json:"last_name" validate:"omitempty,min=2,max=50"`
+	BirthDate *string `json:"birth_date" validate:"omitempty,datetime=2006-01-02"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	Password        string `json:"password" validate:"required"`
+	ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=Password"`
+}
+
+type VerifyPhoneRequest struct {
+	Code string `json:"code" validate:"required,numeric,len=6"`
+}
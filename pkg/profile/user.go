@@ -0,0 +1,18 @@
+package profile
+
+import "time"
+
+type User struct {
+	ID            string    `json:"id,omitempty"`
+	Email         string    `json:"email,omitempty"`
+	Phone         string    `json:"phone,omitempty"`
+	PhoneVerified bool      `json:"phone_verified"`
+	Gender        string    `json:"gender,omitempty"`
+	Status        string    `json:"status,omitempty"`
+	Role          string    `json:"role,omitempty"`
+	FirstName     string    `json:"first_name,omitempty"`
+	LastName      string    `json:"last_name,omitempty"`
+	BirthDate     time.Time `json:"birth_date,omitempty"`
+	CreatedAt     time.Time `json:"created_at,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at,omitempty"`
+}
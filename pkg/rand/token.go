@@ -0,0 +1,29 @@
+package rand
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"math/big"
+)
+
+// Token returns url safe string built from n cryptographically secure random bytes
+func Token(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Digits returns string of n cryptographically secure random decimal digits
+func Digits(n int) (string, error) {
+	b := make([]byte, n)
+	for i := range b {
+		d, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		b[i] = byte('0' + d.Int64())
+	}
+	return string(b), nil
+}
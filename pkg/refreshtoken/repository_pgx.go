@@ -5,10 +5,14 @@ import (
 	"fmt"
 	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
 	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
 )
 
+// pgxRefreshTokenRepository keeps only sha256 digests of tokens,
+// the methods take plain tokens and hash them before querying.
 type pgxRefreshTokenRepository struct {
 	db *pgxpool.Pool
 }
@@ -19,10 +23,12 @@ func NewRefreshTokenRepositoryPgx(dbpool *pgxpool.Pool) entity.RefreshTokenRepos
 
 func (p *pgxRefreshTokenRepository) Store(ctx context.Context, m *entity.RefreshToken) error {
 	_, err := p.db.Exec(ctx, `INSERT INTO "refresh_token"(
-		user_id, token, created_at)
-		VALUES ($1, $2, $3);`,
+		user_id, token, family_id, parent_token, created_at)
+		VALUES ($1, $2, $3, $4, $5);`,
 		m.UserID,
-		m.Token,
+		hash.HashToken(m.Token),
+		m.FamilyID,
+		digestParent(m.ParentToken),
 		m.CreatedAt,
 	)
 
@@ -33,8 +39,33 @@ func (p *pgxRefreshTokenRepository) Store(ctx context.Context, m *entity.Refresh
 	return nil
 }
 
+// rotate marks the old token rotated, stores the new one and removes expired tokens of the family in one statement,
+// so a failed rotation leaves the old token usable. It returns false when the old token is rotated already.
+func (p *pgxRefreshTokenRepository) Rotate(ctx context.Context, old string, m *entity.RefreshToken, expiredBefore time.Time) (bool, error) {
+	var stored int
+	err := p.db.QueryRow(ctx, `WITH rotated AS (
+			UPDATE "refresh_token" SET rotated_at=$1 WHERE token=$2 AND rotated_at IS NULL RETURNING family_id
+		), stored AS (
+			INSERT INTO "refresh_token"(user_id, token, family_id, parent_token, created_at)
+			SELECT $3, $4, family_id, $2, $1 FROM rotated RETURNING 1
+		), pruned AS (
+			DELETE FROM "refresh_token" WHERE family_id IN (SELECT family_id FROM rotated) AND token<>$2 AND created_at < $5
+		)
+		SELECT count(*) FROM stored`,
+		m.CreatedAt,
+		hash.HashToken(old),
+		m.UserID,
+		hash.HashToken(m.Token),
+		expiredBefore,
+	).Scan(&stored)
+	if err != nil {
+		return false, errors.ErrRepository{Err: fmt.Errorf("error during rotate to refresh token repository: %w", err)}
+	}
+	return stored == 1, nil
+}
+
 func (p *pgxRefreshTokenRepository) Delete(ctx context.Context, token string) error {
-	if _, err := p.db.Exec(ctx, `DELETE FROM "refresh_token" WHERE token=$1`, token); err != nil {
+	if _, err := p.db.Exec(ctx, `DELETE FROM "refresh_token" WHERE token=$1`, hash.HashToken(token)); err != nil {
 		return errors.ErrRepository{Err: fmt.Errorf("error during delete to refresh token repository: %w", err)}
 	}
 	return nil
@@ -47,13 +78,23 @@ func (p *pgxRefreshTokenRepository) DeleteByUserId(ctx context.Context, id strin
 	return nil
 }
 
+func (p *pgxRefreshTokenRepository) DeleteByFamily(ctx context.Context, familyID string) error {
+	if _, err := p.db.Exec(ctx, `DELETE FROM "refresh_token" WHERE family_id=$1`, familyID); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during delete by family to refresh token repository: %w", err)}
+	}
+	return nil
+}
+
+// find returns the token with the plain value it was looked up by
 func (p *pgxRefreshTokenRepository) Find(ctx context.Context, token string) (*entity.RefreshToken, error) {
-	refreshToken := entity.RefreshToken{}
-	row := p.db.QueryRow(ctx, `SELECT user_id, token, created_at FROM "refresh_token" WHERE token=$1`, token)
+	refreshToken := entity.RefreshToken{Token: token}
+	row := p.db.QueryRow(ctx, `SELECT user_id, family_id, parent_token, rotated_at, created_at FROM "refresh_token" WHERE token=$1`, hash.HashToken(token))
 
 	err := row.Scan(
 		&refreshToken.UserID,
-		&refreshToken.Token,
+		&refreshToken.FamilyID,
+		&refreshToken.ParentToken,
+		&refreshToken.RotatedAt,
 		&refreshToken.CreatedAt,
 	)
 
@@ -67,3 +108,11 @@ func (p *pgxRefreshTokenRepository) Find(ctx context.Context, token string) (*en
 
 	return &refreshToken, nil
 }
+
+// digest parent keeps empty parent of the first token in a family empty
+func digestParent(token string) string {
+	if token == "" {
+		return ""
+	}
+	return hash.HashToken(token)
+}
@@ -4,25 +4,39 @@ import (
 	"context"
 	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
 	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/rand"
 	"time"
 )
 
 type refreshTokenUsecase struct {
 	refreshTokenRepo entity.RefreshTokenRepository
+	sessionRepo      entity.SessionRepository
+	refreshTTL       time.Duration
 	contextTimeout   time.Duration
 }
 
-// New refresh token usecase
-func NewRefreshTokenUsecase(repo entity.RefreshTokenRepository, timeout time.Duration) refreshTokenUsecase {
+// New refresh token usecase, rotated tokens are kept for refresh ttl so their reuse is detected until they expire
+func NewRefreshTokenUsecase(repo entity.RefreshTokenRepository, sessionRepo entity.SessionRepository, refreshTTL time.Duration, timeout time.Duration) refreshTokenUsecase {
 	return refreshTokenUsecase{
 		refreshTokenRepo: repo,
+		sessionRepo:      sessionRepo,
+		refreshTTL:       refreshTTL,
 		contextTimeout:   timeout,
 	}
 }
 
-// Before Store
+// Before Store, a token without family starts a new one
 func (r *refreshTokenUsecase) BeforeStore(m *entity.RefreshToken) error {
 	m.CreatedAt = time.Now().UTC()
+
+	if m.FamilyID == "" {
+		familyID, err := rand.Token(16)
+		if err != nil {
+			return err
+		}
+		m.FamilyID = familyID
+	}
+
 	return nil
 }
 
@@ -38,6 +52,39 @@ func (r *refreshTokenUsecase) Store(ctx context.Context, m *entity.RefreshToken)
 	return r.refreshTokenRepo.Store(ctx, m)
 }
 
+// Rotate marks the old token rotated and stores the new one in the same family, expired tokens of the family are removed.
+// Presenting a token that was rotated before means it leaked, so the whole family is revoked
+// together with its session, which ends access tokens of the session as well.
+func (r *refreshTokenUsecase) Rotate(ctx context.Context, old *entity.RefreshToken, m *entity.RefreshToken) error {
+	ctx, cancel := context.WithTimeout(ctx, r.contextTimeout)
+	defer cancel()
+
+	m.UserID = old.UserID
+	m.FamilyID = old.FamilyID
+	m.ParentToken = old.Token
+
+	if err := r.BeforeStore(m); err != nil {
+		return err
+	}
+
+	rotated, err := r.refreshTokenRepo.Rotate(ctx, old.Token, m, m.CreatedAt.Add(-r.refreshTTL))
+	if err != nil {
+		return err
+	}
+
+	if !rotated {
+		if err := r.refreshTokenRepo.DeleteByFamily(ctx, old.FamilyID); err != nil {
+			return err
+		}
+		if err := r.sessionRepo.Delete(ctx, old.FamilyID); err != nil {
+			return err
+		}
+		return errors.ErrRefreshTokenReused
+	}
+
+	return nil
+}
+
 // Delete
 func (r *refreshTokenUsecase) Delete(ctx context.Context, token string) error {
 	ctx, cancel := context.WithTimeout(ctx, r.contextTimeout)
@@ -48,7 +95,7 @@ func (r *refreshTokenUsecase) Delete(ctx context.Context, token string) error {
 		return err
 	}
 
-	if *existedToken == (entity.RefreshToken{}) {
+	if existedToken.Token == "" {
 		return errors.NewErrNotFound("user")
 	}
 
@@ -63,6 +110,14 @@ func (r *refreshTokenUsecase) DeleteByUserId(ctx context.Context, id string) err
 	return r.refreshTokenRepo.DeleteByUserId(ctx, id)
 }
 
+// Delete by family
+func (r *refreshTokenUsecase) DeleteByFamily(ctx context.Context, familyID string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.contextTimeout)
+	defer cancel()
+
+	return r.refreshTokenRepo.DeleteByFamily(ctx, familyID)
+}
+
 // Find
 func (r *refreshTokenUsecase) Find(ctx context.Context, token string) (*entity.RefreshToken, error) {
 	ctx, cancel := context.WithTimeout(ctx, r.contextTimeout)
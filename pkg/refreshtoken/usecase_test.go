@@ -0,0 +1,68 @@
+package refreshtoken
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity/mocks"
+	apperrors "github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	mockRepo := new(mocks.RefreshTokenRepository)
+	mockSessionRepo := new(mocks.SessionRepository)
+	mockRepo.On("Store", mock.Anything, mock.AnythingOfType("*entity.RefreshToken")).Return(nil).Once()
+
+	refreshToken := &entity.RefreshToken{UserID: "123456789", Token: "token"}
+	usecase := NewRefreshTokenUsecase(mockRepo, mockSessionRepo, time.Hour*24, time.Second*2)
+	err := usecase.Store(context.TODO(), refreshToken)
+
+	assert := assert.New(t)
+	assert.NoError(err)
+	assert.NotEmpty(refreshToken.FamilyID)
+	assert.NotEmpty(refreshToken.CreatedAt)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRotate(t *testing.T) {
+	mockRepo := new(mocks.RefreshTokenRepository)
+	mockSessionRepo := new(mocks.SessionRepository)
+	old := &entity.RefreshToken{UserID: "123456789", Token: "old", FamilyID: "family"}
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo.On("Rotate", mock.Anything, "old", mock.AnythingOfType("*entity.RefreshToken"), mock.MatchedBy(func(expiredBefore time.Time) bool {
+			return expiredBefore.Before(time.Now().Add(-time.Hour * 23))
+		})).Return(true, nil).Once()
+
+		refreshToken := &entity.RefreshToken{Token: "new"}
+		usecase := NewRefreshTokenUsecase(mockRepo, mockSessionRepo, time.Hour*24, time.Second*2)
+		err := usecase.Rotate(context.TODO(), old, refreshToken)
+
+		assert := assert.New(t)
+		assert.NoError(err)
+		assert.Equal(old.UserID, refreshToken.UserID)
+		assert.Equal(old.FamilyID, refreshToken.FamilyID)
+		assert.Equal(old.Token, refreshToken.ParentToken)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-reused", func(t *testing.T) {
+		mockRepo.On("Rotate", mock.Anything, "old", mock.AnythingOfType("*entity.RefreshToken"), mock.AnythingOfType("time.Time")).Return(false, nil).Once()
+		mockRepo.On("DeleteByFamily", mock.Anything, "family").Return(nil).Once()
+		mockSessionRepo.On("Delete", mock.Anything, "family").Return(nil).Once()
+
+		usecase := NewRefreshTokenUsecase(mockRepo, mockSessionRepo, time.Hour*24, time.Second*2)
+		err := usecase.Rotate(context.TODO(), old, &entity.RefreshToken{Token: "new"})
+
+		assert.Equal(t, apperrors.ErrRefreshTokenReused, err)
+
+		// the session of the family ends as well
+		mockRepo.AssertExpectations(t)
+		mockSessionRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,20 @@
+package revocation
+
+import (
+	"fmt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/config"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// New token revocation repository by configured driver
+func NewTokenRevocationRepository(config *config.Config, dbpool *pgxpool.Pool) (entity.TokenRevocationRepository, error) {
+	switch config.Revocation.Driver {
+	case "postgres", "":
+		return NewTokenRevocationRepositoryPgx(dbpool), nil
+	case "memory":
+		return NewTokenRevocationRepositoryMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown token revocation driver: %s", config.Revocation.Driver)
+	}
+}
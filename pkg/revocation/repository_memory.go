@@ -0,0 +1,84 @@
+package revocation
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"sync"
+	"time"
+)
+
+// memoryTokenRevocationRepository keeps revocations in memory of the process,
+// it is meant for tests and a single instance deployment
+type memoryTokenRevocationRepository struct {
+	mu     sync.RWMutex
+	tokens map[string]entity.RevokedToken
+	users  map[string]entity.RevokedUser
+}
+
+func NewTokenRevocationRepositoryMemory() entity.TokenRevocationRepository {
+	return &memoryTokenRevocationRepository{
+		tokens: map[string]entity.RevokedToken{},
+		users:  map[string]entity.RevokedUser{},
+	}
+}
+
+func (m *memoryTokenRevocationRepository) Store(ctx context.Context, revokedToken *entity.RevokedToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tokens[revokedToken.ID] = *revokedToken
+	return nil
+}
+
+func (m *memoryTokenRevocationRepository) StoreNew(ctx context.Context, revokedToken *entity.RevokedToken) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.tokens[revokedToken.ID]; ok {
+		return false, nil
+	}
+	m.tokens[revokedToken.ID] = *revokedToken
+	return true, nil
+}
+
+func (m *memoryTokenRevocationRepository) StoreUser(ctx context.Context, revokedUser *entity.RevokedUser) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.users[revokedUser.UserID] = *revokedUser
+	return nil
+}
+
+func (m *memoryTokenRevocationRepository) Exists(ctx context.Context, jti string, now time.Time) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	revokedToken, ok := m.tokens[jti]
+	return ok && revokedToken.ExpiresAt.After(now), nil
+}
+
+func (m *memoryTokenRevocationRepository) ExistsUser(ctx context.Context, userID string, issuedAt time.Time, now time.Time) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	revokedUser, ok := m.users[userID]
+	return ok && !issuedAt.After(revokedUser.RevokedAt) && revokedUser.ExpiresAt.After(now), nil
+}
+
+func (m *memoryTokenRevocationRepository) DeleteExpired(ctx context.Context, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, revokedToken := range m.tokens {
+		if !revokedToken.ExpiresAt.After(now) {
+			delete(m.tokens, id)
+		}
+	}
+
+	for id, revokedUser := range m.users {
+		if !revokedUser.ExpiresAt.After(now) {
+			delete(m.users, id)
+		}
+	}
+	return nil
+}
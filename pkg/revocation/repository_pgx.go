@@ -0,0 +1,75 @@
+package revocation
+
+import (
+	"context"
+	"fmt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+type pgxTokenRevocationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTokenRevocationRepositoryPgx(dbpool *pgxpool.Pool) entity.TokenRevocationRepository {
+	return &pgxTokenRevocationRepository{db: dbpool}
+}
+
+func (p *pgxTokenRevocationRepository) Store(ctx context.Context, m *entity.RevokedToken) error {
+	_, err := p.db.Exec(ctx, `INSERT INTO "revoked_token"(id, expires_at) VALUES ($1, $2)
+		ON CONFLICT (id) DO NOTHING`, m.ID, m.ExpiresAt)
+	if err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during store to token revocation repository: %w", err)}
+	}
+	return nil
+}
+
+// store new stores the revocation unless the token is revoked already, false is returned then
+func (p *pgxTokenRevocationRepository) StoreNew(ctx context.Context, m *entity.RevokedToken) (bool, error) {
+	tag, err := p.db.Exec(ctx, `INSERT INTO "revoked_token"(id, expires_at) VALUES ($1, $2)
+		ON CONFLICT (id) DO NOTHING`, m.ID, m.ExpiresAt)
+	if err != nil {
+		return false, errors.ErrRepository{Err: fmt.Errorf("error during store new to token revocation repository: %w", err)}
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (p *pgxTokenRevocationRepository) StoreUser(ctx context.Context, m *entity.RevokedUser) error {
+	_, err := p.db.Exec(ctx, `INSERT INTO "revoked_user"(user_id, revoked_at, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET revoked_at=EXCLUDED.revoked_at, expires_at=EXCLUDED.expires_at`,
+		m.UserID, m.RevokedAt, m.ExpiresAt)
+	if err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during store user to token revocation repository: %w", err)}
+	}
+	return nil
+}
+
+func (p *pgxTokenRevocationRepository) Exists(ctx context.Context, jti string, now time.Time) (bool, error) {
+	var exists bool
+	err := p.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM "revoked_token" WHERE id=$1 AND expires_at > $2)`, jti, now).Scan(&exists)
+	if err != nil {
+		return false, errors.ErrRepository{Err: fmt.Errorf("error during exists to token revocation repository: %w", err)}
+	}
+	return exists, nil
+}
+
+func (p *pgxTokenRevocationRepository) ExistsUser(ctx context.Context, userID string, issuedAt time.Time, now time.Time) (bool, error) {
+	var exists bool
+	err := p.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM "revoked_user" WHERE user_id=$1 AND revoked_at >= $2 AND expires_at > $3)`, userID, issuedAt, now).Scan(&exists)
+	if err != nil {
+		return false, errors.ErrRepository{Err: fmt.Errorf("error during exists user to token revocation repository: %w", err)}
+	}
+	return exists, nil
+}
+
+func (p *pgxTokenRevocationRepository) DeleteExpired(ctx context.Context, now time.Time) error {
+	if _, err := p.db.Exec(ctx, `DELETE FROM "revoked_token" WHERE expires_at <= $1`, now); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during delete expired to token revocation repository: %w", err)}
+	}
+	if _, err := p.db.Exec(ctx, `DELETE FROM "revoked_user" WHERE expires_at <= $1`, now); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during delete expired to token revocation repository: %w", err)}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package revocation
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"time"
+)
+
+type tokenRevocationUsecase struct {
+	revocationRepo entity.TokenRevocationRepository
+	accessTTL      time.Duration
+	contextTimeout time.Duration
+}
+
+// New token revocation usecase, access ttl is the longest lifetime of a revoked token
+func NewTokenRevocationUsecase(repo entity.TokenRevocationRepository, accessTTL time.Duration, timeout time.Duration) tokenRevocationUsecase {
+	return tokenRevocationUsecase{
+		revocationRepo: repo,
+		accessTTL:      accessTTL,
+		contextTimeout: timeout,
+	}
+}
+
+// Revoke keeps the token revoked until its expiry, expired revocations are removed on the way
+func (t *tokenRevocationUsecase) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, t.contextTimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	if err := t.revocationRepo.DeleteExpired(ctx, now); err != nil {
+		return err
+	}
+
+	if !expiresAt.After(now) {
+		return nil
+	}
+
+	return t.revocationRepo.Store(ctx, &entity.RevokedToken{
+		ID:        jti,
+		ExpiresAt: expiresAt.UTC(),
+	})
+}
+
+// Consume revokes the single use token, false is returned when the token was used already or expired
+func (t *tokenRevocationUsecase) Consume(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.contextTimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	if err := t.revocationRepo.DeleteExpired(ctx, now); err != nil {
+		return false, err
+	}
+
+	if !expiresAt.After(now) {
+		return false, nil
+	}
+
+	return t.revocationRepo.StoreNew(ctx, &entity.RevokedToken{
+		ID:        jti,
+		ExpiresAt: expiresAt.UTC(),
+	})
+}
+
+// Revoke user revokes every access token issued to the user so far
+func (t *tokenRevocationUsecase) RevokeUser(ctx context.Context, userID string) error {
+	ctx, cancel := context.WithTimeout(ctx, t.contextTimeout)
+	defer cancel()
+
+	return t.revokeUser(ctx, userID)
+}
+
+// Revoke user client revokes every access token the oauth client got for the user so far,
+// other tokens of the user are kept
+func (t *tokenRevocationUsecase) RevokeUserClient(ctx context.Context, userID, clientID string) error {
+	ctx, cancel := context.WithTimeout(ctx, t.contextTimeout)
+	defer cancel()
+
+	return t.revokeUser(ctx, userClientKey(userID, clientID))
+}
+
+func (t *tokenRevocationUsecase) revokeUser(ctx context.Context, userID string) error {
+	now := time.Now().UTC()
+	if err := t.revocationRepo.DeleteExpired(ctx, now); err != nil {
+		return err
+	}
+
+	// "iat" claim has microseconds precision as the database has
+	revokedAt := now.Truncate(time.Microsecond)
+
+	return t.revocationRepo.StoreUser(ctx, &entity.RevokedUser{
+		UserID:    userID,
+		RevokedAt: revokedAt,
+		ExpiresAt: revokedAt.Add(t.accessTTL + time.Second),
+	})
+}
+
+// Is revoked checks both the token and the tokens of its user, tokens issued up to the revocation of the user
+// are revoked. Tokens issued without jti can be revoked only with the user.
+func (t *tokenRevocationUsecase) IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.contextTimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	if jti != "" {
+		revoked, err := t.revocationRepo.Exists(ctx, jti, now)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	return t.revocationRepo.ExistsUser(ctx, userID, issuedAt.UTC(), now)
+}
+
+// Is client revoked checks the access token the oauth client got for the user like IsRevoked does,
+// tokens issued up to the revocation of the user and the client are revoked too
+func (t *tokenRevocationUsecase) IsClientRevoked(ctx context.Context, jti, userID, clientID string, issuedAt time.Time) (bool, error) {
+	revoked, err := t.IsRevoked(ctx, jti, userID, issuedAt)
+	if err != nil || revoked {
+		return revoked, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.contextTimeout)
+	defer cancel()
+
+	return t.revocationRepo.ExistsUser(ctx, userClientKey(userID, clientID), issuedAt.UTC(), time.Now().UTC())
+}
+
+func userClientKey(userID, clientID string) string {
+	return userID + "@" + clientID
+}
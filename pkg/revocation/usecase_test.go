@@ -0,0 +1,111 @@
+package revocation
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRevoke(t *testing.T) {
+	repo := NewTokenRevocationRepositoryMemory()
+	usecase := NewTokenRevocationUsecase(repo, time.Hour, time.Second*2)
+	issuedAt := time.Now().Add(-time.Minute)
+
+	require.NoError(t, usecase.Revoke(context.TODO(), "jti", time.Now().Add(time.Hour)))
+
+	revoked, err := usecase.IsRevoked(context.TODO(), "jti", "123456789", issuedAt)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = usecase.IsRevoked(context.TODO(), "other", "123456789", issuedAt)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+func TestRevokeExpired(t *testing.T) {
+	repo := NewTokenRevocationRepositoryMemory()
+	usecase := NewTokenRevocationUsecase(repo, time.Hour, time.Second*2)
+
+	// revocation of expired token is not kept
+	require.NoError(t, usecase.Revoke(context.TODO(), "expired", time.Now().Add(-time.Minute)))
+	require.NoError(t, repo.Store(context.TODO(), &entity.RevokedToken{ID: "stale", ExpiresAt: time.Now().Add(-time.Minute)}))
+
+	exists, err := repo.Exists(context.TODO(), "expired", time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// next revocation removes entries of expired tokens
+	require.NoError(t, usecase.Revoke(context.TODO(), "jti", time.Now().Add(time.Hour)))
+	exists, err = repo.Exists(context.TODO(), "stale", time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestRevokeUser(t *testing.T) {
+	repo := NewTokenRevocationRepositoryMemory()
+	usecase := NewTokenRevocationUsecase(repo, time.Hour, time.Second*2)
+	issuedBefore := time.Now().Add(-time.Minute)
+
+	issuedJustBefore := time.Now().Truncate(time.Microsecond)
+	require.NoError(t, usecase.RevokeUser(context.TODO(), "123456789"))
+	issuedAfter := time.Now().Add(time.Microsecond)
+
+	t.Run("issued-before", func(t *testing.T) {
+		revoked, err := usecase.IsRevoked(context.TODO(), "jti", "123456789", issuedBefore)
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+
+		// tokens without jti are revoked with the user as well
+		revoked, err = usecase.IsRevoked(context.TODO(), "", "123456789", time.Time{})
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+	})
+
+	t.Run("issued-same-second", func(t *testing.T) {
+		revoked, err := usecase.IsRevoked(context.TODO(), "jti", "123456789", issuedJustBefore)
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+	})
+
+	t.Run("issued-after", func(t *testing.T) {
+		revoked, err := usecase.IsRevoked(context.TODO(), "jti", "123456789", issuedAfter)
+		assert.NoError(t, err)
+		assert.False(t, revoked)
+	})
+
+	t.Run("other-user", func(t *testing.T) {
+		revoked, err := usecase.IsRevoked(context.TODO(), "jti", "987654321", issuedBefore)
+		assert.NoError(t, err)
+		assert.False(t, revoked)
+	})
+}
+
+func TestConsume(t *testing.T) {
+	repo := NewTokenRevocationRepositoryMemory()
+	usecase := NewTokenRevocationUsecase(repo, time.Hour, time.Second*2)
+
+	t.Run("success", func(t *testing.T) {
+		consumed, err := usecase.Consume(context.TODO(), "challenge", time.Now().Add(time.Minute))
+		assert.NoError(t, err)
+		assert.True(t, consumed)
+
+		revoked, err := usecase.IsRevoked(context.TODO(), "challenge", "123456789", time.Now())
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+	})
+
+	t.Run("error-consumed", func(t *testing.T) {
+		consumed, err := usecase.Consume(context.TODO(), "challenge", time.Now().Add(time.Minute))
+		assert.NoError(t, err)
+		assert.False(t, consumed)
+	})
+
+	t.Run("error-expired", func(t *testing.T) {
+		consumed, err := usecase.Consume(context.TODO(), "expired", time.Now().Add(-time.Minute))
+		assert.NoError(t, err)
+		assert.False(t, consumed)
+	})
+}
@@ -0,0 +1,51 @@
+package session
+
+import "strings"
+
+// user agent markers in order of checking, Edge and Opera contain "Chrome" and Chrome contains "Safari"
+var browsers = []struct{ marker, name string }{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"Firefox/", "Firefox"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+}
+
+// iPhone and Android user agents contain "Mac OS X" and "Linux"
+var platforms = []struct{ marker, name string }{
+	{"iPhone", "iPhone"},
+	{"iPad", "iPad"},
+	{"Android", "Android"},
+	{"Windows", "Windows"},
+	{"Mac OS X", "macOS"},
+	{"Linux", "Linux"},
+}
+
+// DeviceName returns readable name of a device like "Chrome on Windows" from the user agent
+func DeviceName(userAgent string) string {
+	var browser, platform string
+
+	for _, b := range browsers {
+		if strings.Contains(userAgent, b.marker) {
+			browser = b.name
+			break
+		}
+	}
+
+	for _, p := range platforms {
+		if strings.Contains(userAgent, p.marker) {
+			platform = p.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	}
+	return "Unknown device"
+}
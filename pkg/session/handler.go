@@ -0,0 +1,119 @@
+package session
+
+import (
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/middleware"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/response"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type SessionHandler struct {
+	logger         *zap.Logger
+	sessionUsecase entity.SessionUsecase
+}
+
+// New session handler
+func NewSessionHandler(r chi.Router, sessionUsecase entity.SessionUsecase, auth func(http.Handler) http.Handler, logger *zap.Logger) {
+	handler := SessionHandler{
+		logger:         logger,
+		sessionUsecase: sessionUsecase,
+	}
+
+	r.Group(func(r chi.Router) {
+		r.Use(auth)
+		r.Use(middleware.NoAPIKey)
+		r.Use(middleware.NoImpersonation)
+		r.Get("/auth/sessions", handler.findAll())
+		r.Delete("/auth/sessions", handler.deleteOthers())
+		r.Delete("/auth/sessions/{id}", handler.delete())
+	})
+}
+
+// convert entity session to session model
+func (s *SessionHandler) convert(session *entity.Session, currentID string) *Session {
+	return &Session{
+		ID:         session.ID,
+		DeviceName: session.DeviceName,
+		UserAgent:  session.UserAgent,
+		IP:         session.IP,
+		Current:    session.ID == currentID,
+		CreatedAt:  session.CreatedAt,
+		LastUsedAt: session.LastUsedAt,
+	}
+}
+
+// find all sessions of the authenticated user
+func (s *SessionHandler) findAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.GetAuthUser(r.Context())
+		if !ok {
+			response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		ctx := r.Context()
+		items, err := s.sessionUsecase.FindByUserId(ctx, user.ID)
+		if err != nil {
+			s.logger.Error("session find all", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		currentID := middleware.GetSessionID(ctx)
+		sessions := []*Session{}
+		for _, item := range items {
+			sessions = append(sessions, s.convert(item, currentID))
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+			"data":   sessions,
+		})
+	}
+}
+
+// delete revokes a session of the authenticated user
+func (s *SessionHandler) delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.GetAuthUser(r.Context())
+		if !ok {
+			response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		if err := s.sessionUsecase.Delete(r.Context(), user.ID, chi.URLParam(r, "id")); err != nil {
+			s.logger.Error("session delete", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+		})
+	}
+}
+
+// delete others logs out everywhere except the current session
+func (s *SessionHandler) deleteOthers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.GetAuthUser(r.Context())
+		if !ok {
+			response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		ctx := r.Context()
+		if err := s.sessionUsecase.DeleteOthers(ctx, user.ID, middleware.GetSessionID(ctx)); err != nil {
+			s.logger.Error("session delete others", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+		})
+	}
+}
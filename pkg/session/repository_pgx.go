@@ -0,0 +1,138 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+const sessionColumns = `id, user_id, user_agent, ip, device_name, created_at, last_used_at`
+
+type pgxSessionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSessionRepositoryPgx(dbpool *pgxpool.Pool) entity.SessionRepository {
+	return &pgxSessionRepository{db: dbpool}
+}
+
+// scan session row in order of session columns
+func scanSession(row pgx.Row, session *entity.Session) error {
+	return row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.UserAgent,
+		&session.IP,
+		&session.DeviceName,
+		&session.CreatedAt,
+		&session.LastUsedAt,
+	)
+}
+
+func (p *pgxSessionRepository) Store(ctx context.Context, m *entity.Session) error {
+	_, err := p.db.Exec(ctx, `INSERT INTO "session"(`+sessionColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		m.ID,
+		m.UserID,
+		m.UserAgent,
+		m.IP,
+		m.DeviceName,
+		m.CreatedAt,
+		m.LastUsedAt,
+	)
+
+	if err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during store to session repository: %w", err)}
+	}
+
+	return nil
+}
+
+func (p *pgxSessionRepository) Touch(ctx context.Context, id string, lastUsedAt time.Time) error {
+	if _, err := p.db.Exec(ctx, `UPDATE "session" SET last_used_at=$1 WHERE id=$2`, lastUsedAt, id); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during touch to session repository: %w", err)}
+	}
+	return nil
+}
+
+func (p *pgxSessionRepository) Find(ctx context.Context, id string) (*entity.Session, error) {
+	session := entity.Session{}
+	row := p.db.QueryRow(ctx, `SELECT `+sessionColumns+` FROM "session" WHERE id=$1`, id)
+
+	err := scanSession(row, &session)
+
+	if err == pgx.ErrNoRows {
+		return nil, errors.NewErrNotFound("session")
+	}
+
+	if err != nil {
+		return nil, errors.ErrRepository{Err: fmt.Errorf("error during find to session repository: %w", err)}
+	}
+
+	return &session, nil
+}
+
+// find by user id returns only sessions having a refresh token which was neither rotated nor expired yet,
+// sessions of revoked or expired token families are not active anymore
+func (p *pgxSessionRepository) FindByUserId(ctx context.Context, userID string, expiredBefore time.Time) ([]*entity.Session, error) {
+	var items []*entity.Session
+	rows, err := p.db.Query(ctx, `SELECT `+sessionColumns+`
+		FROM "session" s
+		WHERE s.user_id=$1 AND EXISTS (
+			SELECT 1 FROM "refresh_token" t WHERE t.family_id = s.id AND t.rotated_at IS NULL AND t.created_at >= $2
+		)
+		ORDER BY s.last_used_at DESC`, userID, expiredBefore)
+	if err != nil {
+		return items, errors.ErrRepository{Err: fmt.Errorf("error during find by user id to session repository: %w", err)}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		session := entity.Session{}
+		if err := scanSession(rows, &session); err != nil {
+			return items, errors.ErrRepository{Err: fmt.Errorf("error during find by user id to session repository: %w", err)}
+		}
+		items = append(items, &session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return items, errors.ErrRepository{Err: fmt.Errorf("error during find by user id to session repository: %w", err)}
+	}
+
+	return items, nil
+}
+
+func (p *pgxSessionRepository) Delete(ctx context.Context, id string) error {
+	if _, err := p.db.Exec(ctx, `DELETE FROM "session" WHERE id=$1`, id); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during delete to session repository: %w", err)}
+	}
+	return nil
+}
+
+func (p *pgxSessionRepository) DeleteByUserId(ctx context.Context, userID string) error {
+	if _, err := p.db.Exec(ctx, `DELETE FROM "session" WHERE user_id=$1`, userID); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during delete by user id to session repository: %w", err)}
+	}
+	return nil
+}
+
+// delete expired removes sessions of the user without an active refresh token together with their token families,
+// sessions created after expired before are kept as their first token may not be stored yet
+func (p *pgxSessionRepository) DeleteExpired(ctx context.Context, userID string, expiredBefore time.Time) error {
+	_, err := p.db.Exec(ctx, `WITH expired AS (
+			DELETE FROM "session" s
+			WHERE s.user_id=$1 AND s.created_at < $2 AND NOT EXISTS (
+				SELECT 1 FROM "refresh_token" t WHERE t.family_id = s.id AND t.rotated_at IS NULL AND t.created_at >= $2
+			)
+			RETURNING id
+		)
+		DELETE FROM "refresh_token" WHERE family_id IN (SELECT id FROM expired)`, userID, expiredBefore)
+	if err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during delete expired to session repository: %w", err)}
+	}
+	return nil
+}
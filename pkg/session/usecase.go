@@ -0,0 +1,171 @@
+package session
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/rand"
+	"time"
+)
+
+// lengths of the session columns, longer values of the client are truncated
+const (
+	MAX_USER_AGENT_LENGTH  = 500
+	MAX_IP_LENGTH          = 64
+	MAX_DEVICE_NAME_LENGTH = 100
+)
+
+type sessionUsecase struct {
+	sessionRepo      entity.SessionRepository
+	refreshTokenRepo entity.RefreshTokenRepository
+	refreshTTL       time.Duration
+	contextTimeout   time.Duration
+}
+
+// New session usecase, sessions expire with the refresh token ttl after the last rotation
+func NewSessionUsecase(repo entity.SessionRepository, refreshTokenRepo entity.RefreshTokenRepository, refreshTTL, timeout time.Duration) sessionUsecase {
+	return sessionUsecase{
+		sessionRepo:      repo,
+		refreshTokenRepo: refreshTokenRepo,
+		refreshTTL:       refreshTTL,
+		contextTimeout:   timeout,
+	}
+}
+
+// Before Store, id of a session is used as family id of its refresh tokens.
+// User agent, ip and device name come from the client and are truncated to fit the columns.
+func (s *sessionUsecase) BeforeStore(m *entity.Session) error {
+	id, err := rand.Token(16)
+	if err != nil {
+		return err
+	}
+
+	m.ID = id
+	m.CreatedAt = time.Now().UTC()
+	m.LastUsedAt = m.CreatedAt
+
+	if m.DeviceName == "" {
+		m.DeviceName = DeviceName(m.UserAgent)
+	}
+
+	m.UserAgent = truncate(m.UserAgent, MAX_USER_AGENT_LENGTH)
+	m.IP = truncate(m.IP, MAX_IP_LENGTH)
+	m.DeviceName = truncate(m.DeviceName, MAX_DEVICE_NAME_LENGTH)
+
+	return nil
+}
+
+// Store
+func (s *sessionUsecase) Store(ctx context.Context, m *entity.Session) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	if err := s.BeforeStore(m); err != nil {
+		return err
+	}
+
+	return s.sessionRepo.Store(ctx, m)
+}
+
+// Touch updates last used time of the session
+func (s *sessionUsecase) Touch(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	return s.sessionRepo.Touch(ctx, id, time.Now().UTC())
+}
+
+// Find
+func (s *sessionUsecase) Find(ctx context.Context, id string) (*entity.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	return s.sessionRepo.Find(ctx, id)
+}
+
+// Find by user id returns active sessions of the user, expired sessions are pruned on the way
+func (s *sessionUsecase) FindByUserId(ctx context.Context, userID string) ([]*entity.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	expiredBefore := s.expiredBefore()
+	if err := s.sessionRepo.DeleteExpired(ctx, userID, expiredBefore); err != nil {
+		return nil, err
+	}
+
+	return s.sessionRepo.FindByUserId(ctx, userID, expiredBefore)
+}
+
+// Delete revokes refresh tokens of the session, sessions of other users are not found
+func (s *sessionUsecase) Delete(ctx context.Context, userID, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	existedSession, err := s.sessionRepo.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if existedSession.UserID != userID {
+		return errors.NewErrNotFound("session")
+	}
+
+	return s.delete(ctx, id)
+}
+
+// Delete others revokes every session of the user except the current one
+func (s *sessionUsecase) DeleteOthers(ctx context.Context, userID, currentID string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	sessions, err := s.sessionRepo.FindByUserId(ctx, userID, s.expiredBefore())
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if session.ID == currentID {
+			continue
+		}
+		if err := s.delete(ctx, session.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete by user id revokes every session of the user
+func (s *sessionUsecase) DeleteByUserId(ctx context.Context, userID string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.contextTimeout)
+	defer cancel()
+
+	if err := s.refreshTokenRepo.DeleteByUserId(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.sessionRepo.DeleteByUserId(ctx, userID)
+}
+
+// delete removes refresh token family and the session
+func (s *sessionUsecase) delete(ctx context.Context, id string) error {
+	if err := s.refreshTokenRepo.DeleteByFamily(ctx, id); err != nil {
+		return err
+	}
+
+	return s.sessionRepo.Delete(ctx, id)
+}
+
+// truncate to at most n characters, columns count characters rather than bytes
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// expired before is the creation time refresh tokens of active sessions are not older than
+func (s *sessionUsecase) expiredBefore() time.Time {
+	return time.Now().UTC().Add(-s.refreshTTL)
+}
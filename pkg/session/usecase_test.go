@@ -0,0 +1,141 @@
+package session
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity/mocks"
+	apperrors "github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	mockRepo := new(mocks.SessionRepository)
+	mockRepo.On("Store", mock.Anything, mock.AnythingOfType("*entity.Session")).Return(nil).Once()
+
+	session := &entity.Session{
+		UserID:    "123456789",
+		UserAgent: "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+	}
+	usecase := NewSessionUsecase(mockRepo, new(mocks.RefreshTokenRepository), time.Hour*24, time.Second*2)
+	err := usecase.Store(context.TODO(), session)
+
+	assert := assert.New(t)
+	assert.NoError(err)
+	assert.NotEmpty(session.ID)
+	assert.Equal(session.CreatedAt, session.LastUsedAt)
+	assert.Equal("Chrome on Linux", session.DeviceName)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestStoreTruncate(t *testing.T) {
+	mockRepo := new(mocks.SessionRepository)
+	mockRepo.On("Store", mock.Anything, mock.AnythingOfType("*entity.Session")).Return(nil).Once()
+
+	session := &entity.Session{
+		UserID:     "123456789",
+		UserAgent:  strings.Repeat("ü", MAX_USER_AGENT_LENGTH+1),
+		IP:         strings.Repeat("1", MAX_IP_LENGTH+1),
+		DeviceName: strings.Repeat("d", MAX_DEVICE_NAME_LENGTH+1),
+	}
+	usecase := NewSessionUsecase(mockRepo, new(mocks.RefreshTokenRepository), time.Hour*24, time.Second*2)
+	err := usecase.Store(context.TODO(), session)
+
+	assert := assert.New(t)
+	assert.NoError(err)
+	assert.Equal(strings.Repeat("ü", MAX_USER_AGENT_LENGTH), session.UserAgent)
+	assert.Len(session.IP, MAX_IP_LENGTH)
+	assert.Len(session.DeviceName, MAX_DEVICE_NAME_LENGTH)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDelete(t *testing.T) {
+	session := &entity.Session{ID: "session", UserID: "123456789"}
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo := new(mocks.SessionRepository)
+		mockRefreshTokenRepo := new(mocks.RefreshTokenRepository)
+		mockRepo.On("Find", mock.Anything, "session").Return(session, nil).Once()
+		mockRefreshTokenRepo.On("DeleteByFamily", mock.Anything, "session").Return(nil).Once()
+		mockRepo.On("Delete", mock.Anything, "session").Return(nil).Once()
+
+		usecase := NewSessionUsecase(mockRepo, mockRefreshTokenRepo, time.Hour*24, time.Second*2)
+		err := usecase.Delete(context.TODO(), "123456789", "session")
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockRefreshTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-other-user", func(t *testing.T) {
+		mockRepo := new(mocks.SessionRepository)
+		mockRefreshTokenRepo := new(mocks.RefreshTokenRepository)
+		mockRepo.On("Find", mock.Anything, "session").Return(session, nil).Once()
+
+		usecase := NewSessionUsecase(mockRepo, mockRefreshTokenRepo, time.Hour*24, time.Second*2)
+		err := usecase.Delete(context.TODO(), "987654321", "session")
+
+		assert.IsType(t, &apperrors.ErrNotFound{}, err)
+		mockRepo.AssertExpectations(t)
+		mockRefreshTokenRepo.AssertNotCalled(t, "DeleteByFamily", mock.Anything, mock.Anything)
+	})
+}
+
+func TestDeleteOthers(t *testing.T) {
+	mockRepo := new(mocks.SessionRepository)
+	mockRefreshTokenRepo := new(mocks.RefreshTokenRepository)
+	sessions := []*entity.Session{
+		{ID: "current", UserID: "123456789"},
+		{ID: "other", UserID: "123456789"},
+	}
+	mockRepo.On("FindByUserId", mock.Anything, "123456789", mock.AnythingOfType("time.Time")).Return(sessions, nil).Once()
+	mockRefreshTokenRepo.On("DeleteByFamily", mock.Anything, "other").Return(nil).Once()
+	mockRepo.On("Delete", mock.Anything, "other").Return(nil).Once()
+
+	usecase := NewSessionUsecase(mockRepo, mockRefreshTokenRepo, time.Hour*24, time.Second*2)
+	err := usecase.DeleteOthers(context.TODO(), "123456789", "current")
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockRefreshTokenRepo.AssertExpectations(t)
+	mockRefreshTokenRepo.AssertNotCalled(t, "DeleteByFamily", mock.Anything, "current")
+}
+
+func TestFindByUserId(t *testing.T) {
+	mockRepo := new(mocks.SessionRepository)
+	sessions := []*entity.Session{{ID: "active", UserID: "123456789"}}
+	var pruned time.Time
+	mockRepo.On("DeleteExpired", mock.Anything, "123456789", mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) { pruned = args.Get(2).(time.Time) }).Return(nil).Once()
+	mockRepo.On("FindByUserId", mock.Anything, "123456789", mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) {
+			// sessions are listed with the same expiry as they are pruned with
+			assert.Equal(t, pruned, args.Get(2).(time.Time))
+		}).Return(sessions, nil).Once()
+
+	usecase := NewSessionUsecase(mockRepo, new(mocks.RefreshTokenRepository), time.Hour*24, time.Second*2)
+	result, err := usecase.FindByUserId(context.TODO(), "123456789")
+
+	assert.NoError(t, err)
+	assert.Equal(t, sessions, result)
+	assert.WithinDuration(t, time.Now().UTC().Add(-time.Hour*24), pruned, time.Minute)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDeviceName(t *testing.T) {
+	tests := map[string]string{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36 Edg/120.0":                   "Edge on Windows",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1": "Safari on iPhone",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 14.0; rv:120.0) Gecko/20100101 Firefox/120.0":                                                     "Firefox on macOS",
+		"curl/8.0": "Unknown device",
+	}
+
+	for userAgent, name := range tests {
+		assert.Equal(t, name, DeviceName(userAgent), userAgent)
+	}
+}
@@ -0,0 +1,45 @@
+package sms
+
+import (
+	"context"
+	"sync"
+)
+
+// MemorySender keeps sent messages in memory, it is meant for tests
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []*Message
+}
+
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+func (s *MemorySender) Send(ctx context.Context, message *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = append(s.messages, message)
+	return nil
+}
+
+// messages sent so far
+func (s *MemorySender) Messages() []*Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	messages := make([]*Message, len(s.messages))
+	copy(messages, s.messages)
+	return messages
+}
+
+// last sent message or nil
+func (s *MemorySender) Last() *Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.messages) == 0 {
+		return nil
+	}
+	return s.messages[len(s.messages)-1]
+}
@@ -0,0 +1,41 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/config"
+	"go.uber.org/zap"
+	"time"
+)
+
+type Message struct {
+	To   string
+	Body string
+}
+
+type SMSSender interface {
+	Send(ctx context.Context, message *Message) error
+}
+
+// New sms sender by configured driver
+func NewSMSSender(config *config.Config, logger *zap.Logger) (SMSSender, error) {
+	switch config.SMS.Driver {
+	case "webhook":
+		timeout := 10 * time.Second
+		if config.SMS.Timeout != "" {
+			var err error
+			if timeout, err = time.ParseDuration(config.SMS.Timeout); err != nil {
+				return nil, err
+			}
+		}
+		return NewWebhookSender(config.SMS.WebhookURL, config.SMS.WebhookSecret, timeout)
+	case "file":
+		return NewFileSender(config.SMS.Dir)
+	case "log", "":
+		return NewLogSender(logger), nil
+	case "memory":
+		return NewMemorySender(), nil
+	default:
+		return nil, fmt.Errorf("unknown sms driver: %s", config.SMS.Driver)
+	}
+}
@@ -0,0 +1,48 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// logSender writes messages to the log instead of delivering them, it is meant for local development
+type logSender struct {
+	logger *zap.Logger
+}
+
+func NewLogSender(logger *zap.Logger) SMSSender {
+	return &logSender{logger: logger}
+}
+
+func (s *logSender) Send(ctx context.Context, message *Message) error {
+	s.logger.Info("sms", zap.String("to", message.To), zap.String("body", message.Body))
+	return nil
+}
+
+// fileSender writes every message to its own file in the directory
+type fileSender struct {
+	dir string
+}
+
+func NewFileSender(dir string) (SMSSender, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error during create sms directory: %w", err)
+	}
+	return &fileSender{dir: dir}, nil
+}
+
+func (s *fileSender) Send(ctx context.Context, message *Message) error {
+	name := fmt.Sprintf("%d.sms", time.Now().UnixNano())
+	content := fmt.Sprintf("To: %s\n\n%s\n", message.To, message.Body)
+
+	if err := ioutil.WriteFile(filepath.Join(s.dir, name), []byte(content), 0644); err != nil {
+		return fmt.Errorf("error during write sms file: %w", err)
+	}
+
+	return nil
+}
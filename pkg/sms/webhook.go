@@ -0,0 +1,73 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// header with hex hmac-sha256 of the request body, present when the secret is configured
+const SIGNATURE_HEADER = "X-Signature"
+
+// webhookSender posts messages as json {"to": "...", "body": "..."} to the url of a gateway,
+// any 2xx status is treated as accepted
+type webhookSender struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func NewWebhookSender(webhookURL, secret string, timeout time.Duration) (SMSSender, error) {
+	if _, err := url.ParseRequestURI(webhookURL); err != nil {
+		return nil, fmt.Errorf("invalid sms webhook url: %w", err)
+	}
+	return &webhookSender{
+		url:    webhookURL,
+		secret: secret,
+		client: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (s *webhookSender) Send(ctx context.Context, message *Message) error {
+	body, err := json.Marshal(map[string]string{
+		"to":   message.To,
+		"body": message.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error during create sms webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if s.secret != "" {
+		mac := hmac.New(sha256.New, []byte(s.secret))
+		mac.Write(body)
+		req.Header.Set(SIGNATURE_HEADER, hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error during send sms webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("sms webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package sms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookSender(t *testing.T) {
+	var received map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write(body)
+		if r.Header.Get(SIGNATURE_HEADER) != hex.EncodeToString(mac.Sum(nil)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		json.Unmarshal(body, &received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	t.Run("success", func(t *testing.T) {
+		sender, err := NewWebhookSender(server.URL, "secret", time.Second)
+		require.NoError(t, err)
+
+		err = sender.Send(context.TODO(), &Message{To: "+998901234567", Body: "code 123456"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "+998901234567", received["to"])
+		assert.Equal(t, "code 123456", received["body"])
+	})
+
+	t.Run("error-status", func(t *testing.T) {
+		sender, err := NewWebhookSender(server.URL, "wrong", time.Second)
+		require.NoError(t, err)
+
+		err = sender.Send(context.TODO(), &Message{To: "+998901234567", Body: "code 123456"})
+
+		assert.Error(t, err)
+	})
+}
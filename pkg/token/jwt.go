@@ -3,20 +3,63 @@ package token
 import (
 	"fmt"
 	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/rand"
 	"github.com/dgrijalva/jwt-go"
+	"math"
 	"net/http"
 	"time"
 )
 
-func GenerateToken(jwtsecret, access_ttl, refresh_ttl, sub string) (string, string, error) {
+// token types kept in "typ" claim, only access tokens are accepted by GetAuthUser
+const (
+	TYPE_ACCESS       = "access"
+	TYPE_REFRESH      = "refresh"
+	TYPE_MFA          = "mfa"
+	TYPE_OAUTH_ACCESS = "oauth_access"
+	TYPE_ID           = "id"
+)
+
+// AccessToken is the parsed access token of the request, ID is the "jti" claim and
+// ActorID is the admin impersonating the user
+type AccessToken struct {
+	ID        string
+	User      *entity.User
+	SessionID string
+	ActorID   string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// MFAChallenge is the parsed mfa challenge, ID is the "jti" claim making the challenge single use
+type MFAChallenge struct {
+	ID        string
+	UserID    string
+	ExpiresAt time.Time
+}
+
+// GenerateToken returns access and refresh tokens, sid is the session the tokens belong to
+func GenerateToken(keys *KeySet, access_ttl, refresh_ttl string, user *entity.User, sid string) (string, string, error) {
 	accessttl, err := time.ParseDuration(access_ttl)
 	if err != nil {
 		return "", "", err
 	}
 
-	access_token, err := GenerateJwtToken(jwtsecret, &jwt.MapClaims{
-		"sub": sub,
-		"exp": time.Now().Add(accessttl).Unix(),
+	// jti identifies the access token in revocation list
+	accessJti, err := rand.Token(16)
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	access_token, err := GenerateJwtToken(keys, &jwt.MapClaims{
+		"typ":         TYPE_ACCESS,
+		"jti":         accessJti,
+		"sub":         user.ID,
+		"sid":         sid,
+		"role":        user.Role,
+		"permissions": user.Permissions,
+		"iat":         issuedAt(now),
+		"exp":         now.Add(accessttl).Unix(),
 	})
 	if err != nil {
 		return "", "", err
@@ -27,7 +70,15 @@ func GenerateToken(jwtsecret, access_ttl, refresh_ttl, sub string) (string, stri
 		return "", "", err
 	}
 
-	refresh_token, err := GenerateJwtToken(jwtsecret, &jwt.MapClaims{
+	// jti keeps refresh tokens issued in the same second unique
+	jti, err := rand.Token(16)
+	if err != nil {
+		return "", "", err
+	}
+
+	refresh_token, err := GenerateJwtToken(keys, &jwt.MapClaims{
+		"typ": TYPE_REFRESH,
+		"jti": jti,
 		"exp": time.Now().Add(refreshttl).Unix(),
 	})
 	if err != nil {
@@ -37,20 +88,86 @@ func GenerateToken(jwtsecret, access_ttl, refresh_ttl, sub string) (string, stri
 	return access_token, refresh_token, err
 }
 
-func GenerateJwtToken(jwtsecret string, claims *jwt.MapClaims) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	// Sign and get the complete encoded token as a string using the secret
-	return token.SignedString([]byte(jwtsecret))
+// GenerateImpersonationToken returns access token of the user for the admin acting as the user, "act" claim
+// names the admin, RFC 8693 section 4.1. The token has neither session nor refresh token.
+func GenerateImpersonationToken(keys *KeySet, ttl time.Duration, user *entity.User, actorID string) (string, error) {
+	jti, err := rand.Token(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	return GenerateJwtToken(keys, &jwt.MapClaims{
+		"typ":         TYPE_ACCESS,
+		"jti":         jti,
+		"sub":         user.ID,
+		"act":         map[string]interface{}{"sub": actorID},
+		"role":        user.Role,
+		"permissions": user.Permissions,
+		"iat":         issuedAt(now),
+		"exp":         now.Add(ttl).Unix(),
+	})
 }
 
-func ParseJwtToken(tokenStr, jwtsecret string) (map[string]interface{}, error) {
-	var claims map[string]interface{}
-	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(jwtsecret), nil
+// GenerateMFAChallenge returns short-lived token proving the password of the user was checked
+func GenerateMFAChallenge(keys *KeySet, ttl, sub string) (string, error) {
+	challengettl, err := time.ParseDuration(ttl)
+	if err != nil {
+		return "", err
+	}
+
+	// jti is stored once the challenge is used
+	jti, err := rand.Token(16)
+	if err != nil {
+		return "", err
+	}
+
+	return GenerateJwtToken(keys, &jwt.MapClaims{
+		"typ": TYPE_MFA,
+		"jti": jti,
+		"sub": sub,
+		"exp": time.Now().Add(challengettl).Unix(),
 	})
+}
+
+// ParseMFAChallenge returns valid mfa challenge token
+func ParseMFAChallenge(tokenStr string, keys *KeySet) (*MFAChallenge, error) {
+	claims, err := ParseJwtToken(tokenStr, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := MFAChallenge{}
+	challenge.ID, _ = claims["jti"].(string)
+	challenge.UserID, _ = claims["sub"].(string)
+	if claims["typ"] != TYPE_MFA || challenge.ID == "" || challenge.UserID == "" {
+		return nil, fmt.Errorf("Token is not mfa challenge")
+	}
+	if exp, ok := claims["exp"].(float64); ok {
+		challenge.ExpiresAt = time.Unix(int64(exp), 0)
+	}
+	return &challenge, nil
+}
+
+// issued at is "iat" claim of access tokens with microseconds, so revocations made in the same second
+// as the token was issued are told apart from it
+func issuedAt(now time.Time) float64 {
+	return float64(now.UnixNano()/int64(time.Microsecond)) / 1e6
+}
+
+// parse issued at, tokens issued before microseconds were introduced have whole seconds
+func parseIssuedAt(iat float64) time.Time {
+	return time.Unix(0, int64(math.Round(iat*1e6))*int64(time.Microsecond))
+}
+
+func GenerateJwtToken(keys *KeySet, claims *jwt.MapClaims) (string, error) {
+	// Sign and get the complete encoded token as a string using the signing key
+	return keys.Sign(claims)
+}
+
+func ParseJwtToken(tokenStr string, keys *KeySet) (map[string]interface{}, error) {
+	var claims map[string]interface{}
+	token, err := jwt.Parse(tokenStr, keys.Keyfunc)
 
 	if token != nil && token.Valid {
 		if mapClaims, ok := token.Claims.(jwt.MapClaims); ok {
@@ -74,19 +191,63 @@ func ParseJwtToken(tokenStr, jwtsecret string) (map[string]interface{}, error) {
 	return claims, err
 }
 
-func GetAuthUser(jwtsecret string, r *http.Request) (*entity.User, error) {
-	var user entity.User
+func GetAuthUser(keys *KeySet, r *http.Request) (*entity.User, error) {
+	accessToken, err := ParseAccessToken(keys, r)
+	if err != nil {
+		return &entity.User{}, err
+	}
+	return accessToken.User, nil
+}
+
+// ParseAccessToken parses access token from authorization header of the request
+func ParseAccessToken(keys *KeySet, r *http.Request) (*AccessToken, error) {
 	token := r.Header.Get("Authorization")
 	if len(token) > 10 {
 		token = token[7:]
 	}
+	return ParseAccessTokenString(keys, token)
+}
 
-	fmt.Println("token", token)
-
-	claims, err := ParseJwtToken(token, jwtsecret)
+// ParseAccessTokenString parses first-party access token
+func ParseAccessTokenString(keys *KeySet, tokenStr string) (*AccessToken, error) {
+	var user entity.User
+	claims, err := ParseJwtToken(tokenStr, keys)
 	if err != nil {
-		return &user, err
+		return nil, err
+	}
+
+	// tokens issued before "typ" claim was introduced are access tokens
+	if typ, ok := claims["typ"].(string); ok && typ != TYPE_ACCESS {
+		return nil, fmt.Errorf("Token is not access token")
+	}
+
+	sub, ok := claims["sub"].(string)
+	if !ok || sub == "" {
+		return nil, fmt.Errorf("Token has no subject")
+	}
+	user.ID = sub
+	user.Role, _ = claims["role"].(string)
+
+	if permissions, ok := claims["permissions"].([]interface{}); ok {
+		for _, permission := range permissions {
+			if p, ok := permission.(string); ok {
+				user.Permissions = append(user.Permissions, p)
+			}
+		}
+	}
+
+	// tokens issued before sessions and revocation were introduced have no "sid", "jti" and "iat" claims
+	accessToken := AccessToken{User: &user}
+	accessToken.SessionID, _ = claims["sid"].(string)
+	if act, ok := claims["act"].(map[string]interface{}); ok {
+		accessToken.ActorID, _ = act["sub"].(string)
+	}
+	accessToken.ID, _ = claims["jti"].(string)
+	if iat, ok := claims["iat"].(float64); ok {
+		accessToken.IssuedAt = parseIssuedAt(iat)
+	}
+	if exp, ok := claims["exp"].(float64); ok {
+		accessToken.ExpiresAt = time.Unix(int64(exp), 0)
 	}
-	user.ID = claims["sub"].(string)
-	return &user, nil
+	return &accessToken, nil
 }
@@ -0,0 +1,123 @@
+package token
+
+import (
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGetAuthUser(t *testing.T) {
+	keys := TestKeySet(t)
+	user := &entity.User{ID: "123", Role: entity.USER_ROLE_ADMIN, Permissions: []string{entity.PERMISSION_USER_READ}}
+
+	access, refresh, err := GenerateToken(keys, "1h", "24h", user, "session")
+	require.NoError(t, err)
+
+	t.Run("success", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Authorization", "Bearer "+access)
+
+		authUser, err := GetAuthUser(keys, r)
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, authUser.ID)
+		assert.Equal(t, user.Role, authUser.Role)
+		assert.Equal(t, user.Permissions, authUser.Permissions)
+
+		accessToken, err := ParseAccessToken(keys, r)
+		assert.NoError(t, err)
+		assert.Equal(t, "session", accessToken.SessionID)
+		assert.NotEmpty(t, accessToken.ID)
+		assert.False(t, accessToken.IssuedAt.IsZero())
+		assert.True(t, accessToken.ExpiresAt.After(accessToken.IssuedAt))
+	})
+
+	t.Run("success-impersonation-token", func(t *testing.T) {
+		impersonation, err := GenerateImpersonationToken(keys, time.Minute, user, "admin")
+		require.NoError(t, err)
+
+		accessToken, err := ParseAccessTokenString(keys, impersonation)
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, accessToken.User.ID)
+		assert.Equal(t, "admin", accessToken.ActorID)
+		assert.Empty(t, accessToken.SessionID)
+		assert.NotEmpty(t, accessToken.ID)
+		assert.WithinDuration(t, accessToken.IssuedAt.Add(time.Minute), accessToken.ExpiresAt, time.Second)
+	})
+
+	t.Run("error-refresh-token", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Authorization", "Bearer "+refresh)
+
+		_, err := GetAuthUser(keys, r)
+		assert.Error(t, err)
+	})
+
+	t.Run("error-mfa-challenge", func(t *testing.T) {
+		challenge, err := GenerateMFAChallenge(keys, "5m", user.ID)
+		require.NoError(t, err)
+
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Authorization", "Bearer "+challenge)
+
+		_, err = GetAuthUser(keys, r)
+		assert.Error(t, err)
+
+		mfaChallenge, err := ParseMFAChallenge(challenge, keys)
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, mfaChallenge.UserID)
+		assert.NotEmpty(t, mfaChallenge.ID)
+		assert.True(t, mfaChallenge.ExpiresAt.After(time.Now()))
+	})
+
+	t.Run("error-oauth-access-token", func(t *testing.T) {
+		oauthToken, err := GenerateOAuthAccessToken(keys, "http://localhost", time.Hour, user.ID, "client", []string{"profile"})
+		require.NoError(t, err)
+
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Authorization", "Bearer "+oauthToken)
+
+		_, err = GetAuthUser(keys, r)
+		assert.Error(t, err)
+
+		accessToken, err := ParseOAuthAccessToken(keys, oauthToken)
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, accessToken.Subject)
+		assert.Equal(t, "client", accessToken.ClientID)
+		assert.Equal(t, []string{"profile"}, accessToken.Scopes)
+	})
+
+	t.Run("error-id-token", func(t *testing.T) {
+		idToken, err := GenerateIDToken(keys, "http://localhost", time.Hour, "client", "", map[string]interface{}{"sub": user.ID})
+		require.NoError(t, err)
+
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Authorization", "Bearer "+idToken)
+
+		_, err = GetAuthUser(keys, r)
+		assert.Error(t, err)
+
+		_, err = ParseOAuthAccessToken(keys, idToken)
+		assert.Error(t, err)
+	})
+}
+
+func TestIssuedAt(t *testing.T) {
+	keys := TestKeySet(t)
+	user := &entity.User{ID: "123", Role: entity.USER_ROLE_USER}
+
+	before := time.Now().Truncate(time.Microsecond)
+	access, _, err := GenerateToken(keys, "1h", "24h", user, "session")
+	require.NoError(t, err)
+	after := time.Now()
+
+	accessToken, err := ParseAccessTokenString(keys, access)
+	require.NoError(t, err)
+	assert.False(t, accessToken.IssuedAt.Before(before), "iat keeps microseconds")
+	assert.False(t, accessToken.IssuedAt.After(after))
+
+	// tokens issued with whole seconds are still parsed
+	assert.Equal(t, time.Unix(1600000000, 0), parseIssuedAt(1600000000))
+}
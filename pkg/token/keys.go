@@ -0,0 +1,355 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/config"
+	"github.com/dgrijalva/jwt-go"
+	"io/ioutil"
+	"math/big"
+	"time"
+)
+
+// Key is one signing or verification key identified by "kid" header,
+// a key with Until verifies no tokens after that time
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private interface{}
+	Public  interface{}
+	Until   time.Time
+}
+
+// KeySet signs tokens with one key and verifies them with every key of the set,
+// so that tokens signed by a retired key stay valid until they expire.
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// New key set, signing id must name a key with a private part
+func NewKeySet(signingID string, keys ...*Key) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*Key)}
+	for _, key := range keys {
+		if _, ok := ks.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate jwt key id: %s", key.ID)
+		}
+		ks.keys[key.ID] = key
+	}
+
+	signing, ok := ks.keys[signingID]
+	if !ok {
+		return nil, fmt.Errorf("jwt signing key not found: %s", signingID)
+	}
+	if signing.Private == nil {
+		return nil, fmt.Errorf("jwt signing key has no private key: %s", signingID)
+	}
+	ks.signing = signing
+
+	return ks, nil
+}
+
+// New verification key set, it has no signing key and is used to verify tokens of other issuers
+func NewVerificationKeySet(keys ...*Key) *KeySet {
+	ks := &KeySet{keys: make(map[string]*Key)}
+	for _, key := range keys {
+		ks.keys[key.ID] = key
+	}
+	return ks
+}
+
+// New hmac key set, it is used when no asymmetric keys are configured
+func NewHMACKeySet(secret string) *KeySet {
+	key := &Key{
+		ID:      "",
+		Method:  jwt.SigningMethodHS256,
+		Private: []byte(secret),
+		Public:  []byte(secret),
+	}
+	return &KeySet{signing: key, keys: map[string]*Key{key.ID: key}}
+}
+
+// Load key set from pem files listed in config, falls back to hmac secret when there are none.
+// With pem files the hmac secret only verifies tokens signed before the keys were configured,
+// until legacy secret until, which must be set then, so a leaked secret does not forge tokens forever.
+func LoadKeySet(config *config.Config) (*KeySet, error) {
+	if len(config.Jwt.Keys) == 0 {
+		return NewHMACKeySet(config.Jwt.Secret), nil
+	}
+
+	var keys []*Key
+	// hmac tokens have no "kid" header and the key has no private part, so nothing is signed with it
+	if config.Jwt.Secret != "" {
+		if config.Jwt.LegacySecretUntil == "" {
+			return nil, fmt.Errorf("jwt legacy_secret_until is required when secret is kept with keys")
+		}
+		until, err := time.Parse(time.RFC3339, config.Jwt.LegacySecretUntil)
+		if err != nil {
+			return nil, fmt.Errorf("jwt legacy_secret_until: %w", err)
+		}
+		keys = append(keys, &Key{ID: "", Method: jwt.SigningMethodHS256, Public: []byte(config.Jwt.Secret), Until: until})
+	}
+	for _, keyConfig := range config.Jwt.Keys {
+		key, err := LoadKey(keyConfig.ID, keyConfig.Algorithm, keyConfig.PrivateKey, keyConfig.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return NewKeySet(config.Jwt.SigningKey, keys...)
+}
+
+// Load key from pem files, the public key is derived when only the private one is given
+func LoadKey(id, algorithm, privateKeyPath, publicKeyPath string) (*Key, error) {
+	method := jwt.GetSigningMethod(algorithm)
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA, *SigningMethodEdDSA:
+	default:
+		return nil, fmt.Errorf("unsupported jwt key algorithm %q of key %s", algorithm, id)
+	}
+
+	key := &Key{ID: id, Method: method}
+
+	if privateKeyPath != "" {
+		block, err := readPEM(privateKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		if key.Private, err = parsePrivateKey(block); err != nil {
+			return nil, fmt.Errorf("jwt key %s: %w", id, err)
+		}
+		signer, ok := key.Private.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("jwt key %s: unsupported private key type", id)
+		}
+		key.Public = signer.Public()
+	}
+
+	if publicKeyPath != "" {
+		block, err := readPEM(publicKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		if key.Public, err = parsePublicKey(block); err != nil {
+			return nil, fmt.Errorf("jwt key %s: %w", id, err)
+		}
+	}
+
+	if key.Public == nil {
+		return nil, fmt.Errorf("jwt key %s has neither private nor public key", id)
+	}
+
+	if err := checkKeyType(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// Sign claims with the signing key
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.Method, claims)
+	if ks.signing.ID != "" {
+		token.Header["kid"] = ks.signing.ID
+	}
+	return token.SignedString(ks.signing.Private)
+}
+
+// SigningAlgorithm returns "alg" of the tokens signed by the set
+func (ks *KeySet) SigningAlgorithm() string {
+	return ks.signing.Method.Alg()
+}
+
+// Keyfunc finds verification key by "kid" header and refuses algorithm other than the key one
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("Unknown key id: %v", token.Header["kid"])
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+	}
+	if !key.Until.IsZero() && time.Now().After(key.Until) {
+		return nil, fmt.Errorf("Retired key id: %v", token.Header["kid"])
+	}
+	return key.Public, nil
+}
+
+// JWKS returns public keys of the set, hmac keys are never published
+func (ks *KeySet) JWKS() []JWK {
+	jwks := []JWK{}
+	for _, key := range ks.keys {
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case *ecdsa.PublicKey:
+			size := (public.Curve.Params().BitSize + 7) / 8
+			jwk.Kty = "EC"
+			jwk.Crv = public.Curve.Params().Name
+			jwk.X = base64.RawURLEncoding.EncodeToString(padLeft(public.X.Bytes(), size))
+			jwk.Y = base64.RawURLEncoding.EncodeToString(padLeft(public.Y.Bytes(), size))
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+		jwks = append(jwks, jwk)
+	}
+	return jwks
+}
+
+// Parse JWK published by other issuer, the algorithm is derived from the key type when it is not given
+func ParseJWK(jwk JWK) (*Key, error) {
+	key := &Key{ID: jwk.Kid}
+
+	switch jwk.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %s: invalid modulus: %w", jwk.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %s: invalid exponent: %w", jwk.Kid, err)
+		}
+		key.Public = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if jwk.Alg == "" {
+			jwk.Alg = "RS256"
+		}
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("jwk %s: unsupported curve %q", jwk.Kid, jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %s: invalid x coordinate: %w", jwk.Kid, err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %s: invalid y coordinate: %w", jwk.Kid, err)
+		}
+		key.Public = &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if jwk.Alg == "" {
+			jwk.Alg = map[string]string{"P-256": "ES256", "P-384": "ES384", "P-521": "ES512"}[jwk.Crv]
+		}
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return nil, fmt.Errorf("jwk %s: unsupported curve %q", jwk.Kid, jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("jwk %s: invalid public key", jwk.Kid)
+		}
+		key.Public = ed25519.PublicKey(x)
+		if jwk.Alg == "" {
+			jwk.Alg = "EdDSA"
+		}
+	default:
+		return nil, fmt.Errorf("jwk %s: unsupported key type %q", jwk.Kid, jwk.Kty)
+	}
+
+	if key.Method = jwt.GetSigningMethod(jwk.Alg); key.Method == nil {
+		return nil, fmt.Errorf("jwk %s: unsupported algorithm %q", jwk.Kid, jwk.Alg)
+	}
+
+	if err := checkKeyType(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error during read jwt key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no pem data found in %s", path)
+	}
+	return block, nil
+}
+
+func parsePrivateKey(block *pem.Block) (interface{}, error) {
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+}
+
+func parsePublicKey(block *pem.Block) (interface{}, error) {
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+}
+
+// check key type matches the algorithm, e.g. ES256 requires P-256 key
+func checkKeyType(key *Key) error {
+	ok := false
+	switch method := key.Method.(type) {
+	case *jwt.SigningMethodRSA:
+		_, ok = key.Public.(*rsa.PublicKey)
+	case *jwt.SigningMethodECDSA:
+		var public *ecdsa.PublicKey
+		if public, ok = key.Public.(*ecdsa.PublicKey); ok {
+			ok = public.Curve.Params().BitSize == method.CurveBits
+		}
+	case *SigningMethodEdDSA:
+		_, ok = key.Public.(ed25519.PublicKey)
+	}
+	if !ok {
+		return fmt.Errorf("jwt key %s does not match algorithm %s", key.ID, key.Method.Alg())
+	}
+	return nil
+}
+
+func padLeft(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	padded := make([]byte, size)
+	copy(padded[size-len(b):], b)
+	return padded
+}
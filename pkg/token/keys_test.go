@@ -0,0 +1,240 @@
+package token
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/BurntSushi/toml"
+	"github.com/Jamshid90/go-clean-architecture/pkg/config"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testKeys(t *testing.T) map[string]*Key {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	return map[string]*Key{
+		"RS256": {ID: "rsa", Method: jwt.SigningMethodRS256, Private: rsaKey, Public: &rsaKey.PublicKey},
+		"ES256": {ID: "ec", Method: jwt.SigningMethodES256, Private: ecKey, Public: &ecKey.PublicKey},
+		"EdDSA": {ID: "ed", Method: SigningMethodEd25519, Private: edPrivate, Public: edPublic},
+	}
+}
+
+func TestKeySetSignAndParse(t *testing.T) {
+	for alg, key := range testKeys(t) {
+		t.Run(alg, func(t *testing.T) {
+			keys, err := NewKeySet(key.ID, key)
+			require.NoError(t, err)
+
+			tokenStr, err := keys.Sign(jwt.MapClaims{"sub": "123", "exp": time.Now().Add(time.Minute).Unix()})
+			require.NoError(t, err)
+
+			claims, err := ParseJwtToken(tokenStr, keys)
+			assert.NoError(t, err)
+			assert.Equal(t, "123", claims["sub"])
+
+			parsed, _ := jwt.Parse(tokenStr, keys.Keyfunc)
+			assert.Equal(t, key.ID, parsed.Header["kid"])
+			assert.Equal(t, alg, parsed.Header["alg"])
+		})
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	keys := testKeys(t)
+	oldKeys, err := NewKeySet("rsa", keys["RS256"])
+	require.NoError(t, err)
+
+	tokenStr, err := oldKeys.Sign(jwt.MapClaims{"sub": "123"})
+	require.NoError(t, err)
+
+	// the retired key is kept for verification only
+	retired := &Key{ID: "rsa", Method: jwt.SigningMethodRS256, Public: keys["RS256"].Public}
+	newKeys, err := NewKeySet("ed", keys["EdDSA"], retired)
+	require.NoError(t, err)
+
+	_, err = ParseJwtToken(tokenStr, newKeys)
+	assert.NoError(t, err)
+
+	_, err = NewKeySet("rsa", retired)
+	assert.Error(t, err)
+}
+
+func TestKeySetRejectsAlgorithmConfusion(t *testing.T) {
+	keys := testKeys(t)
+	keySet, err := NewKeySet("rsa", keys["RS256"])
+	require.NoError(t, err)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "123"})
+	token.Header["kid"] = "rsa"
+	tokenStr, err := token.SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	_, err = ParseJwtToken(tokenStr, keySet)
+	assert.Error(t, err)
+}
+
+func TestKeySetJWKS(t *testing.T) {
+	keys := testKeys(t)
+	keySet, err := NewKeySet("rsa", keys["RS256"], keys["ES256"], keys["EdDSA"])
+	require.NoError(t, err)
+
+	kty := make(map[string]string)
+	for _, jwk := range keySet.JWKS() {
+		kty[jwk.Kid] = jwk.Kty
+	}
+	assert.Equal(t, map[string]string{"rsa": "RSA", "ec": "EC", "ed": "OKP"}, kty)
+
+	assert.Empty(t, TestKeySet(t).JWKS())
+}
+
+func TestParseJWK(t *testing.T) {
+	keys := testKeys(t)
+	keySet, err := NewKeySet("rsa", keys["RS256"], keys["ES256"], keys["EdDSA"])
+	require.NoError(t, err)
+
+	// tokens of the set are verified by keys parsed from its jwks
+	var parsed []*Key
+	for _, jwk := range keySet.JWKS() {
+		key, err := ParseJWK(jwk)
+		require.NoError(t, err)
+		parsed = append(parsed, key)
+	}
+	verificationSet := NewVerificationKeySet(parsed...)
+
+	signed, err := keySet.Sign(jwt.MapClaims{"sub": "123", "exp": time.Now().Add(time.Minute).Unix()})
+	require.NoError(t, err)
+
+	_, err = jwt.Parse(signed, verificationSet.Keyfunc)
+	assert.NoError(t, err)
+
+	_, err = ParseJWK(JWK{Kid: "hmac", Kty: "oct"})
+	assert.Error(t, err)
+
+	_, err = ParseJWK(JWK{Kid: "rsa", Kty: "RSA", Alg: "HS256", N: "AQAB", E: "AQAB"})
+	assert.Error(t, err)
+}
+
+func TestLoadKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwt-keys")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+	privatePath := filepath.Join(dir, "ec.pem")
+	require.NoError(t, ioutil.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600))
+
+	t.Run("success", func(t *testing.T) {
+		key, err := LoadKey("ec", "ES256", privatePath, "")
+		assert.NoError(t, err)
+		assert.Equal(t, &ecKey.PublicKey, key.Public)
+	})
+
+	t.Run("error-algorithm-mismatch", func(t *testing.T) {
+		_, err := LoadKey("ec", "ES384", privatePath, "")
+		assert.Error(t, err)
+
+		_, err = LoadKey("ec", "HS256", privatePath, "")
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwt-keys")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+	privatePath := filepath.Join(dir, "ec.pem")
+	require.NoError(t, ioutil.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600))
+
+	loadConfig := func(secret, until string) *config.Config {
+		var cfg config.Config
+		_, err := toml.Decode(`
+[jwt]
+    secret      = "`+secret+`"
+    signing_key = "ec"
+    legacy_secret_until = "`+until+`"
+    [[jwt.keys]]
+        id          = "ec"
+        algorithm   = "ES256"
+        private_key = "`+privatePath+`"
+`, &cfg)
+		require.NoError(t, err)
+		return &cfg
+	}
+
+	hmacToken, err := NewHMACKeySet("secret").Sign(jwt.MapClaims{"sub": "123"})
+	require.NoError(t, err)
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+
+	t.Run("success", func(t *testing.T) {
+		keys, err := LoadKeySet(loadConfig("secret", future))
+		require.NoError(t, err)
+		assert.Equal(t, "ES256", keys.SigningAlgorithm())
+		assert.Len(t, keys.JWKS(), 1)
+
+		// tokens signed with the secret before the keys were configured stay valid
+		claims, err := ParseJwtToken(hmacToken, keys)
+		assert.NoError(t, err)
+		assert.Equal(t, "123", claims["sub"])
+	})
+
+	t.Run("error-without-secret", func(t *testing.T) {
+		keys, err := LoadKeySet(loadConfig("", ""))
+		require.NoError(t, err)
+
+		_, err = ParseJwtToken(hmacToken, keys)
+		assert.Error(t, err)
+	})
+
+	t.Run("error-other-secret", func(t *testing.T) {
+		keys, err := LoadKeySet(loadConfig("other", future))
+		require.NoError(t, err)
+
+		_, err = ParseJwtToken(hmacToken, keys)
+		assert.Error(t, err)
+	})
+
+	t.Run("error-secret-retired", func(t *testing.T) {
+		keys, err := LoadKeySet(loadConfig("secret", past))
+		require.NoError(t, err)
+
+		_, err = ParseJwtToken(hmacToken, keys)
+		assert.Error(t, err)
+	})
+
+	t.Run("error-secret-without-until", func(t *testing.T) {
+		_, err := LoadKeySet(loadConfig("secret", ""))
+		assert.Error(t, err)
+	})
+
+	t.Run("error-invalid-until", func(t *testing.T) {
+		_, err := LoadKeySet(loadConfig("secret", "tomorrow"))
+		assert.Error(t, err)
+	})
+}
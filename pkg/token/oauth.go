@@ -0,0 +1,91 @@
+package token
+
+import (
+	"fmt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/rand"
+	"github.com/dgrijalva/jwt-go"
+	"strings"
+	"time"
+)
+
+// OAuthAccessToken is the parsed access token issued to oauth client, subject is the user
+// or the client itself for client credentials grant
+type OAuthAccessToken struct {
+	ID        string
+	Subject   string
+	ClientID  string
+	Scopes    []string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// GenerateOAuthAccessToken returns access token issued to oauth client, sub is the user or the client itself
+// for client credentials grant. The token has own type, so it is not accepted by the first-party api.
+func GenerateOAuthAccessToken(keys *KeySet, issuer string, ttl time.Duration, sub, clientID string, scopes []string) (string, error) {
+	jti, err := rand.Token(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	return GenerateJwtToken(keys, &jwt.MapClaims{
+		"typ":       TYPE_OAUTH_ACCESS,
+		"jti":       jti,
+		"iss":       issuer,
+		"sub":       sub,
+		"client_id": clientID,
+		"scope":     strings.Join(scopes, " "),
+		"iat":       issuedAt(now),
+		"exp":       now.Add(ttl).Unix(),
+	})
+}
+
+// ParseOAuthAccessToken parses access token issued to oauth client
+func ParseOAuthAccessToken(keys *KeySet, tokenStr string) (*OAuthAccessToken, error) {
+	claims, err := ParseJwtToken(tokenStr, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims["typ"] != TYPE_OAUTH_ACCESS {
+		return nil, fmt.Errorf("Token is not oauth access token")
+	}
+
+	accessToken := OAuthAccessToken{}
+	accessToken.ID, _ = claims["jti"].(string)
+	accessToken.Subject, _ = claims["sub"].(string)
+	accessToken.ClientID, _ = claims["client_id"].(string)
+	if scope, ok := claims["scope"].(string); ok {
+		accessToken.Scopes = strings.Fields(scope)
+	}
+	if iat, ok := claims["iat"].(float64); ok {
+		accessToken.IssuedAt = parseIssuedAt(iat)
+	}
+	if exp, ok := claims["exp"].(float64); ok {
+		accessToken.ExpiresAt = time.Unix(int64(exp), 0)
+	}
+	if accessToken.Subject == "" || accessToken.ClientID == "" {
+		return nil, fmt.Errorf("Token has no subject")
+	}
+	return &accessToken, nil
+}
+
+// GenerateIDToken returns openid connect id token for the client, claims hold "sub" and the user claims
+// released by the granted scopes, nonce of the authorization request is echoed when given.
+// The "typ" claim keeps id tokens given to clients from being accepted as access tokens.
+func GenerateIDToken(keys *KeySet, issuer string, ttl time.Duration, clientID, nonce string, claims map[string]interface{}) (string, error) {
+	now := time.Now()
+	idClaims := jwt.MapClaims{}
+	for key, value := range claims {
+		idClaims[key] = value
+	}
+	idClaims["typ"] = TYPE_ID
+	idClaims["iss"] = issuer
+	idClaims["aud"] = clientID
+	idClaims["iat"] = now.Unix()
+	idClaims["exp"] = now.Add(ttl).Unix()
+	if nonce != "" {
+		idClaims["nonce"] = nonce
+	}
+	return GenerateJwtToken(keys, &idClaims)
+}
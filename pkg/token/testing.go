@@ -0,0 +1,10 @@
+package token
+
+import (
+	"testing"
+)
+
+func TestKeySet(t *testing.T) *KeySet {
+	t.Helper()
+	return NewHMACKeySet("secret")
+}
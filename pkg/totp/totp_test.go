@@ -0,0 +1,60 @@
+package totp
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// rfc 6238 appendix b test vectors for sha1
+func TestHotp(t *testing.T) {
+	key := []byte("12345678901234567890")
+	vectors := map[int64]string{
+		59:          "94287082",
+		1111111109:  "07081804",
+		1111111111:  "14050471",
+		1234567890:  "89005924",
+		2000000000:  "69279037",
+		20000000000: "65353130",
+	}
+	for unix, code := range vectors {
+		assert.Equal(t, code, hotp(key, uint64(unix/Period), 8))
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret := encoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(59, 0)
+
+	t.Run("success", func(t *testing.T) {
+		step, ok := Validate("287082", secret, now, 1)
+		assert.True(t, ok)
+		assert.Equal(t, int64(1), step)
+	})
+
+	t.Run("skew", func(t *testing.T) {
+		_, ok := Validate("287082", secret, now.Add(Period*time.Second), 1)
+		assert.True(t, ok)
+
+		_, ok = Validate("287082", secret, now.Add(2*Period*time.Second), 1)
+		assert.False(t, ok)
+	})
+
+	t.Run("error-invalid-code", func(t *testing.T) {
+		_, ok := Validate("000000", secret, now, 1)
+		assert.False(t, ok)
+
+		_, ok = Validate("28708", secret, now, 1)
+		assert.False(t, ok)
+	})
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	code, err := Code(secret, Step(time.Now()))
+	assert.NoError(t, err)
+	assert.Len(t, code, Digits)
+}
@@ -0,0 +1,212 @@
+package user
+
+import (
+	"github.com/Jamshid90/go-clean-architecture/pkg/cursor"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DEFAULT_LIMIT = 10
+	MAX_LIMIT     = 100
+	// MAX_QUERY_LENGTH limits free-text search
+	MAX_QUERY_LENGTH = 100
+)
+
+// filter parameters of find all, other parameters are rejected
+var filterParams = map[string]bool{
+	"limit":           true,
+	"offset":          true,
+	"cursor":          true,
+	"total":           true,
+	"status":          true,
+	"type":            true,
+	"gender":          true,
+	"email":           true,
+	"q":               true,
+	"created_at_from": true,
+	"created_at_to":   true,
+	"birth_date_from": true,
+	"birth_date_to":   true,
+	"sort":            true,
+}
+
+// user cursor is the position signed into the opaque cursor
+type userCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+	Backward  bool      `json:"b,omitempty"`
+}
+
+// EncodeUserCursor returns opaque cursor of the position signed with the secret
+func EncodeUserCursor(secret []byte, position *entity.UserCursor) (string, error) {
+	return cursor.Encode(secret, userCursor{
+		CreatedAt: position.CreatedAt,
+		ID:        position.ID,
+		Backward:  position.Backward,
+	})
+}
+
+// NewUserFilter parses find all query, unknown parameters and invalid values are validation errors.
+// Statuses and sort fields are comma separated, "-" before the sort field sorts descending.
+// Cursors are verified with the secret and can not be combined with offset or sort by other fields.
+func NewUserFilter(query url.Values, cursorSecret []byte) (*entity.UserFilter, error) {
+	filter := entity.UserFilter{Limit: DEFAULT_LIMIT}
+	errValidation := errors.NewErrValidation()
+
+	for param, values := range query {
+		if !filterParams[param] {
+			errValidation.Errors[param] = "unknown filter " + param
+			continue
+		}
+		if len(values) > 1 {
+			errValidation.Errors[param] = param + " must be given once"
+		}
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > MAX_LIMIT {
+			errValidation.Errors["limit"] = "limit must be between 1 and " + strconv.Itoa(MAX_LIMIT)
+		}
+		filter.Limit = limit
+	}
+
+	if v := query.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			errValidation.Errors["offset"] = "offset must be a non-negative integer"
+		}
+		filter.Offset = offset
+	}
+
+	if v := query.Get("status"); v != "" {
+		for _, status := range strings.Split(v, ",") {
+			if _, ok := entity.UserStatusTransitions[status]; !ok {
+				errValidation.Errors["status"] = "unknown status " + status
+				break
+			}
+			filter.Status = append(filter.Status, status)
+		}
+	}
+
+	if v := query.Get("type"); v != "" {
+		if v != entity.USER_TYPE_HUMAN && v != entity.USER_TYPE_SERVICE {
+			errValidation.Errors["type"] = "type must be human or service"
+		}
+		filter.Type = v
+	}
+
+	if v := query.Get("gender"); v != "" {
+		if v != "male" && v != "female" {
+			errValidation.Errors["gender"] = "gender must be male or female"
+		}
+		filter.Gender = v
+	}
+
+	filter.EmailPrefix = strings.TrimSpace(query.Get("email"))
+
+	filter.Query = strings.TrimSpace(query.Get("q"))
+	if len(filter.Query) > MAX_QUERY_LENGTH {
+		errValidation.Errors["q"] = "q must be at most " + strconv.Itoa(MAX_QUERY_LENGTH) + " characters"
+	}
+
+	filter.CreatedFrom = parseFilterTime(query, "created_at_from", time.RFC3339, errValidation)
+	filter.CreatedTo = parseFilterTime(query, "created_at_to", time.RFC3339, errValidation)
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && filter.CreatedFrom.After(*filter.CreatedTo) {
+		errValidation.Errors["created_at_to"] = "created_at_to must not be before created_at_from"
+	}
+
+	filter.BirthDateFrom = parseFilterTime(query, "birth_date_from", "2006-01-02", errValidation)
+	filter.BirthDateTo = parseFilterTime(query, "birth_date_to", "2006-01-02", errValidation)
+	if filter.BirthDateFrom != nil && filter.BirthDateTo != nil && filter.BirthDateFrom.After(*filter.BirthDateTo) {
+		errValidation.Errors["birth_date_to"] = "birth_date_to must not be before birth_date_from"
+	}
+
+	if v := query.Get("sort"); v != "" {
+		sort, message := parseUserSort(v)
+		if message != "" {
+			errValidation.Errors["sort"] = message
+		}
+		filter.Sort = sort
+	}
+
+	if v := query.Get("total"); v != "" {
+		total, err := strconv.ParseBool(v)
+		if err != nil {
+			errValidation.Errors["total"] = "total must be true or false"
+		}
+		filter.WithTotal = total
+	}
+
+	if v := query.Get("cursor"); v != "" {
+		var position userCursor
+		switch {
+		case cursor.Decode(cursorSecret, v, &position) != nil:
+			errValidation.Errors["cursor"] = "cursor is invalid"
+		case query.Get("offset") != "":
+			errValidation.Errors["cursor"] = "cursor can not be combined with offset"
+		case !filter.IsKeyset():
+			errValidation.Errors["cursor"] = "cursor requires sort by created_at only"
+		default:
+			filter.Cursor = &entity.UserCursor{CreatedAt: position.CreatedAt, ID: position.ID, Backward: position.Backward}
+		}
+	}
+
+	if len(errValidation.Errors) > 0 {
+		return nil, errValidation
+	}
+	return &filter, nil
+}
+
+// parse time of the parameter in the layout, the parameter is optional
+func parseFilterTime(query url.Values, param, layout string, errValidation *errors.ErrValidation) *time.Time {
+	v := query.Get(param)
+	if v == "" {
+		return nil
+	}
+	t, err := time.Parse(layout, v)
+	if err != nil {
+		errValidation.Errors[param] = param + " must be in format " + layout
+		return nil
+	}
+	return &t
+}
+
+// parse sort like "-created_at,last_name", fields are whitelisted and given once, the message tells what is wrong
+func parseUserSort(v string) ([]entity.UserSort, string) {
+	var (
+		sort = []entity.UserSort{}
+		seen = map[string]bool{}
+	)
+
+	for _, field := range strings.Split(v, ",") {
+		userSort := entity.UserSort{Field: field}
+		if strings.HasPrefix(field, "-") {
+			userSort = entity.UserSort{Field: field[1:], Desc: true}
+		}
+
+		if !isUserSortField(userSort.Field) {
+			return nil, "unknown sort field " + userSort.Field
+		}
+		if seen[userSort.Field] {
+			return nil, "sort field " + userSort.Field + " is given twice"
+		}
+		seen[userSort.Field] = true
+		sort = append(sort, userSort)
+	}
+	return sort, ""
+}
+
+func isUserSortField(field string) bool {
+	for _, f := range entity.UserSortFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
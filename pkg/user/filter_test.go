@@ -0,0 +1,129 @@
+package user
+
+import (
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	apperrors "github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+var cursorSecret = []byte("secret")
+
+func TestNewUserFilter(t *testing.T) {
+	position := &entity.UserCursor{CreatedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), ID: "123", Backward: true}
+	validCursor, err := EncodeUserCursor(cursorSecret, position)
+	require.NoError(t, err)
+	forgedCursor, err := EncodeUserCursor([]byte("other"), position)
+	require.NoError(t, err)
+
+	t.Run("success", func(t *testing.T) {
+		query, err := url.ParseQuery("limit=20&offset=40&status=active,pending&gender=female&email=jo&q=smith" +
+			"&created_at_from=2026-01-01T00:00:00Z&birth_date_to=2000-12-31&sort=-created_at,last_name")
+		require.NoError(t, err)
+
+		filter, err := NewUserFilter(query, cursorSecret)
+		assert.NoError(t, err)
+		assert.Equal(t, 20, filter.Limit)
+		assert.Equal(t, 40, filter.Offset)
+		assert.Equal(t, []string{entity.USER_STATUS_ACTIVE, entity.USER_STATUS_PENDING}, filter.Status)
+		assert.Equal(t, "female", filter.Gender)
+		assert.Equal(t, "jo", filter.EmailPrefix)
+		assert.Equal(t, "smith", filter.Query)
+		assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), *filter.CreatedFrom)
+		assert.Nil(t, filter.CreatedTo)
+		assert.Equal(t, time.Date(2000, 12, 31, 0, 0, 0, 0, time.UTC), *filter.BirthDateTo)
+		assert.Equal(t, []entity.UserSort{{Field: "created_at", Desc: true}, {Field: "last_name"}}, filter.Sort)
+	})
+
+	t.Run("success-cursor", func(t *testing.T) {
+		filter, err := NewUserFilter(url.Values{"cursor": {validCursor}, "sort": {"created_at"}, "total": {"true"}}, cursorSecret)
+		assert.NoError(t, err)
+		assert.Equal(t, position, filter.Cursor)
+		assert.True(t, filter.WithTotal)
+	})
+
+	t.Run("success-default", func(t *testing.T) {
+		filter, err := NewUserFilter(url.Values{}, cursorSecret)
+		assert.NoError(t, err)
+		assert.Equal(t, &entity.UserFilter{Limit: DEFAULT_LIMIT}, filter)
+	})
+
+	t.Run("error-validation", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			query string
+			field string
+		}{
+			"unknown-filter":       {"password=secret", "password"},
+			"repeated-filter":      {"status=active&status=pending", "status"},
+			"invalid-limit":        {"limit=1000", "limit"},
+			"invalid-offset":       {"offset=-1", "offset"},
+			"unknown-status":       {"status=active,deleted", "status"},
+			"invalid-gender":       {"gender=other", "gender"},
+			"invalid-created-at":   {"created_at_from=yesterday", "created_at_from"},
+			"invalid-birth-range":  {"birth_date_from=2000-01-02&birth_date_to=2000-01-01", "birth_date_to"},
+			"unknown-sort-field":   {"sort=password", "sort"},
+			"repeated-sort-field":  {"sort=email,-email", "sort"},
+			"empty-sort-field":     {"sort=email,", "sort"},
+			"too-long-text-search": {"q=" + strings.Repeat("a", MAX_QUERY_LENGTH+1), "q"},
+			"invalid-total":        {"total=maybe", "total"},
+			"forged-cursor":        {"cursor=" + forgedCursor, "cursor"},
+			"cursor-with-offset":   {"offset=10&cursor=" + validCursor, "cursor"},
+			"cursor-with-sort":     {"sort=last_name&cursor=" + validCursor, "cursor"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				query, err := url.ParseQuery(tc.query)
+				require.NoError(t, err)
+
+				_, err = NewUserFilter(query, cursorSecret)
+				errValidation, ok := err.(*apperrors.ErrValidation)
+				if assert.True(t, ok) {
+					assert.Contains(t, errValidation.Errors, tc.field)
+				}
+			})
+		}
+	})
+}
+
+func TestUserFilterQuery(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		createdFrom := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		query, args := userFilterQuery(&entity.UserFilter{
+			Limit:       10,
+			Offset:      20,
+			Status:      []string{entity.USER_STATUS_ACTIVE},
+			EmailPrefix: "jo_",
+			Query:       "50%",
+			CreatedFrom: &createdFrom,
+			Sort:        []entity.UserSort{{Field: "last_name", Desc: true}, {Field: "first_name"}},
+		})
+
+		assert.Equal(t, " WHERE status = ANY($1) AND email ILIKE $2 AND (first_name ILIKE $3 OR last_name ILIKE $3 OR email ILIKE $3)"+
+			" AND created_at >= $4 ORDER BY last_name DESC, first_name ASC, id ASC LIMIT $5 OFFSET $6", query)
+		assert.Equal(t, []interface{}{[]string{entity.USER_STATUS_ACTIVE}, `jo\_%`, `%50\%%`, createdFrom, 10, 20}, args)
+	})
+
+	t.Run("success-default-sort", func(t *testing.T) {
+		query, args := userFilterQuery(&entity.UserFilter{Limit: 10, Sort: []entity.UserSort{{Field: "password"}}})
+
+		assert.Equal(t, " ORDER BY created_at DESC, id DESC LIMIT $1 OFFSET $2", query)
+		assert.Equal(t, []interface{}{10, 0}, args)
+	})
+
+	t.Run("success-cursor", func(t *testing.T) {
+		createdAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		query, args := userFilterQuery(&entity.UserFilter{Limit: 10, Gender: "male", Cursor: &entity.UserCursor{CreatedAt: createdAt, ID: "123"}})
+		assert.Equal(t, " WHERE gender = $1 AND (created_at, id) < ($2, $3) ORDER BY created_at DESC, id DESC LIMIT $4", query)
+		assert.Equal(t, []interface{}{"male", createdAt, "123", 10}, args)
+
+		query, _ = userFilterQuery(&entity.UserFilter{Limit: 10, Cursor: &entity.UserCursor{CreatedAt: createdAt, ID: "123", Backward: true}})
+		assert.Equal(t, " WHERE (created_at, id) > ($1, $2) ORDER BY created_at ASC, id ASC LIMIT $3", query)
+
+		query, _ = userFilterQuery(&entity.UserFilter{Limit: 10, Sort: []entity.UserSort{{Field: "created_at"}}, Cursor: &entity.UserCursor{CreatedAt: createdAt, ID: "123", Backward: true}})
+		assert.Equal(t, " WHERE (created_at, id) < ($1, $2) ORDER BY created_at DESC, id DESC LIMIT $3", query)
+	})
+}
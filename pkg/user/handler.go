@@ -2,53 +2,78 @@ package user
 
 import (
 	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/middleware"
 	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/request"
 	"github.com/Jamshid90/go-clean-architecture/pkg/http/rest/response"
+	"github.com/Jamshid90/go-clean-architecture/pkg/jsonpatch"
 	"github.com/Jamshid90/go-clean-architecture/pkg/validation"
 	"github.com/go-chi/chi"
 	"go.uber.org/zap"
+	"mime"
 	"net/http"
-	"strconv"
 	"time"
 )
 
 type UserHandler struct {
-	logger      *zap.Logger
-	userUsecase entity.UserUsecase
+	logger              *zap.Logger
+	userUsecase         entity.UserUsecase
+	loginAttemptUsecase entity.LoginAttemptUsecase
+	cursorSecret        []byte
 }
 
-// New user handler
-func NewUserHandler(r chi.Router, userUsecase entity.UserUsecase, logger *zap.Logger) {
+// New user handler, cursors of user listing are signed with the cursor secret
+func NewUserHandler(r chi.Router, userUsecase entity.UserUsecase, loginAttemptUsecase entity.LoginAttemptUsecase, cursorSecret []byte, auth func(http.Handler) http.Handler, logger *zap.Logger) {
 	handler := UserHandler{
-		userUsecase: userUsecase,
-		logger:      logger,
+		userUsecase:         userUsecase,
+		loginAttemptUsecase: loginAttemptUsecase,
+		cursorSecret:        cursorSecret,
+		logger:              logger,
 	}
-	r.Get("/user", handler.findAll())
-	r.Get("/user/{id}", handler.find())
-	r.Post("/user", handler.store())
-	r.Put("/user", handler.update())
-	r.Delete("/user/{id}", handler.delete())
+
+	r.Group(func(r chi.Router) {
+		r.Use(auth)
+		r.With(middleware.Permission(entity.PERMISSION_USER_READ)).Get("/user", handler.findAll())
+		r.With(middleware.PermissionOrSelf(entity.PERMISSION_USER_READ, "id")).Get("/user/{id}", handler.find())
+
+		// users are changed neither with api keys nor under impersonation, like the profile
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.NoAPIKey)
+			r.Use(middleware.NoImpersonation)
+			r.With(middleware.Permission(entity.PERMISSION_USER_WRITE)).Post("/user", handler.store())
+			r.Put("/user", handler.update())
+			r.With(middleware.PermissionOrSelf(entity.PERMISSION_USER_WRITE, "id")).Patch("/user/{id}", handler.patch())
+			r.With(middleware.Permission(entity.PERMISSION_USER_DELETE)).Delete("/user/{id}", handler.delete())
+			r.With(middleware.Permission(entity.PERMISSION_USER_WRITE)).Post("/user/{id}/unlock", handler.unlock())
+		})
+	})
 }
 
 // convert entity user to user model
 func (uh *UserHandler) convert(user *entity.User) *User {
 	return &User{
-		ID:        user.ID,
-		Status:    user.Status,
-		Email:     user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Password:  user.Password,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:          user.ID,
+		Type:        user.Type,
+		Status:      user.Status,
+		Role:        user.Role,
+		Permissions: user.Permissions,
+		Email:       user.Email,
+		Phone:       user.Phone,
+		Gender:      user.Gender,
+		FirstName:   user.FirstName,
+		LastName:    user.LastName,
+		Password:    user.Password,
+		BirthDate:   user.BirthDate,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
 	}
 }
 
 // convert items
 func (uh *UserHandler) convertItems(items []*entity.User) []*User {
-	var users []*User
+	users := []*User{}
 	for _, item := range items {
-		users = append(users, uh.convert(item))
+		users = append(users, uh.convert(item).Sanitize())
 	}
 	return users
 }
@@ -67,6 +92,18 @@ func (uh *UserHandler) store() http.HandlerFunc {
 			return
 		}
 
+		authUser, ok := middleware.GetAuthUser(r.Context())
+		if !ok {
+			response.Error(w, r, errors.ErrUnauthorized, http.StatusUnauthorized)
+			return
+		}
+
+		// role and permissions can grant only permissions the auth user has
+		if !authUser.CanGrant(&entity.User{}, userRequest.Role, userRequest.Permissions) {
+			response.Error(w, r, errors.ErrForbidden, http.StatusForbidden)
+			return
+		}
+
 		birthDate, err := time.Parse("2006-01-02", userRequest.BirthDate)
 		if err != nil {
 			uh.logger.Error("user store parse birth date", zap.Error(err))
@@ -76,14 +113,16 @@ func (uh *UserHandler) store() http.HandlerFunc {
 
 		ctx := r.Context()
 		user := entity.User{
-			Status:    userRequest.Status,
-			Email:     userRequest.Email,
-			Phone:     userRequest.Phone,
-			Gender:    userRequest.Gender,
-			FirstName: userRequest.FirstName,
-			LastName:  userRequest.LastName,
-			Password:  userRequest.Password,
-			BirthDate: birthDate,
+			Status:      userRequest.Status,
+			Role:        userRequest.Role,
+			Permissions: userRequest.Permissions,
+			Email:       userRequest.Email,
+			Phone:       userRequest.Phone,
+			Gender:      userRequest.Gender,
+			FirstName:   userRequest.FirstName,
+			LastName:    userRequest.LastName,
+			Password:    userRequest.Password,
+			BirthDate:   birthDate,
 		}
 		if err := uh.userUsecase.Store(ctx, &user); err != nil {
 			uh.logger.Error("user store", zap.Error(err))
@@ -113,6 +152,19 @@ func (uh *UserHandler) update() http.HandlerFunc {
 			return
 		}
 
+		authUser, ok := middleware.GetAuthUser(r.Context())
+		if !ok {
+			response.Error(w, r, errors.ErrUnauthorized, http.StatusUnauthorized)
+			return
+		}
+
+		// users without write permission can update only themselves
+		canManage := authUser.HasPermission(entity.PERMISSION_USER_WRITE)
+		if !canManage && authUser.ID != userRequest.ID {
+			response.Error(w, r, errors.ErrForbidden, http.StatusForbidden)
+			return
+		}
+
 		birthDate, err := time.Parse("2006-01-02", userRequest.BirthDate)
 		if err != nil {
 			uh.logger.Error("user update parse birth date", zap.Error(err))
@@ -122,14 +174,51 @@ func (uh *UserHandler) update() http.HandlerFunc {
 
 		ctx := r.Context()
 		user := entity.User{
-			ID:        userRequest.ID,
-			Status:    userRequest.Status,
-			Email:     userRequest.Email,
-			Phone:     userRequest.Phone,
-			Gender:    userRequest.Gender,
-			FirstName: userRequest.FirstName,
-			LastName:  userRequest.LastName,
-			BirthDate: birthDate,
+			ID:          userRequest.ID,
+			Status:      userRequest.Status,
+			Role:        userRequest.Role,
+			Permissions: userRequest.Permissions,
+			Email:       userRequest.Email,
+			Phone:       userRequest.Phone,
+			Gender:      userRequest.Gender,
+			FirstName:   userRequest.FirstName,
+			LastName:    userRequest.LastName,
+			BirthDate:   birthDate,
+		}
+
+		// status, role and permissions are kept as they are on self editing
+		if !canManage {
+			user.Status = ""
+			user.Role = ""
+			user.Permissions = nil
+		}
+
+		if user.Role != "" || user.Permissions != nil || authUser.ID != user.ID {
+			current, err := uh.userUsecase.Find(ctx, user.ID)
+			if err != nil {
+				response.Error(w, r, err, response.GetStatusCodeErr(err))
+				return
+			}
+
+			// status, email, role and permissions of users with more permissions than the auth user can not be changed
+			changed := user.Status != "" && user.Status != current.Status || user.Email != current.Email || user.Role != "" || user.Permissions != nil
+			if changed && !authUser.CanManage(current) {
+				response.Error(w, r, errors.ErrForbidden, http.StatusForbidden)
+				return
+			}
+
+			// role and permissions can grant only permissions the auth user has
+			role, permissions := user.Role, user.Permissions
+			if role == "" {
+				role = current.Role
+			}
+			if permissions == nil {
+				permissions = current.Permissions
+			}
+			if !authUser.CanGrant(current, role, permissions) {
+				response.Error(w, r, errors.ErrForbidden, http.StatusForbidden)
+				return
+			}
 		}
 
 		if err := uh.userUsecase.Update(ctx, &user); err != nil {
@@ -145,11 +234,111 @@ func (uh *UserHandler) update() http.HandlerFunc {
 	}
 }
 
+// patch user with json merge patch, RFC 7396, or with json patch, RFC 6902, chosen by content type,
+// plain json is a merge patch
+func (uh *UserHandler) patch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		authUser, ok := middleware.GetAuthUser(ctx)
+		if !ok {
+			response.Error(w, r, errors.ErrUnauthorized, http.StatusUnauthorized)
+			return
+		}
+
+		user, err := uh.userUsecase.Find(ctx, chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		doc, err := patchDocument(user)
+		if err != nil {
+			uh.logger.Error("user patch document", zap.Error(err))
+			response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		var patched interface{}
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediaType {
+		case CONTENT_TYPE_JSON_PATCH:
+			var operations []jsonpatch.Operation
+			if err := request.DecodeJson(r, &operations); err != nil {
+				response.Error(w, r, err, response.GetStatusCodeErr(err))
+				return
+			}
+			if patched, err = jsonpatch.Apply(doc, operations); err != nil {
+				errValidation := errors.NewErrValidation()
+				errValidation.Errors["patch"] = err.Error()
+				response.Error(w, r, errValidation, response.GetStatusCodeErr(errValidation))
+				return
+			}
+		case CONTENT_TYPE_MERGE_PATCH, "application/json":
+			var mergePatch interface{}
+			if err := request.DecodeJson(r, &mergePatch); err != nil {
+				response.Error(w, r, err, response.GetStatusCodeErr(err))
+				return
+			}
+			patched = jsonpatch.MergePatch(doc, mergePatch)
+		default:
+			w.Header().Set("Accept-Patch", CONTENT_TYPE_MERGE_PATCH+", "+CONTENT_TYPE_JSON_PATCH)
+			response.Error(w, r, &errors.ErrBadRequest{Message: "unsupported patch media type"}, http.StatusUnsupportedMediaType)
+			return
+		}
+
+		userPatch, err := newUserPatch(doc, patched)
+		if err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		// users without write permission can not change their status, role and permissions
+		if !authUser.HasPermission(entity.PERMISSION_USER_WRITE) && (userPatch.Status != nil || userPatch.Role != nil || userPatch.Permissions != nil) {
+			response.Error(w, r, errors.ErrForbidden, http.StatusForbidden)
+			return
+		}
+
+		// status, email, role and permissions of users with more permissions than the auth user can not be changed
+		if (userPatch.Status != nil || userPatch.Email != nil || userPatch.Role != nil || userPatch.Permissions != nil) && !authUser.CanManage(user) {
+			response.Error(w, r, errors.ErrForbidden, http.StatusForbidden)
+			return
+		}
+
+		// role and permissions can grant only permissions the auth user has
+		if userPatch.Role != nil || userPatch.Permissions != nil {
+			role, permissions := user.Role, user.Permissions
+			if userPatch.Role != nil {
+				role = *userPatch.Role
+			}
+			if userPatch.Permissions != nil {
+				permissions = *userPatch.Permissions
+			}
+			if !authUser.CanGrant(user, role, permissions) {
+				response.Error(w, r, errors.ErrForbidden, http.StatusForbidden)
+				return
+			}
+		}
+
+		patchedUser, err := uh.userUsecase.Patch(ctx, user.ID, userPatch)
+		if err != nil {
+			uh.logger.Error("user patch", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+			"user":   uh.convert(patchedUser).Sanitize(),
+		})
+	}
+}
+
 // delete
 func (uh *UserHandler) delete() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
-		if err := uh.userUsecase.Delete(ctx, chi.URLParam(r, "id")); err != nil {
+		id := chi.URLParam(r, "id")
+		if err := uh.userUsecase.Delete(ctx, id); err != nil {
 			uh.logger.Error("user delete", zap.Error(err))
 			response.Error(w, r, err, response.GetStatusCodeErr(err))
 			return
@@ -161,6 +350,35 @@ func (uh *UserHandler) delete() http.HandlerFunc {
 	}
 }
 
+// unlock removes login lockout of the user
+func (uh *UserHandler) unlock() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		user, err := uh.userUsecase.Find(ctx, chi.URLParam(r, "id"))
+		if err != nil {
+			uh.logger.Error("user unlock find", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		if err := uh.loginAttemptUsecase.Unlock(ctx, user.Email); err != nil {
+			uh.logger.Error("user unlock", zap.Error(err))
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
+		}
+
+		admin, _ := middleware.GetAuthUser(ctx)
+		uh.logger.Info("login unlocked",
+			zap.String("user_id", user.ID),
+			zap.String("admin_id", admin.ID),
+		)
+
+		response.Json(w, r, 200, map[string]interface{}{
+			"status": "success",
+		})
+	}
+}
+
 // find
 func (uh *UserHandler) find() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -182,37 +400,53 @@ func (uh *UserHandler) find() http.HandlerFunc {
 // find all
 func (uh *UserHandler) findAll() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var (
-			limit  = 10
-			offset = 0
-			params = make(map[string]interface{})
-		)
-
-		if _limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil {
-			uh.logger.Error("user find all limit", zap.Error(err))
-			limit = _limit
-		}
-
-		if _offset, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil {
-			uh.logger.Error("user find all offset", zap.Error(err))
-			offset = _offset
-		}
-
-		for i, v := range r.URL.Query() {
-			params[i] = v
+		filter, err := NewUserFilter(r.URL.Query(), uh.cursorSecret)
+		if err != nil {
+			response.Error(w, r, err, response.GetStatusCodeErr(err))
+			return
 		}
 
 		ctx := r.Context()
-		items, err := uh.userUsecase.FindAll(ctx, limit, offset, params)
+		page, err := uh.userUsecase.FindAll(ctx, filter)
 		if err != nil {
 			uh.logger.Error("user find all", zap.Error(err))
 			response.Error(w, r, err, response.GetStatusCodeErr(err))
 			return
 		}
 
+		meta := Meta{Limit: filter.Limit, Offset: filter.Offset, Total: page.Total}
+		if meta.Next, err = uh.link(r, page.Next); err != nil {
+			uh.logger.Error("user find all next link", zap.Error(err))
+			response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+			return
+		}
+		if meta.Prev, err = uh.link(r, page.Prev); err != nil {
+			uh.logger.Error("user find all prev link", zap.Error(err))
+			response.Error(w, r, errors.ErrInternalServerError, http.StatusInternalServerError)
+			return
+		}
+
 		response.Json(w, r, 200, map[string]interface{}{
 			"status": "success",
-			"items":  uh.convertItems(items),
+			"data":   uh.convertItems(page.Items),
+			"meta":   meta,
 		})
 	}
 }
+
+// link to the page of the cursor with filters of the request, empty without cursor
+func (uh *UserHandler) link(r *http.Request, position *entity.UserCursor) (string, error) {
+	if position == nil {
+		return "", nil
+	}
+
+	c, err := EncodeUserCursor(uh.cursorSecret, position)
+	if err != nil {
+		return "", err
+	}
+
+	query := r.URL.Query()
+	query.Del("offset")
+	query.Set("cursor", c)
+	return r.URL.Path + "?" + query.Encode(), nil
+}
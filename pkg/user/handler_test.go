@@ -0,0 +1,221 @@
+package user
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity/mocks"
+	"github.com/Jamshid90/go-clean-architecture/pkg/token"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// test auth authenticates every request as the user, like Auth middleware does with the token or the api key
+func testAuth(user *entity.User, accessToken *token.AccessToken, apiKey *entity.APIKey) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), "user", user)
+			if accessToken != nil {
+				ctx = context.WithValue(ctx, "access_token", accessToken)
+			}
+			if apiKey != nil {
+				ctx = context.WithValue(ctx, "api_key", apiKey)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func serveUser(t *testing.T, userUsecase entity.UserUsecase, auth func(http.Handler) http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r := chi.NewRouter()
+	NewUserHandler(r, userUsecase, nil, cursorSecret, auth, zap.NewNop())
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestStoreHandler(t *testing.T) {
+	writer := &entity.User{ID: "writer", Role: entity.USER_ROLE_USER, Permissions: []string{entity.PERMISSION_USER_WRITE}}
+	admin := &entity.User{ID: "admin", Role: entity.USER_ROLE_ADMIN}
+	body := func(role, permissions string) string {
+		return `{"status":"active","role":"` + role + `","permissions":` + permissions + `,"email":"user@info.com","phone":"+998901234567",` +
+			`"gender":"male","first_name":"User","last_name":"Admin","birth_date":"2000-01-01","password":"123456789","confirm_password":"123456789"}`
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockUsecase := new(mocks.UserUsecase)
+		mockUsecase.On("Store", mock.Anything, mock.MatchedBy(func(m *entity.User) bool {
+			return m.Role == entity.USER_ROLE_ADMIN
+		})).Return(nil).Once()
+
+		w := serveUser(t, mockUsecase, testAuth(admin, nil, nil), http.MethodPost, "/user", body(entity.USER_ROLE_ADMIN, "[]"))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("success-held-permission", func(t *testing.T) {
+		mockUsecase := new(mocks.UserUsecase)
+		mockUsecase.On("Store", mock.Anything, mock.AnythingOfType("*entity.User")).Return(nil).Once()
+
+		w := serveUser(t, mockUsecase, testAuth(writer, nil, nil), http.MethodPost, "/user", body(entity.USER_ROLE_USER, `["user:write"]`))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("error-create-admin", func(t *testing.T) {
+		mockUsecase := new(mocks.UserUsecase)
+
+		w := serveUser(t, mockUsecase, testAuth(writer, nil, nil), http.MethodPost, "/user", body(entity.USER_ROLE_ADMIN, "[]"))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockUsecase.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error-grant-permission", func(t *testing.T) {
+		mockUsecase := new(mocks.UserUsecase)
+
+		w := serveUser(t, mockUsecase, testAuth(writer, nil, nil), http.MethodPost, "/user", body(entity.USER_ROLE_USER, `["user:delete"]`))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockUsecase.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+	})
+}
+
+func TestMutatingRoutes(t *testing.T) {
+	admin := &entity.User{ID: "admin", Role: entity.USER_ROLE_ADMIN}
+
+	for name, auth := range map[string]func(http.Handler) http.Handler{
+		"error-api-key":             testAuth(admin, nil, &entity.APIKey{ID: "key", UserID: admin.ID}),
+		"error-under-impersonation": testAuth(admin, &token.AccessToken{ID: "jti", User: admin, ActorID: "other-admin"}, nil),
+	} {
+		t.Run(name, func(t *testing.T) {
+			mockUsecase := new(mocks.UserUsecase)
+
+			for _, route := range []struct{ method, path string }{
+				{http.MethodPost, "/user"},
+				{http.MethodPut, "/user"},
+				{http.MethodPatch, "/user/123456789"},
+				{http.MethodDelete, "/user/123456789"},
+				{http.MethodPost, "/user/123456789/unlock"},
+			} {
+				w := serveUser(t, mockUsecase, auth, route.method, route.path, `{"email":"attacker@info.com"}`)
+				assert.Equal(t, http.StatusForbidden, w.Code, route.method+" "+route.path)
+			}
+			assert.Empty(t, mockUsecase.Calls)
+		})
+	}
+}
+
+func TestPatchHandler(t *testing.T) {
+	admin := &entity.User{ID: "admin", Role: entity.USER_ROLE_ADMIN}
+
+	t.Run("success", func(t *testing.T) {
+		user := TestUser(t)
+		user.Gender = "male"
+		user.Role = entity.USER_ROLE_USER
+		user.BirthDate = time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)
+
+		patched := *user
+		patched.Phone = "+998907654321"
+		patched.Gender = "female"
+		patched.BirthDate = time.Date(2001, 2, 3, 0, 0, 0, 0, time.UTC)
+
+		mockUsecase := new(mocks.UserUsecase)
+		mockUsecase.On("Find", mock.Anything, user.ID).Return(user, nil).Once()
+		mockUsecase.On("Patch", mock.Anything, user.ID, mock.MatchedBy(func(p *entity.UserPatch) bool {
+			return p.Phone != nil && *p.Phone == patched.Phone && p.Gender != nil && *p.Gender == patched.Gender &&
+				p.BirthDate != nil && p.BirthDate.Equal(patched.BirthDate)
+		})).Return(&patched, nil).Once()
+
+		w := serveUser(t, mockUsecase, testAuth(admin, nil, nil), http.MethodPatch, "/user/"+user.ID,
+			`{"phone":"+998907654321","gender":"female","birth_date":"2001-02-03"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"phone":"+998907654321"`)
+		assert.Contains(t, w.Body.String(), `"gender":"female"`)
+		assert.Contains(t, w.Body.String(), `"birth_date":"2001-02-03T00:00:00Z"`)
+		mockUsecase.AssertExpectations(t)
+	})
+}
+
+func TestManageHigherPrivilegedUser(t *testing.T) {
+	writer := &entity.User{ID: "writer", Role: entity.USER_ROLE_USER, Permissions: []string{entity.PERMISSION_USER_WRITE}}
+	target := TestUser(t)
+	target.Gender = "male"
+	target.Role = entity.USER_ROLE_ADMIN
+	target.BirthDate = time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	updateBody := func(email string) string {
+		return `{"id":"` + target.ID + `","email":"` + email + `","phone":"` + target.Phone + `","gender":"male",` +
+			`"first_name":"` + target.FirstName + `","last_name":"` + target.LastName + `","birth_date":"2000-01-02"}`
+	}
+
+	t.Run("error-patch-status", func(t *testing.T) {
+		mockUsecase := new(mocks.UserUsecase)
+		mockUsecase.On("Find", mock.Anything, target.ID).Return(target, nil).Once()
+
+		w := serveUser(t, mockUsecase, testAuth(writer, nil, nil), http.MethodPatch, "/user/"+target.ID, `{"status":"suspended"}`)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockUsecase.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error-patch-email", func(t *testing.T) {
+		mockUsecase := new(mocks.UserUsecase)
+		mockUsecase.On("Find", mock.Anything, target.ID).Return(target, nil).Once()
+
+		w := serveUser(t, mockUsecase, testAuth(writer, nil, nil), http.MethodPatch, "/user/"+target.ID, `{"email":"attacker@info.com"}`)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockUsecase.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error-update-email", func(t *testing.T) {
+		mockUsecase := new(mocks.UserUsecase)
+		mockUsecase.On("Find", mock.Anything, target.ID).Return(target, nil).Once()
+
+		w := serveUser(t, mockUsecase, testAuth(writer, nil, nil), http.MethodPut, "/user", updateBody("attacker@info.com"))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockUsecase.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("success-update-profile", func(t *testing.T) {
+		mockUsecase := new(mocks.UserUsecase)
+		mockUsecase.On("Find", mock.Anything, target.ID).Return(target, nil).Once()
+		mockUsecase.On("Update", mock.Anything, mock.AnythingOfType("*entity.User")).Return(nil).Once()
+
+		w := serveUser(t, mockUsecase, testAuth(writer, nil, nil), http.MethodPut, "/user", updateBody(target.Email))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("success-patch-user", func(t *testing.T) {
+		user := TestUser(t)
+		user.Gender = "male"
+		user.Role = entity.USER_ROLE_USER
+
+		mockUsecase := new(mocks.UserUsecase)
+		mockUsecase.On("Find", mock.Anything, user.ID).Return(user, nil).Once()
+		mockUsecase.On("Patch", mock.Anything, user.ID, mock.AnythingOfType("*entity.UserPatch")).Return(user, nil).Once()
+
+		w := serveUser(t, mockUsecase, testAuth(writer, nil, nil), http.MethodPatch, "/user/"+user.ID, `{"status":"suspended"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockUsecase.AssertExpectations(t)
+	})
+}
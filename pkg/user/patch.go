@@ -0,0 +1,156 @@
+package user
+
+import (
+	"encoding/json"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/jsonpatch"
+	"github.com/Jamshid90/go-clean-architecture/pkg/validation"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	CONTENT_TYPE_MERGE_PATCH = "application/merge-patch+json"
+	CONTENT_TYPE_JSON_PATCH  = "application/json-patch+json"
+)
+
+// patchable fields, json names of PatchUserRequest fields and names of the struct fields
+var patchFields = map[string]string{
+	"status":      "Status",
+	"role":        "Role",
+	"permissions": "Permissions",
+	"email":       "Email",
+	"phone":       "Phone",
+	"gender":      "Gender",
+	"first_name":  "FirstName",
+	"last_name":   "LastName",
+	"birth_date":  "BirthDate",
+}
+
+// fields every user has, they can be changed but not removed
+var requiredPatchFields = []string{"status", "role", "email"}
+
+// patch document of the user, patches are applied to the document
+func patchDocument(user *entity.User) (map[string]interface{}, error) {
+	permissions := user.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	doc, err := jsonpatch.Document(&PatchUserRequest{
+		Status:      user.Status,
+		Role:        user.Role,
+		Permissions: permissions,
+		Email:       user.Email,
+		Phone:       user.Phone,
+		Gender:      user.Gender,
+		FirstName:   user.FirstName,
+		LastName:    user.LastName,
+		BirthDate:   user.BirthDate.Format("2006-01-02"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return doc.(map[string]interface{}), nil
+}
+
+// new user patch compares the patched document with the document of the user, only the changed
+// fields are validated and make the patch. Fields out of the document can not be patched.
+func newUserPatch(doc map[string]interface{}, patched interface{}) (*entity.UserPatch, error) {
+	errValidation := errors.NewErrValidation()
+
+	patchedObject, ok := patched.(map[string]interface{})
+	if !ok {
+		errValidation.Errors["patch"] = "patched user must be an object"
+		return nil, errValidation
+	}
+
+	for name := range patchedObject {
+		if _, ok := patchFields[name]; !ok {
+			errValidation.Errors[name] = name + " can not be patched"
+		}
+	}
+	if len(errValidation.Errors) > 0 {
+		return nil, errValidation
+	}
+
+	// removed fields are null, so they are decoded as empty
+	var (
+		changed = map[string]interface{}{}
+		fields  []string
+	)
+	for name, field := range patchFields {
+		if !reflect.DeepEqual(doc[name], patchedObject[name]) {
+			changed[name] = patchedObject[name]
+			fields = append(fields, field)
+		}
+	}
+
+	for _, name := range requiredPatchFields {
+		if value, ok := changed[name]; ok && value == nil {
+			errValidation.Errors[name] = name + " can not be removed"
+		}
+	}
+	if len(errValidation.Errors) > 0 {
+		return nil, errValidation
+	}
+
+	userPatch := entity.UserPatch{}
+	if len(changed) == 0 {
+		return &userPatch, nil
+	}
+
+	data, err := json.Marshal(changed)
+	if err != nil {
+		return nil, err
+	}
+
+	var patchRequest PatchUserRequest
+	if err := json.Unmarshal(data, &patchRequest); err != nil {
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+			name := strings.Split(typeErr.Field, ".")[0]
+			errValidation.Errors[name] = name + " must be " + typeErr.Type.String()
+			return nil, errValidation
+		}
+		return nil, err
+	}
+
+	if err := validation.ValidatorPartial(&patchRequest, fields...); err != nil {
+		return nil, err
+	}
+
+	for name := range changed {
+		switch name {
+		case "status":
+			userPatch.Status = &patchRequest.Status
+		case "role":
+			userPatch.Role = &patchRequest.Role
+		case "permissions":
+			permissions := patchRequest.Permissions
+			if permissions == nil {
+				permissions = []string{}
+			}
+			userPatch.Permissions = &permissions
+		case "email":
+			userPatch.Email = &patchRequest.Email
+		case "phone":
+			userPatch.Phone = &patchRequest.Phone
+		case "gender":
+			userPatch.Gender = &patchRequest.Gender
+		case "first_name":
+			userPatch.FirstName = &patchRequest.FirstName
+		case "last_name":
+			userPatch.LastName = &patchRequest.LastName
+		case "birth_date":
+			birthDate, err := time.Parse("2006-01-02", patchRequest.BirthDate)
+			if err != nil {
+				return nil, err
+			}
+			userPatch.BirthDate = &birthDate
+		}
+	}
+
+	return &userPatch, nil
+}
@@ -0,0 +1,103 @@
+package user
+
+import (
+	"encoding/json"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	apperrors "github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/jsonpatch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestNewUserPatch(t *testing.T) {
+	user := TestUser(t)
+	user.Gender = "male"
+	user.Role = entity.USER_ROLE_USER
+	user.Permissions = []string{entity.PERMISSION_USER_READ}
+	user.BirthDate = time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	doc, err := patchDocument(user)
+	require.NoError(t, err)
+
+	mergePatch := func(t *testing.T, data string) interface{} {
+		var patch interface{}
+		require.NoError(t, json.Unmarshal([]byte(data), &patch))
+		return jsonpatch.MergePatch(doc, patch)
+	}
+
+	t.Run("success-merge-patch", func(t *testing.T) {
+		userPatch, err := newUserPatch(doc, mergePatch(t, `{"first_name":"Patched","last_name":"Qwerty","birth_date":"2001-02-03","permissions":null}`))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Patched", *userPatch.FirstName)
+		assert.Nil(t, userPatch.LastName, "unchanged field is not patched")
+		assert.Equal(t, time.Date(2001, 2, 3, 0, 0, 0, 0, time.UTC), *userPatch.BirthDate)
+		assert.Equal(t, []string{}, *userPatch.Permissions)
+		assert.Nil(t, userPatch.Email)
+		assert.Nil(t, userPatch.Status)
+	})
+
+	t.Run("success-json-patch", func(t *testing.T) {
+		var operations []jsonpatch.Operation
+		require.NoError(t, json.Unmarshal([]byte(`[
+			{"op":"test","path":"/email","value":"user@inifo.com"},
+			{"op":"replace","path":"/email","value":"patched@info.com"},
+			{"op":"add","path":"/permissions/-","value":"user:write"}
+		]`), &operations))
+
+		patched, err := jsonpatch.Apply(doc, operations)
+		require.NoError(t, err)
+
+		userPatch, err := newUserPatch(doc, patched)
+		assert.NoError(t, err)
+		assert.Equal(t, "patched@info.com", *userPatch.Email)
+		assert.Equal(t, []string{entity.PERMISSION_USER_READ, entity.PERMISSION_USER_WRITE}, *userPatch.Permissions)
+	})
+
+	t.Run("success-empty", func(t *testing.T) {
+		userPatch, err := newUserPatch(doc, mergePatch(t, `{}`))
+
+		assert.NoError(t, err)
+		assert.True(t, userPatch.IsEmpty())
+	})
+
+	t.Run("error-json-patch-remove-role", func(t *testing.T) {
+		patched, err := jsonpatch.Apply(doc, []jsonpatch.Operation{{Op: "remove", Path: "/role"}})
+		require.NoError(t, err)
+
+		_, err = newUserPatch(doc, patched)
+
+		errValidation, ok := err.(*apperrors.ErrValidation)
+		if assert.True(t, ok) {
+			assert.Equal(t, "role can not be removed", errValidation.Errors["role"])
+		}
+	})
+
+	t.Run("error-validation", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			patch string
+			field string
+		}{
+			"unknown-field":   {`{"password":"secret"}`, "password"},
+			"read-only-field": {`{"id":"987654321"}`, "id"},
+			"removed-field":   {`{"first_name":null}`, "first_name"},
+			"invalid-value":   {`{"first_name":"U","gender":"other"}`, "gender"},
+			"invalid-type":    {`{"status":1}`, "status"},
+			"removed-role":    {`{"role":null}`, "role"},
+			"removed-status":  {`{"status":null}`, "status"},
+			"removed-email":   {`{"email":null}`, "email"},
+			"not-object":      {`["first_name"]`, "patch"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := newUserPatch(doc, mergePatch(t, tc.patch))
+
+				errValidation, ok := err.(*apperrors.ErrValidation)
+				if assert.True(t, ok) {
+					assert.Contains(t, errValidation.Errors, tc.field)
+				}
+			})
+		}
+	})
+}
@@ -7,8 +7,13 @@ import (
 	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"strconv"
+	"strings"
+	"time"
 )
 
+const userColumns = `id, type, status, role, permissions, email, phone, phone_verified_at, gender, first_name, last_name, password, birth_date, created_at, updated_at`
+
 type pgxUserRepository struct {
 	db *pgxpool.Pool
 }
@@ -17,14 +22,38 @@ func NewPgxUserRepository(dbpool *pgxpool.Pool) entity.UserRepository {
 	return &pgxUserRepository{db: dbpool}
 }
 
+// scan user row in order of user columns
+func scanUser(row pgx.Row, user *entity.User) error {
+	return row.Scan(
+		&user.ID,
+		&user.Type,
+		&user.Status,
+		&user.Role,
+		&user.Permissions,
+		&user.Email,
+		&user.Phone,
+		&user.PhoneVerifiedAt,
+		&user.Gender,
+		&user.FirstName,
+		&user.LastName,
+		&user.Password,
+		&user.BirthDate,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+}
+
 func (p *pgxUserRepository) Store(ctx context.Context, m *entity.User) error {
-	_, err := p.db.Exec(ctx, `INSERT INTO "user"(
-		id, status, email, phone, gender, first_name, last_name, password, birth_date, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+	_, err := p.db.Exec(ctx, `INSERT INTO "user"(`+userColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
 		m.ID,
+		m.Type,
 		m.Status,
+		m.Role,
+		m.Permissions,
 		m.Email,
 		m.Phone,
+		m.PhoneVerifiedAt,
 		m.Gender,
 		m.FirstName,
 		m.LastName,
@@ -43,11 +72,14 @@ func (p *pgxUserRepository) Store(ctx context.Context, m *entity.User) error {
 
 func (p *pgxUserRepository) Update(ctx context.Context, m *entity.User) error {
 	_, err := p.db.Exec(ctx, `UPDATE "user" 
-	    SET status=$1, email=$2, phone=$3, gender=$4, first_name=$5, last_name=$6, birth_date=$7 updated_at=$8
-	    WHERE id=$6`,
+	    SET status=$1, role=$2, permissions=$3, email=$4, phone=$5, phone_verified_at=$6, gender=$7, first_name=$8, last_name=$9, birth_date=$10, updated_at=$11
+	    WHERE id=$12`,
 		m.Status,
+		m.Role,
+		m.Permissions,
 		m.Email,
 		m.Phone,
+		m.PhoneVerifiedAt,
 		m.Gender,
 		m.FirstName,
 		m.LastName,
@@ -57,9 +89,82 @@ func (p *pgxUserRepository) Update(ctx context.Context, m *entity.User) error {
 	)
 
 	if err != nil {
-		return errors.ErrRepository{Err: fmt.Errorf("error during store to user repository: %w", err)}
+		return errors.ErrRepository{Err: fmt.Errorf("error during update to user repository: %w", err)}
+	}
+
+	return nil
+}
+
+// patch updates only the columns of the patch, a changed phone is not verified
+func (p *pgxUserRepository) Patch(ctx context.Context, id string, patch *entity.UserPatch) error {
+	var (
+		set  []string
+		args []interface{}
+	)
+	column := func(name string, v interface{}) {
+		args = append(args, v)
+		set = append(set, name+"=$"+strconv.Itoa(len(args)))
+	}
+
+	if patch.Status != nil {
+		column("status", *patch.Status)
+	}
+	if patch.Role != nil {
+		column("role", *patch.Role)
+	}
+	if patch.Permissions != nil {
+		column("permissions", *patch.Permissions)
+	}
+	if patch.Email != nil {
+		column("email", *patch.Email)
+	}
+	if patch.Phone != nil {
+		column("phone", *patch.Phone)
+		set = append(set, "phone_verified_at=NULL")
+	}
+	if patch.Gender != nil {
+		column("gender", *patch.Gender)
+	}
+	if patch.FirstName != nil {
+		column("first_name", *patch.FirstName)
+	}
+	if patch.LastName != nil {
+		column("last_name", *patch.LastName)
+	}
+	if patch.BirthDate != nil {
+		column("birth_date", *patch.BirthDate)
+	}
+	column("updated_at", patch.UpdatedAt)
+
+	args = append(args, id)
+	_, err := p.db.Exec(ctx, `UPDATE "user" SET `+strings.Join(set, ", ")+` WHERE id=$`+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during patch to user repository: %w", err)}
 	}
+	return nil
+}
 
+func (p *pgxUserRepository) UpdateStatus(ctx context.Context, id, status string) error {
+	_, err := p.db.Exec(ctx, `UPDATE "user" SET status=$1, updated_at=$2 WHERE id=$3`, status, time.Now().UTC(), id)
+	if err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during update status to user repository: %w", err)}
+	}
+	return nil
+}
+
+func (p *pgxUserRepository) UpdatePassword(ctx context.Context, id, password string) error {
+	_, err := p.db.Exec(ctx, `UPDATE "user" SET password=$1, updated_at=$2 WHERE id=$3`, password, time.Now().UTC(), id)
+	if err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during update password to user repository: %w", err)}
+	}
+	return nil
+}
+
+func (p *pgxUserRepository) VerifyPhone(ctx context.Context, id string, verifiedAt time.Time) error {
+	_, err := p.db.Exec(ctx, `UPDATE "user" SET phone_verified_at=$1, updated_at=$1 WHERE id=$2`, verifiedAt, id)
+	if err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during verify phone to user repository: %w", err)}
+	}
 	return nil
 }
 
@@ -72,23 +177,11 @@ func (p *pgxUserRepository) Delete(ctx context.Context, id string) error {
 
 func (p *pgxUserRepository) Find(ctx context.Context, id string) (*entity.User, error) {
 	user := entity.User{}
-	row := p.db.QueryRow(ctx, `SELECT id, status, email, phone, gender, first_name, last_name, password, birth_date, created_at, updated_at 
+	row := p.db.QueryRow(ctx, `SELECT `+userColumns+`
                                    FROM "user" 
                                    WHERE id=$1`, id)
 
-	err := row.Scan(
-		&user.ID,
-		&user.Status,
-		&user.Email,
-		&user.Phone,
-		&user.Gender,
-		&user.FirstName,
-		&user.LastName,
-		&user.Password,
-		&user.BirthDate,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	err := scanUser(row, &user)
 
 	if err == pgx.ErrNoRows {
 		return nil, errors.NewErrNotFound("user")
@@ -101,56 +194,173 @@ func (p *pgxUserRepository) Find(ctx context.Context, id string) (*entity.User,
 	return &user, nil
 }
 
-func (p *pgxUserRepository) FindAll(ctx context.Context, limit, offset int, params map[string]interface{}) ([]*entity.User, error) {
+// like pattern escapes, the value of the filter is matched literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// user filter where builds conditions of the filter, values are passed as arguments
+func userFilterWhere(filter *entity.UserFilter, arg func(v interface{}) string) []string {
+	var where []string
+
+	if len(filter.Status) > 0 {
+		where = append(where, "status = ANY("+arg(filter.Status)+")")
+	}
+	// "type" limits the users to human or service accounts
+	if filter.Type != "" {
+		where = append(where, "type = "+arg(filter.Type))
+	}
+	if filter.Gender != "" {
+		where = append(where, "gender = "+arg(filter.Gender))
+	}
+	if filter.EmailPrefix != "" {
+		where = append(where, "email ILIKE "+arg(likeEscaper.Replace(filter.EmailPrefix)+"%"))
+	}
+	if filter.Query != "" {
+		q := arg("%" + likeEscaper.Replace(filter.Query) + "%")
+		where = append(where, "(first_name ILIKE "+q+" OR last_name ILIKE "+q+" OR email ILIKE "+q+")")
+	}
+	if filter.CreatedFrom != nil {
+		where = append(where, "created_at >= "+arg(*filter.CreatedFrom))
+	}
+	if filter.CreatedTo != nil {
+		where = append(where, "created_at <= "+arg(*filter.CreatedTo))
+	}
+	if filter.BirthDateFrom != nil {
+		where = append(where, "birth_date >= "+arg(*filter.BirthDateFrom))
+	}
+	if filter.BirthDateTo != nil {
+		where = append(where, "birth_date <= "+arg(*filter.BirthDateTo))
+	}
+
+	return where
+}
+
+// user filter query builds where, order by, limit and offset of the filter, values are passed as
+// arguments and sort columns come from the whitelist only. Pages of the cursor are found by
+// (created_at, id) of the cursor, backward pages are found in reverse order.
+func userFilterQuery(filter *entity.UserFilter) (string, []interface{}) {
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	where := userFilterWhere(filter, arg)
+
+	var sort []entity.UserSort
+	for _, s := range filter.Sort {
+		if userSortColumns[s.Field] {
+			sort = append(sort, s)
+		}
+	}
+	if len(sort) == 0 {
+		sort = []entity.UserSort{{Field: entity.USER_SORT_CREATED_AT, Desc: true}}
+	}
+
+	keyset := filter.Cursor != nil && filter.IsKeyset()
+	backward := false
+	if keyset {
+		backward = filter.Cursor.Backward
+		operator := ">"
+		if filter.IsDesc() != backward {
+			operator = "<"
+		}
+		where = append(where, "(created_at, id) "+operator+" ("+arg(filter.Cursor.CreatedAt)+", "+arg(filter.Cursor.ID)+")")
+	}
+
+	// id breaks ties in direction of the last field, so pages are stable
+	var (
+		orderBy []string
+		desc    bool
+	)
+	for _, s := range sort {
+		desc = s.Desc != backward
+		if desc {
+			orderBy = append(orderBy, s.Field+" DESC")
+		} else {
+			orderBy = append(orderBy, s.Field+" ASC")
+		}
+	}
+	if desc {
+		orderBy = append(orderBy, "id DESC")
+	} else {
+		orderBy = append(orderBy, "id ASC")
+	}
+
+	query := ""
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY " + strings.Join(orderBy, ", ")
+	query += " LIMIT " + arg(filter.Limit)
+	if !keyset {
+		query += " OFFSET " + arg(filter.Offset)
+	}
+
+	return query, args
+}
+
+// sort fields of the filter are the columns
+var userSortColumns = func() map[string]bool {
+	columns := map[string]bool{}
+	for _, field := range entity.UserSortFields {
+		columns[field] = true
+	}
+	return columns
+}()
+
+// find all, users of backward pages are returned in order of the listing too
+func (p *pgxUserRepository) FindAll(ctx context.Context, filter *entity.UserFilter) ([]*entity.User, error) {
 	var items []*entity.User
-	rows, err := p.db.Query(ctx, `SELECT id, status, email, phone, gender, first_name, last_name, password, birth_date, created_at, updated_at
-                                       FROM "user" 
- 								       LIMIT $1 
-									   OFFSET $2`, limit, offset)
+	query, args := userFilterQuery(filter)
+	rows, err := p.db.Query(ctx, `SELECT `+userColumns+` FROM "user"`+query, args...)
 	if err != nil {
 		return items, errors.ErrRepository{Err: fmt.Errorf("error during find all to user repository: %w", err)}
 	}
+	defer rows.Close()
+
 	for rows.Next() {
 		user := entity.User{}
-		err := rows.Scan(
-			&user.ID,
-			&user.Status,
-			&user.Email,
-			&user.Phone,
-			&user.Gender,
-			&user.FirstName,
-			&user.LastName,
-			&user.Password,
-			&user.BirthDate,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		)
-		if err != nil {
+		if err := scanUser(rows, &user); err != nil {
 			return items, errors.ErrRepository{Err: fmt.Errorf("error during find all to user repository: %w", err)}
 		}
 		items = append(items, &user)
 	}
+
+	if filter.Cursor != nil && filter.Cursor.Backward && filter.IsKeyset() {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
 	return items, nil
 }
 
+// count users of the filter, the cursor, limit and offset are ignored
+func (p *pgxUserRepository) Count(ctx context.Context, filter *entity.UserFilter) (int, error) {
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	query := `SELECT count(*) FROM "user"`
+	if where := userFilterWhere(filter, arg); len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var count int
+	if err := p.db.QueryRow(ctx, query, args...).Scan(&count); err != nil {
+		return 0, errors.ErrRepository{Err: fmt.Errorf("error during count to user repository: %w", err)}
+	}
+	return count, nil
+}
+
 func (p *pgxUserRepository) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
 	user := entity.User{}
-	row := p.db.QueryRow(ctx, `SELECT id, status, email, phone, gender, first_name, last_name, password, birth_date, created_at, updated_at
+	row := p.db.QueryRow(ctx, `SELECT `+userColumns+`
  							        FROM "user"
   							        WHERE email=$1`, email)
-	err := row.Scan(
-		&user.ID,
-		&user.Status,
-		&user.Email,
-		&user.Phone,
-		&user.Gender,
-		&user.FirstName,
-		&user.LastName,
-		&user.Password,
-		&user.BirthDate,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+
+	err := scanUser(row, &user)
 
 	if err == pgx.ErrNoRows {
 		return nil, errors.NewErrNotFound("user")
@@ -162,3 +372,23 @@ func (p *pgxUserRepository) FindByEmail(ctx context.Context, email string) (*ent
 
 	return &user, nil
 }
+
+// find by phone, only the user who verified the phone is found
+func (p *pgxUserRepository) FindByPhone(ctx context.Context, phone string) (*entity.User, error) {
+	user := entity.User{}
+	row := p.db.QueryRow(ctx, `SELECT `+userColumns+`
+ 							        FROM "user"
+  							        WHERE phone=$1 AND phone_verified_at IS NOT NULL`, phone)
+
+	err := scanUser(row, &user)
+
+	if err == pgx.ErrNoRows {
+		return nil, errors.NewErrNotFound("user")
+	}
+
+	if err != nil {
+		return nil, errors.ErrRepository{Err: fmt.Errorf("error during find by phone to user repository: %w", err)}
+	}
+
+	return &user, nil
+}
@@ -1,24 +1,42 @@
 package user
 
 type CreateUserRequest struct {
-	Status          string `json:"status" validate:"required"`
-	Email           string `json:"email" validate:"required,email"`
-	Phone           string `json:"phone" validate:"required"`
-	Gender          string `json:"gender" validate:"required,eq=male|eq=female"`
-	FirstName       string `json:"first_name" validate:"required,min=2,max=50"`
-	LastName        string `json:"last_name" validate:"required,min=2,max=50"`
-	BirthDate       string `json:"birth_date" validate:"required,datetime=2006-01-02"`
-	Password        string `json:"password" validate:"required,min=8"`
-	ConfirmPassword string `json:"confirm_password" validate:"required,min=8,eqfield=Password"`
+	Status          string   `json:"status" validate:"required,oneof=pending active suspended deactive"`
+	Role            string   `json:"role" validate:"omitempty,oneof=admin user"`
+	Permissions     []string `json:"permissions" validate:"omitempty,dive,permission"`
+	Email           string   `json:"email" validate:"required,email"`
+	Phone           string   `json:"phone" validate:"required"`
+	Gender          string   `json:"gender" validate:"required,eq=male|eq=female"`
+	FirstName       string   `json:"first_name" validate:"required,min=2,max=50"`
+	LastName        string   `json:"last_name" validate:"required,min=2,max=50"`
+	BirthDate       string   `json:"birth_date" validate:"required,datetime=2006-01-02"`
+	Password        string   `json:"password" validate:"required"`
+	ConfirmPassword string   `json:"confirm_password" validate:"required,eqfield=Password"`
 }
 
 type UpdateUserRequest struct {
-	ID        string `json:"id" validate:"required"`
-	Status    string `json:"status" validate:"required"`
-	Email     string `json:"email" validate:"required,email"`
-	Phone     string `json:"phone" validate:"required"`
-	Gender    string `json:"gender" validate:"required,eq=male|eq=female"`
-	FirstName string `json:"first_name" validate:"required,min=2,max=50"`
-	LastName  string `json:"last_name" validate:"required,min=2,max=50"`
-	BirthDate string `json:"birth_date" validate:"required,datetime=2006-01-02"`
+	ID          string   `json:"id" validate:"required"`
+	Status      string   `json:"status" validate:"omitempty,oneof=pending active suspended deactive"`
+	Role        string   `json:"role" validate:"omitempty,oneof=admin user"`
+	Permissions []string `json:"permissions" validate:"omitempty,dive,permission"`
+	Email       string   `json:"email" validate:"required,email"`
+	Phone       string   `json:"phone" validate:"required"`
+	Gender      string   `json:"gender" validate:"required,eq=male|eq=female"`
+	FirstName   string   `json:"first_name" validate:"required,min=2,max=50"`
+	LastName    string   `json:"last_name" validate:"required,min=2,max=50"`
+	BirthDate   string   `json:"birth_date" validate:"required,datetime=2006-01-02"`
+}
+
+// PatchUserRequest is the patchable document of the user, only the changed fields are validated
+// and removed fields are validated as empty, so required fields can not be removed
+type PatchUserRequest struct {
+	Status      string   `json:"status" validate:"required,oneof=pending active suspended deactive"`
+	Role        string   `json:"role" validate:"required,oneof=admin user"`
+	Permissions []string `json:"permissions" validate:"omitempty,dive,permission"`
+	Email       string   `json:"email" validate:"required,email"`
+	Phone       string   `json:"phone" validate:"required"`
+	Gender      string   `json:"gender" validate:"required,eq=male|eq=female"`
+	FirstName   string   `json:"first_name" validate:"required,min=2,max=50"`
+	LastName    string   `json:"last_name" validate:"required,min=2,max=50"`
+	BirthDate   string   `json:"birth_date" validate:"required,datetime=2006-01-02"`
 }
@@ -2,6 +2,10 @@ package user
 
 import (
 	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
+	"github.com/Jamshid90/go-clean-architecture/pkg/passwordpolicy"
+	"github.com/Jamshid90/go-clean-architecture/pkg/revocation"
+	"golang.org/x/crypto/bcrypt"
 	"testing"
 	"time"
 )
@@ -11,7 +15,7 @@ func TestUser(t *testing.T) *entity.User {
 	return &entity.User{
 		ID:        "123456789",
 		Email:     "user@inifo.com",
-		Phone:     "000000000000",
+		Phone:     "+998901234567",
 		Status:    "active",
 		FirstName: "User",
 		LastName:  "Qwerty",
@@ -23,3 +27,26 @@ func TestUserEmpty(t *testing.T) *entity.User {
 	t.Helper()
 	return &entity.User{}
 }
+
+// TestPasswordHasher returns fast bcrypt hasher for tests
+func TestPasswordHasher(t *testing.T) hash.PasswordHasher {
+	t.Helper()
+	return hash.NewBcryptHasher(bcrypt.MinCost)
+}
+
+// TestPasswordPolicy returns policy with minimal length and personal data check
+func TestPasswordPolicy(t *testing.T) entity.PasswordPolicy {
+	t.Helper()
+	return &passwordpolicy.Policy{
+		MinLength:      8,
+		MaxLength:      128,
+		ForbidPersonal: true,
+	}
+}
+
+// TestRevocationUsecase returns token revocation usecase with memory repository
+func TestRevocationUsecase(t *testing.T) entity.TokenRevocationUsecase {
+	t.Helper()
+	revocationUsecase := revocation.NewTokenRevocationUsecase(revocation.NewTokenRevocationRepositoryMemory(), time.Hour, time.Second*2)
+	return &revocationUsecase
+}
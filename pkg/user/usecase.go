@@ -2,23 +2,41 @@ package user
 
 import (
 	"context"
+	"fmt"
 	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
 	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
 	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
+	"github.com/Jamshid90/go-clean-architecture/pkg/phone"
 	"github.com/Jamshid90/go-clean-architecture/pkg/rand"
+	"reflect"
 	"time"
 )
 
 type userUsecase struct {
-	userRepo       entity.UserRepository
-	contextTimeout time.Duration
+	userRepo                 entity.UserRepository
+	sessionUsecase           entity.SessionUsecase
+	emailVerificationUsecase entity.EmailVerificationUsecase
+	revocationUsecase        entity.TokenRevocationUsecase
+	apiKeyRepo               entity.APIKeyRepository
+	oauthRefreshTokenRepo    entity.OAuthRefreshTokenRepository
+	passwordHasher           hash.PasswordHasher
+	passwordPolicy           entity.PasswordPolicy
+	contextTimeout           time.Duration
 }
 
-// new user usecase
-func NewUserUsecase(repo entity.UserRepository, timeout time.Duration) userUsecase {
+// new user usecase, changed emails are verified with the email verification usecase,
+// tokens, sessions and api keys of deleted users are removed with the user
+func NewUserUsecase(repo entity.UserRepository, sessionUsecase entity.SessionUsecase, emailVerificationUsecase entity.EmailVerificationUsecase, revocationUsecase entity.TokenRevocationUsecase, apiKeyRepo entity.APIKeyRepository, oauthRefreshTokenRepo entity.OAuthRefreshTokenRepository, passwordHasher hash.PasswordHasher, passwordPolicy entity.PasswordPolicy, timeout time.Duration) userUsecase {
 	return userUsecase{
-		userRepo:       repo,
-		contextTimeout: timeout,
+		userRepo:                 repo,
+		sessionUsecase:           sessionUsecase,
+		emailVerificationUsecase: emailVerificationUsecase,
+		revocationUsecase:        revocationUsecase,
+		apiKeyRepo:               apiKeyRepo,
+		oauthRefreshTokenRepo:    oauthRefreshTokenRepo,
+		passwordHasher:           passwordHasher,
+		passwordPolicy:           passwordPolicy,
+		contextTimeout:           timeout,
 	}
 }
 
@@ -44,16 +62,46 @@ func (u *userUsecase) BeforeStore(ctx context.Context, m *entity.User) error {
 	m.CreatedAt = time.Now().UTC()
 	m.UpdatedAt = m.CreatedAt
 
-	hashPassword, err := hash.HashPassword(m.Password)
-	if err != nil {
-		return err
+	if m.Type == "" {
+		m.Type = entity.USER_TYPE_HUMAN
+	}
+
+	if m.Role == "" {
+		m.Role = entity.USER_ROLE_USER
+	}
+
+	if m.Permissions == nil {
+		m.Permissions = []string{}
+	}
+
+	if _, ok := entity.UserStatusTransitions[m.Status]; !ok {
+		errValidation := errors.NewErrValidation()
+		errValidation.Errors["status"] = "status is unknown"
+		return errValidation
+	}
+
+	// users signed up with external identity may have neither phone nor password
+	if m.Phone != "" {
+		number, err := normalizePhone(m.Phone)
+		if err != nil {
+			return err
+		}
+		m.Phone = number
+	}
+
+	if m.Password != "" {
+		hashPassword, err := u.passwordHasher.Hash(m.Password)
+		if err != nil {
+			return err
+		}
+		m.Password = hashPassword
 	}
-	m.Password = hashPassword
 
-	m.ID, err = u.NewID(ctx)
+	id, err := u.NewID(ctx)
 	if err != nil {
 		return err
 	}
+	m.ID = id
 
 	return nil
 }
@@ -63,14 +111,23 @@ func (u *userUsecase) Store(ctx context.Context, m *entity.User) error {
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
 
-	user, err := u.userRepo.FindByEmail(ctx, m.Email)
+	// service accounts have no email
+	if m.Email != "" {
+		user, err := u.userRepo.FindByEmail(ctx, m.Email)
 
-	if err != nil && err.Error() != errors.NewErrNotFound("user").Error() {
-		return err
+		if err != nil && err.Error() != errors.NewErrNotFound("user").Error() {
+			return err
+		}
+
+		if user != nil {
+			return errors.NewErrConflict("email")
+		}
 	}
 
-	if user != nil {
-		return errors.NewErrConflict("email")
+	if m.Password != "" {
+		if err := u.passwordPolicy.Validate(m.Password, m); err != nil {
+			return err
+		}
 	}
 
 	if err := u.BeforeStore(ctx, m); err != nil {
@@ -90,15 +147,301 @@ func (u *userUsecase) Update(ctx context.Context, m *entity.User) error {
 		return err
 	}
 
-	if userByEmail, _ := u.userRepo.FindByEmail(ctx, m.Email); userByEmail != nil && userByEmail.ID != user.ID {
-		return errors.NewErrConflict("email")
+	if m.Email != "" {
+		userByEmail, err := u.userRepo.FindByEmail(ctx, m.Email)
+
+		if err != nil && err.Error() != errors.NewErrNotFound("user").Error() {
+			return err
+		}
+
+		if userByEmail != nil && userByEmail.ID != user.ID {
+			return errors.NewErrConflict("email")
+		}
+	}
+
+	// the type can not be changed, keep the current status, role and permissions when they are not given
+	m.Type = user.Type
+	if m.Status == "" {
+		m.Status = user.Status
+	}
+	if m.Role == "" {
+		m.Role = user.Role
+	}
+	if m.Permissions == nil {
+		m.Permissions = user.Permissions
+	}
+
+	if !user.CanChangeStatus(m.Status) {
+		return errStatusTransition(user.Status, m.Status)
 	}
+
+	// a new email has to be verified again, disabled users keep their status
+	emailChanged := m.Email != user.Email
+	if emailChanged && m.Status == entity.USER_STATUS_ACTIVE {
+		m.Status = entity.USER_STATUS_PENDING
+	}
+
+	if m.Phone != "" {
+		number, err := normalizePhone(m.Phone)
+		if err != nil {
+			return err
+		}
+		m.Phone = number
+	}
+
+	// a new phone has to be verified again
+	if m.Phone == user.Phone {
+		m.PhoneVerifiedAt = user.PhoneVerifiedAt
+	} else {
+		m.PhoneVerifiedAt = nil
+	}
+
 	m.CreatedAt = user.CreatedAt
 	m.UpdatedAt = time.Now().UTC()
-	return u.userRepo.Update(ctx, m)
+	if err := u.userRepo.Update(ctx, m); err != nil {
+		return err
+	}
+
+	if err := u.afterStatusChange(ctx, user, m.Status); err != nil {
+		return err
+	}
+
+	if emailChanged {
+		return u.afterEmailChange(ctx, m)
+	}
+	return nil
+}
+
+// patch changes only the fields of the patch, fields equal to the current ones are dropped from the patch.
+// The same rules as on update apply to the changed fields.
+func (u *userUsecase) Patch(ctx context.Context, id string, patch *entity.UserPatch) (*entity.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	user, err := u.userRepo.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if patch.Phone != nil {
+		number, err := normalizePhone(*patch.Phone)
+		if err != nil {
+			return nil, err
+		}
+		patch.Phone = &number
+	}
+
+	if patch.Status != nil && *patch.Status == user.Status {
+		patch.Status = nil
+	}
+	if patch.Role != nil && *patch.Role == user.Role {
+		patch.Role = nil
+	}
+	if patch.Permissions != nil && (len(*patch.Permissions) == 0 && len(user.Permissions) == 0 || reflect.DeepEqual(*patch.Permissions, user.Permissions)) {
+		patch.Permissions = nil
+	}
+	if patch.Email != nil && *patch.Email == user.Email {
+		patch.Email = nil
+	}
+	if patch.Phone != nil && *patch.Phone == user.Phone {
+		patch.Phone = nil
+	}
+	if patch.Gender != nil && *patch.Gender == user.Gender {
+		patch.Gender = nil
+	}
+	if patch.FirstName != nil && *patch.FirstName == user.FirstName {
+		patch.FirstName = nil
+	}
+	if patch.LastName != nil && *patch.LastName == user.LastName {
+		patch.LastName = nil
+	}
+	if patch.BirthDate != nil && patch.BirthDate.Equal(user.BirthDate) {
+		patch.BirthDate = nil
+	}
+
+	if patch.IsEmpty() {
+		return user, nil
+	}
+
+	// status, role and email can be changed but not removed
+	errValidation := errors.NewErrValidation()
+	for name, value := range map[string]*string{"status": patch.Status, "role": patch.Role, "email": patch.Email} {
+		if value != nil && *value == "" {
+			errValidation.Errors[name] = name + " can not be removed"
+		}
+	}
+	if len(errValidation.Errors) > 0 {
+		return nil, errValidation
+	}
+
+	if patch.Email != nil {
+		if userByEmail, _ := u.userRepo.FindByEmail(ctx, *patch.Email); userByEmail != nil && userByEmail.ID != user.ID {
+			return nil, errors.NewErrConflict("email")
+		}
+	}
+
+	if patch.Status != nil && !user.CanChangeStatus(*patch.Status) {
+		return nil, errStatusTransition(user.Status, *patch.Status)
+	}
+
+	// a new email has to be verified again, disabled users keep their status
+	if patch.Email != nil {
+		status := user.Status
+		if patch.Status != nil {
+			status = *patch.Status
+		}
+		if status == entity.USER_STATUS_ACTIVE {
+			pending := entity.USER_STATUS_PENDING
+			patch.Status = &pending
+		}
+	}
+
+	patch.UpdatedAt = time.Now().UTC()
+	if err := u.userRepo.Patch(ctx, id, patch); err != nil {
+		return nil, err
+	}
+
+	patched := *user
+	patch.Apply(&patched)
+
+	if err := u.afterStatusChange(ctx, user, patched.Status); err != nil {
+		return nil, err
+	}
+
+	if patch.Email != nil {
+		if err := u.afterEmailChange(ctx, &patched); err != nil {
+			return nil, err
+		}
+	}
+	return &patched, nil
+}
+
+// update status, the status must be reachable from the current one
+func (u *userUsecase) UpdateStatus(ctx context.Context, id, status string) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	user, err := u.userRepo.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !user.CanChangeStatus(status) {
+		return errStatusTransition(user.Status, status)
+	}
+
+	if err := u.userRepo.UpdateStatus(ctx, id, status); err != nil {
+		return err
+	}
+
+	return u.afterStatusChange(ctx, user, status)
 }
 
-// delete
+// after status change, a disabled user loses sessions and their refresh tokens, so access tokens of the sessions end too
+func (u *userUsecase) afterStatusChange(ctx context.Context, user *entity.User, status string) error {
+	if user.IsDisabled() || !entity.IsDisabledStatus(status) {
+		return nil
+	}
+
+	return u.sessionUsecase.DeleteByUserId(ctx, user.ID)
+}
+
+// after email change, pending users get the verification of the new email
+func (u *userUsecase) afterEmailChange(ctx context.Context, user *entity.User) error {
+	if user.Status != entity.USER_STATUS_PENDING {
+		return nil
+	}
+
+	return u.emailVerificationUsecase.Send(ctx, user)
+}
+
+// normalize phone to E.164, invalid phone is reported as validation error
+func normalizePhone(number string) (string, error) {
+	normalized, err := phone.Normalize(number)
+	if err != nil {
+		errValidation := errors.NewErrValidation()
+		errValidation.Errors["phone"] = err.Error()
+		return "", errValidation
+	}
+	return normalized, nil
+}
+
+func errStatusTransition(from, to string) error {
+	errValidation := errors.NewErrValidation()
+	errValidation.Errors["status"] = fmt.Sprintf("status can not be changed from %s to %s", from, to)
+	return errValidation
+}
+
+// update password, the password is checked by the policy and hashed before storing
+func (u *userUsecase) UpdatePassword(ctx context.Context, id, password string) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	user, err := u.userRepo.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := u.passwordPolicy.Validate(password, user); err != nil {
+		return err
+	}
+
+	hashPassword, err := u.passwordHasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	if err := u.userRepo.UpdatePassword(ctx, id, hashPassword); err != nil {
+		return err
+	}
+
+	// oauth clients can not refresh grants of the old password, access tokens are revoked with the user
+	return u.oauthRefreshTokenRepo.DeleteByUserId(ctx, id)
+}
+
+// validate password against the policy for the user without changing it
+func (u *userUsecase) ValidatePassword(ctx context.Context, id, password string) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	user, err := u.userRepo.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return u.passwordPolicy.Validate(password, user)
+}
+
+// check password, hash made with outdated algorithm or parameters is replaced after successful check.
+// The error is about storing the new hash only, the result of the check is valid anyway.
+func (u *userUsecase) CheckPassword(ctx context.Context, user *entity.User, password string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	// users without password sign in with external identity only
+	if user.Password == "" || !u.passwordHasher.Check(password, user.Password) {
+		return false, nil
+	}
+
+	if !u.passwordHasher.NeedsRehash(user.Password) {
+		return true, nil
+	}
+
+	hashPassword, err := u.passwordHasher.Hash(password)
+	if err != nil {
+		return true, err
+	}
+
+	if err := u.userRepo.UpdatePassword(ctx, user.ID, hashPassword); err != nil {
+		return true, err
+	}
+
+	user.Password = hashPassword
+	return true, nil
+}
+
+// delete revokes access tokens and removes sessions, api keys and oauth refresh tokens of the user
+// before the user, so a failed delete can be retried and leaves nothing usable behind
 func (u *userUsecase) Delete(ctx context.Context, id string) error {
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
@@ -108,10 +451,26 @@ func (u *userUsecase) Delete(ctx context.Context, id string) error {
 		return err
 	}
 
-	if *existedUser == (entity.User{}) {
+	if existedUser.ID == "" {
 		return errors.NewErrNotFound("user")
 	}
 
+	if err := u.revocationUsecase.RevokeUser(ctx, id); err != nil {
+		return err
+	}
+
+	if err := u.sessionUsecase.DeleteByUserId(ctx, id); err != nil {
+		return err
+	}
+
+	if err := u.apiKeyRepo.DeleteByUserId(ctx, id); err != nil {
+		return err
+	}
+
+	if err := u.oauthRefreshTokenRepo.DeleteByUserId(ctx, id); err != nil {
+		return err
+	}
+
 	return u.userRepo.Delete(ctx, id)
 }
 
@@ -123,12 +482,55 @@ func (u *userUsecase) Find(ctx context.Context, id string) (*entity.User, error)
 	return u.userRepo.Find(ctx, id)
 }
 
-// find all
-func (u *userUsecase) FindAll(ctx context.Context, limit, offset int, params map[string]interface{}) ([]*entity.User, error) {
+// find all, one more user than the limit is found to tell whether there is the next page.
+// Cursors of adjacent pages are set for listings sorted by created_at only.
+func (u *userUsecase) FindAll(ctx context.Context, filter *entity.UserFilter) (*entity.UserPage, error) {
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
 
-	return u.userRepo.FindAll(ctx, limit, offset, params)
+	query := *filter
+	query.Limit++
+	items, err := u.userRepo.FindAll(ctx, &query)
+	if err != nil {
+		return nil, err
+	}
+
+	backward := filter.Cursor != nil && filter.Cursor.Backward
+	more := len(items) > filter.Limit
+	if more {
+		if backward {
+			items = items[len(items)-filter.Limit:]
+		} else {
+			items = items[:filter.Limit]
+		}
+	}
+
+	page := entity.UserPage{Items: items}
+	if filter.IsKeyset() && len(items) > 0 {
+		hasNext, hasPrev := more, filter.Cursor != nil || filter.Offset > 0
+		if backward {
+			hasNext, hasPrev = true, more
+		}
+
+		if hasNext {
+			last := items[len(items)-1]
+			page.Next = &entity.UserCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		}
+		if hasPrev {
+			first := items[0]
+			page.Prev = &entity.UserCursor{CreatedAt: first.CreatedAt, ID: first.ID, Backward: true}
+		}
+	}
+
+	if filter.WithTotal {
+		total, err := u.userRepo.Count(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	return &page, nil
 }
 
 // find by email
@@ -138,3 +540,38 @@ func (u *userUsecase) FindByEmail(ctx context.Context, email string) (*entity.Us
 
 	return u.userRepo.FindByEmail(ctx, email)
 }
+
+// find by phone, the phone is normalized and only the user who verified it is found
+func (u *userUsecase) FindByPhone(ctx context.Context, number string) (*entity.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	number, err := normalizePhone(number)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.userRepo.FindByPhone(ctx, number)
+}
+
+// verify phone marks the current phone of the user as verified, a phone is verified by one user only
+func (u *userUsecase) VerifyPhone(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	user, err := u.userRepo.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	owner, err := u.userRepo.FindByPhone(ctx, user.Phone)
+	if err != nil && err.Error() != errors.NewErrNotFound("user").Error() {
+		return err
+	}
+
+	if owner != nil && owner.ID != user.ID {
+		return errors.NewErrConflict("phone")
+	}
+
+	return u.userRepo.VerifyPhone(ctx, id, time.Now().UTC())
+}
@@ -6,12 +6,38 @@ import (
 	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
 	"github.com/Jamshid90/go-clean-architecture/pkg/entity/mocks"
 	apperrors "github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
 	"testing"
 	"time"
 )
 
+// test env has the user usecase with mocks of its dependencies, revocations are kept in memory
+type testEnv struct {
+	usecase                  userUsecase
+	sessionUsecase           *mocks.SessionUsecase
+	emailVerificationUsecase *mocks.EmailVerificationUsecase
+	revocationUsecase        entity.TokenRevocationUsecase
+	apiKeyRepo               *mocks.APIKeyRepository
+	oauthRefreshTokenRepo    *mocks.OAuthRefreshTokenRepository
+}
+
+func newTestEnv(t *testing.T, userRepo *mocks.UserRepository) *testEnv {
+	t.Helper()
+
+	env := &testEnv{
+		sessionUsecase:           new(mocks.SessionUsecase),
+		emailVerificationUsecase: new(mocks.EmailVerificationUsecase),
+		revocationUsecase:        TestRevocationUsecase(t),
+		apiKeyRepo:               new(mocks.APIKeyRepository),
+		oauthRefreshTokenRepo:    new(mocks.OAuthRefreshTokenRepository),
+	}
+	env.usecase = NewUserUsecase(userRepo, env.sessionUsecase, env.emailVerificationUsecase, env.revocationUsecase, env.apiKeyRepo, env.oauthRefreshTokenRepo, TestPasswordHasher(t), TestPasswordPolicy(t), time.Second*2)
+	return env
+}
+
 func TestBeforeStore(t *testing.T) {
 
 	mockUser := TestUser(t)
@@ -19,25 +45,27 @@ func TestBeforeStore(t *testing.T) {
 	mockUserRepo := new(mocks.UserRepository)
 	mockUserRepo.On("Find", mock.Anything, mock.AnythingOfType("string")).Return(nil, nil).Once()
 
-	userUse := NewUserUsecase(mockUserRepo, time.Second*2)
+	userUse := newTestEnv(t, mockUserRepo).usecase
 	userUse.BeforeStore(context.Background(), mockUser)
 
 	assert := assert.New(t)
 	assert.NotEmpty(mockUser.CreatedAt)
 	assert.NotEmpty(mockUser.UpdatedAt)
 	assert.Equal(mockUser.CreatedAt, mockUser.UpdatedAt)
+	assert.Equal(entity.USER_ROLE_USER, mockUser.Role)
 }
 
 func TestStore(t *testing.T) {
 	mockUserRepo := new(mocks.UserRepository)
 	mockUser := TestUser(t)
+	mockUser.Password = "new-password"
 
 	t.Run("success", func(t *testing.T) {
 		mockUserRepo.On("Find", mock.Anything, mock.AnythingOfType("string")).Return(nil, nil).Once()
 		mockUserRepo.On("FindByEmail", mock.Anything, mock.AnythingOfType("string")).Return(nil, nil).Once()
 		mockUserRepo.On("Store", mock.Anything, mock.AnythingOfType("*entity.User")).Return(nil).Once()
 
-		userUse := NewUserUsecase(mockUserRepo, time.Second*2)
+		userUse := newTestEnv(t, mockUserRepo).usecase
 		err := userUse.Store(context.TODO(), mockUser)
 
 		assert.NoError(t, err)
@@ -48,7 +76,7 @@ func TestStore(t *testing.T) {
 	t.Run("error-email-already-exist", func(t *testing.T) {
 		mockUserRepo.On("FindByEmail", mock.Anything, mock.AnythingOfType("string")).Return(TestUserEmpty(t), apperrors.NewErrConflict("email")).Once()
 
-		userUse := NewUserUsecase(mockUserRepo, time.Second*2)
+		userUse := newTestEnv(t, mockUserRepo).usecase
 		err := userUse.Store(context.TODO(), mockUser)
 
 		assert := assert.New(t)
@@ -64,7 +92,7 @@ func TestStore(t *testing.T) {
 		mockUserRepo.On("FindByEmail", mock.Anything, mock.AnythingOfType("string")).Return(nil, nil).Once()
 		mockUserRepo.On("Store", mock.Anything, mock.AnythingOfType("*entity.User")).Return(errRepository).Once()
 
-		userUse := NewUserUsecase(mockUserRepo, time.Second*2)
+		userUse := newTestEnv(t, mockUserRepo).usecase
 		err := userUse.Store(context.TODO(), mockUser)
 
 		assert := assert.New(t)
@@ -73,6 +101,21 @@ func TestStore(t *testing.T) {
 
 		mockUserRepo.AssertExpectations(t)
 	})
+
+	t.Run("error-invalid-phone", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("FindByEmail", mock.Anything, mock.AnythingOfType("string")).Return(nil, nil).Once()
+
+		invalidUser := TestUser(t)
+		invalidUser.Password = "new-password"
+		invalidUser.Phone = "901234567"
+
+		userUse := newTestEnv(t, mockUserRepo).usecase
+		err := userUse.Store(context.TODO(), invalidUser)
+
+		assert.IsType(t, &apperrors.ErrValidation{}, err)
+		mockUserRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+	})
 }
 
 func TestUpdate(t *testing.T) {
@@ -84,7 +127,7 @@ func TestUpdate(t *testing.T) {
 		mockUserRepo.On("FindByEmail", mock.Anything, mock.AnythingOfType("string")).Return(mockUser, nil).Once()
 		mockUserRepo.On("Update", mock.Anything, mock.AnythingOfType("*entity.User")).Return(nil).Once()
 
-		userUse := NewUserUsecase(mockUserRepo, time.Second*2)
+		userUse := newTestEnv(t, mockUserRepo).usecase
 		err := userUse.Update(context.TODO(), mockUser)
 
 		assert.NoError(t, err)
@@ -92,10 +135,32 @@ func TestUpdate(t *testing.T) {
 		mockUserRepo.AssertExpectations(t)
 	})
 
+	t.Run("success-email-changed", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("Find", mock.Anything, mockUser.ID).Return(TestUser(t), nil).Once()
+		mockUserRepo.On("FindByEmail", mock.Anything, "new@info.com").Return(nil, apperrors.NewErrNotFound("user")).Once()
+		mockUserRepo.On("Update", mock.Anything, mock.MatchedBy(func(user *entity.User) bool {
+			return user.Email == "new@info.com" && user.Status == entity.USER_STATUS_PENDING
+		})).Return(nil).Once()
+		env := newTestEnv(t, mockUserRepo)
+		mockEmailVerification := env.emailVerificationUsecase
+		mockEmailVerification.On("Send", mock.Anything, mock.AnythingOfType("*entity.User")).Return(nil).Once()
+
+		changed := TestUser(t)
+		changed.Email = "new@info.com"
+		userUse := env.usecase
+		err := userUse.Update(context.TODO(), changed)
+
+		assert.NoError(t, err)
+		assert.Equal(t, entity.USER_STATUS_PENDING, changed.Status)
+		mockUserRepo.AssertExpectations(t)
+		mockEmailVerification.AssertExpectations(t)
+	})
+
 	t.Run("error-not-found", func(t *testing.T) {
 		mockUserRepo.On("Find", mock.Anything, mock.AnythingOfType("string")).Return(TestUserEmpty(t), apperrors.NewErrNotFound("user")).Once()
 
-		userUse := NewUserUsecase(mockUserRepo, time.Second*2)
+		userUse := newTestEnv(t, mockUserRepo).usecase
 		err := userUse.Update(context.TODO(), mockUser)
 
 		assert := assert.New(t)
@@ -110,7 +175,7 @@ func TestUpdate(t *testing.T) {
 		mockUserRepo.On("Find", mock.Anything, mock.AnythingOfType("string")).Return(mockUser, nil).Once()
 		mockUserRepo.On("FindByEmail", mock.Anything, mock.AnythingOfType("string")).Return(TestUserEmpty(t), apperrors.NewErrConflict("email")).Once()
 
-		userUse := NewUserUsecase(mockUserRepo, time.Second*2)
+		userUse := newTestEnv(t, mockUserRepo).usecase
 		err := userUse.Update(context.TODO(), mockUser)
 
 		assert := assert.New(t)
@@ -120,6 +185,36 @@ func TestUpdate(t *testing.T) {
 		mockUserRepo.AssertExpectations(t)
 	})
 
+	t.Run("error-email-of-other-user", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		other := TestUser(t)
+		other.ID = "987654321"
+		mockUserRepo.On("Find", mock.Anything, mockUser.ID).Return(TestUser(t), nil).Once()
+		mockUserRepo.On("FindByEmail", mock.Anything, mockUser.Email).Return(other, nil).Once()
+
+		userUse := newTestEnv(t, mockUserRepo).usecase
+		err := userUse.Update(context.TODO(), TestUser(t))
+
+		assert.Equal(t, apperrors.NewErrConflict("email"), err)
+		mockUserRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-find-by-email-in-db", func(t *testing.T) {
+		errRepository := apperrors.NewErrRepository(errors.New("Unexpected error"))
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("Find", mock.Anything, mockUser.ID).Return(TestUser(t), nil).Once()
+		mockUserRepo.On("FindByEmail", mock.Anything, mockUser.Email).Return(nil, errRepository).Once()
+
+		userUse := newTestEnv(t, mockUserRepo).usecase
+		err := userUse.Update(context.TODO(), TestUser(t))
+
+		// the email is not updated without the conflict check
+		assert.Equal(t, errRepository, err)
+		mockUserRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		mockUserRepo.AssertExpectations(t)
+	})
+
 	t.Run("error-happens-in-db", func(t *testing.T) {
 		errRepository := apperrors.NewErrRepository(errors.New("Unexpected error"))
 
@@ -127,7 +222,7 @@ func TestUpdate(t *testing.T) {
 		mockUserRepo.On("FindByEmail", mock.Anything, mock.AnythingOfType("string")).Return(mockUser, nil).Once()
 		mockUserRepo.On("Update", mock.Anything, mock.AnythingOfType("*entity.User")).Return(errRepository).Once()
 
-		userUse := NewUserUsecase(mockUserRepo, time.Second*2)
+		userUse := newTestEnv(t, mockUserRepo).usecase
 		err := userUse.Update(context.TODO(), mockUser)
 
 		assert := assert.New(t)
@@ -138,6 +233,371 @@ func TestUpdate(t *testing.T) {
 	})
 }
 
+func TestPatch(t *testing.T) {
+	stringPtr := func(v string) *string { return &v }
+
+	t.Run("success", func(t *testing.T) {
+		mockUser := TestUser(t)
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("Find", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+		mockUserRepo.On("Patch", mock.Anything, mockUser.ID, mock.MatchedBy(func(patch *entity.UserPatch) bool {
+			return patch.FirstName == nil && *patch.LastName == "Patched" && *patch.Phone == "+998901234568" && !patch.UpdatedAt.IsZero()
+		})).Return(nil).Once()
+
+		userUse := newTestEnv(t, mockUserRepo).usecase
+		user, err := userUse.Patch(context.TODO(), mockUser.ID, &entity.UserPatch{
+			FirstName: stringPtr(mockUser.FirstName),
+			LastName:  stringPtr("Patched"),
+			Phone:     stringPtr("+998 90 123 45 68"),
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, mockUser.FirstName, user.FirstName)
+		assert.Equal(t, "Patched", user.LastName)
+		assert.Equal(t, "+998901234568", user.Phone)
+		assert.Equal(t, mockUser.Email, user.Email)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("success-unchanged", func(t *testing.T) {
+		mockUser := TestUser(t)
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("Find", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+
+		userUse := newTestEnv(t, mockUserRepo).usecase
+		user, err := userUse.Patch(context.TODO(), mockUser.ID, &entity.UserPatch{Email: stringPtr(mockUser.Email)})
+
+		assert.NoError(t, err)
+		assert.Equal(t, mockUser, user)
+		mockUserRepo.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("success-disabled", func(t *testing.T) {
+		mockUser := TestUser(t)
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("Find", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+		mockUserRepo.On("Patch", mock.Anything, mockUser.ID, mock.AnythingOfType("*entity.UserPatch")).Return(nil).Once()
+		env := newTestEnv(t, mockUserRepo)
+		mockSessionUsecase := env.sessionUsecase
+		mockSessionUsecase.On("DeleteByUserId", mock.Anything, mockUser.ID).Return(nil).Once()
+
+		userUse := env.usecase
+		user, err := userUse.Patch(context.TODO(), mockUser.ID, &entity.UserPatch{Status: stringPtr(entity.USER_STATUS_SUSPENDED)})
+
+		assert.NoError(t, err)
+		assert.Equal(t, entity.USER_STATUS_SUSPENDED, user.Status)
+		mockSessionUsecase.AssertExpectations(t)
+	})
+
+	t.Run("success-email-changed", func(t *testing.T) {
+		mockUser := TestUser(t)
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("Find", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+		mockUserRepo.On("FindByEmail", mock.Anything, "new@info.com").Return(nil, apperrors.NewErrNotFound("user")).Once()
+		mockUserRepo.On("Patch", mock.Anything, mockUser.ID, mock.MatchedBy(func(patch *entity.UserPatch) bool {
+			return *patch.Email == "new@info.com" && *patch.Status == entity.USER_STATUS_PENDING
+		})).Return(nil).Once()
+		env := newTestEnv(t, mockUserRepo)
+		mockEmailVerification := env.emailVerificationUsecase
+		mockEmailVerification.On("Send", mock.Anything, mock.MatchedBy(func(user *entity.User) bool {
+			return user.Email == "new@info.com"
+		})).Return(nil).Once()
+
+		userUse := env.usecase
+		user, err := userUse.Patch(context.TODO(), mockUser.ID, &entity.UserPatch{Email: stringPtr("new@info.com")})
+
+		assert.NoError(t, err)
+		assert.Equal(t, entity.USER_STATUS_PENDING, user.Status)
+		assert.False(t, user.IsEmailVerified())
+		mockUserRepo.AssertExpectations(t)
+		mockEmailVerification.AssertExpectations(t)
+	})
+
+	t.Run("success-email-changed-disabled", func(t *testing.T) {
+		mockUser := TestUser(t)
+		mockUser.Status = entity.USER_STATUS_SUSPENDED
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("Find", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+		mockUserRepo.On("FindByEmail", mock.Anything, "new@info.com").Return(nil, apperrors.NewErrNotFound("user")).Once()
+		mockUserRepo.On("Patch", mock.Anything, mockUser.ID, mock.MatchedBy(func(patch *entity.UserPatch) bool {
+			return patch.Status == nil
+		})).Return(nil).Once()
+		env := newTestEnv(t, mockUserRepo)
+		mockEmailVerification := env.emailVerificationUsecase
+
+		userUse := env.usecase
+		user, err := userUse.Patch(context.TODO(), mockUser.ID, &entity.UserPatch{Email: stringPtr("new@info.com")})
+
+		assert.NoError(t, err)
+		assert.Equal(t, entity.USER_STATUS_SUSPENDED, user.Status)
+		mockEmailVerification.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error-email-already-exist", func(t *testing.T) {
+		mockUser := TestUser(t)
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("Find", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+		mockUserRepo.On("FindByEmail", mock.Anything, "other@info.com").Return(&entity.User{ID: "987654321"}, nil).Once()
+
+		userUse := newTestEnv(t, mockUserRepo).usecase
+		_, err := userUse.Patch(context.TODO(), mockUser.ID, &entity.UserPatch{Email: stringPtr("other@info.com")})
+
+		assert.Equal(t, apperrors.NewErrConflict("email"), err)
+		mockUserRepo.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error-status-transition", func(t *testing.T) {
+		mockUser := TestUser(t)
+		mockUser.Status = entity.USER_STATUS_DEACTIVE
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("Find", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+
+		userUse := newTestEnv(t, mockUserRepo).usecase
+		_, err := userUse.Patch(context.TODO(), mockUser.ID, &entity.UserPatch{Status: stringPtr(entity.USER_STATUS_PENDING)})
+
+		assert.IsType(t, &apperrors.ErrValidation{}, err)
+		mockUserRepo.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything, mock.Anything)
+	})
+	t.Run("error-removed-role", func(t *testing.T) {
+		mockUser := TestUser(t)
+		mockUser.Role = entity.USER_ROLE_USER
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("Find", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+
+		userUse := newTestEnv(t, mockUserRepo).usecase
+		_, err := userUse.Patch(context.TODO(), mockUser.ID, &entity.UserPatch{Role: stringPtr("")})
+
+		errValidation, ok := err.(*apperrors.ErrValidation)
+		if assert.True(t, ok) {
+			assert.Contains(t, errValidation.Errors, "role")
+		}
+		mockUserRepo.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestUpdatePassword(t *testing.T) {
+	mockUserRepo := new(mocks.UserRepository)
+	mockUser := TestUser(t)
+
+	t.Run("success", func(t *testing.T) {
+		mockUserRepo.On("Find", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+		mockUserRepo.On("UpdatePassword", mock.Anything, mockUser.ID, mock.MatchedBy(func(password string) bool {
+			return TestPasswordHasher(t).Check("new-password", password)
+		})).Return(nil).Once()
+		env := newTestEnv(t, mockUserRepo)
+		mockOAuthRefreshTokenRepo := env.oauthRefreshTokenRepo
+		mockOAuthRefreshTokenRepo.On("DeleteByUserId", mock.Anything, mockUser.ID).Return(nil).Once()
+
+		userUse := env.usecase
+		err := userUse.UpdatePassword(context.TODO(), mockUser.ID, "new-password")
+
+		assert.NoError(t, err)
+
+		mockUserRepo.AssertExpectations(t)
+		mockOAuthRefreshTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-not-found", func(t *testing.T) {
+		mockUserRepo.On("Find", mock.Anything, mockUser.ID).Return(nil, apperrors.NewErrNotFound("user")).Once()
+
+		userUse := newTestEnv(t, mockUserRepo).usecase
+		err := userUse.UpdatePassword(context.TODO(), mockUser.ID, "new-password")
+
+		assert.Equal(t, err, apperrors.NewErrNotFound("user"))
+
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-password-policy", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("Find", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+
+		userUse := newTestEnv(t, mockUserRepo).usecase
+		err := userUse.UpdatePassword(context.TODO(), mockUser.ID, "qwerty-password")
+
+		assert.IsType(t, &apperrors.ErrValidation{}, err)
+
+		mockUserRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+		mockUserRepo.AssertExpectations(t)
+	})
+}
+
+func TestValidatePassword(t *testing.T) {
+	mockUser := TestUser(t)
+
+	t.Run("success", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("Find", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+
+		userUse := newTestEnv(t, mockUserRepo).usecase
+		err := userUse.ValidatePassword(context.TODO(), mockUser.ID, "new-password")
+
+		assert.NoError(t, err)
+		mockUserRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error-password-policy", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("Find", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+
+		userUse := newTestEnv(t, mockUserRepo).usecase
+		err := userUse.ValidatePassword(context.TODO(), mockUser.ID, "qwerty-password")
+
+		assert.IsType(t, &apperrors.ErrValidation{}, err)
+	})
+}
+
+func TestUpdateStatus(t *testing.T) {
+	t.Run("success-deactivate", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		env := newTestEnv(t, mockUserRepo)
+		mockSessionUsecase := env.sessionUsecase
+		mockUser := TestUser(t)
+		mockUserRepo.On("Find", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+		mockUserRepo.On("UpdateStatus", mock.Anything, mockUser.ID, entity.USER_STATUS_DEACTIVE).Return(nil).Once()
+		mockSessionUsecase.On("DeleteByUserId", mock.Anything, mockUser.ID).Return(nil).Once()
+
+		userUse := env.usecase
+		err := userUse.UpdateStatus(context.TODO(), mockUser.ID, entity.USER_STATUS_DEACTIVE)
+
+		assert.NoError(t, err)
+
+		mockUserRepo.AssertExpectations(t)
+		mockSessionUsecase.AssertExpectations(t)
+	})
+
+	t.Run("success-activate", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		env := newTestEnv(t, mockUserRepo)
+		mockSessionUsecase := env.sessionUsecase
+		mockUser := TestUser(t)
+		mockUser.Status = entity.USER_STATUS_SUSPENDED
+		mockUserRepo.On("Find", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+		mockUserRepo.On("UpdateStatus", mock.Anything, mockUser.ID, entity.USER_STATUS_ACTIVE).Return(nil).Once()
+
+		userUse := env.usecase
+		err := userUse.UpdateStatus(context.TODO(), mockUser.ID, entity.USER_STATUS_ACTIVE)
+
+		assert.NoError(t, err)
+
+		mockUserRepo.AssertExpectations(t)
+		mockSessionUsecase.AssertNotCalled(t, "DeleteByUserId", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error-invalid-transition", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockUser := TestUser(t)
+		mockUser.Status = entity.USER_STATUS_DEACTIVE
+		mockUserRepo.On("Find", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+
+		userUse := newTestEnv(t, mockUserRepo).usecase
+		err := userUse.UpdateStatus(context.TODO(), mockUser.ID, entity.USER_STATUS_SUSPENDED)
+
+		assert.IsType(t, &apperrors.ErrValidation{}, err)
+
+		mockUserRepo.AssertExpectations(t)
+		mockUserRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestCheckPassword(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockUser := TestUser(t)
+		mockUser.Password, _ = TestPasswordHasher(t).Hash("password")
+
+		userUse := newTestEnv(t, mockUserRepo).usecase
+		ok, err := userUse.CheckPassword(context.TODO(), mockUser, "password")
+
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		mockUserRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("success-rehash", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockUser := TestUser(t)
+		mockUser.Password, _ = hash.NewBcryptHasher(bcrypt.MinCost + 1).Hash("password")
+		oldPassword := mockUser.Password
+
+		mockUserRepo.On("UpdatePassword", mock.Anything, mockUser.ID, mock.MatchedBy(func(password string) bool {
+			return !TestPasswordHasher(t).NeedsRehash(password)
+		})).Return(nil).Once()
+
+		userUse := newTestEnv(t, mockUserRepo).usecase
+		ok, err := userUse.CheckPassword(context.TODO(), mockUser, "password")
+
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.NotEqual(t, oldPassword, mockUser.Password)
+
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-wrong-password", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockUser := TestUser(t)
+		mockUser.Password, _ = hash.NewBcryptHasher(bcrypt.MinCost + 1).Hash("password")
+
+		userUse := newTestEnv(t, mockUserRepo).usecase
+		ok, err := userUse.CheckPassword(context.TODO(), mockUser, "wrong-password")
+
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		mockUserRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestVerifyPhone(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockUser := TestUser(t)
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("Find", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+		mockUserRepo.On("FindByPhone", mock.Anything, mockUser.Phone).Return(nil, apperrors.NewErrNotFound("user")).Once()
+		mockUserRepo.On("VerifyPhone", mock.Anything, mockUser.ID, mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+		userUse := newTestEnv(t, mockUserRepo).usecase
+		err := userUse.VerifyPhone(context.TODO(), mockUser.ID)
+
+		assert.NoError(t, err)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-verified-by-other-user", func(t *testing.T) {
+		mockUser := TestUser(t)
+		owner := TestUser(t)
+		owner.ID = "987654321"
+
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("Find", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+		mockUserRepo.On("FindByPhone", mock.Anything, mockUser.Phone).Return(owner, nil).Once()
+
+		userUse := newTestEnv(t, mockUserRepo).usecase
+		err := userUse.VerifyPhone(context.TODO(), mockUser.ID)
+
+		assert.Equal(t, apperrors.NewErrConflict("phone"), err)
+		mockUserRepo.AssertNotCalled(t, "VerifyPhone", mock.Anything, mock.Anything, mock.Anything)
+		mockUserRepo.AssertExpectations(t)
+	})
+}
+
+func TestFindByPhone(t *testing.T) {
+	mockUser := TestUser(t)
+	mockUserRepo := new(mocks.UserRepository)
+	mockUserRepo.On("FindByPhone", mock.Anything, "+998901234567").Return(mockUser, nil).Once()
+
+	userUse := newTestEnv(t, mockUserRepo).usecase
+
+	// the phone is normalized before lookup
+	user, err := userUse.FindByPhone(context.TODO(), "00 998 90 123-45-67")
+
+	assert.NoError(t, err)
+	assert.Equal(t, mockUser, user)
+	mockUserRepo.AssertExpectations(t)
+}
+
 func TestDelete(t *testing.T) {
 
 	mockUserRepo := new(mocks.UserRepository)
@@ -146,19 +606,48 @@ func TestDelete(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		mockUserRepo.On("Find", mock.Anything, mock.AnythingOfType("string")).Return(mockUser, nil).Once()
 		mockUserRepo.On("Delete", mock.Anything, mock.AnythingOfType("string")).Return(nil).Once()
-
-		userUse := NewUserUsecase(mockUserRepo, time.Second*2)
+		env := newTestEnv(t, mockUserRepo)
+		mockSessionUsecase := env.sessionUsecase
+		mockSessionUsecase.On("DeleteByUserId", mock.Anything, mockUser.ID).Return(nil).Once()
+		mockAPIKeyRepo := env.apiKeyRepo
+		mockAPIKeyRepo.On("DeleteByUserId", mock.Anything, mockUser.ID).Return(nil).Once()
+		mockOAuthRefreshTokenRepo := env.oauthRefreshTokenRepo
+		mockOAuthRefreshTokenRepo.On("DeleteByUserId", mock.Anything, mockUser.ID).Return(nil).Once()
+		revocationUsecase := env.revocationUsecase
+
+		userUse := env.usecase
 		err := userUse.Delete(context.TODO(), mockUser.ID)
 
 		assert.NoError(t, err)
+		revoked, err := revocationUsecase.IsRevoked(context.TODO(), "", mockUser.ID, time.Now().Add(-time.Minute))
+		assert.NoError(t, err)
+		assert.True(t, revoked)
 
 		mockUserRepo.AssertExpectations(t)
+		mockSessionUsecase.AssertExpectations(t)
+		mockAPIKeyRepo.AssertExpectations(t)
+		mockOAuthRefreshTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-delete-sessions", func(t *testing.T) {
+		errRepository := apperrors.NewErrRepository(errors.New("Unexpected error"))
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("Find", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+		env := newTestEnv(t, mockUserRepo)
+		mockSessionUsecase := env.sessionUsecase
+		mockSessionUsecase.On("DeleteByUserId", mock.Anything, mockUser.ID).Return(errRepository).Once()
+
+		userUse := env.usecase
+		err := userUse.Delete(context.TODO(), mockUser.ID)
+
+		assert.Equal(t, errRepository, err)
+		mockUserRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
 	})
 
 	t.Run("error-not-found", func(t *testing.T) {
 		mockUserRepo.On("Find", mock.Anything, mock.AnythingOfType("string")).Return(TestUserEmpty(t), apperrors.NewErrNotFound("user")).Once()
 
-		userUse := NewUserUsecase(mockUserRepo, time.Second*2)
+		userUse := newTestEnv(t, mockUserRepo).usecase
 		err := userUse.Delete(context.TODO(), mockUser.ID)
 
 		assert := assert.New(t)
@@ -172,7 +661,7 @@ func TestDelete(t *testing.T) {
 		errRepository := apperrors.NewErrRepository(errors.New("Unexpected error"))
 		mockUserRepo.On("Find", mock.Anything, mock.AnythingOfType("string")).Return(TestUserEmpty(t), errRepository).Once()
 
-		userUse := NewUserUsecase(mockUserRepo, time.Second*2)
+		userUse := newTestEnv(t, mockUserRepo).usecase
 		err := userUse.Delete(context.TODO(), mockUser.ID)
 
 		assert := assert.New(t)
@@ -191,7 +680,7 @@ func TestFind(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		mockUserRepo.On("Find", mock.Anything, mock.AnythingOfType("string")).Return(mockUser, nil).Once()
-		userUse := NewUserUsecase(mockUserRepo, time.Second*2)
+		userUse := newTestEnv(t, mockUserRepo).usecase
 		user, err := userUse.Find(context.TODO(), mockUser.ID)
 
 		assert.NoError(t, err)
@@ -203,7 +692,7 @@ func TestFind(t *testing.T) {
 	t.Run("error-failed", func(t *testing.T) {
 		mockUserRepo.On("Find", mock.Anything, mock.AnythingOfType("string")).Return(&entity.User{}, errors.New("Unexpected error")).Once()
 
-		userUse := NewUserUsecase(mockUserRepo, time.Second*2)
+		userUse := newTestEnv(t, mockUserRepo).usecase
 		user, err := userUse.Find(context.TODO(), mockUser.ID)
 
 		assert.Error(t, err)
@@ -225,33 +714,86 @@ func TestFindAll(t *testing.T) {
 
 		mockUserRepo.On("FindAll",
 			mock.Anything,
-			mock.AnythingOfType("int"),
-			mock.AnythingOfType("int"),
-			mock.Anything,
+			mock.AnythingOfType("*entity.UserFilter"),
 		).Return(mockListUser, nil).Once()
 
-		userUse := NewUserUsecase(mockUserRepo, time.Second*2)
-		list, err := userUse.FindAll(context.TODO(), 10, 0, make(map[string]interface{}))
+		userUse := newTestEnv(t, mockUserRepo).usecase
+		page, err := userUse.FindAll(context.TODO(), &entity.UserFilter{Limit: 10})
 
 		assert := assert.New(t)
 		assert.NoError(err)
-		assert.Len(list, len(mockListUser))
+		assert.Len(page.Items, len(mockListUser))
+		assert.Nil(page.Next)
+		assert.Nil(page.Prev)
+		assert.Nil(page.Total)
+
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("success-keyset", func(t *testing.T) {
+		createdAt := time.Now().UTC()
+		users := []*entity.User{
+			{ID: "3", CreatedAt: createdAt.Add(-time.Minute)},
+			{ID: "2", CreatedAt: createdAt.Add(-time.Minute * 2)},
+			{ID: "1", CreatedAt: createdAt.Add(-time.Minute * 3)},
+		}
+		cursor := &entity.UserCursor{CreatedAt: createdAt, ID: "4"}
 
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("FindAll", mock.Anything, mock.MatchedBy(func(filter *entity.UserFilter) bool {
+			return filter.Limit == 3 && filter.Cursor == cursor
+		})).Return(users, nil).Once()
+		mockUserRepo.On("Count", mock.Anything, mock.AnythingOfType("*entity.UserFilter")).Return(10, nil).Once()
+
+		userUse := newTestEnv(t, mockUserRepo).usecase
+		page, err := userUse.FindAll(context.TODO(), &entity.UserFilter{Limit: 2, Cursor: cursor, WithTotal: true})
+
+		assert.NoError(t, err)
+		assert.Equal(t, users[:2], page.Items)
+		assert.Equal(t, &entity.UserCursor{CreatedAt: users[1].CreatedAt, ID: "2"}, page.Next)
+		assert.Equal(t, &entity.UserCursor{CreatedAt: users[0].CreatedAt, ID: "3", Backward: true}, page.Prev)
+		assert.Equal(t, 10, *page.Total)
 		mockUserRepo.AssertExpectations(t)
 	})
 
+	t.Run("success-keyset-backward", func(t *testing.T) {
+		users := []*entity.User{{ID: "3"}, {ID: "2"}}
+		cursor := &entity.UserCursor{ID: "1", Backward: true}
+
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("FindAll", mock.Anything, mock.AnythingOfType("*entity.UserFilter")).Return(users, nil).Once()
+
+		userUse := newTestEnv(t, mockUserRepo).usecase
+		page, err := userUse.FindAll(context.TODO(), &entity.UserFilter{Limit: 2, Cursor: cursor})
+
+		assert.NoError(t, err)
+		assert.Equal(t, users, page.Items)
+		assert.Equal(t, "2", page.Next.ID)
+		assert.Nil(t, page.Prev)
+	})
+
+	t.Run("success-sorted-by-other-field", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("FindAll", mock.Anything, mock.AnythingOfType("*entity.UserFilter")).Return([]*entity.User{{ID: "1"}, {ID: "2"}}, nil).Once()
+
+		userUse := newTestEnv(t, mockUserRepo).usecase
+		page, err := userUse.FindAll(context.TODO(), &entity.UserFilter{Limit: 1, Sort: []entity.UserSort{{Field: entity.USER_SORT_LAST_NAME}}})
+
+		assert.NoError(t, err)
+		assert.Len(t, page.Items, 1)
+		assert.Nil(t, page.Next)
+	})
+
 	t.Run("error-happens-in-db", func(t *testing.T) {
 		errRepository := apperrors.NewErrRepository(errors.New("Unexpected error"))
 
 		mockUserRepo.On("FindAll",
 			mock.Anything,
-			mock.AnythingOfType("int"),
-			mock.AnythingOfType("int"),
-			mock.Anything,
+			mock.AnythingOfType("*entity.UserFilter"),
 		).Return(mockListUser, errRepository).Once()
 
-		userUse := NewUserUsecase(mockUserRepo, time.Second*2)
-		_, err := userUse.FindAll(context.TODO(), 10, 0, make(map[string]interface{}))
+		userUse := newTestEnv(t, mockUserRepo).usecase
+		_, err := userUse.FindAll(context.TODO(), &entity.UserFilter{Limit: 10})
 
 		assert := assert.New(t)
 		assert.Error(err)
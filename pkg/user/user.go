@@ -3,17 +3,29 @@ package user
 import "time"
 
 type User struct {
-	ID        string    `json:"id,omitempty"`
-	Email     string    `json:"email,omitempty"`
-	Phone     string    `json:"phone,omitempty"`
-	Gender    string    `json:"gender,omitempty"`
-	Status    string    `json:"status,omitempty"`
-	FirstName string    `json:"first_name,omitempty"`
-	LastName  string    `json:"last_name,omitempty"`
-	Password  string    `json:"password,omitempty"`
-	BirthDate time.Time `json:"birth_date,omitempty"`
-	CreatedAt time.Time `json:"created_at,omitempty"`
-	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	ID          string    `json:"id,omitempty"`
+	Type        string    `json:"type,omitempty"`
+	Email       string    `json:"email,omitempty"`
+	Phone       string    `json:"phone,omitempty"`
+	Gender      string    `json:"gender,omitempty"`
+	Status      string    `json:"status,omitempty"`
+	Role        string    `json:"role,omitempty"`
+	Permissions []string  `json:"permissions,omitempty"`
+	FirstName   string    `json:"first_name,omitempty"`
+	LastName    string    `json:"last_name,omitempty"`
+	Password    string    `json:"password,omitempty"`
+	BirthDate   time.Time `json:"birth_date,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty"`
+}
+
+// Meta of the page, next and prev are links to adjacent pages of listings sorted by created_at
+type Meta struct {
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset,omitempty"`
+	Total  *int   `json:"total,omitempty"`
+	Next   string `json:"next,omitempty"`
+	Prev   string `json:"prev,omitempty"`
 }
 
 func (u *User) Sanitize() *User {
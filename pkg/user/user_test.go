@@ -1,6 +1,7 @@
 package user
 
 import (
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -11,3 +12,13 @@ func TestSanitize(t *testing.T) {
 	}
 	assert.Empty(t, user.Sanitize().Password)
 }
+
+func TestConvertItems(t *testing.T) {
+	uh := &UserHandler{}
+	users := uh.convertItems([]*entity.User{{ID: "1", Password: "hash"}, {ID: "2", Password: "hash"}})
+
+	assert.Len(t, users, 2)
+	for _, user := range users {
+		assert.Empty(t, user.Password)
+	}
+}
@@ -0,0 +1,114 @@
+package usertoken
+
+import (
+	"context"
+	"fmt"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"strings"
+	"time"
+)
+
+type pgxUserTokenRepository struct {
+	db    *pgxpool.Pool
+	table string
+	name  string
+}
+
+// New user token repository of the table, tables of user tokens have the same columns.
+// Used tokens are neither found nor consumed.
+func NewUserTokenRepositoryPgx(dbpool *pgxpool.Pool, table string) entity.UserTokenRepository {
+	return &pgxUserTokenRepository{
+		db:    dbpool,
+		table: table,
+		name:  strings.Replace(table, "_", " ", -1),
+	}
+}
+
+func (p *pgxUserTokenRepository) Store(ctx context.Context, m *entity.UserToken) error {
+	_, err := p.db.Exec(ctx, `INSERT INTO "`+p.table+`"(
+		user_id, token, expires_at, created_at)
+		VALUES ($1, $2, $3, $4);`,
+		m.UserID,
+		m.Token,
+		m.ExpiresAt,
+		m.CreatedAt,
+	)
+
+	if err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during store to %s repository: %w", p.name, err)}
+	}
+
+	return nil
+}
+
+func (p *pgxUserTokenRepository) Find(ctx context.Context, token string) (*entity.UserToken, error) {
+	return p.scan(p.db.QueryRow(ctx, `SELECT user_id, token, expires_at, created_at FROM "`+p.table+`" WHERE token=$1 AND used_at IS NULL`, token), "find")
+}
+
+// consume deletes the token and returns it in one statement, so only one of parallel requests gets the token
+func (p *pgxUserTokenRepository) Consume(ctx context.Context, token string) (*entity.UserToken, error) {
+	return p.scan(p.db.QueryRow(ctx, `DELETE FROM "`+p.table+`" WHERE token=$1 AND used_at IS NULL RETURNING user_id, token, expires_at, created_at`, token), "consume")
+}
+
+// use marks the token used and returns it in one statement, so only one of parallel requests gets the token.
+// Unlike consumed tokens used ones are kept until they expire, so they are counted by CountByUserIdSince.
+func (p *pgxUserTokenRepository) Use(ctx context.Context, token string, usedAt time.Time) (*entity.UserToken, error) {
+	return p.scan(p.db.QueryRow(ctx, `UPDATE "`+p.table+`" SET used_at=$2 WHERE token=$1 AND used_at IS NULL RETURNING user_id, token, expires_at, created_at`, token, usedAt), "use")
+}
+
+// release makes the used token valid again
+func (p *pgxUserTokenRepository) Release(ctx context.Context, token string) error {
+	if _, err := p.db.Exec(ctx, `UPDATE "`+p.table+`" SET used_at=NULL WHERE token=$1`, token); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during release to %s repository: %w", p.name, err)}
+	}
+	return nil
+}
+
+func (p *pgxUserTokenRepository) DeleteByUserId(ctx context.Context, id string) error {
+	if _, err := p.db.Exec(ctx, `DELETE FROM "`+p.table+`" WHERE user_id=$1`, id); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during delete by user id to %s repository: %w", p.name, err)}
+	}
+	return nil
+}
+
+func (p *pgxUserTokenRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	if _, err := p.db.Exec(ctx, `DELETE FROM "`+p.table+`" WHERE expires_at<$1`, before); err != nil {
+		return errors.ErrRepository{Err: fmt.Errorf("error during delete expired to %s repository: %w", p.name, err)}
+	}
+	return nil
+}
+
+// count by user id since counts used tokens as well
+func (p *pgxUserTokenRepository) CountByUserIdSince(ctx context.Context, id string, since time.Time) (int, error) {
+	var count int
+	row := p.db.QueryRow(ctx, `SELECT count(*) FROM "`+p.table+`" WHERE user_id=$1 AND created_at>=$2`, id, since)
+
+	if err := row.Scan(&count); err != nil {
+		return 0, errors.ErrRepository{Err: fmt.Errorf("error during count by user id to %s repository: %w", p.name, err)}
+	}
+
+	return count, nil
+}
+
+func (p *pgxUserTokenRepository) scan(row pgx.Row, operation string) (*entity.UserToken, error) {
+	userToken := entity.UserToken{}
+	err := row.Scan(
+		&userToken.UserID,
+		&userToken.Token,
+		&userToken.ExpiresAt,
+		&userToken.CreatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, errors.NewErrNotFound(p.name)
+	}
+
+	if err != nil {
+		return nil, errors.ErrRepository{Err: fmt.Errorf("error during %s to %s repository: %w", operation, p.name, err)}
+	}
+
+	return &userToken, nil
+}
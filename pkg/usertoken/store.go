@@ -0,0 +1,89 @@
+package usertoken
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
+	"github.com/Jamshid90/go-clean-architecture/pkg/rand"
+	"time"
+)
+
+// TokenStore issues single use tokens of users, only digests of the tokens are stored
+type TokenStore struct {
+	tokenRepo entity.UserTokenRepository
+	ttl       time.Duration
+}
+
+// New token store, tokens expire after ttl
+func NewTokenStore(repo entity.UserTokenRepository, ttl time.Duration) TokenStore {
+	return TokenStore{
+		tokenRepo: repo,
+		ttl:       ttl,
+	}
+}
+
+// Issue replaces previous tokens of the user with a new one and returns it
+func (s *TokenStore) Issue(ctx context.Context, userID string) (string, error) {
+	token, err := rand.Token(32)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.tokenRepo.DeleteByUserId(ctx, userID); err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	if err := s.tokenRepo.Store(ctx, &entity.UserToken{
+		UserID:    userID,
+		Token:     hash.HashToken(token),
+		ExpiresAt: now.Add(s.ttl),
+		CreatedAt: now,
+	}); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Check returns id of the user the token was issued for without invalidating it
+func (s *TokenStore) Check(ctx context.Context, token string) (string, error) {
+	userToken, err := s.tokenRepo.Find(ctx, hash.HashToken(token))
+	return userID(userToken, err)
+}
+
+// Consume invalidates the token and returns id of the user it was issued for,
+// the token is deleted at once so it can not be consumed twice
+func (s *TokenStore) Consume(ctx context.Context, token string) (string, error) {
+	userToken, err := s.tokenRepo.Consume(ctx, hash.HashToken(token))
+	return userID(userToken, err)
+}
+
+// Use invalidates the token like Consume and returns id of the user it was issued for,
+// the token is kept as used so Release can make it valid again
+func (s *TokenStore) Use(ctx context.Context, token string) (string, error) {
+	userToken, err := s.tokenRepo.Use(ctx, hash.HashToken(token), time.Now().UTC())
+	return userID(userToken, err)
+}
+
+// Release makes the used token valid again, so the request it was used for can be retried
+func (s *TokenStore) Release(ctx context.Context, token string) error {
+	return s.tokenRepo.Release(ctx, hash.HashToken(token))
+}
+
+// user id of the found token, unknown and expired tokens are reported as invalid
+func userID(userToken *entity.UserToken, err error) (string, error) {
+	if err != nil {
+		if _, ok := err.(*errors.ErrNotFound); ok {
+			return "", &errors.ErrBadRequest{Err: err, Message: errors.ErrInvalidOrExpiredToken.Error()}
+		}
+		return "", err
+	}
+
+	if time.Now().UTC().After(userToken.ExpiresAt) {
+		return "", &errors.ErrBadRequest{Err: errors.ErrInvalidOrExpiredToken, Message: errors.ErrInvalidOrExpiredToken.Error()}
+	}
+
+	return userToken.UserID, nil
+}
@@ -0,0 +1,111 @@
+package usertoken
+
+import (
+	"context"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity/mocks"
+	apperrors "github.com/Jamshid90/go-clean-architecture/pkg/errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestIssue(t *testing.T) {
+	mockRepo := new(mocks.UserTokenRepository)
+	store := NewTokenStore(mockRepo, time.Hour)
+
+	var stored *entity.UserToken
+	mockRepo.On("DeleteByUserId", mock.Anything, "123456789").Return(nil).Once()
+	mockRepo.On("Store", mock.Anything, mock.AnythingOfType("*entity.UserToken")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*entity.UserToken) }).
+		Return(nil).Once()
+
+	token, err := store.Issue(context.TODO(), "123456789")
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+	mockRepo.AssertExpectations(t)
+
+	// only the digest of the token is stored
+	assert.Equal(t, "123456789", stored.UserID)
+	assert.Equal(t, hash.HashToken(token), stored.Token)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), stored.ExpiresAt, time.Minute)
+}
+
+func TestCheck(t *testing.T) {
+	mockRepo := new(mocks.UserTokenRepository)
+	store := NewTokenStore(mockRepo, time.Hour)
+
+	t.Run("success", func(t *testing.T) {
+		userToken := &entity.UserToken{
+			UserID:    "123456789",
+			Token:     hash.HashToken("token"),
+			ExpiresAt: time.Now().UTC().Add(time.Hour),
+		}
+		mockRepo.On("Find", mock.Anything, hash.HashToken("token")).Return(userToken, nil).Once()
+
+		userID, err := store.Check(context.TODO(), "token")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "123456789", userID)
+		mockRepo.AssertNotCalled(t, "Consume", mock.Anything, mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-not-found", func(t *testing.T) {
+		mockRepo.On("Find", mock.Anything, hash.HashToken("unknown")).Return(nil, apperrors.NewErrNotFound("password reset token")).Once()
+
+		_, err := store.Check(context.TODO(), "unknown")
+
+		assert.IsType(t, &apperrors.ErrBadRequest{}, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestConsume(t *testing.T) {
+	mockRepo := new(mocks.UserTokenRepository)
+	store := NewTokenStore(mockRepo, time.Hour)
+
+	t.Run("success", func(t *testing.T) {
+		userToken := &entity.UserToken{
+			UserID:    "123456789",
+			Token:     hash.HashToken("token"),
+			ExpiresAt: time.Now().UTC().Add(time.Hour),
+		}
+		mockRepo.On("Consume", mock.Anything, hash.HashToken("token")).Return(userToken, nil).Once()
+
+		userID, err := store.Consume(context.TODO(), "token")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "123456789", userID)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-expired", func(t *testing.T) {
+		userToken := &entity.UserToken{
+			UserID:    "123456789",
+			Token:     hash.HashToken("token"),
+			ExpiresAt: time.Now().UTC().Add(-time.Minute),
+		}
+		mockRepo.On("Consume", mock.Anything, hash.HashToken("token")).Return(userToken, nil).Once()
+
+		_, err := store.Consume(context.TODO(), "token")
+
+		assert.Error(t, err)
+		assert.Equal(t, apperrors.ErrInvalidOrExpiredToken.Error(), err.Error())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error-consumed", func(t *testing.T) {
+		// unknown tokens and tokens consumed by a parallel request are not found
+		mockRepo.On("Consume", mock.Anything, hash.HashToken("token")).Return(nil, apperrors.NewErrNotFound("password reset token")).Once()
+
+		_, err := store.Consume(context.TODO(), "token")
+
+		assert.Error(t, err)
+		assert.Equal(t, apperrors.ErrInvalidOrExpiredToken.Error(), err.Error())
+		mockRepo.AssertExpectations(t)
+	})
+}
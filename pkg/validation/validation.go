@@ -2,6 +2,7 @@ package validation
 
 import (
 	"errors"
+	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
 	apperrors "github.com/Jamshid90/go-clean-architecture/pkg/errors"
 	"github.com/go-playground/locales/en"
 	ut "github.com/go-playground/universal-translator"
@@ -16,14 +17,45 @@ var (
 	uni        = ut.New(translator, translator)
 )
 
+func init() {
+	// "permission" accepts the known permissions of entity only
+	validate.RegisterValidation("permission", func(fl validator.FieldLevel) bool {
+		return entity.IsPermission(fl.Field().String())
+	})
+}
+
 func Validator(s interface{}) error {
 	trans, found := uni.GetTranslator("en")
 	if !found {
 		return errors.New("Validator translator not found")
 	}
+	registerTranslations(trans)
+	return validationErr(s, validate.Struct(s), trans)
+}
+
+// ValidatorPartial validates only the fields of the struct, fields are names of the struct fields
+func ValidatorPartial(s interface{}, fields ...string) error {
+	trans, found := uni.GetTranslator("en")
+	if !found {
+		return errors.New("Validator translator not found")
+	}
+	registerTranslations(trans)
+	return validationErr(s, validate.StructPartial(s, fields...), trans)
+}
+
+// default translations and translations of the custom tags
+func registerTranslations(trans ut.Translator) {
 	field_tag.RegisterDefaultTranslations(validate, trans)
-	err := validate.Struct(s)
+	validate.RegisterTranslation("permission", trans, func(ut ut.Translator) error {
+		return ut.Add("permission", "{0} must be a known permission", true)
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		t, _ := ut.T("permission", fe.Field())
+		return t
+	})
+}
 
+// validation errors by json names of the fields
+func validationErr(s interface{}, err error, trans ut.Translator) error {
 	if err != nil {
 		errValidation := apperrors.NewErrValidation()
 		errValidation.Err = err
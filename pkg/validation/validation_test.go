@@ -2,6 +2,7 @@ package validation
 
 import (
 	"github.com/Jamshid90/go-clean-architecture/pkg/entity"
+	"github.com/Jamshid90/go-clean-architecture/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -22,3 +23,41 @@ func TestValidator(t *testing.T) {
 		assert.NotEmpty(t, errValidation.Errors["email"])
 	})
 }
+
+func TestValidatorPartial(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		data := &TestUserData{FirstName: "User"}
+
+		err := ValidatorPartial(data, "FirstName")
+		assert.NoError(t, err)
+	})
+	t.Run("error", func(t *testing.T) {
+		data := &TestUserData{FirstName: "U"}
+
+		err := ValidatorPartial(data, "FirstName", "LastName")
+		assert.Error(t, err)
+
+		errValidation := err.(*errors.ErrValidation)
+		assert.NotEmpty(t, errValidation.Errors["first_name"])
+		assert.NotEmpty(t, errValidation.Errors["last_name"])
+		assert.Empty(t, errValidation.Errors["email"])
+	})
+}
+
+func TestValidatorPermission(t *testing.T) {
+	type data struct {
+		Permissions []string `json:"permissions" validate:"omitempty,dive,permission"`
+	}
+
+	t.Run("success", func(t *testing.T) {
+		err := Validator(&data{Permissions: []string{entity.PERMISSION_USER_READ, entity.PERMISSION_USER_IMPERSONATE}})
+		assert.NoError(t, err)
+	})
+	t.Run("error", func(t *testing.T) {
+		err := Validator(&data{Permissions: []string{entity.PERMISSION_USER_READ, "user:everything"}})
+		assert.Error(t, err)
+
+		errValidation := err.(*errors.ErrValidation)
+		assert.Equal(t, "Permissions[1] must be a known permission", errValidation.Errors["Permissions[1]"])
+	})
+}